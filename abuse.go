@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type BanDB struct {
+	IP        string    `gorm:"primaryKey;type:varchar(45)" json:"ip"`
+	Reason    string    `gorm:"type:varchar(255);not null" json:"reason"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+type ipStats struct {
+	windowStart time.Time
+	errors      int
+	throttled   int
+}
+
+// abuseDetector contabiliza erros e respostas 429 por IP e aplica banimentos temporários
+type abuseDetector struct {
+	mu    sync.Mutex
+	stats map[string]*ipStats
+	bans  map[string]time.Time
+}
+
+var abuse = &abuseDetector{
+	stats: make(map[string]*ipStats),
+	bans:  make(map[string]time.Time),
+}
+
+// loadBans carrega do banco os banimentos ainda vigentes
+func (a *abuseDetector) loadBans() error {
+	var bans []BanDB
+	if err := db.Where("expires_at > ?", time.Now()).Find(&bans).Error; err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, ban := range bans {
+		a.bans[ban.IP] = ban.ExpiresAt
+	}
+	return nil
+}
+
+func (a *abuseDetector) isBanned(ip string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	expiresAt, ok := a.bans[ip]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiresAt) {
+		delete(a.bans, ip)
+		return false
+	}
+	return true
+}
+
+func (a *abuseDetector) record(ip string, status int) {
+	if status < http.StatusBadRequest {
+		return
+	}
+
+	a.mu.Lock()
+	now := time.Now()
+	s, ok := a.stats[ip]
+	if !ok || now.Sub(s.windowStart) > cfg.AbuseWindow {
+		s = &ipStats{windowStart: now}
+		a.stats[ip] = s
+	}
+
+	if status == http.StatusTooManyRequests {
+		s.throttled++
+	} else {
+		s.errors++
+	}
+
+	var reason string
+	switch {
+	case s.throttled >= cfg.AbuseMaxThrottled:
+		reason = "excesso de respostas 429"
+	case s.errors >= cfg.AbuseMaxErrors:
+		reason = "excesso de respostas de erro"
+	}
+	if reason == "" {
+		a.mu.Unlock()
+		return
+	}
+
+	delete(a.stats, ip)
+	ban := BanDB{IP: ip, Reason: reason, ExpiresAt: now.Add(cfg.AbuseBanDuration)}
+	a.bans[ip] = ban.ExpiresAt
+	a.mu.Unlock()
+
+	log.Printf("IP %s banido até %s: %s", ip, ban.ExpiresAt.Format(time.RFC3339), reason)
+	if err := db.Save(&ban).Error; err != nil {
+		log.Printf("Erro ao persistir banimento de %s: %v", ip, err)
+	}
+}
+
+// sweep remove as janelas encerradas e os banimentos vencidos, para que o mapa não cresça
+// com cada IP que já passou pelo servidor
+func (a *abuseDetector) sweep(now time.Time) (removed int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for ip, s := range a.stats {
+		if now.Sub(s.windowStart) > cfg.AbuseWindow {
+			delete(a.stats, ip)
+			removed++
+		}
+	}
+	for ip, expiresAt := range a.bans {
+		if now.After(expiresAt) {
+			delete(a.bans, ip)
+			removed++
+		}
+	}
+	return removed
+}
+
+// startAbuseSweep limpa o detector a cada janela
+func startAbuseSweep(ctx context.Context, interval time.Duration) {
+	runPeriodically(ctx, interval, func(context.Context) {
+		abuse.sweep(time.Now())
+	})
+}
+
+func (a *abuseDetector) lift(ip string) error {
+	a.mu.Lock()
+	delete(a.bans, ip)
+	delete(a.stats, ip)
+	a.mu.Unlock()
+
+	return db.Delete(&BanDB{}, "ip = ?", ip).Error
+}
+
+// AbuseMiddleware bloqueia IPs banidos e contabiliza as respostas de erro dos demais. As
+// requisições liberadas pela allowlist não são bloqueadas nem contabilizadas, e seguem
+// marcadas no contexto para ficarem fora dos limites de concorrência
+func AbuseMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowlist.allows(r) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), allowlistedKey, true)))
+			return
+		}
+
+		ip := clientIP(r)
+		if abuse.isBanned(ip) {
+			writeError(w, http.StatusForbidden, "IP temporariamente banido")
+			return
+		}
+
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+		abuse.record(ip, rec.status)
+	})
+}
+
+// BansHandler lista (GET) ou remove (DELETE ?ip=) banimentos vigentes
+func BansHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var bans []BanDB
+		if err := db.WithContext(r.Context()).Where("expires_at > ?", time.Now()).Order("expires_at").Find(&bans).Error; err != nil {
+			logf(r.Context(), "Erro ao consultar banimentos: %v", err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		writeJSON(w, http.StatusOK, bans)
+	case http.MethodDelete:
+		ip := r.URL.Query().Get("ip")
+		if ip == "" {
+			writeError(w, http.StatusBadRequest, "parâmetro ip obrigatório")
+			return
+		}
+		if err := abuse.lift(ip); err != nil {
+			logf(r.Context(), "Erro ao remover banimento de %s: %v", ip, err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		logf(r.Context(), "Banimento de %s removido manualmente", ip)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
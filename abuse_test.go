@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAbuseSweep(t *testing.T) {
+	now := time.Now()
+	a := &abuseDetector{
+		stats: map[string]*ipStats{
+			"203.0.113.1": {windowStart: now.Add(-2 * cfg.AbuseWindow), errors: 1},
+			"203.0.113.2": {windowStart: now, errors: 1},
+		},
+		bans: map[string]time.Time{
+			"203.0.113.3": now.Add(-time.Second),
+			"203.0.113.4": now.Add(time.Hour),
+		},
+	}
+
+	if removed := a.sweep(now); removed != 2 {
+		t.Fatalf("removidos = %d, esperado 2", removed)
+	}
+	tests := []struct {
+		name string
+		ip   string
+		want bool
+	}{
+		{name: "janela encerrada", ip: "203.0.113.1"},
+		{name: "janela vigente", ip: "203.0.113.2", want: true},
+		{name: "banimento vencido", ip: "203.0.113.3"},
+		{name: "banimento vigente", ip: "203.0.113.4", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, inStats := a.stats[tt.ip]
+			_, inBans := a.bans[tt.ip]
+			if got := inStats || inBans; got != tt.want {
+				t.Errorf("%s mantido = %v, esperado %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Agregado diário das cotações, mantido após a remoção dos dados brutos pela retenção
+type RateDailyDB struct {
+	Pair     string          `gorm:"primaryKey;type:varchar(21)" json:"pair"`
+	Day      string          `gorm:"primaryKey;type:varchar(10)" json:"day"` // YYYY-MM-DD em UTC
+	OpenBid  decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"open_bid"`
+	HighBid  decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"high_bid"`
+	LowBid   decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"low_bid"`
+	CloseBid decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"close_bid"`
+	AvgBid   decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"avg_bid"`
+	AvgAsk   decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"avg_ask"`
+	Samples  int             `gorm:"not null" json:"samples"`
+}
+
+// Agregado por hora das cotações
+type RateHourlyDB struct {
+	Pair     string          `gorm:"primaryKey;type:varchar(21)" json:"pair"`
+	Hour     string          `gorm:"primaryKey;type:varchar(16)" json:"hour"` // YYYY-MM-DD HH:00 em UTC
+	OpenBid  decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"open_bid"`
+	HighBid  decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"high_bid"`
+	LowBid   decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"low_bid"`
+	CloseBid decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"close_bid"`
+	AvgBid   decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"avg_bid"`
+	AvgAsk   decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"avg_ask"`
+	Samples  int             `gorm:"not null" json:"samples"`
+}
+
+// Recalcula, a partir dos dados brutos não removidos, os agregados dos períodos com cotações
+// no intervalo; {period:x} é substituído pela expressão que trunca o timestamp da tabela x
+// ao período
+const rollupSQLTemplate = `
+INSERT INTO {table} (pair, {column}, open_bid, high_bid, low_bid, close_bid, avg_bid, avg_ask, samples)
+SELECT r.pair, {period:r},
+	(SELECT o.bid FROM usd_to_brl_rate_dbs o WHERE o.pair = r.pair AND o.deleted_at IS NULL AND {period:o} = {period:r} ORDER BY o.timestamp ASC LIMIT 1),
+	MAX(r.bid), MIN(r.bid),
+	(SELECT c.bid FROM usd_to_brl_rate_dbs c WHERE c.pair = r.pair AND c.deleted_at IS NULL AND {period:c} = {period:r} ORDER BY c.timestamp DESC LIMIT 1),
+	ROUND(AVG(r.bid), 4), ROUND(AVG(r.ask), 4), COUNT(*)
+FROM usd_to_brl_rate_dbs r
+WHERE r.timestamp >= ? AND r.timestamp < ? AND r.deleted_at IS NULL
+GROUP BY r.pair, {period:r}
+ON CONFLICT (pair, {column}) DO UPDATE SET
+	open_bid = excluded.open_bid,
+	high_bid = excluded.high_bid,
+	low_bid = excluded.low_bid,
+	close_bid = excluded.close_bid,
+	avg_bid = excluded.avg_bid,
+	avg_ask = excluded.avg_ask,
+	samples = excluded.samples`
+
+type rollupLevel struct {
+	table    string
+	column   string
+	format   string // formato strftime do período
+	duration time.Duration
+}
+
+var (
+	hourlyRollup = rollupLevel{table: "rate_hourly_dbs", column: "hour", format: "%Y-%m-%d %H:00", duration: time.Hour}
+	dailyRollup  = rollupLevel{table: "rate_daily_dbs", column: "day", format: "%Y-%m-%d", duration: 24 * time.Hour}
+)
+
+func (l rollupLevel) sql() string {
+	s := strings.NewReplacer("{table}", l.table, "{column}", l.column).Replace(rollupSQLTemplate)
+	for _, alias := range []string{"r", "o", "c"} {
+		s = strings.ReplaceAll(s, "{period:"+alias+"}", "strftime('"+l.format+"', "+alias+".timestamp, 'unixepoch')")
+	}
+	return s
+}
+
+// rollup recalcula os agregados dos níveis informados para as cotações em [from, to),
+// alinhando from ao início do período para que cada período seja recalculado por inteiro
+func rollup(tx *gorm.DB, from, to time.Time, levels ...rollupLevel) (int64, error) {
+	var affected int64
+	for _, level := range levels {
+		start := from.UTC().Truncate(level.duration)
+		result := tx.Exec(level.sql(), start.Unix(), to.Unix())
+		if result.Error != nil {
+			return affected, result.Error
+		}
+		affected += result.RowsAffected
+	}
+	return affected, nil
+}
+
+// rollupJob mantém os agregados atualizados; a primeira execução processa todo o histórico
+type rollupJob struct {
+	mu         sync.Mutex
+	lastRollup time.Time
+}
+
+var rollups = &rollupJob{}
+
+func (j *rollupJob) run(ctx context.Context) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	if _, err := rollup(db.WithContext(ctx), j.lastRollup, now.Add(time.Minute), hourlyRollup, dailyRollup); err != nil {
+		log.Printf("Erro ao consolidar agregados: %v", err)
+		return
+	}
+	historyResults.invalidateRange(j.lastRollup.UTC().Truncate(24*time.Hour), now.Add(time.Minute))
+	j.lastRollup = now
+}
+
+func startRollup(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	log.Printf("Consolidação de agregados iniciada com intervalo de %s", interval)
+	runPeriodically(ctx, interval, rollups.run)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	channelWebhook  = "webhook"
+	channelEmail    = "email"
+	channelTelegram = "telegram"
+)
+
+// alertSender entrega o evento de um alerta pelo seu canal; falhas são repetidas por deliverAlert
+type alertSender func(ctx context.Context, alert AlertDB, event AlertEvent) error
+
+var alertSenders = map[string]alertSender{
+	channelWebhook:  sendWebhook,
+	channelEmail:    sendEmail,
+	channelTelegram: sendTelegram,
+}
+
+// Cliente da API do Telegram, configurada pelo administrador; os webhooks dos usuários usam
+// webhookClient, que recusa endereços internos
+var telegramClient = &http.Client{Timeout: 5 * time.Second}
+
+var conditionLabels = map[string]string{
+	alertAbove:     "bid acima de",
+	alertBelow:     "bid abaixo de",
+	alertChangePct: "variação (%) de pelo menos",
+}
+
+// Mensagem enviada por email e Telegram
+var alertMessage = template.Must(template.New("alert").Funcs(template.FuncMap{
+	"condition": func(c string) string { return conditionLabels[c] },
+}).Parse(`{{if .Test}}[TESTE] {{end}}Alerta de cotação {{.Rule.Pair}}: {{condition .Rule.Condition}} {{.Rule.Threshold}}
+
+Bid atual: {{.Bid}}
+Ask atual: {{.Ask}}
+Variação no dia: {{if .PctChange}}{{.PctChange}}%{{else}}n/d{{end}}
+Disparado em: {{.Firing.Time.Format "02/01/2006 15:04:05 MST"}}
+
+Histórico: {{.HistoryURL}}
+`))
+
+func renderAlertMessage(event AlertEvent) (string, error) {
+	var buf bytes.Buffer
+	if err := alertMessage.Execute(&buf, event); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// historyURL aponta para as últimas 24 horas do par no endpoint de histórico
+func historyURL(pair string, at time.Time) string {
+	q := url.Values{}
+	q.Set("pair", pair)
+	q.Set("from", strconv.FormatInt(at.Add(-24*time.Hour).Unix(), 10))
+	q.Set("to", strconv.FormatInt(at.Add(time.Minute).Unix(), 10))
+	return strings.TrimSuffix(cfg.PublicBaseURL, "/") + "/historico?" + q.Encode()
+}
+
+// validateChannel confere o destino do canal escolhido e se o canal está configurado no servidor
+func validateChannel(req *AlertRequest) error {
+	if req.Channel == "" {
+		req.Channel = channelWebhook
+	}
+
+	switch req.Channel {
+	case channelWebhook:
+		u, err := url.Parse(req.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return errors.New("webhook_url deve ser uma URL http ou https")
+		}
+		if !publicHost(u.Hostname()) {
+			return errors.New("webhook_url deve apontar para um endereço público")
+		}
+	case channelEmail:
+		if cfg.SMTPHost == "" || cfg.SMTPFrom == "" {
+			return errors.New("canal email indisponível: SMTP não configurado no servidor")
+		}
+		addr, err := mail.ParseAddress(req.Target)
+		if err != nil {
+			return errors.New("target deve ser um endereço de email válido")
+		}
+		req.Target = addr.Address
+	case channelTelegram:
+		if cfg.TelegramBotToken == "" {
+			return errors.New("canal telegram indisponível: bot não configurado no servidor")
+		}
+		if req.Target == "" {
+			return errors.New("target deve ser o chat_id do Telegram")
+		}
+	default:
+		return fmt.Errorf("channel deve ser %s, %s ou %s", channelWebhook, channelEmail, channelTelegram)
+	}
+	return nil
+}
+
+// publicHost resolve o host do webhook e confere que nenhum dos endereços é interno. Hosts que
+// não resolvem também são recusados
+func publicHost(host string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func sendEmail(ctx context.Context, alert AlertDB, event AlertEvent) error {
+	text, err := renderAlertMessage(event)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Alerta de cotação %s", event.Rule.Pair)
+	if event.Test {
+		subject = "[TESTE] " + subject
+	}
+	msg := "From: " + cfg.SMTPFrom + "\r\n" +
+		"To: " + alert.Target + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + strings.ReplaceAll(text, "\n", "\r\n")
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	// net/smtp não aceita contexto; o envio roda à parte para respeitar o cancelamento
+	addr := cfg.SMTPHost + ":" + strconv.Itoa(cfg.SMTPPort)
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, cfg.SMTPFrom, []string{alert.Target}, []byte(msg))
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+func sendTelegram(ctx context.Context, alert AlertDB, event AlertEvent) error {
+	text, err := renderAlertMessage(event)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  alert.Target,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(cfg.TelegramAPIURL, "/") + "/bot" + cfg.TelegramBotToken + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := telegramClient.Do(req)
+	if err != nil {
+		// O erro de rede inclui a URL, que contém o token do bot
+		return errors.New(strings.ReplaceAll(err.Error(), cfg.TelegramBotToken, "***"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileAlertRule é uma entrada de ALERT_RULES_FILE, com os mesmos campos do corpo de
+// POST /alerts e um nome que a identifica entre as recargas:
+//
+//	alerts:
+//	  - name: dolar-acima-de-6
+//	    pair: USD-BRL
+//	    condition: above
+//	    threshold: 6.0
+//	    cooldown: 1h
+//	    channel: webhook
+//	    webhook_url: https://exemplo.com/hooks/cotacao
+//	    secret: segredo-do-webhook
+//	  - name: euro-volatil
+//	    pair: EUR-BRL
+//	    condition: change_pct
+//	    threshold: 1.5
+//	    channel: telegram
+//	    target: 123456789
+type fileAlertRule struct {
+	Name string `json:"name"`
+	AlertRequest
+}
+
+// parseAlertRules lê as regras do arquivo; qualquer regra inválida invalida o arquivo todo,
+// para que uma edição com erro não desative parte dos alertas sem aviso
+func parseAlertRules(data []byte) ([]AlertDB, error) {
+	var file struct {
+		Alerts []map[string]any `yaml:"alerts"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	alerts := make([]AlertDB, 0, len(file.Alerts))
+	names := make(map[string]bool)
+	for i, entry := range file.Alerts {
+		if target, ok := entry["target"]; ok {
+			entry["target"] = fmt.Sprint(target) // chat_id do Telegram costuma ser escrito sem aspas
+		}
+		// os campos passam por JSON para reaproveitar a validação e os tipos da API
+		// (Duration, decimal) e recusar campos desconhecidos
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("regra %d: %w", i+1, err)
+		}
+		var rule fileAlertRule
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("regra %d: %w", i+1, err)
+		}
+
+		if rule.Name == "" {
+			return nil, fmt.Errorf("regra %d: name é obrigatório", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("regra %q repetida", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if err := rule.AlertRule.validate(); err != nil {
+			return nil, fmt.Errorf("regra %q: %w", rule.Name, err)
+		}
+		if err := validateChannel(&rule.AlertRequest); err != nil {
+			return nil, fmt.Errorf("regra %q: %w", rule.Name, err)
+		}
+		if rule.Channel == channelWebhook && rule.Secret == "" {
+			// sem banco, um segredo gerado mudaria a cada reinício
+			return nil, fmt.Errorf("regra %q: secret é obrigatório para webhooks", rule.Name)
+		}
+		if !pairs.isEnabled(rule.Pair) {
+			return nil, fmt.Errorf("regra %q: par %s não habilitado", rule.Name, rule.Pair)
+		}
+
+		alerts = append(alerts, AlertDB{
+			Name: rule.Name, AlertRule: rule.AlertRule, Channel: rule.Channel, Target: rule.Target,
+			WebhookURL: rule.WebhookURL, Secret: rule.Secret, Enabled: rule.Enabled == nil || *rule.Enabled,
+		})
+	}
+	return alerts, nil
+}
+
+// loadFile substitui as regras do arquivo. Regras inalteradas mantêm o estado do avaliador
+// (armado, último disparo), para que uma recarga não as faça disparar de novo
+func (e *alertEngine) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	alerts, err := parseAlertRules(data)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := make(map[string]fileAlert, len(alerts))
+	for _, alert := range alerts {
+		if !alert.Enabled {
+			continue
+		}
+		if current, ok := e.fileRules[alert.Name]; ok && sameAlert(current.alert, alert) {
+			rules[alert.Name] = current
+			continue
+		}
+		rules[alert.Name] = fileAlert{alert: alert, evaluator: newAlertEvaluator(alert.AlertRule)}
+	}
+	e.fileRules = rules
+	log.Printf("%d regras de alerta carregadas de %s", len(rules), path)
+	return nil
+}
+
+func sameAlert(a, b AlertDB) bool {
+	return a.Pair == b.Pair && a.Condition == b.Condition && a.Threshold.Equal(b.Threshold) &&
+		a.Cooldown == b.Cooldown && a.Channel == b.Channel && a.Target == b.Target &&
+		a.WebhookURL == b.WebhookURL && a.Secret == b.Secret
+}
+
+// watchFile recarrega o arquivo de regras quando a data de modificação ou o tamanho mudam;
+// se a nova versão for inválida, as regras anteriores continuam valendo
+func (e *alertEngine) watchFile(ctx context.Context, path string) {
+	last, _ := os.Stat(path)
+
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		ticker := time.NewTicker(cfg.AlertRulesPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			if err := e.loadFile(path); err != nil {
+				log.Printf("Arquivo de regras de alerta %s inválido, mantendo as regras anteriores: %v", path, err)
+			}
+		}
+	}()
+}
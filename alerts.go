@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	alertAbove     = "above"
+	alertBelow     = "below"
+	alertChangePct = "change_pct"
+)
+
+// AlertRule descreve quando um alerta deve disparar para um par: bid acima ou abaixo do
+// limite, ou variação percentual entre pontos consecutivos maior que o limite. Após disparar,
+// o alerta só volta a valer quando a condição deixa de ser verdadeira e o cooldown expira
+type AlertRule struct {
+	Pair      string          `json:"pair"`
+	Condition string          `json:"condition"`
+	Threshold decimal.Decimal `json:"threshold"`
+	Cooldown  Duration        `json:"cooldown,omitempty"`
+}
+
+// Duration aceita durações no formato do Go ("30m", "2h") em JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (rule *AlertRule) validate() error {
+	rule.Pair = strings.ToUpper(strings.TrimSpace(rule.Pair))
+	if rule.Pair == "" {
+		rule.Pair = defaultPair
+	}
+	if !pairPattern.MatchString(rule.Pair) {
+		return fmt.Errorf("par inválido %q", rule.Pair)
+	}
+	switch rule.Condition {
+	case alertAbove, alertBelow, alertChangePct:
+	default:
+		return fmt.Errorf("condition deve ser %s, %s ou %s", alertAbove, alertBelow, alertChangePct)
+	}
+	if !rule.Threshold.IsPositive() {
+		return fmt.Errorf("threshold deve ser positivo")
+	}
+	if rule.Cooldown < 0 {
+		return fmt.Errorf("cooldown não pode ser negativo")
+	}
+	return nil
+}
+
+type AlertFiring struct {
+	Time  time.Time       `json:"time"`
+	Value decimal.Decimal `json:"value"`
+}
+
+// alertEvaluator avalia a regra ponto a ponto, guardando o estado entre as avaliações
+type alertEvaluator struct {
+	rule      AlertRule
+	armed     bool
+	lastFired time.Time
+	prevClose decimal.Decimal
+}
+
+func newAlertEvaluator(rule AlertRule) *alertEvaluator {
+	return &alertEvaluator{rule: rule, armed: true}
+}
+
+// observe avalia um ponto da série e informa se o alerta dispararia nele
+func (e *alertEvaluator) observe(p HistoryPoint) (AlertFiring, bool) {
+	var value decimal.Decimal
+	var triggered bool
+	switch e.rule.Condition {
+	case alertAbove:
+		value, triggered = p.High, p.High.GreaterThanOrEqual(e.rule.Threshold)
+	case alertBelow:
+		value, triggered = p.Low, p.Low.LessThanOrEqual(e.rule.Threshold)
+	case alertChangePct:
+		if e.prevClose.IsPositive() {
+			value = p.Close.Sub(e.prevClose).Div(e.prevClose).Mul(decimal.NewFromInt(100)).Round(moneyScale)
+			triggered = value.Abs().GreaterThanOrEqual(e.rule.Threshold)
+		}
+		e.prevClose = p.Close
+	}
+
+	if !triggered {
+		e.armed = true
+		return AlertFiring{}, false
+	}
+	if !e.armed || (!e.lastFired.IsZero() && p.Time.Sub(e.lastFired) < time.Duration(e.rule.Cooldown)) {
+		return AlertFiring{}, false
+	}
+
+	e.armed = false
+	e.lastFired = p.Time
+	return AlertFiring{Time: p.Time, Value: value}, true
+}
+
+type BacktestResponse struct {
+	Rule       AlertRule     `json:"rule"`
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	Resolution string        `json:"resolution"`
+	Evaluated  int           `json:"evaluated"`
+	Firings    []AlertFiring `json:"firings"`
+}
+
+// AlertBacktestHandler avalia uma regra de alerta sobre o histórico armazenado (from/to/resolution
+// como em /historico) e retorna os momentos em que ela teria disparado
+func AlertBacktestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rule AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+	if err := rule.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	history, msg := parseHistoryRange(r.URL.Query())
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	history.Pair = rule.Pair
+
+	points, err := loadHistory(r.Context(), history)
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar histórico para backtest: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	resp := BacktestResponse{
+		Rule: rule, From: history.From, To: history.To, Resolution: history.Resolution,
+		Evaluated: len(points), Firings: []AlertFiring{},
+	}
+	evaluator := newAlertEvaluator(rule)
+	for _, p := range points {
+		if firing, fired := evaluator.observe(p); fired {
+			resp.Firings = append(resp.Firings, firing)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlertsHandler gerencia os alertas do usuário autenticado: GET lista, POST
+// cadastra (o segredo só é retornado na criação) e DELETE ?id= remove
+func AlertsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		listAlerts(w, r, userID)
+
+	case http.MethodPost:
+		createAlert(w, r, userID)
+
+	case http.MethodDelete:
+		id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id inválido")
+			return
+		}
+		deleteAlert(w, r, userID, uint(id))
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Campos ordenáveis dos alertas; since/until filtram pela criação e threshold_min/max pelo limite
+var alertListSpec = listSpec{
+	sortFields:     map[string]string{"id": "id", "created_at": "created_at", "pair": "pair", "threshold": "threshold"},
+	defaultSort:    "id:asc",
+	valueParam:     "threshold",
+	defaultPerPage: 100,
+	maxPerPage:     1000,
+}
+
+func listAlerts(w http.ResponseWriter, r *http.Request, userID uint) {
+	q, err := parseListQuery(r.URL.Query(), alertListSpec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx := db.WithContext(r.Context()).Model(&AlertDB{}).Where("user_id = ?", userID)
+	if q.Pair != "" {
+		tx = tx.Where("pair = ?", q.Pair)
+	}
+	if !q.Since.IsZero() {
+		tx = tx.Where("created_at >= ?", q.Since)
+	}
+	if !q.Until.IsZero() {
+		tx = tx.Where("created_at < ?", q.Until)
+	}
+	if q.MinValue != nil {
+		tx = tx.Where("threshold >= ?", *q.MinValue)
+	}
+	if q.MaxValue != nil {
+		tx = tx.Where("threshold <= ?", *q.MaxValue)
+	}
+
+	var total int64
+	var alerts []AlertDB
+	err = tx.Count(&total).Error
+	if err == nil {
+		err = tx.Order(fmt.Sprintf("%s %s, id %[2]s", q.Sort, q.direction())).
+			Offset(q.offset()).Limit(q.PerPage).Find(&alerts).Error
+	}
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar alertas: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	for i := range alerts {
+		alerts[i].Secret = ""
+	}
+	writePageHeaders(w, r, q, total)
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// AlertHandler atende /alerts/{id}: GET consulta, PUT substitui a regra e o canal, PATCH
+// altera apenas enabled e cooldown e DELETE remove. POST /alerts/{id}/test envia uma
+// notificação de teste pelo canal do alerta
+func AlertHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	rest := strings.TrimPrefix(r.URL.Path, "/alerts/")
+	idPart, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	if action != "" {
+		if action != "test" {
+			writeError(w, http.StatusNotFound, "recurso não encontrado")
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		testAlert(w, r, userID, uint(id))
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		deleteAlert(w, r, userID, uint(id))
+		return
+	}
+
+	alert, ok := findAlert(w, r, userID, uint(id))
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		alert.Secret = ""
+		writeJSON(w, http.StatusOK, alert)
+	case http.MethodPut:
+		updateAlert(w, r, alert)
+	case http.MethodPatch:
+		patchAlert(w, r, alert)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// findAlert carrega o alerta do usuário, respondendo 404 se ele não existir ou for de outro usuário
+func findAlert(w http.ResponseWriter, r *http.Request, userID, id uint) (AlertDB, bool) {
+	var alert AlertDB
+	err := db.WithContext(r.Context()).Where("id = ? AND user_id = ?", id, userID).First(&alert).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, "alerta não encontrado")
+		return alert, false
+	}
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar alerta: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return alert, false
+	}
+	return alert, true
+}
+
+// decodeAlertRequest lê e valida o corpo de POST e PUT, respondendo 400 ou 404 em caso de erro
+func decodeAlertRequest(w http.ResponseWriter, r *http.Request) (AlertRequest, bool) {
+	var req AlertRequest
+	if !decodeValidated(w, r, "alert", &req) {
+		return req, false
+	}
+	if err := req.AlertRule.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	if err := validateChannel(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	if !pairs.isEnabled(req.Pair) {
+		writeError(w, http.StatusNotFound, "par não habilitado")
+		return req, false
+	}
+	if req.Channel != channelWebhook {
+		req.WebhookURL, req.Secret = "", ""
+	}
+	return req, true
+}
+
+func createAlert(w http.ResponseWriter, r *http.Request, userID uint) {
+	req, ok := decodeAlertRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Channel == channelWebhook && req.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			logf(r.Context(), "Erro ao gerar segredo do webhook: %v", err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		req.Secret = secret
+	}
+
+	alert := AlertDB{
+		UserID: userID, AlertRule: req.AlertRule, Channel: req.Channel, Target: req.Target,
+		WebhookURL: req.WebhookURL, Secret: req.Secret, Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if err := db.WithContext(r.Context()).Create(&alert).Error; err != nil {
+		logf(r.Context(), "Erro ao cadastrar alerta: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	alertsEngine.add(alert)
+	writeJSON(w, http.StatusCreated, alert)
+}
+
+// updateAlert substitui a regra e o canal do alerta. Um webhook sem segredo no corpo mantém
+// o segredo atual; o segredo só é retornado quando é novo
+func updateAlert(w http.ResponseWriter, r *http.Request, alert AlertDB) {
+	req, ok := decodeAlertRequest(w, r)
+	if !ok {
+		return
+	}
+
+	returnSecret := req.Secret != ""
+	if req.Channel == channelWebhook && req.Secret == "" {
+		req.Secret = alert.Secret
+		if req.Secret == "" {
+			secret, err := newWebhookSecret()
+			if err != nil {
+				logf(r.Context(), "Erro ao gerar segredo do webhook: %v", err)
+				writeError(w, http.StatusInternalServerError, "erro interno")
+				return
+			}
+			req.Secret, returnSecret = secret, true
+		}
+	}
+
+	alert.AlertRule = req.AlertRule
+	alert.Channel, alert.Target = req.Channel, req.Target
+	alert.WebhookURL, alert.Secret = req.WebhookURL, req.Secret
+	if req.Enabled != nil {
+		alert.Enabled = *req.Enabled
+	}
+	if !saveAlert(w, r, &alert) {
+		return
+	}
+
+	if !returnSecret {
+		alert.Secret = ""
+	}
+	writeJSON(w, http.StatusOK, alert)
+}
+
+// patchAlert habilita ou desabilita o alerta e ajusta o cooldown sem reenviar a regra
+func patchAlert(w http.ResponseWriter, r *http.Request, alert AlertDB) {
+	var req struct {
+		Enabled  *bool     `json:"enabled"`
+		Cooldown *Duration `json:"cooldown"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+	if req.Enabled != nil {
+		alert.Enabled = *req.Enabled
+	}
+	if req.Cooldown != nil {
+		if *req.Cooldown < 0 {
+			writeError(w, http.StatusBadRequest, "cooldown não pode ser negativo")
+			return
+		}
+		alert.Cooldown = *req.Cooldown
+	}
+	if !saveAlert(w, r, &alert) {
+		return
+	}
+
+	alert.Secret = ""
+	writeJSON(w, http.StatusOK, alert)
+}
+
+// saveAlert grava o alerta e o recarrega no avaliador, o que rearma a regra
+func saveAlert(w http.ResponseWriter, r *http.Request, alert *AlertDB) bool {
+	if err := db.WithContext(r.Context()).Save(alert).Error; err != nil {
+		logf(r.Context(), "Erro ao atualizar alerta: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return false
+	}
+	alertsEngine.add(*alert)
+	return true
+}
+
+func deleteAlert(w http.ResponseWriter, r *http.Request, userID, id uint) {
+	result := db.WithContext(r.Context()).Where("id = ? AND user_id = ?", id, userID).Delete(&AlertDB{})
+	if result.Error != nil {
+		logf(r.Context(), "Erro ao remover alerta: %v", result.Error)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	if result.RowsAffected == 0 {
+		writeError(w, http.StatusNotFound, "alerta não encontrado")
+		return
+	}
+	alertsEngine.remove(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// testAlert envia uma notificação marcada como teste com a cotação atual do par, em uma única
+// tentativa e sem registrar disparo, para que o usuário confira o canal. Funciona também com
+// o alerta desabilitado
+func testAlert(w http.ResponseWriter, r *http.Request, userID, id uint) {
+	alert, ok := findAlert(w, r, userID, id)
+	if !ok {
+		return
+	}
+
+	var quote *Quote
+	if entry, ok := cache.get(alert.Pair); ok {
+		quote = entry.quote
+	} else if quote, ok = quoteForPair(w, r, alert.Pair); !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	event := AlertEvent{
+		AlertID: alert.ID, Rule: alert.AlertRule,
+		Firing: AlertFiring{Time: now, Value: parseDecimal(quote.Bid)},
+		Bid:    quote.Bid, Ask: quote.Ask, PctChange: quote.PctChange, Provider: quote.Provider,
+		Timestamp: now.Unix(), HistoryURL: historyURL(alert.Pair, now), Test: true,
+	}
+
+	send, ok := alertSenders[alert.Channel]
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "canal desconhecido")
+		return
+	}
+	if err := send(r.Context(), alert, event); err != nil {
+		logf(r.Context(), "Teste do alerta %d via %s falhou: %v", alert.ID, alert.Channel, err)
+		writeError(w, http.StatusBadGateway, "falha ao enviar notificação de teste")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"delivered": true, "channel": alert.Channel})
+}
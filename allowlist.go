@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	allowKindIP  = "ip"
+	allowKindKey = "key"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+const allowlistedKey contextKey = "allowlisted"
+
+// AllowlistEntryDB isenta um IP (ou faixa CIDR) ou uma chave de API dos limites de
+// concorrência e dos banimentos por abuso. As chaves são guardadas apenas como hash SHA-256
+type AllowlistEntryDB struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kind      string    `gorm:"type:varchar(10);not null" json:"kind"`
+	Value     string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"value"` // CIDR ou hash da chave
+	Note      string    `gorm:"type:varchar(255);not null;default:''" json:"note,omitempty"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+
+	Source string `gorm:"-" json:"source"` // api (banco) ou config (ALLOWLIST_IPS/ALLOWLIST_KEYS)
+}
+
+// AllowlistRequest é o corpo de POST /admin/allowlist; para kind=key sem value uma chave é
+// gerada e retornada apenas na criação
+type AllowlistRequest struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Note  string `json:"note"`
+}
+
+type allowlistSet struct {
+	mu     sync.RWMutex
+	config []AllowlistEntryDB
+	stored []AllowlistEntryDB
+	nets   []*net.IPNet
+	keys   map[string]bool
+}
+
+var allowlist = &allowlistSet{keys: make(map[string]bool)}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseAllowedNet aceita um IP isolado ou uma faixa CIDR e retorna a faixa normalizada
+func parseAllowedNet(v string) (*net.IPNet, error) {
+	v = strings.TrimSpace(v)
+	if !strings.Contains(v, "/") {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, fmt.Errorf("IP ou CIDR inválido %q", v)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(v)
+	if err != nil {
+		return nil, fmt.Errorf("IP ou CIDR inválido %q", v)
+	}
+	return ipNet, nil
+}
+
+// configure valida as entradas de ALLOWLIST_IPS e ALLOWLIST_KEYS
+func (a *allowlistSet) configure(ips, keys []string) error {
+	var entries []AllowlistEntryDB
+	for _, ip := range ips {
+		ipNet, err := parseAllowedNet(ip)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, AllowlistEntryDB{Kind: allowKindIP, Value: ipNet.String(), Source: "config"})
+	}
+	for _, key := range keys {
+		entries = append(entries, AllowlistEntryDB{Kind: allowKindKey, Value: hashAPIKey(key), Source: "config"})
+	}
+
+	a.mu.Lock()
+	a.config = entries
+	a.rebuildLocked()
+	a.mu.Unlock()
+	return nil
+}
+
+// load carrega as entradas cadastradas pela API administrativa
+func (a *allowlistSet) load(ctx context.Context) error {
+	var entries []AllowlistEntryDB
+	if err := db.WithContext(ctx).Order("id").Find(&entries).Error; err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].Source = "api"
+	}
+
+	a.mu.Lock()
+	a.stored = entries
+	a.rebuildLocked()
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *allowlistSet) rebuildLocked() {
+	a.nets = a.nets[:0]
+	a.keys = make(map[string]bool)
+	for _, entry := range append(a.config[:len(a.config):len(a.config)], a.stored...) {
+		switch entry.Kind {
+		case allowKindIP:
+			if ipNet, err := parseAllowedNet(entry.Value); err == nil {
+				a.nets = append(a.nets, ipNet)
+			}
+		case allowKindKey:
+			a.keys[entry.Value] = true
+		}
+	}
+}
+
+func (a *allowlistSet) entries() []AllowlistEntryDB {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append(append([]AllowlistEntryDB{}, a.config...), a.stored...)
+}
+
+// allows indica se a requisição vem de um IP liberado ou traz uma chave liberada
+func (a *allowlistSet) allows(r *http.Request) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if key := r.Header.Get(apiKeyHeader); key != "" && a.keys[hashAPIKey(key)] {
+		return true
+	}
+	ip := net.ParseIP(clientIP(r))
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range a.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllowlisted(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allowlistedKey).(bool)
+	return allowed
+}
+
+// AllowlistHandler lista (GET), cadastra (POST) ou remove (DELETE ?id=) isenções. As entradas
+// de ALLOWLIST_IPS/ALLOWLIST_KEYS aparecem na listagem com source=config e não podem ser removidas
+func AllowlistHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, allowlist.entries())
+
+	case http.MethodPost:
+		var req AllowlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+			return
+		}
+
+		entry := AllowlistEntryDB{Kind: req.Kind, Note: req.Note, Source: "api"}
+		var generatedKey string
+		switch req.Kind {
+		case allowKindIP:
+			ipNet, err := parseAllowedNet(req.Value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			entry.Value = ipNet.String()
+		case allowKindKey:
+			key := req.Value
+			if key == "" {
+				secret, err := newWebhookSecret()
+				if err != nil {
+					logf(r.Context(), "Erro ao gerar chave de API: %v", err)
+					writeError(w, http.StatusInternalServerError, "erro interno")
+					return
+				}
+				key, generatedKey = secret, secret
+			}
+			entry.Value = hashAPIKey(key)
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("kind deve ser %s ou %s", allowKindIP, allowKindKey))
+			return
+		}
+		if len(entry.Note) > 255 {
+			writeError(w, http.StatusBadRequest, "note deve ter no máximo 255 caracteres")
+			return
+		}
+
+		var existing int64
+		if err := db.WithContext(r.Context()).Model(&AllowlistEntryDB{}).Where("value = ?", entry.Value).Count(&existing).Error; err != nil {
+			logf(r.Context(), "Erro ao consultar allowlist: %v", err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		if existing > 0 {
+			writeError(w, http.StatusConflict, "entrada já cadastrada")
+			return
+		}
+		if err := db.WithContext(r.Context()).Create(&entry).Error; err != nil {
+			logf(r.Context(), "Erro ao cadastrar entrada na allowlist: %v", err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		if err := allowlist.load(r.Context()); err != nil {
+			logf(r.Context(), "Erro ao recarregar allowlist: %v", err)
+		}
+
+		logf(r.Context(), "Entrada %d (%s) adicionada à allowlist", entry.ID, entry.Kind)
+		if generatedKey != "" {
+			writeJSON(w, http.StatusCreated, struct {
+				AllowlistEntryDB
+				Key string `json:"key"`
+			}{entry, generatedKey})
+			return
+		}
+		writeJSON(w, http.StatusCreated, entry)
+
+	case http.MethodDelete:
+		id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id inválido")
+			return
+		}
+		result := db.WithContext(r.Context()).Delete(&AllowlistEntryDB{}, id)
+		if err := result.Error; err != nil {
+			logf(r.Context(), "Erro ao remover entrada %d da allowlist: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		if result.RowsAffected == 0 {
+			writeError(w, http.StatusNotFound, "entrada não encontrada")
+			return
+		}
+		if err := allowlist.load(r.Context()); err != nil {
+			logf(r.Context(), "Erro ao recarregar allowlist: %v", err)
+		}
+		logf(r.Context(), "Entrada %d removida da allowlist", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
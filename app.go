@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// newApp monta os componentes do servidor com o fx. A ordem dos Invoke é a ordem de
+// construção e de início; o encerramento segue a ordem inversa: servidor HTTP, jobs, MQTT,
+// barramento de mensagens, buffer em lote, journal, Redis, armazenamento, GeoIP, banco e tracing. Os
+// handlers continuam lendo as variáveis do pacote, preenchidas pelos construtores
+func newApp(report *shutdownReport, srv **httpServer) *fx.App {
+	return fx.New(
+		fx.NopLogger,
+		fx.Supply(report),
+		fx.Provide(newDatabase, newHTTPServer),
+		fx.Invoke(startTracing, loadState, openRateRepository, connectRedis, openJournal, startBatcher, startBus, startMQTT, startJobs),
+		fx.Populate(srv),
+	)
+}
+
+func startTracing(lc fx.Lifecycle) error {
+	shutdown, err := setupTracing(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	lc.Append(fx.StopHook(shutdown))
+	return nil
+}
+
+// newDatabase abre e migra o banco; ao parar, depois dos jobs e do buffer em lote, registra
+// o que ficou pendente e fecha as conexões
+func newDatabase(lc fx.Lifecycle, report *shutdownReport) (*gorm.DB, error) {
+	var err error
+	if db, err = openDatabase(); err != nil {
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+
+	migrator, err := newMigrator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	// O banco em memória nasce vazio e sempre precisa ser migrado
+	if cfg.AutoMigrate || memoryOnly {
+		if err := runMigrations(migrator); err != nil {
+			return nil, fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+	if err := checkSchemaVersion(migrator); err != nil {
+		return nil, fmt.Errorf("incompatible schema: %w", err)
+	}
+	if err := checkSchemaDrift(context.Background()); err != nil {
+		return nil, fmt.Errorf("schema drift detected: %w", err)
+	}
+	log.Println("Database connected and schema migrated successfully.")
+
+	lc.Append(fx.StopHook(func() {
+		report.jobsStopped()
+		report.closeDatabase()
+	}))
+	return db, nil
+}
+
+// loadState carrega do banco e da configuração o estado usado pelos handlers
+func loadState(lc fx.Lifecycle, _ *gorm.DB) error {
+	if err := checkJWTSecret(); err != nil {
+		return fmt.Errorf("invalid JWT secret: %w", err)
+	}
+	upstreamClient = newUpstreamClient(cfg)
+	if err := setIDStrategy(cfg.IDStrategy); err != nil {
+		return fmt.Errorf("invalid id strategy: %w", err)
+	}
+	if n, err := backfillIDs(db); err != nil {
+		return fmt.Errorf("failed to backfill ids: %w", err)
+	} else if n > 0 {
+		log.Printf("IDs: %d IDs globais gravados com a estratégia %s", n, cfg.IDStrategy)
+	}
+
+	openGeoIP()
+	lc.Append(fx.StopHook(closeGeoIP))
+
+	if err := abuse.loadBans(); err != nil {
+		log.Printf("Erro ao carregar banimentos: %v", err)
+	}
+	if err := allowlist.configure(cfg.AllowlistIPs, cfg.AllowlistKeys); err != nil {
+		return fmt.Errorf("invalid allowlist: %w", err)
+	}
+	if err := allowlist.load(context.Background()); err != nil {
+		return fmt.Errorf("failed to load allowlist: %w", err)
+	}
+
+	if err := quota.load(cfg.UpstreamDailyQuota); err != nil {
+		log.Printf("Erro ao carregar uso da cota diária: %v", err)
+	}
+
+	if err := pairs.load(); err != nil {
+		return fmt.Errorf("failed to load pairs: %w", err)
+	}
+
+	if err := alertsEngine.load(); err != nil {
+		return fmt.Errorf("failed to load alerts: %w", err)
+	}
+	if cfg.AlertRulesFile != "" {
+		if err := alertsEngine.loadFile(cfg.AlertRulesFile); err != nil {
+			return fmt.Errorf("failed to load alert rules file: %w", err)
+		}
+	}
+
+	var err error
+	if redactedFields, err = parseRedactedFields(cfg.RedactFields); err != nil {
+		return fmt.Errorf("invalid redact fields: %w", err)
+	}
+
+	historyResults.configure(cfg.HistoryCacheTTL, cfg.HistoryCacheMaxEntries)
+
+	if cfg.MarkupType != markupFixed && cfg.MarkupType != markupPercent {
+		return fmt.Errorf("invalid MARKUP_TYPE: %s", cfg.MarkupType)
+	}
+	if cfg.PollJitter < 0 || cfg.PollJitter > maxPollJitter {
+		return fmt.Errorf("invalid POLL_JITTER: %d", cfg.PollJitter)
+	}
+
+	if providerChain, err = parseProviderChain(cfg.Providers); err != nil {
+		return fmt.Errorf("invalid providers: %w", err)
+	}
+
+	if composites, err = parseComposites(cfg.CompositePairs); err != nil {
+		return fmt.Errorf("invalid composite pairs: %w", err)
+	}
+	if err := registerComposites(); err != nil {
+		return fmt.Errorf("failed to register composite pairs: %w", err)
+	}
+
+	if derivedPairs, err = parseDerivedPairs(cfg.DerivedSeries); err != nil {
+		return fmt.Errorf("invalid derived series: %w", err)
+	}
+	if err := registerDerivedPairs(); err != nil {
+		return fmt.Errorf("failed to register derived series: %w", err)
+	}
+
+	if routeLimits, err = parseConcurrencyLimits(cfg.ConcurrencyLimits); err != nil {
+		return fmt.Errorf("invalid concurrency limits: %w", err)
+	}
+	return nil
+}
+
+func openRateRepository(lc fx.Lifecycle, _ *gorm.DB) error {
+	var err error
+	if rateRepo, err = newRateRepository(); err != nil {
+		return fmt.Errorf("failed to open rate storage: %w", err)
+	}
+	if closer, ok := rateRepo.(io.Closer); ok {
+		lc.Append(fx.StopHook(closer.Close))
+	}
+	log.Printf("Cotações armazenadas no backend %s", cfg.StorageBackend)
+	return nil
+}
+
+// connectRedis compartilha o cache de cotações e as consultas do agendador entre as réplicas
+// pelo Redis (REDIS_URL); ao parar, depois dos jobs, fecha a conexão
+func connectRedis(lc fx.Lifecycle) error {
+	if cfg.RedisURL == "" {
+		return nil
+	}
+	var err error
+	if redisClient, err = openRedis(cfg.RedisURL); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	cache.shared = &redisQuoteCache{client: redisClient, prefix: cfg.RedisPrefix}
+	pollLock = &redisPollLock{client: redisClient, prefix: cfg.RedisPrefix, owner: newID()}
+	lc.Append(fx.StopHook(redisClient.Close))
+	log.Printf("Cache de cotações e agendador compartilhados pelo Redis")
+	return nil
+}
+
+// openJournal abre o journal local (JOURNAL_PATH) das cotações que o banco recusar; a
+// regravação roda em startJobs. Ao parar, depois do buffer em lote, informa o que ficou pendente
+func openJournal(lc fx.Lifecycle, report *shutdownReport) error {
+	if cfg.JournalPath == "" || memoryOnly {
+		return nil
+	}
+	var err error
+	if journal, err = openRateJournal(cfg.JournalPath); err != nil {
+		return fmt.Errorf("failed to open rate journal: %w", err)
+	}
+	if pending := journal.pending.Load(); pending > 0 {
+		log.Printf("Journal %s com %d cotações a regravar", cfg.JournalPath, pending)
+	}
+	lc.Append(fx.StopHook(func() {
+		report.JournalPending = journal.pending.Load()
+	}))
+	return nil
+}
+
+// startBatcher habilita a persistência em lote; ao parar, grava as cotações ainda no buffer
+func startBatcher(lc fx.Lifecycle, report *shutdownReport) {
+	// O lote grava direto no SQLite e não se aplica ao armazenamento em memória
+	if cfg.PersistMode != "batch" || memoryOnly || cfg.StorageBackend != storageSQLite {
+		return
+	}
+	batcher = newBatchWriter(cfg.BatchSize, cfg.BatchInterval)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			batcher.start()
+			log.Printf("Persistência em lote habilitada (%d linhas ou %s)", cfg.BatchSize, cfg.BatchInterval)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			report.BatchRowsFlushed, report.BatchRowsDropped = batcher.close()
+			return nil
+		},
+	})
+}
+
+// startBus conecta ao barramento de mensagens (BUS_BACKEND); ao parar, depois dos jobs que
+// entregam as cotações, publica o que ficou na fila e fecha a conexão
+func startBus(lc fx.Lifecycle, report *shutdownReport) error {
+	var err error
+	if messageBus, err = newQuoteBus(); err != nil {
+		return fmt.Errorf("failed to open message bus: %w", err)
+	}
+	if messageBus == nil {
+		return nil
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			messageBus.start()
+			log.Printf("Cotações publicadas no %s em %s (%s)", cfg.BusBackend, cfg.BusTopic, cfg.BusFormat)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			dropped, err := messageBus.close(ctx)
+			report.BusMessagesDropped = dropped
+			report.addError("bus", err)
+			return nil
+		},
+	})
+	return nil
+}
+
+// startMQTT conecta ao broker MQTT (MQTT_BROKER) dos mostradores; ao parar, depois dos jobs,
+// anuncia o status offline e desconecta
+func startMQTT(lc fx.Lifecycle, report *shutdownReport) error {
+	var err error
+	if mqttDisplay, err = newMQTTTicker(); err != nil {
+		return fmt.Errorf("invalid MQTT configuration: %w", err)
+	}
+	if mqttDisplay == nil {
+		return nil
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			mqttDisplay.start()
+			log.Printf("Bids publicados no MQTT em %s/{par}", cfg.MQTTTopic)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			report.addError("mqtt", mqttDisplay.close())
+			return nil
+		},
+	})
+	return nil
+}
+
+// startJobs inicia os jobs em segundo plano; o agendador e a avaliação de alertas rodam só na
+// instância eleita líder (LEADER_ELECTION). Ao parar, aguarda os ciclos em andamento e
+// devolve a liderança
+func startJobs(lc fx.Lifecycle, report *shutdownReport) error {
+	var err error
+	if leader, err = newLeaderElector(); err != nil {
+		return fmt.Errorf("invalid leader election: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			// Antes da outbox, que entrega as cotações aos alertas
+			pollScheduler = newScheduler(cfg.PollInterval, cfg.PollJitter)
+			leader.start(ctx, func(ctx context.Context) {
+				alertsEngine.start(ctx)
+				pollScheduler.start(ctx)
+			})
+			if cfg.AlertRulesFile != "" {
+				alertsEngine.watchFile(ctx, cfg.AlertRulesFile)
+			}
+			outbox.start(ctx, cfg.OutboxPollInterval, cfg.OutboxRetention)
+			startDiscovery(ctx, cfg.DiscoveryInterval)
+			// Cada instância regrava o próprio journal, independente da liderança
+			if journal != nil {
+				startJournalReplay(ctx, cfg.JournalReplayInterval)
+			}
+			gapBackfills.start(ctx)
+			if cfg.AbuseWindow > 0 {
+				startAbuseSweep(ctx, cfg.AbuseWindow)
+			}
+			// Os demais backends limitam o armazenamento por conta própria (buffer circular, TTL)
+			// e não mantêm agregados
+			if repo, ok := rateRepo.(maintainedRepository); ok {
+				repo.startMaintenance(ctx)
+			}
+			if !memoryOnly {
+				startAuditRetention(ctx, cfg.PruneInterval, cfg.AuditRetention)
+				startQuotaFlush(ctx)
+			}
+			if cfg.StorageBackend == storageSQLite {
+				startRetention(ctx, cfg.PruneInterval, cfg.RetentionRaw)
+				startRollup(ctx, cfg.RollupInterval)
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			backgroundJobs.Wait()
+			if !memoryOnly {
+				report.addError("quota", quota.flush(context.Background()))
+			}
+			report.addError("leader", leader.release())
+			return nil
+		},
+	})
+	return nil
+}
+
+// httpServer é o servidor da API com o socket em que atende, usado também para o
+// reinício sem interrupção
+type httpServer struct {
+	server *http.Server
+	ln     net.Listener
+}
+
+func newHTTPServer(lc fx.Lifecycle, report *shutdownReport) (*httpServer, error) {
+	http.HandleFunc("/cotacao", GetExchangeRateHandler)
+	http.HandleFunc("/cotacao/interna", InternalRateHandler)
+	http.HandleFunc("/cotacao/compare", CompareHandler)
+	http.HandleFunc("/cotacao/ptax", PTAXHandler)
+	http.HandleFunc("/converter", ConverterHandler)
+	http.HandleFunc("/historico", AuthMiddleware(GetHistoryHandler))
+	http.HandleFunc("/historico/export", AuthMiddleware(ExportHandler))
+	http.HandleFunc("/cotacao/export", AuthMiddleware(ExportHandler))
+	http.HandleFunc("/historico/resumos", AuthMiddleware(SummariesHandler))
+	http.HandleFunc("/historico/compartilhar", AuthMiddleware(ShareLinkHandler))
+	http.HandleFunc("/compartilhado/historico", SharedHistoryHandler)
+	http.HandleFunc("/compartilhado/export", SharedExportHandler)
+	http.HandleFunc("/trades", AuthMiddleware(TradesHandler))
+	http.HandleFunc("/graphql", newGraphQLHandler())
+	http.HandleFunc("/alerts", AuthMiddleware(AlertsHandler))
+	http.HandleFunc("/alerts/", AuthMiddleware(AlertHandler))
+	http.HandleFunc("/alerts/backtest", AuthMiddleware(AlertBacktestHandler))
+	http.HandleFunc("/auth/register", RegisterHandler)
+	http.HandleFunc("/auth/login", LoginHandler)
+	http.HandleFunc("/admin/bans", AdminMiddleware(BansHandler))
+	http.HandleFunc("/admin/allowlist", AdminMiddleware(AllowlistHandler))
+	http.HandleFunc("/admin/providers", AdminMiddleware(ProvidersHandler))
+	http.HandleFunc("/admin/pairs", AdminMiddleware(AdminPairsHandler))
+	http.HandleFunc("/admin/pairs/available", AdminMiddleware(AvailablePairsHandler))
+	http.HandleFunc("/admin/notifications", AdminMiddleware(NotificationsHandler))
+	http.HandleFunc("/admin/prune", AdminMiddleware(PruneHandler))
+	http.HandleFunc("/admin/refresh", AdminMiddleware(RefreshHandler))
+	http.HandleFunc("/admin/backfill", AdminMiddleware(BackfillHandler))
+	http.HandleFunc("/admin/dedup", AdminMiddleware(DedupHandler))
+	http.HandleFunc("/admin/data-quality", AdminMiddleware(DataQualityHandler))
+	http.HandleFunc("/admin/backfill-gaps", AdminMiddleware(BackfillGapsHandler))
+	http.HandleFunc("/admin/audit", AdminMiddleware(AuditHandler))
+	http.HandleFunc("/admin/stats", AdminMiddleware(StatsHandler))
+	http.HandleFunc("/admin/leader", AdminMiddleware(LeaderHandler))
+	http.HandleFunc("/events/export", AdminMiddleware(EventsExportHandler))
+	http.HandleFunc("/pairs", PairsHandler)
+	http.HandleFunc("/pairs/", PairHandler)
+	http.HandleFunc("/schema", SchemaHandler)
+	http.HandleFunc("/schema/", SchemaHandler)
+	http.HandleFunc("/", DashboardHandler)
+	http.Handle("/dashboard/", dashboardAssets)
+	if cfg.ProxyMode {
+		http.HandleFunc(proxyPrefix, ProxyHandler)
+	}
+	http.Handle("/metrics", promhttp.Handler())
+
+	mux := http.DefaultServeMux
+	handler := RequestIDMiddleware(AbuseMiddleware(CORSMiddleware(CompressionMiddleware(ConcurrencyMiddleware(mux, MetricsMiddleware(mux))))))
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: HoneypotMiddleware(otelhttp.NewHandler(handler, "http.server")),
+
+		// Sem prazos, conexões lentas de propósito (slowloris) ocupariam o servidor indefinidamente
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+	}
+	server.SetKeepAlivesEnabled(cfg.HTTPKeepAlive)
+	redirect, err := configureTLS(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if err := configureHTTP2(server); err != nil {
+		return nil, fmt.Errorf("failed to configure HTTP/2: %w", err)
+	}
+
+	s := &httpServer{server: server}
+	redirectCtx, cancelRedirect := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			if s.ln, err = listen(server.Addr); err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+			go func() {
+				// O socket é fechado antes do encerramento quando outra instância assume
+				serve := func() error { return server.Serve(s.ln) }
+				if server.TLSConfig != nil {
+					log.Printf("Servidor HTTPS iniciado na porta %s...", cfg.Port)
+					serve = func() error { return server.ServeTLS(s.ln, "", "") }
+				} else {
+					log.Printf("Servidor iniciado na porta %s...", cfg.Port)
+				}
+				if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+					log.Fatal("failed to start server: ", err)
+				}
+			}()
+
+			// Com autocert, sem o listener HTTP só o desafio TLS-ALPN-01 (na porta HTTPS) é usado
+			if redirect != nil && cfg.TLSRedirectAddr != "" {
+				serveRedirect(redirectCtx, cfg.TLSRedirectAddr, redirect)
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			defer cancelRedirect()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Erro ao encerrar servidor: %v", err)
+				report.addError("server", err)
+			}
+			report.serverStopped()
+			return nil
+		},
+	})
+	return s, nil
+}
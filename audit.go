@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operações registradas no log de auditoria
+const (
+	auditInsert   = "insert"
+	auditPrune    = "prune"
+	auditBackfill = "backfill"
+	auditDedup    = "dedup"
+)
+
+// Origens das cotações gravadas, na coluna source
+const (
+	sourceRequest     = "request"
+	sourceScheduler   = "scheduler"
+	sourceBackfill    = "backfill"
+	sourceGapBackfill = "gap-backfill"
+	sourceDerived     = "derived"
+)
+
+// AuditLogDB registra uma operação sobre as cotações gravadas: quem a executou (actor),
+// em qual requisição e quantas cotações foram afetadas
+type AuditLogDB struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Action    string    `gorm:"type:varchar(20);not null;index" json:"action"`
+	Actor     string    `gorm:"type:varchar(100);not null" json:"actor"` // user:{id}, anonymous ou system
+	RequestID string    `gorm:"type:varchar(128)" json:"request_id,omitempty"`
+	Pair      string    `gorm:"type:varchar(21)" json:"pair,omitempty"`
+	Affected  int64     `gorm:"not null" json:"affected"`
+	Details   string    `gorm:"type:text" json:"details,omitempty"`
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+type (
+	rateSourceKey struct{}
+	auditActorKey struct{}
+)
+
+// withRateSource marca a origem das cotações gravadas com ctx
+func withRateSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, rateSourceKey{}, source)
+}
+
+// rateSourceFromContext devolve a origem marcada em ctx; sem marca, a cotação veio de uma
+// requisição à API
+func rateSourceFromContext(ctx context.Context) string {
+	if source, ok := ctx.Value(rateSourceKey{}).(string); ok {
+		return source
+	}
+	return sourceRequest
+}
+
+// auditActor identifica quem executa a operação: o usuário autenticado, um cliente anônimo
+// da API ou o próprio serviço, nas tarefas em segundo plano
+func auditActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey{}).(string); ok {
+		return actor
+	}
+	if userID, ok := userIDFromContext(ctx); ok {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	if requestIDFromContext(ctx) != "" {
+		return "anonymous"
+	}
+	return "system"
+}
+
+// withAuditActor atribui a actor as operações executadas com ctx, como as tarefas em segundo
+// plano agendadas por um usuário
+func withAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// newAuditEntry monta o registro de uma operação executada com ctx
+func newAuditEntry(ctx context.Context, action, pair string, affected int64, details string) AuditLogDB {
+	return AuditLogDB{
+		Action:    action,
+		Actor:     auditActor(ctx),
+		RequestID: requestIDFromContext(ctx),
+		Pair:      pair,
+		Affected:  affected,
+		Details:   details,
+	}
+}
+
+// insertAudit registra a gravação de uma cotação com os dados de auditoria da própria linha
+func insertAudit(row *USDToBRLRateDB) AuditLogDB {
+	return AuditLogDB{
+		Action:    auditInsert,
+		Actor:     row.CreatedBy,
+		RequestID: row.RequestID,
+		Pair:      row.Pair,
+		Affected:  1,
+		Details:   fmt.Sprintf("uid=%s timestamp=%d source=%s provider=%s", row.UID, row.Timestamp, row.Source, row.Provider),
+	}
+}
+
+// auditInserts monta os registros de auditoria de um lote de cotações gravadas
+func auditInserts(rows []USDToBRLRateDB) []AuditLogDB {
+	entries := make([]AuditLogDB, len(rows))
+	for i := range rows {
+		entries[i] = insertAudit(&rows[i])
+	}
+	return entries
+}
+
+// recordAudit grava o registro; uma falha só é registrada no log, sem desfazer a operação
+// auditada. Dentro de uma transação, use tx.Create para gravar junto com a operação
+func recordAudit(ctx context.Context, entry AuditLogDB) {
+	if err := db.WithContext(context.WithoutCancel(ctx)).Create(&entry).Error; err != nil {
+		logf(ctx, "Erro ao registrar auditoria de %s: %v", entry.Action, err)
+	}
+}
+
+// pruneAuditLog apaga os registros de auditoria anteriores a retention; cada cotação gravada
+// gera um registro, e sem a limpeza a tabela cresce sem limite
+func pruneAuditLog(ctx context.Context, retention time.Duration) (int64, error) {
+	result := db.WithContext(ctx).Where("created_at < ?", time.Now().UTC().Add(-retention)).Delete(&AuditLogDB{})
+	return result.RowsAffected, result.Error
+}
+
+// startAuditRetention agenda a limpeza do log de auditoria, que fica no SQLite qualquer que
+// seja o backend das cotações
+func startAuditRetention(ctx context.Context, interval, retention time.Duration) {
+	if interval <= 0 || retention <= 0 {
+		return
+	}
+	runPeriodically(ctx, interval, func(ctx context.Context) {
+		removed, err := pruneAuditLog(ctx, retention)
+		if err != nil {
+			log.Printf("Erro ao limpar o log de auditoria: %v", err)
+			return
+		}
+		if removed > 0 {
+			log.Printf("Log de auditoria: %d registros anteriores a %s removidos", removed, retention)
+		}
+	})
+}
+
+// Campos ordenáveis do log de auditoria; since/until filtram pelo horário da operação
+var auditListSpec = listSpec{
+	sortFields:     map[string]string{"id": "id", "created_at": "created_at"},
+	defaultSort:    "id:desc",
+	defaultPerPage: 100,
+	maxPerPage:     1000,
+}
+
+// AuditHandler lista o log de auditoria, do registro mais recente para o mais antigo, com a
+// paginação de /historico e os filtros ?action=, ?actor=, ?request_id=, ?pair=, ?since= e ?until=
+func AuditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	q, err := parseListQuery(params, auditListSpec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx := db.WithContext(r.Context()).Model(&AuditLogDB{})
+	if action := params.Get("action"); action != "" {
+		tx = tx.Where("action = ?", strings.ToLower(action))
+	}
+	if actor := params.Get("actor"); actor != "" {
+		tx = tx.Where("actor = ?", actor)
+	}
+	if requestID := params.Get("request_id"); requestID != "" {
+		tx = tx.Where("request_id = ?", requestID)
+	}
+	if q.Pair != "" {
+		tx = tx.Where("pair = ?", q.Pair)
+	}
+	if !q.Since.IsZero() {
+		tx = tx.Where("created_at >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		tx = tx.Where("created_at < ?", q.Until.UTC())
+	}
+
+	var total int64
+	entries := []AuditLogDB{}
+	err = tx.Count(&total).Error
+	if err == nil {
+		err = tx.Order(fmt.Sprintf("%s %s, id %[2]s", q.Sort, q.direction())).
+			Offset(q.offset()).Limit(q.PerPage).Find(&entries).Error
+	}
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar o log de auditoria: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	writePageHeaders(w, r, q, total)
+	writeJSON(w, http.StatusOK, entries)
+}
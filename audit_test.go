@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	newTestDB(t)
+	now := time.Now()
+	userCtx := context.WithValue(context.WithValue(context.Background(), userIDKey, uint(7)), requestIDKey, "req-1")
+
+	for _, at := range []time.Time{now.AddDate(0, 0, -20), now.AddDate(0, 0, -15), now} {
+		quote := testQuote("5.1", at)
+		quote.Provider = awesomeAPIProvider
+		if err := SaveExchangeRate(userCtx, "USD-BRL", &quote); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scheduled := testQuote("5.2", now.Add(-time.Minute))
+	if err := SaveExchangeRate(withRateSource(context.Background(), sourceScheduler), "USD-BRL", &scheduled); err != nil {
+		t.Fatal(err)
+	}
+
+	var row USDToBRLRateDB
+	if err := db.Where("timestamp = ?", now.Unix()).First(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.CreatedBy != "user:7" || row.Source != sourceRequest || row.Provider != awesomeAPIProvider {
+		t.Errorf("auditoria da cotação = %q/%q/%q, esperado user:7/request/awesomeapi", row.CreatedBy, row.Source, row.Provider)
+	}
+
+	result, err := pruneRates(context.Background(), 10*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PrunedRows != 2 {
+		t.Fatalf("pruned_rows = %d, esperado 2", result.PrunedRows)
+	}
+	var visible, stored int64
+	db.Model(&USDToBRLRateDB{}).Count(&visible)
+	db.Unscoped().Model(&USDToBRLRateDB{}).Count(&stored)
+	if visible != 2 || stored != 2 {
+		t.Errorf("cotações visíveis/gravadas = %d/%d, esperado 2/2", visible, stored)
+	}
+
+	// A retenção apaga de vez, e o backfill pode gravar de novo a cotação removida
+	pruned := testQuote("5.1", now.AddDate(0, 0, -20))
+	if err := SaveExchangeRate(userCtx, "USD-BRL", &pruned); err != nil {
+		t.Fatal(err)
+	}
+	db.Unscoped().Model(&USDToBRLRateDB{}).Count(&stored)
+	if stored != 3 {
+		t.Errorf("cotação removida não foi gravada de novo: %d cotações, esperado 3", stored)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTotal  string
+		wantFirst  AuditLogDB
+	}{
+		{name: "mais recente primeiro", wantStatus: http.StatusOK, wantTotal: "6",
+			wantFirst: AuditLogDB{Action: auditInsert, Actor: "user:7", RequestID: "req-1", Pair: "USD-BRL", Affected: 1}},
+		{name: "retenção", query: "action=prune", wantStatus: http.StatusOK, wantTotal: "1",
+			wantFirst: AuditLogDB{Action: auditPrune, Actor: "system", Affected: 2}},
+		{name: "por ação", query: "action=insert&sort=id:asc", wantStatus: http.StatusOK, wantTotal: "5",
+			wantFirst: AuditLogDB{Action: auditInsert, Actor: "user:7", RequestID: "req-1", Pair: "USD-BRL", Affected: 1}},
+		{name: "por ator", query: "actor=system&action=insert", wantStatus: http.StatusOK, wantTotal: "1",
+			wantFirst: AuditLogDB{Action: auditInsert, Actor: "system", Pair: "USD-BRL", Affected: 1}},
+		{name: "por requisição", query: "request_id=req-1&per_page=1", wantStatus: http.StatusOK, wantTotal: "4",
+			wantFirst: AuditLogDB{Action: auditInsert, Actor: "user:7", RequestID: "req-1", Pair: "USD-BRL", Affected: 1}},
+		{name: "ordenação inválida", query: "sort=actor", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, ok := serve(t, AuditHandler, http.MethodGet, "/admin/audit?"+tt.query, "", tt.wantStatus)
+			if !ok {
+				return
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("X-Total-Count = %s, esperado %s", got, tt.wantTotal)
+			}
+
+			entries := decodeJSON[[]AuditLogDB](t, rec)
+			if len(entries) == 0 {
+				t.Fatal("nenhum registro")
+			}
+			got := entries[0]
+			if got.Action != tt.wantFirst.Action || got.Actor != tt.wantFirst.Actor || got.RequestID != tt.wantFirst.RequestID ||
+				got.Pair != tt.wantFirst.Pair || got.Affected != tt.wantFirst.Affected {
+				t.Errorf("primeiro registro = %+v, esperado %+v", got, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestPruneAuditLog(t *testing.T) {
+	newTestDB(t)
+	now := time.Now().UTC()
+	for _, at := range []time.Time{now.AddDate(0, 0, -100), now.AddDate(0, 0, -95), now.AddDate(0, 0, -1)} {
+		if err := db.Create(&AuditLogDB{Action: auditInsert, Actor: "system", Affected: 1, CreatedAt: at}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := pruneAuditLog(context.Background(), 90*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left int64
+	db.Model(&AuditLogDB{}).Count(&left)
+	if removed != 2 || left != 1 {
+		t.Errorf("removidos/restantes = %d/%d, esperado 2/1", removed, left)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type UserDB struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	Email        string    `gorm:"type:varchar(255);uniqueIndex;not null"`
+	PasswordHash string    `gorm:"not null"`
+	IsAdmin      bool      `gorm:"not null;default:false"`
+	CreatedAt    time.Time `gorm:"not null"`
+}
+
+// Registro de uso da API por usuário autenticado
+type AccessLogDB struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"index;not null"`
+	Method    string `gorm:"type:varchar(10);not null"`
+	Path      string `gorm:"type:varchar(255);not null"`
+	IP        string `gorm:"type:varchar(45)"`
+	Country   string `gorm:"type:varchar(2)"`
+	ASN       uint
+	ASOrg     string    `gorm:"type:varchar(255)"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type contextKey string
+
+const userIDKey contextKey = "userID"
+
+func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var creds Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+	creds.Email = strings.TrimSpace(strings.ToLower(creds.Email))
+	if creds.Email == "" || len(creds.Password) < 8 {
+		writeError(w, http.StatusBadRequest, "email obrigatório e senha com no mínimo 8 caracteres")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		logf(r.Context(), "Erro ao gerar hash da senha: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	user := UserDB{Email: creds.Email, PasswordHash: string(hash), IsAdmin: slices.Contains(cfg.AdminEmails, creds.Email)}
+	if err := db.WithContext(r.Context()).Create(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "UNIQUE") {
+			writeError(w, http.StatusConflict, "email já cadastrado")
+			return
+		}
+		logf(r.Context(), "Erro ao criar usuário: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": user.ID, "email": user.Email})
+}
+
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var creds Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+
+	var user UserDB
+	err := db.WithContext(r.Context()).Where("email = ?", strings.TrimSpace(strings.ToLower(creds.Email))).First(&user).Error
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(creds.Password)) != nil {
+		writeError(w, http.StatusUnauthorized, "credenciais inválidas")
+		return
+	}
+
+	token, expiresAt, err := generateToken(user.ID)
+	if err != nil {
+		logf(r.Context(), "Erro ao gerar token: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TokenResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+// Antigo padrão de JWT_SECRET; quem o conhecesse forjaria tokens e links de compartilhamento
+const jwtSecretPlaceholder = "change-me"
+
+// checkJWTSecret exige um JWT_SECRET próprio, que assina os tokens e, sem SHARE_LINK_SECRET, os
+// links de compartilhamento. Com DEV_MODE o servidor sobe sem ele, usando um segredo aleatório:
+// os tokens deixam de valer a cada reinício
+func checkJWTSecret() error {
+	if cfg.JWTSecret != "" && cfg.JWTSecret != jwtSecretPlaceholder {
+		return nil
+	}
+	if !cfg.DevMode {
+		return errors.New("JWT_SECRET ausente ou com o valor de exemplo; defina um segredo ou use DEV_MODE=true em desenvolvimento")
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	cfg.JWTSecret = secret
+	log.Printf("DEV_MODE: JWT_SECRET não definido, usando um segredo aleatório")
+	return nil
+}
+
+func generateToken(userID uint) (string, time.Time, error) {
+	expiresAt := time.Now().Add(cfg.JWTExpiration)
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+	return signed, expiresAt, err
+}
+
+func parseToken(tokenString string) (uint, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
+		return []byte(cfg.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	if !ok {
+		return 0, errors.New("claims inválidas")
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// AuthMiddleware valida o token JWT e registra o uso da API pelo usuário
+func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || tokenString == "" {
+			writeError(w, http.StatusUnauthorized, "token ausente")
+			return
+		}
+
+		userID, err := parseToken(tokenString)
+		if err != nil || userID == 0 {
+			writeError(w, http.StatusUnauthorized, "token inválido")
+			return
+		}
+
+		ip := clientIP(r)
+		geo := lookupGeo(ip)
+		accessLog := AccessLogDB{
+			UserID:  userID,
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			IP:      ip,
+			Country: geo.Country,
+			ASN:     geo.ASN,
+			ASOrg:   geo.ASOrg,
+		}
+		if err := db.WithContext(r.Context()).Create(&accessLog).Error; err != nil {
+			logf(r.Context(), "Erro ao registrar uso da API: %v", err)
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// AdminMiddleware exige um usuário autenticado com perfil de administrador
+func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := userIDFromContext(r.Context())
+
+		var user UserDB
+		if err := db.WithContext(r.Context()).First(&user, userID).Error; err != nil || !user.IsAdmin {
+			writeError(w, http.StatusForbidden, "acesso restrito a administradores")
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+func userIDFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(userIDKey).(uint)
+	return id, ok
+}
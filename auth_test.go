@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+func TestCheckJWTSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		devMode    bool
+		wantErr    bool
+		wantRandom bool
+	}{
+		{name: "segredo definido", secret: "s3cr3t"},
+		{name: "sem segredo", wantErr: true},
+		{name: "valor de exemplo", secret: jwtSecretPlaceholder, wantErr: true},
+		{name: "sem segredo em desenvolvimento", devMode: true, wantRandom: true},
+		{name: "valor de exemplo em desenvolvimento", secret: jwtSecretPlaceholder, devMode: true, wantRandom: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, func(c *config.Config) {
+				c.JWTSecret, c.DevMode = tt.secret, tt.devMode
+			})
+
+			err := checkJWTSecret()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+			if random := cfg.JWTSecret != tt.secret; random != tt.wantRandom {
+				t.Errorf("JWT_SECRET = %q, esperado segredo aleatório %v", cfg.JWTSecret, tt.wantRandom)
+			}
+			if tt.wantRandom && len(cfg.JWTSecret) < 32 {
+				t.Errorf("segredo aleatório curto: %q", cfg.JWTSecret)
+			}
+		})
+	}
+}
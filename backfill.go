@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limite de dias por importação aceito pelo endpoint diário do AwesomeAPI
+const maxBackfillDays = 1000
+
+type BackfillResult struct {
+	Pair     string    `json:"pair"`
+	Days     int       `json:"days"`
+	Received int       `json:"received"`
+	Imported int       `json:"imported"`
+	Skipped  int       `json:"skipped"` // cotações já existentes no banco
+	From     time.Time `json:"from"`    // intervalo das cotações importadas
+	To       time.Time `json:"to"`
+}
+
+// fetchDailyHistory consulta o histórico diário do AwesomeAPI (/json/daily/{par}/{dias});
+// só a primeira cotação traz code e codein, que são copiados para as demais
+func fetchDailyHistory(ctx context.Context, pair string, days int) ([]Quote, error) {
+	return fetchDaily(ctx, pair, days, nil)
+}
+
+// fetchDailyRange consulta o mesmo endpoint restrito às datas (UTC) de from a to, com
+// start_date e end_date; a API devolve até maxBackfillDays cotações do período
+func fetchDailyRange(ctx context.Context, pair string, from, to time.Time) ([]Quote, error) {
+	query := url.Values{}
+	query.Set("start_date", from.UTC().Format("20060102"))
+	query.Set("end_date", to.UTC().Format("20060102"))
+	return fetchDaily(ctx, pair, maxBackfillDays, query)
+}
+
+func fetchDaily(ctx context.Context, pair string, days int, query url.Values) ([]Quote, error) {
+	if quota.exhausted() {
+		return nil, errQuotaExhausted
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	target := fmt.Sprintf("%s/json/daily/%s/%d", cfg.AwesomeAPIBaseURL, url.PathEscape(pair), days)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quota.increment()
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream respondeu com status %d", resp.StatusCode)
+	}
+
+	var quotes []Quote
+	if err := json.NewDecoder(resp.Body).Decode(&quotes); err != nil {
+		return nil, err
+	}
+	for i := range quotes {
+		quotes[i].Code, quotes[i].Codein = quotes[0].Code, quotes[0].Codein
+	}
+	return quotes, nil
+}
+
+// importRates grava as cotações do par, ignorando as cujo timestamp já existe no banco; a
+// importação pode ser repetida sem duplicar dados. As cotações importadas não geram eventos
+// na outbox, pois não são novidades para os alertas, e a importação toda é registrada como
+// uma única operação no log de auditoria. Timestamps removidos pela retenção continuam no
+// índice único e também são ignorados
+func importRates(ctx context.Context, pair string, quotes []Quote, result *BackfillResult) error {
+	result.Received = len(quotes)
+
+	for i := range quotes {
+		var payloadErr *PayloadError
+		if err := validateQuote(&quotes[i]); errors.As(err, &payloadErr) {
+			rejectPayload(ctx, awesomeAPIProvider, pair, &quotes[i], payloadErr)
+			result.Skipped++
+			continue
+		}
+		row := newRateRow(ctx, pair, &quotes[i])
+
+		err := rateRepo.Save(ctx, &row)
+		if errors.Is(err, errDuplicateRate) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		result.Imported++
+		at := time.Unix(row.Timestamp, 0).UTC()
+		if result.From.IsZero() || at.Before(result.From) {
+			result.From = at
+		}
+		if at.After(result.To) {
+			result.To = at
+		}
+	}
+
+	recordAudit(ctx, newAuditEntry(ctx, auditBackfill, pair, int64(result.Imported),
+		fmt.Sprintf("source=%s received=%d skipped=%d", rateSourceFromContext(ctx), result.Received, result.Skipped)))
+
+	if result.Imported > 0 {
+		to := result.To.Add(time.Second)
+		if cfg.StorageBackend == storageSQLite {
+			if _, err := rollup(db.WithContext(ctx), result.From, to, hourlyRollup, dailyRollup); err != nil {
+				return fmt.Errorf("erro ao consolidar agregados: %w", err)
+			}
+		}
+		historyResults.invalidateRange(result.From.Truncate(24*time.Hour), to)
+	}
+	return nil
+}
+
+// BackfillHandler importa o histórico diário de ?pair= (padrão USD-BRL) dos últimos ?days=
+// dias (padrão 30), para que as análises tenham dados desde o primeiro dia
+func BackfillHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	pair := strings.ToUpper(q.Get("pair"))
+	if pair == "" {
+		pair = defaultPair
+	}
+	if isVirtualPair(pair) || !pairs.isEnabled(pair) {
+		writeError(w, http.StatusNotFound, "par não habilitado")
+		return
+	}
+
+	days := 30
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxBackfillDays {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("days deve estar entre 1 e %d", maxBackfillDays))
+			return
+		}
+		days = n
+	}
+
+	quotes, err := fetchDailyHistory(r.Context(), pair, days)
+	if err != nil {
+		logf(r.Context(), "Erro ao obter histórico diário de %s: %v", pair, err)
+		writeError(w, http.StatusBadGateway, "erro ao obter histórico do provedor")
+		return
+	}
+
+	result := BackfillResult{Pair: pair, Days: days}
+	if err := importRates(withRateSource(r.Context(), sourceBackfill), pair, quotes, &result); err != nil {
+		logf(r.Context(), "Erro ao importar histórico de %s: %v", pair, err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	logf(r.Context(), "Histórico de %s importado: %d cotações novas, %d já existentes", pair, result.Imported, result.Skipped)
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Estados de contrapressão do agendador, na ordem de gravidade
+const (
+	pressureNormal = iota
+	pressureSlow
+	pressurePaused
+)
+
+var pressureNames = []string{"normal", "lento", "pausado"}
+
+// No estado lento o agendador executa apenas um a cada slowCycles ciclos
+const slowCycles = 4
+
+var schedulerPressure = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "scheduler_backpressure_state",
+	Help: "Estado de contrapressão do agendador: 0 normal, 1 lento, 2 pausado.",
+})
+
+// backpressure mede as filas que dependem do banco: o buffer do modo em lote e os eventos
+// da outbox ainda não entregues. Acima dos limites, novas cotações só aumentariam o atraso
+func backpressure(ctx context.Context) (level int, reason string) {
+	if batcher != nil {
+		fill := float64(len(batcher.rows)) / float64(cap(batcher.rows))
+		reason = fmt.Sprintf("fila de gravação em lote com %.0f%% de ocupação", fill*100)
+		switch {
+		case fill >= cfg.BackpressurePauseRatio:
+			return pressurePaused, reason
+		case fill >= cfg.BackpressureSlowRatio:
+			level = pressureSlow
+		}
+	}
+
+	// Os eventos abandonados pelo dispatcher não contam, como no relatório de encerramento
+	var pending int64
+	if err := db.WithContext(ctx).Model(&OutboxEventDB{}).
+		Where("dispatched_at IS NULL AND attempts < ?", outboxMaxAttempts).Count(&pending).Error; err != nil {
+		return level, reason
+	}
+	outboxReason := fmt.Sprintf("%d eventos pendentes na outbox", pending)
+	switch {
+	case cfg.OutboxBacklogPause > 0 && pending >= int64(cfg.OutboxBacklogPause):
+		return pressurePaused, outboxReason
+	case cfg.OutboxBacklogSlow > 0 && pending >= int64(cfg.OutboxBacklogSlow) && level < pressureSlow:
+		return pressureSlow, outboxReason
+	}
+	return level, reason
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+var (
+	batchFlushes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_batch_flushes_total",
+		Help: "Descargas do buffer de inserções em lote por resultado.",
+	}, []string{"result"})
+
+	batchRows = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "db_batch_rows_total",
+		Help: "Linhas gravadas pelo buffer de inserções em lote.",
+	})
+)
+
+// batchRow é uma cotação e o evento da outbox gravados juntos
+type batchRow struct {
+	rate  USDToBRLRateDB
+	event OutboxEventDB
+}
+
+// batchWriter acumula cotações e as grava com CreateInBatches a cada size linhas ou
+// interval, reduzindo a contenção de escrita no SQLite; cada lote e seus eventos da outbox
+// são gravados na mesma transação
+type batchWriter struct {
+	rows     chan batchRow
+	size     int
+	interval time.Duration
+	done     chan struct{}
+
+	// mu impede que enqueue envie para rows depois de close; revalidações em segundo plano
+	// podem terminar durante o encerramento
+	mu     sync.RWMutex
+	closed bool
+
+	written atomic.Int64 // linhas gravadas
+	failed  atomic.Int64 // linhas perdidas em lotes que falharam
+}
+
+// errBatcherClosed indica que o buffer já foi encerrado e a cotação deve ser gravada direto
+var errBatcherClosed = errors.New("buffer de inserções em lote encerrado")
+
+// batcher é nil quando a persistência é síncrona
+var batcher *batchWriter
+
+func newBatchWriter(size int, interval time.Duration) *batchWriter {
+	return &batchWriter{
+		rows:     make(chan batchRow, size*10),
+		size:     size,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *batchWriter) start() {
+	go b.run()
+}
+
+func (b *batchWriter) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	buffer := make([]batchRow, 0, b.size)
+	for {
+		select {
+		case row, ok := <-b.rows:
+			if !ok {
+				b.flush(buffer)
+				return
+			}
+			buffer = append(buffer, row)
+			if len(buffer) >= b.size {
+				b.flush(buffer)
+				buffer = buffer[:0]
+			}
+		case <-ticker.C:
+			b.flush(buffer)
+			buffer = buffer[:0]
+		}
+	}
+}
+
+func (b *batchWriter) flush(buffer []batchRow) {
+	if len(buffer) == 0 {
+		return
+	}
+
+	var rates []USDToBRLRateDB
+	err := db.Transaction(func(tx *gorm.DB) error {
+		fresh, err := dropDuplicates(tx, buffer)
+		if err != nil || len(fresh) == 0 {
+			return err
+		}
+
+		rates = make([]USDToBRLRateDB, len(fresh))
+		events := make([]OutboxEventDB, len(fresh))
+		for i, row := range fresh {
+			rates[i], events[i] = row.rate, row.event
+		}
+		if err := tx.CreateInBatches(rates, b.size).Error; err != nil {
+			return err
+		}
+		if err := addToSummaries(tx, rates); err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(auditInserts(rates), b.size).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(events, b.size).Error
+	})
+	if err != nil {
+		batchFlushes.WithLabelValues("error").Inc()
+		log.Printf("Erro ao gravar lote de %d cotações: %v", len(buffer), err)
+		// As cotações guardadas no journal são regravadas quando o banco voltar
+		b.failed.Add(int64(len(buffer) - journalBatch(buffer, err)))
+		return
+	}
+	batchFlushes.WithLabelValues("ok").Inc()
+	batchRows.Add(float64(len(rates)))
+	b.written.Add(int64(len(rates)))
+	for _, rate := range rates {
+		historyResults.invalidate(rate.Pair, rate.Timestamp)
+	}
+	outbox.notify()
+}
+
+// dropDuplicates descarta do lote as cotações repetidas, no próprio lote ou já gravadas, junto
+// com seus eventos; o lote é gravado de uma vez e o índice único recusaria a transação inteira
+func dropDuplicates(tx *gorm.DB, buffer []batchRow) ([]batchRow, error) {
+	timestamps := make(map[string][]int64)
+	for _, row := range buffer {
+		timestamps[row.rate.Pair] = append(timestamps[row.rate.Pair], row.rate.Timestamp)
+	}
+
+	stored := make(map[rateKey]bool)
+	for pair, values := range timestamps {
+		var existing []int64
+		// As removidas (deleted_at) não estão no índice único e podem ser gravadas de novo
+		if err := tx.Model(&USDToBRLRateDB{}).Where("pair = ? AND timestamp IN ?", pair, values).
+			Pluck("timestamp", &existing).Error; err != nil {
+			return nil, err
+		}
+		for _, ts := range existing {
+			stored[rateKey{pair, ts}] = true
+		}
+	}
+
+	fresh := make([]batchRow, 0, len(buffer))
+	for _, row := range buffer {
+		key := rateKey{row.rate.Pair, row.rate.Timestamp}
+		if stored[key] {
+			duplicateRates.Inc()
+			continue
+		}
+		stored[key] = true
+		fresh = append(fresh, row)
+	}
+	return fresh, nil
+}
+
+// enqueue adiciona a linha ao buffer, aguardando vaga no máximo até o prazo do contexto
+func (b *batchWriter) enqueue(ctx context.Context, row batchRow) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errBatcherClosed
+	}
+	select {
+	case b.rows <- row:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close interrompe o recebimento e aguarda a descarga final do buffer, retornando quantas
+// linhas pendentes foram gravadas e quantas se perderam
+func (b *batchWriter) close() (flushed, dropped int64) {
+	written, failed := b.written.Load(), b.failed.Load()
+	b.mu.Lock()
+	b.closed = true
+	close(b.rows)
+	b.mu.Unlock()
+	<-b.done
+	return b.written.Load() - written, b.failed.Load() - failed
+}
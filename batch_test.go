@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Revalidações em segundo plano podem gravar depois do encerramento do buffer
+func TestSaveAfterBatcherClose(t *testing.T) {
+	conn := newTestDB(t)
+	batcher = newBatchWriter(10, time.Hour)
+	batcher.start()
+	t.Cleanup(func() { batcher = nil })
+
+	now := time.Now()
+	quote := testQuote("5.1", now)
+	if err := SaveExchangeRate(context.Background(), "USD-BRL", &quote); err != nil {
+		t.Fatal(err)
+	}
+	if flushed, dropped := batcher.close(); flushed != 1 || dropped != 0 {
+		t.Fatalf("descarga final = %d gravadas, %d perdidas; esperado 1, 0", flushed, dropped)
+	}
+
+	quote = testQuote("5.2", now.Add(time.Minute))
+	if err := SaveExchangeRate(context.Background(), "USD-BRL", &quote); err != nil {
+		t.Fatalf("gravação após o encerramento = %v, esperado gravação direta", err)
+	}
+	var got int64
+	if err := conn.Model(&USDToBRLRateDB{}).Count(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got != 2 {
+		t.Errorf("cotações gravadas = %d, esperado 2", got)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Barramentos de mensagens suportados em BUS_BACKEND
+const (
+	busKafka = "kafka"
+	busNATS  = "nats"
+)
+
+// Formatos das mensagens em BUS_FORMAT; o schema protobuf está em proto/rate_event.proto
+const (
+	busFormatJSON     = "json"
+	busFormatProtobuf = "protobuf"
+)
+
+// Tentativas de publicar uma cotação antes de descartá-la; a espera entre elas dobra a cada
+// falha, a partir de busRetryDelay
+const (
+	busMaxAttempts    = 5
+	busRetryDelay     = time.Second
+	busPublishTimeout = 10 * time.Second
+)
+
+var busMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bus_messages_total",
+	Help: "Cotações enviadas ao barramento de mensagens por resultado: published, retried (falha com nova tentativa) ou dropped (fila cheia ou tentativas esgotadas).",
+}, []string{"result"})
+
+// busMessage é uma cotação codificada para o barramento. Key é o par: no Kafka é a chave da
+// mensagem, que mantém as cotações de cada par em ordem na mesma partição; no NATS completa
+// o subject
+type busMessage struct {
+	Key         string
+	Value       []byte
+	ContentType string
+}
+
+// quotePublisher entrega as mensagens a um barramento; Publish retorna depois da confirmação
+// do servidor, para que as falhas sejam tentadas de novo
+type quotePublisher interface {
+	Publish(ctx context.Context, msg busMessage) error
+	Close() error
+}
+
+// quoteBus publica em segundo plano cada cotação nova, para consumidores externos (risco,
+// precificação) sem consultar a API. As mensagens têm o formato de /events/export e são
+// entregues ao menos uma vez: os consumidores descartam repetições pelo id. A publicação é de
+// melhor esforço; com a fila cheia ou esgotadas as tentativas a cotação é descartada, e quem
+// precisa de todas recupera as lacunas de seq em /events/export
+type quoteBus struct {
+	publisher quotePublisher
+	format    string
+	queue     chan EventRecord
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	inflight *EventRecord // interrompida pelo encerramento; publicada de novo em close
+}
+
+// messageBus é nil quando BUS_BACKEND está vazio
+var messageBus *quoteBus
+
+func newQuoteBus() (*quoteBus, error) {
+	if cfg.BusBackend == "" {
+		return nil, nil
+	}
+	if cfg.BusFormat != busFormatJSON && cfg.BusFormat != busFormatProtobuf {
+		return nil, fmt.Errorf("BUS_FORMAT deve ser json ou protobuf")
+	}
+	if len(cfg.BusServers) == 0 || cfg.BusTopic == "" {
+		return nil, fmt.Errorf("BUS_SERVERS e BUS_TOPIC são obrigatórios com BUS_BACKEND=%s", cfg.BusBackend)
+	}
+	if cfg.BusQueueSize <= 0 {
+		return nil, fmt.Errorf("BUS_QUEUE_SIZE deve ser positivo")
+	}
+
+	var publisher quotePublisher
+	switch cfg.BusBackend {
+	case busKafka:
+		publisher = newKafkaPublisher(cfg.BusServers, cfg.BusTopic)
+	case busNATS:
+		var err error
+		if publisher, err = newNATSPublisher(cfg.BusServers, cfg.BusTopic); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("barramento de mensagens desconhecido %q", cfg.BusBackend)
+	}
+	return newBus(publisher, cfg.BusFormat, cfg.BusQueueSize), nil
+}
+
+func newBus(publisher quotePublisher, format string, queueSize int) *quoteBus {
+	return &quoteBus{
+		publisher: publisher,
+		format:    format,
+		queue:     make(chan EventRecord, queueSize),
+		done:      make(chan struct{}),
+	}
+}
+
+// enqueue agenda a publicação do evento sem bloquear quem o entregou; com o barramento
+// desativado não faz nada
+func (b *quoteBus) enqueue(event OutboxEventDB) {
+	if b == nil {
+		return
+	}
+	record := newEventRecord(event)
+	if record.PublishedAt == nil {
+		now := time.Now().UTC()
+		record.PublishedAt = &now
+	}
+	select {
+	case b.queue <- record:
+	default:
+		busMessages.WithLabelValues("dropped").Inc()
+		log.Printf("Fila do barramento cheia: cotação %s de %s descartada", record.ID, record.Pair)
+	}
+}
+
+func (b *quoteBus) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	go func() {
+		defer close(b.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case record := <-b.queue:
+				if err := b.send(ctx, record); errors.Is(err, context.Canceled) {
+					b.inflight = &record
+					return
+				}
+			}
+		}
+	}()
+}
+
+// send publica a cotação, tentando de novo com espera crescente; retorna erro apenas quando
+// o encerramento interrompe as tentativas
+func (b *quoteBus) send(ctx context.Context, record EventRecord) error {
+	msg, err := b.encode(record)
+	if err != nil {
+		busMessages.WithLabelValues("dropped").Inc()
+		log.Printf("Erro ao codificar a cotação %s de %s para o barramento: %v", record.ID, record.Pair, err)
+		return nil
+	}
+
+	delay := busRetryDelay
+	for attempt := 1; ; attempt++ {
+		if err = b.publish(ctx, msg); err == nil {
+			busMessages.WithLabelValues("published").Inc()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt == busMaxAttempts {
+			busMessages.WithLabelValues("dropped").Inc()
+			log.Printf("Erro ao publicar a cotação %s de %s no barramento, descartada após %d tentativas: %v",
+				record.ID, record.Pair, attempt, err)
+			return nil
+		}
+		busMessages.WithLabelValues("retried").Inc()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (b *quoteBus) publish(ctx context.Context, msg busMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, busPublishTimeout)
+	defer cancel()
+	return b.publisher.Publish(ctx, msg)
+}
+
+func (b *quoteBus) encode(record EventRecord) (busMessage, error) {
+	msg := busMessage{Key: record.Pair, ContentType: "application/json"}
+	var err error
+	if b.format == busFormatProtobuf {
+		msg.ContentType = "application/x-protobuf"
+		msg.Value, err = marshalRateEventProto(record)
+	} else {
+		msg.Value, err = json.Marshal(record)
+	}
+	return msg, err
+}
+
+// close interrompe a publicação em segundo plano e publica o que ficou na fila, uma tentativa
+// por cotação até o prazo de ctx, retornando quantas se perderam
+func (b *quoteBus) close(ctx context.Context) (dropped int64, err error) {
+	b.cancel()
+	<-b.done
+
+	pending := make([]EventRecord, 0, len(b.queue)+1)
+	if b.inflight != nil {
+		pending = append(pending, *b.inflight)
+	}
+	for len(b.queue) > 0 {
+		pending = append(pending, <-b.queue)
+	}
+	for _, record := range pending {
+		msg, err := b.encode(record)
+		if err == nil && ctx.Err() == nil {
+			err = b.publish(ctx, msg)
+		}
+		if err != nil {
+			dropped++
+			busMessages.WithLabelValues("dropped").Inc()
+			continue
+		}
+		busMessages.WithLabelValues("published").Inc()
+	}
+	return dropped, b.publisher.Close()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaPublisher grava as cotações em um tópico do Kafka, com o par como chave
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// newKafkaPublisher não conecta aos brokers: a conexão é aberta na primeira publicação e
+// refeita a cada falha
+func newKafkaPublisher(brokers []string, topic string) *kafkaPublisher {
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// As novas tentativas são do quoteBus; as cotações saem uma a uma, sem esperar lote
+		MaxAttempts:  1,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, msg busMessage) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(msg.Key),
+		Value:   msg.Value,
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte(msg.ContentType)}},
+	})
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
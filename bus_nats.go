@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsPublisher publica as cotações no subject {prefixo}.{par}, que permite assinar um par
+// (cotacoes.USD-BRL) ou todos (cotacoes.>)
+type natsPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// newNATSPublisher conecta aos servidores; sem nenhum disponível a inicialização continua e
+// a conexão é tentada de novo em segundo plano, indefinidamente
+func newNATSPublisher(servers []string, prefix string) (*natsPublisher, error) {
+	conn, err := nats.Connect(strings.Join(servers, ","),
+		nats.Name(cfg.ServiceName),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao NATS: %w", err)
+	}
+	return &natsPublisher{conn: conn, prefix: prefix}, nil
+}
+
+// Publish aguarda o servidor confirmar o recebimento; desconectado, falha sem guardar a
+// mensagem no buffer de reconexão, para não duplicá-la quando o quoteBus tentar de novo
+func (p *natsPublisher) Publish(ctx context.Context, msg busMessage) error {
+	if !p.conn.IsConnected() {
+		return fmt.Errorf("NATS desconectado (%s)", p.conn.Status())
+	}
+	m := nats.NewMsg(p.prefix + "." + msg.Key)
+	m.Data = msg.Value
+	m.Header.Set("Content-Type", msg.ContentType)
+	if err := p.conn.PublishMsg(m); err != nil {
+		return err
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *natsPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// marshalRateEventProto codifica o evento como a mensagem RateEvent de proto/rate_event.proto.
+// A codificação é manual, campo a campo, para não gerar código a partir do schema; como no
+// proto3, campos com o valor padrão são omitidos
+func marshalRateEventProto(record EventRecord) ([]byte, error) {
+	var quote Quote
+	if err := json.Unmarshal(record.Payload, &quote); err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	if record.Seq != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(record.Seq))
+	}
+	b = appendProtoString(b, 2, record.ID)
+	b = appendProtoString(b, 3, record.Topic)
+	b = appendProtoString(b, 4, record.Pair)
+	b = appendProtoMessage(b, 5, protoTimestamp(record.CreatedAt))
+	if record.PublishedAt != nil {
+		b = appendProtoMessage(b, 6, protoTimestamp(*record.PublishedAt))
+	}
+	return appendProtoMessage(b, 7, protoQuote(quote)), nil
+}
+
+func protoQuote(q Quote) []byte {
+	var b []byte
+	for i, v := range []string{
+		q.Code, q.Codein, q.Name, q.High, q.Low, q.VarBid, q.PctChange,
+		q.Bid, q.Ask, q.Timestamp, q.CreateDate, q.Provider,
+	} {
+		b = appendProtoString(b, protowire.Number(i+1), v)
+	}
+	return b
+}
+
+// protoTimestamp codifica google.protobuf.Timestamp: segundos Unix e nanossegundos
+func protoTimestamp(t time.Time) []byte {
+	var b []byte
+	if seconds := t.Unix(); seconds != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(seconds))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+func appendProtoString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendProtoMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
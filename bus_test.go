@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fakePublisher registra as mensagens publicadas, falhando nas primeiras failures tentativas
+type fakePublisher struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	published []busMessage
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg busMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts++
+	if p.attempts <= p.failures {
+		return errors.New("broker indisponível")
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+func (p *fakePublisher) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.published)
+}
+
+func testRateEvent(t *testing.T, seq uint, bid string) OutboxEventDB {
+	t.Helper()
+	quote := testQuote(bid, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	quote.Provider = awesomeAPIProvider
+	event, err := newRateEvent("USD-BRL", &quote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.ID, event.CreatedAt = seq, time.Date(2024, 1, 1, 10, 0, 1, 500, time.UTC)
+	return event
+}
+
+// protoFields decodifica o primeiro nível de uma mensagem protobuf: varints e bytes por campo
+func protoFields(t *testing.T, b []byte) map[protowire.Number][]byte {
+	t.Helper()
+	fields := map[protowire.Number][]byte{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			fields[num], b = protowire.AppendVarint(nil, v), b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fields[num], b = v, b[n:]
+		default:
+			t.Fatalf("tipo %d inesperado no campo %d", typ, num)
+		}
+	}
+	return fields
+}
+
+func protoVarint(b []byte) uint64 {
+	v, _ := protowire.ConsumeVarint(b)
+	return v
+}
+
+func TestBusEncoding(t *testing.T) {
+	event := testRateEvent(t, 42, "5.1234")
+
+	tests := []struct {
+		name            string
+		format          string
+		wantContentType string
+		check           func(t *testing.T, value []byte)
+	}{
+		{
+			name: "JSON igual ao de /events/export", format: busFormatJSON, wantContentType: "application/json",
+			check: func(t *testing.T, value []byte) {
+				var record EventRecord
+				if err := json.Unmarshal(value, &record); err != nil {
+					t.Fatal(err)
+				}
+				var quote Quote
+				if err := json.Unmarshal(record.Payload, &quote); err != nil {
+					t.Fatal(err)
+				}
+				if record.Seq != 42 || record.ID != event.UID || record.Pair != "USD-BRL" || quote.Bid != "5.1234" {
+					t.Errorf("mensagem = %+v, cotação %+v", record, quote)
+				}
+				if record.PublishedAt == nil {
+					t.Error("published_at ausente")
+				}
+			},
+		},
+		{
+			name: "protobuf conforme proto/rate_event.proto", format: busFormatProtobuf, wantContentType: "application/x-protobuf",
+			check: func(t *testing.T, value []byte) {
+				fields := protoFields(t, value)
+				if seq := protoVarint(fields[1]); seq != 42 {
+					t.Errorf("seq = %d, esperado 42", seq)
+				}
+				if string(fields[2]) != event.UID || string(fields[3]) != topicRateSaved || string(fields[4]) != "USD-BRL" {
+					t.Errorf("id/topic/pair = %s/%s/%s", fields[2], fields[3], fields[4])
+				}
+				created := protoFields(t, fields[5])
+				if got := time.Unix(int64(protoVarint(created[1])), int64(protoVarint(created[2]))); !got.Equal(event.CreatedAt) {
+					t.Errorf("created_at = %v, esperado %v", got, event.CreatedAt)
+				}
+				if _, ok := fields[6]; !ok {
+					t.Error("published_at ausente")
+				}
+				quote := protoFields(t, fields[7])
+				if string(quote[1]) != "USD" || string(quote[8]) != "5.1234" || string(quote[12]) != awesomeAPIProvider {
+					t.Errorf("cotação = code %s, bid %s, provider %s", quote[1], quote[8], quote[12])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			bus := newBus(publisher, tt.format, 1)
+			bus.enqueue(event)
+			bus.start()
+			if _, err := bus.close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if len(publisher.published) != 1 {
+				t.Fatalf("%d mensagens publicadas, esperado 1", len(publisher.published))
+			}
+			msg := publisher.published[0]
+			if msg.Key != "USD-BRL" || msg.ContentType != tt.wantContentType {
+				t.Errorf("chave/content-type = %s/%s", msg.Key, msg.ContentType)
+			}
+			tt.check(t, msg.Value)
+		})
+	}
+}
+
+func TestBusDelivery(t *testing.T) {
+	tests := []struct {
+		name          string
+		queueSize     int
+		events        int
+		failures      int
+		waitFor       int // publicadas antes de encerrar; 0 encerra logo após iniciar
+		wantPublished int
+		wantDropped   int64
+		wantAttempts  int
+	}{
+		{name: "publica na ordem", queueSize: 10, events: 3, waitFor: 3, wantPublished: 3, wantAttempts: 3},
+		{name: "tenta de novo após falha", queueSize: 10, events: 1, failures: 1, waitFor: 1, wantPublished: 1, wantAttempts: 2},
+		{name: "fila cheia descarta", queueSize: 2, events: 3, waitFor: 2, wantPublished: 2, wantDropped: 1, wantAttempts: 2},
+		{name: "encerramento publica a fila", queueSize: 10, events: 5, wantPublished: 5, wantAttempts: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			droppedBefore := counterValue(busMessages, "dropped")
+			publisher := &fakePublisher{failures: tt.failures}
+			bus := newBus(publisher, busFormatJSON, tt.queueSize)
+			for i := range tt.events {
+				bus.enqueue(testRateEvent(t, uint(i+1), fmt.Sprintf("5.%d", i+1)))
+			}
+
+			bus.start()
+			deadline := time.Now().Add(5 * time.Second)
+			for publisher.count() < tt.waitFor && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			dropped, err := bus.close(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(publisher.published) != tt.wantPublished || publisher.attempts != tt.wantAttempts {
+				t.Errorf("publicadas/tentativas = %d/%d, esperado %d/%d",
+					len(publisher.published), publisher.attempts, tt.wantPublished, tt.wantAttempts)
+			}
+			if got := counterValue(busMessages, "dropped") - droppedBefore; got != tt.wantDropped || dropped != 0 {
+				t.Errorf("descartadas = %d (%d no encerramento), esperado %d", got, dropped, tt.wantDropped)
+			}
+			for i, msg := range publisher.published {
+				var record EventRecord
+				if err := json.Unmarshal(msg.Value, &record); err != nil {
+					t.Fatal(err)
+				}
+				if record.Seq != uint(i+1) {
+					t.Errorf("mensagem %d com seq %d: fora de ordem", i, record.Seq)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type cachedQuote struct {
+	quote     *Quote
+	fetchedAt time.Time
+}
+
+// quoteCache guarda a última cotação obtida de cada par. Com o Redis (REDIS_URL) as cotações
+// também são gravadas nele, e a leitura adota a do Redis quando mais recente que a local, de
+// modo que uma cotação obtida por uma réplica atende as demais. O Redis prevalece: sem a
+// cotação nele, a local é descartada; só quando ele não responde vale o cache da réplica
+type quoteCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedQuote
+	shared  sharedQuoteCache // nil sem Redis
+}
+
+// sharedQuoteCache é o cache compartilhado entre as réplicas
+type sharedQuoteCache interface {
+	load(ctx context.Context, pair string) (cachedQuote, bool, error)
+	store(ctx context.Context, pair string, entry cachedQuote) error
+	remove(ctx context.Context, pair string) error
+}
+
+var cache = &quoteCache{entries: make(map[string]cachedQuote)}
+
+func (c *quoteCache) set(pair string, quote *Quote) {
+	entry := cachedQuote{quote: quote, fetchedAt: time.Now()}
+	c.mu.Lock()
+	c.entries[pair] = entry
+	c.mu.Unlock()
+
+	if c.shared != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+		reportRedis("cache", c.shared.store(ctx, pair, entry))
+	}
+}
+
+func (c *quoteCache) delete(pair string) {
+	c.mu.Lock()
+	delete(c.entries, pair)
+	c.mu.Unlock()
+
+	if c.shared != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+		reportRedis("cache", c.shared.remove(ctx, pair))
+	}
+}
+
+func (c *quoteCache) get(pair string) (cachedQuote, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[pair]
+	c.mu.RUnlock()
+	if c.shared == nil {
+		return entry, ok
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	remote, found, err := c.shared.load(ctx, pair)
+	reportRedis("cache", err)
+	switch {
+	case err != nil || (found && ok && !remote.fetchedAt.After(entry.fetchedAt)):
+		return entry, ok
+	case !found:
+		// Removida por outra réplica, ao desabilitar o par
+		if ok {
+			c.mu.Lock()
+			delete(c.entries, pair)
+			c.mu.Unlock()
+		}
+		return cachedQuote{}, false
+	}
+
+	// Guardada localmente, a cotação mantém o mesmo ponteiro nas leituras seguintes, como
+	// espera setQuoteCacheHeaders
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.entries[pair]; ok && !remote.fetchedAt.After(current.fetchedAt) {
+		return current, true
+	}
+	c.entries[pair] = remote
+	return remote, true
+}
+
+func (c *quoteCache) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+var quoteCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "quote_cache_requests_total",
+	Help: "Cotações atendidas pelo cache dentro do QUOTE_CACHE_TTL (hit), vencidas enquanto são atualizadas (stale) ou pelo provedor (miss).",
+}, []string{"result"})
+
+// revalidating marca os pares com atualização em segundo plano em andamento
+var revalidating sync.Map
+
+// cachedOrFresh aplica o stale-while-revalidate: a cotação em cache é servida sem consultar
+// o provedor até QUOTE_CACHE_TTL e, vencida, por mais QUOTE_STALE_WHILE_REVALIDATE enquanto
+// uma única atualização roda em segundo plano. Depois disso a consulta é feita na hora
+func cachedOrFresh(ctx context.Context, pair string) (*Quote, error) {
+	if cfg.QuoteCacheTTL <= 0 {
+		return fetchAndPersist(ctx, pair)
+	}
+
+	if entry, ok := cache.get(pair); ok {
+		age := time.Since(entry.fetchedAt)
+		switch {
+		case age <= cfg.QuoteCacheTTL:
+			quoteCacheRequests.WithLabelValues("hit").Inc()
+			timingFromContext(ctx).record(0, 0, timingCacheHit)
+			return entry.quote, nil
+		case age <= cfg.QuoteCacheTTL+cfg.QuoteStaleWhileRevalidate:
+			quoteCacheRequests.WithLabelValues("stale").Inc()
+			timingFromContext(ctx).record(0, 0, timingCacheStale)
+			revalidate(ctx, pair)
+			return entry.quote, nil
+		}
+	}
+	quoteCacheRequests.WithLabelValues("miss").Inc()
+	return fetchAndPersist(ctx, pair)
+}
+
+// revalidate atualiza a cotação do par em segundo plano, sem esperar pela resposta nem
+// disparar uma segunda atualização do mesmo par
+func revalidate(ctx context.Context, pair string) {
+	if _, running := revalidating.LoadOrStore(pair, true); running {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer revalidating.Delete(pair)
+		if _, err := fetchFresh(ctx, pair); err != nil {
+			logf(ctx, "Erro ao atualizar em segundo plano a cotação de %s: %v", pair, err)
+		}
+	}()
+}
+
+// setQuoteCacheHeaders expõe a política de cache em Cache-Control e a idade da cotação
+// servida em Age, para que clientes e caches intermediários sigam as mesmas regras
+func setQuoteCacheHeaders(w http.ResponseWriter, pair string, quote *Quote) {
+	if cfg.QuoteCacheTTL <= 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d, stale-while-revalidate=%d",
+		int(cfg.QuoteCacheTTL.Seconds()), int(cfg.QuoteStaleWhileRevalidate.Seconds())))
+	age := 0
+	if entry, ok := cache.get(pair); ok && entry.quote == quote {
+		age = int(time.Since(entry.fetchedAt).Seconds())
+	}
+	w.Header().Set("Age", strconv.Itoa(age))
+}
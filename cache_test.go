@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+func TestCachedOrFresh(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		age         time.Duration // idade da cotação em cache
+		wantBid     string        // cotação servida
+		wantRefresh bool          // o cache passa a ter a cotação do provedor
+	}{
+		{"dentro do TTL", 30 * time.Second, "5.0", false},
+		{"vencida dentro do stale-while-revalidate", 90 * time.Second, "5.0", true},
+		{"vencida além do stale-while-revalidate", 3 * time.Minute, "5.9", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			var calls atomic.Int32
+			upstream := lastHandler("USD-BRL", testQuote("5.9", now))
+			newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				upstream(w, r)
+			})
+			withConfig(t, func(c *config.Config) {
+				c.QuoteCacheTTL = time.Minute
+				c.QuoteStaleWhileRevalidate = time.Minute
+			})
+			cached := testQuote("5.0", now)
+			cache.entries["USD-BRL"] = cachedQuote{quote: &cached, fetchedAt: time.Now().Add(-tt.age)}
+
+			quote, err := cachedOrFresh(context.Background(), "USD-BRL")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Bid != tt.wantBid {
+				t.Errorf("bid = %s, esperado %s", quote.Bid, tt.wantBid)
+			}
+
+			// A atualização do stale-while-revalidate roda em segundo plano
+			deadline := time.Now().Add(time.Second)
+			for tt.wantRefresh && calls.Load() == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			for time.Now().Before(deadline) {
+				if _, running := revalidating.Load("USD-BRL"); !running {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			entry, _ := cache.get("USD-BRL")
+			if refreshed := entry.quote.Bid == "5.9"; refreshed != tt.wantRefresh {
+				t.Errorf("cache atualizado = %v, esperado %v", refreshed, tt.wantRefresh)
+			}
+			wantCalls := int32(0)
+			if tt.wantRefresh {
+				wantCalls = 1
+			}
+			if calls.Load() != wantCalls {
+				t.Errorf("chamadas ao upstream = %d, esperado %d", calls.Load(), wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build embedca
+
+package main
+
+import _ "embed"
+
+// Bundle de CAs embutido no binário com -tags embedca, para imagens scratch e distribuições
+// sem certificados do sistema; substitua certs/ca-bundle.pem antes do build para usar outro
+//
+//go:embed certs/ca-bundle.pem
+var embeddedCAs []byte
@@ -0,0 +1,6 @@
+//go:build !embedca
+
+package main
+
+// Sem a tag embedca o cliente usa apenas os certificados do sistema e o de --cacert
+var embeddedCAs []byte
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+)
+
+// Última cotação obtida com sucesso, usada quando o servidor está fora do ar
+const cacheFile = "cotacao-cache.json"
+
+var cotacaoPattern = regexp.MustCompile(`^Dolar: \{(.+)\}$`)
+
+// O cache usa o mesmo JSON de model.Quote
+func saveCachedQuote(quote model.Quote) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cacheFile, data, 0o644)
+}
+
+// loadCachedQuote lê o cache JSON ou, na falta dele, o cotacao.txt de uma execução anterior
+// no formato txt (neste caso FetchedAt é a data de modificação do arquivo)
+func loadCachedQuote() (model.Quote, error) {
+	var quote model.Quote
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		if err := json.Unmarshal(data, &quote); err == nil && quote.Bid != "" {
+			quote.Stale = true
+			return quote, nil
+		}
+	}
+
+	data, err := os.ReadFile("cotacao.txt")
+	if err != nil {
+		return quote, err
+	}
+	m := cotacaoPattern.FindSubmatch(data)
+	if m == nil {
+		return quote, errors.New("cotacao.txt em formato inesperado")
+	}
+	quote = model.NewQuote("USD-BRL", string(m[1]), "", 0)
+	quote.Stale = true
+	if info, err := os.Stat("cotacao.txt"); err == nil {
+		quote.FetchedAt = info.ModTime()
+	}
+	return quote, nil
+}
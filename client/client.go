@@ -1,78 +0,0 @@
-package main
-
-import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"time"
-)
-
-type USDToBRLRate struct {
-	USDBRL struct {
-		Code       string `json:"code"`
-		Codein     string `json:"codein"`
-		Name       string `json:"name"`
-		High       string `json:"high"`
-		Low        string `json:"low"`
-		VarBid     string `json:"varBid"`
-		PctChange  string `json:"pctChange"`
-		Bid        string `json:"bid"`
-		Ask        string `json:"ask"`
-		Timestamp  string `json:"timestamp"`
-		CreateDate string `json:"create_date"`
-	} `json:"USDBRL"`
-}
-
-func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/cotacao", nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao fazer requisição : %v\n", err)
-		os.Exit(1)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao fazer requisição : %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Erro ao fazer requisição : %v\n", err)
-		os.Exit(1)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao ler corpo da resposta: %v\n", err)
-		os.Exit(1)
-	}
-
-	var rate USDToBRLRate
-	err = json.Unmarshal(body, &rate)
-
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao fazer parse da resposta: %v\n", err)
-		os.Exit(1)
-	}
-
-	file, err := os.Create("cotacao.txt")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao criar arquivo : %v\n", err)
-		os.Exit(1)
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(fmt.Sprintf("Dolar: {%s}", rate.USDBRL.Bid))
-
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao escrever no arquivo : %v\n", err)
-		os.Exit(1)
-	}
-}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	"github.com/spf13/cobra"
+)
+
+func newConvertCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "convert VALOR DE PARA",
+		Short:   "Converte um valor entre duas moedas",
+		Example: "  cotacao convert 100 USD BRL",
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+			defer cancel()
+
+			amount := strings.ReplaceAll(args[0], ",", ".")
+			conversion, err := api.Convert(ctx, amount, strings.ToUpper(args[1]), strings.ToUpper(args[2]))
+			if err != nil {
+				return fmt.Errorf("erro ao converter: %w", err)
+			}
+			return opts.outputOr("-").write(model.Conversion{Conversion: conversion})
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func newGetCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Consulta a cotação atual de um ou mais pares",
+		Long: "Consulta a cotação atual e a grava em cotacao.txt. Com mais de um par em --pair gera o ticker. " +
+			"Com --require-fresh, mantém a saída anterior se a cotação do servidor estiver desatualizada. " +
+			"Com --append, acumula o histórico das execuções (ex.: via cron) no arquivo, rotacionando-o por tamanho. " +
+			"Se o servidor estiver indisponível, exibe a última cotação obtida com um aviso.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGet(cmd, opts)
+		},
+	}
+	addGetFlags(cmd, opts)
+	return cmd
+}
+
+// addGetFlags registra as flags próprias do get; ficam no get e na raiz, que equivale ao get
+func addGetFlags(cmd *cobra.Command, opts *options) {
+	cmd.Flags().DurationVar(&opts.requireFresh, "require-fresh", 0, "não sobrescreve a saída e termina com código 4 se a cotação do servidor for mais antiga que isto (ex.: 5m)")
+	cmd.Flags().StringVar(&opts.failOnChange, "fail-on-change", "", "termina com código 3 se a compra variou ao menos este percentual desde a execução anterior (ex.: 1%)")
+	cmd.Flags().BoolVar(&opts.out.appendLog, "append", false, "acrescenta uma linha por execução (RFC3339, par, compra e venda) em vez de sobrescrever o arquivo")
+	cmd.Flags().StringVar(&opts.maxSize, "max-size", "10MB", "com --append, rotaciona o arquivo ao atingir este tamanho (0 desativa)")
+	cmd.Flags().IntVar(&opts.out.backups, "max-backups", 5, "com --append, arquivos rotacionados mantidos (arquivo.1, arquivo.2...)")
+}
+
+func runGet(cmd *cobra.Command, opts *options) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+	defer cancel()
+
+	var limit float64
+	if opts.failOnChange != "" {
+		var err error
+		if limit, err = parsePercent(opts.failOnChange); err != nil {
+			return fmt.Errorf("--fail-on-change: %w", err)
+		}
+		if len(opts.pairs) > 1 {
+			return fmt.Errorf("--fail-on-change aceita apenas um par")
+		}
+	}
+	if opts.requireFresh < 0 || (opts.requireFresh > 0 && len(opts.pairs) > 1) {
+		return fmt.Errorf("--require-fresh aceita apenas um par e uma duração positiva")
+	}
+
+	out := opts.outputOr("cotacao.txt")
+	if len(opts.pairs) > 1 {
+		if err := runTicker(ctx, opts.pairs, out); err != nil {
+			return fmt.Errorf("erro ao gerar ticker: %w", err)
+		}
+		return nil
+	}
+
+	pair := opts.pairs[0]
+	quote, err := fetchQuote(ctx, pair)
+	if err != nil {
+		if client.IsTemporary(err) {
+			if cached, cacheErr := loadCachedQuote(); cacheErr == nil && cached.Pair == pair {
+				fmt.Fprintf(os.Stderr, "Aviso: servidor indisponível (%v); exibindo a cotação obtida em %s\n", err, cached.FetchedAt.Local().Format("02/01/2006 15:04:05"))
+				out.writeTo(os.Stdout, cached)
+				if out.format == formatText {
+					fmt.Println()
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("erro ao fazer requisição: %w", err)
+	}
+	if opts.requireFresh > 0 {
+		if err := checkFresh(quote, opts.requireFresh); err != nil {
+			return err
+		}
+	}
+
+	pct, changed := 0.0, false
+	if previous, err := loadCachedQuote(); err == nil {
+		pct, changed = diffWithPrevious(&quote, previous)
+	}
+
+	if err := out.write(quote); err != nil {
+		return fmt.Errorf("erro ao escrever a saída: %w", err)
+	}
+	if changed && out.format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Variação desde %s: %s (%s%%)\n",
+			quote.Change.PreviousFetchedAt.Local().Format("02/01/2006 15:04:05"), signed(quote.Change.Delta), signed(quote.Change.Pct))
+	}
+
+	if err := saveCachedQuote(quote); err != nil {
+		fmt.Fprintf(os.Stderr, "Aviso: erro ao gravar cache local: %v\n", err)
+	}
+	if opts.failOnChange != "" && changed && math.Abs(pct) >= limit {
+		return &changeLimitError{pct: pct, limit: limit}
+	}
+	return nil
+}
+
+// fetchQuote consulta a cotação de um único par
+func fetchQuote(ctx context.Context, pair string) (model.Quote, error) {
+	q, err := api.GetRate(ctx, pair)
+	if err != nil {
+		return model.Quote{}, err
+	}
+
+	timestamp, _ := q.Unix()
+	return model.NewQuote(pair, q.Bid, q.Ask, timestamp), nil
+}
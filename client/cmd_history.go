@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd(opts *options) *cobra.Command {
+	var from, to, resolution string
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Consulta o histórico de um par em um intervalo",
+		Long: "Consulta o histórico do par em /historico. --from e --to aceitam RFC3339, YYYY-MM-DD " +
+			"ou Unix timestamp; sem --from, o intervalo são as últimas 24 horas. Exige --token.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.pairs) > 1 {
+				return fmt.Errorf("history aceita apenas um par")
+			}
+			query := client.HistoryQuery{Pair: opts.pairs[0], Resolution: resolution}
+			var err error
+			if query.From, err = parseTimeFlag(from); err != nil {
+				return fmt.Errorf("--from: %w", err)
+			}
+			if query.To, err = parseTimeFlag(to); err != nil {
+				return fmt.Errorf("--to: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+			defer cancel()
+
+			history, err := api.GetHistory(ctx, query)
+			if errors.Is(err, client.ErrUnauthorized) {
+				return fmt.Errorf("autenticação necessária: informe --token ou COTACAO_TOKEN")
+			}
+			if err != nil {
+				return fmt.Errorf("erro ao consultar histórico: %w", err)
+			}
+			return opts.outputOr("-").write(model.History{History: history})
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "início do intervalo")
+	cmd.Flags().StringVar(&to, "to", "", "fim do intervalo (padrão: agora)")
+	cmd.Flags().StringVar(&resolution, "resolution", "", "auto, raw, hour ou day (padrão: escolhida pelo servidor)")
+	return cmd
+}
+
+// parseTimeFlag aceita os mesmos formatos do servidor: RFC3339, YYYY-MM-DD ou Unix
+// timestamp; vazio retorna o instante zero, que deixa o padrão para o SDK
+func parseTimeFlag(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("use RFC3339, YYYY-MM-DD ou Unix timestamp")
+}
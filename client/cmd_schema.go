@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [recurso]",
+		Short: "Exibe o JSON Schema das respostas do servidor",
+		Long: "Exibe o JSON Schema (draft 2020-12) dos formatos de resposta publicado pelo servidor em " +
+			"/schema, para gerar validadores e clientes tipados em outras linguagens. Com um recurso " +
+			"(quote, history, stats, error, alert, trade, pair), exibe só o documento dele.",
+		Example: "  cotacao schema -o cotacao.schema.json\n  cotacao schema quote",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+			defer cancel()
+
+			var schema json.RawMessage
+			var err error
+			if len(args) == 1 {
+				schema, err = api.GetResourceSchema(ctx, args[0])
+			} else {
+				schema, err = api.GetSchema(ctx)
+			}
+			if err != nil {
+				return fmt.Errorf("erro ao obter o schema: %w", err)
+			}
+			return opts.outputOr("-").write(model.Schema{Document: schema})
+		},
+	}
+}
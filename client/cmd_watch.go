@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+	"github.com/spf13/cobra"
+)
+
+func newWatchCmd(opts *options) *cobra.Command {
+	var interval time.Duration
+	var points int
+	var plain, spark bool
+	var socket string
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Consulta os pares periodicamente até ser interrompido",
+		Long: "Consulta os pares a cada --interval. No terminal, com o formato txt, exibe uma tabela " +
+			"atualizada no lugar com compra, venda, variação e sparkline; redirecionada, em outro " +
+			"formato ou com --plain, grava um resultado por consulta. Com --socket, também serve as " +
+			"cotações em um socket Unix local, uma por linha em JSON, para outros processos da máquina.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("--interval deve ser positivo")
+			}
+			if points < 2 {
+				return fmt.Errorf("--points deve ser pelo menos 2")
+			}
+			out := opts.outputOr("-")
+			if spark && (out.format != formatText || out.path != "-") {
+				return fmt.Errorf("--sparkline exige o formato txt na saída padrão")
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			publish := func(model.Quote) {}
+			if socket != "" {
+				pub, err := listenQuotes(socket)
+				if err != nil {
+					return fmt.Errorf("erro ao criar o socket: %w", err)
+				}
+				defer pub.Close()
+				publish = pub.publish
+			}
+
+			var history *bidHistory
+			if spark {
+				history = newBidHistory(points)
+			}
+			step := func(ctx context.Context) error { return watchOnce(ctx, opts, out, history, publish) }
+			if !plain && out.format == formatText && out.path == "-" && isTerminal(os.Stdout) {
+				display := newLiveDisplay(opts.pairs, points, publish)
+				step = func(ctx context.Context) error { return display.refresh(ctx, opts.timeout, os.Stdout) }
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if err := step(ctx); err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "intervalo entre as consultas")
+	cmd.Flags().IntVar(&points, "points", 30, "cotações mantidas na sparkline de cada par")
+	cmd.Flags().BoolVar(&plain, "plain", false, "grava um resultado por consulta mesmo no terminal")
+	cmd.Flags().BoolVar(&spark, "sparkline", false, "no modo de um resultado por consulta, exibe a sparkline das últimas --points compras ao lado de cada atualização")
+	cmd.Flags().StringVar(&socket, "socket", "", "serve as cotações no socket Unix informado (ex.: /tmp/cotacao.sock), uma por linha em JSON")
+	return cmd
+}
+
+// watchOnce consulta os pares, grava o resultado e entrega as cotações obtidas a publish;
+// falhas de consulta aparecem na saída e não interrompem o acompanhamento. Com history, cada
+// atualização traz a sparkline do par
+func watchOnce(parent context.Context, opts *options, out output, history *bidHistory, publish func(model.Quote)) error {
+	ctx, cancel := context.WithTimeout(parent, opts.timeout)
+	defer cancel()
+
+	if len(opts.pairs) > 1 {
+		ticker := fetchTicker(ctx, opts.pairs)
+		for i, q := range ticker.Quotes {
+			if q.Error != "" {
+				continue
+			}
+			publish(model.NewQuote(q.Pair, q.Bid, q.Ask, q.Timestamp))
+			if history != nil {
+				history.add(q.Pair, q.Bid)
+				ticker.Quotes[i].Trend = history.sparkline(q.Pair)
+			}
+		}
+		return out.write(ticker)
+	}
+
+	quote, err := fetchQuote(ctx, opts.pairs[0])
+	if err != nil {
+		// Interrompido pelo usuário durante a consulta
+		if parent.Err() != nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "%s Erro ao consultar %s: %v\n", time.Now().Format("15:04:05"), opts.pairs[0], err)
+		return nil
+	}
+	publish(quote)
+	if err := out.write(quote); err != nil {
+		return err
+	}
+	if history != nil {
+		history.add(quote.Pair, quote.Bid)
+		fmt.Print("  ", history.sparkline(quote.Pair))
+	}
+	if out.format == formatText && out.path == "-" {
+		fmt.Println()
+	}
+	return nil
+}
+
+// liveDisplay redesenha a tabela no mesmo lugar do terminal a cada consulta, guardando as
+// últimas cotações de cada par para a sparkline
+type liveDisplay struct {
+	pairs   []string
+	quotes  map[string]domain.Quote
+	errors  map[string]string
+	history *bidHistory
+	publish func(model.Quote)
+	updated time.Time
+	lines   int // linhas desenhadas na última atualização
+}
+
+func newLiveDisplay(pairs []string, points int, publish func(model.Quote)) *liveDisplay {
+	return &liveDisplay{
+		pairs:   pairs,
+		quotes:  make(map[string]domain.Quote),
+		errors:  make(map[string]string),
+		history: newBidHistory(points),
+		publish: publish,
+	}
+}
+
+func (d *liveDisplay) refresh(parent context.Context, timeout time.Duration, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	type result struct {
+		quote domain.Quote
+		err   error
+	}
+	results := make([]result, len(d.pairs))
+	var wg sync.WaitGroup
+	for i, pair := range d.pairs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].quote, results[i].err = api.GetRate(ctx, pair)
+		}()
+	}
+	wg.Wait()
+	if parent.Err() != nil {
+		return nil
+	}
+
+	for i, pair := range d.pairs {
+		if results[i].err != nil {
+			// Mantém a última cotação na tela, sinalizando o erro
+			d.errors[pair] = results[i].err.Error()
+			continue
+		}
+		delete(d.errors, pair)
+		q := results[i].quote
+		d.quotes[pair] = q
+		d.history.add(pair, q.Bid)
+		timestamp, _ := q.Unix()
+		d.publish(model.NewQuote(pair, q.Bid, q.Ask, timestamp))
+	}
+	d.updated = time.Now()
+	return d.render(w)
+}
+
+func (d *liveDisplay) render(w io.Writer) error {
+	// Volta ao início da tabela anterior e apaga até o fim da tela
+	if d.lines > 0 {
+		fmt.Fprintf(w, "\x1b[%dA\r\x1b[J", d.lines)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PAR\tCOMPRA\tVENDA\tVAR %\tTENDÊNCIA\t")
+	for _, pair := range d.pairs {
+		q, ok := d.quotes[pair]
+		bid, ask, pct := "-", "-", "-"
+		if ok {
+			bid, ask, pct = q.Bid, q.Ask, q.PctChange
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t", pair, bid, ask, pct, d.history.sparkline(pair))
+		if msg, failed := d.errors[pair]; failed {
+			line += "erro: " + msg
+		}
+		fmt.Fprintln(tw, line)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Atualizado às %s (Ctrl+C para sair)\n", d.updated.Format("15:04:05"))
+
+	d.lines = len(d.pairs) + 2
+	return nil
+}
+
+// isTerminal indica se o arquivo é um terminal, e não um arquivo ou pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+)
+
+// changeLimitError indica que a variação desde a execução anterior atingiu o limite
+type changeLimitError struct {
+	pct, limit float64
+}
+
+func (e *changeLimitError) Error() string {
+	return fmt.Sprintf("variação de %.2f%% desde a execução anterior atinge o limite de %.2f%%", e.pct, e.limit)
+}
+
+func (e *changeLimitError) ExitCode() int { return 3 }
+
+// diffWithPrevious preenche quote.Change comparando com a execução anterior do mesmo par
+// e retorna a variação percentual; ok é falso quando não há com o que comparar
+func diffWithPrevious(quote *model.Quote, previous model.Quote) (pct float64, ok bool) {
+	if previous.Pair != quote.Pair {
+		return 0, false
+	}
+	prev, err := strconv.ParseFloat(previous.Bid, 64)
+	if err != nil || prev == 0 {
+		return 0, false
+	}
+	bid, err := strconv.ParseFloat(quote.Bid, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	pct = (bid - prev) / prev * 100
+	quote.Change = &model.Change{
+		PreviousBid:       previous.Bid,
+		PreviousFetchedAt: previous.FetchedAt,
+		Delta:             strconv.FormatFloat(bid-prev, 'f', 4, 64),
+		Pct:               strconv.FormatFloat(pct, 'f', 2, 64),
+	}
+	return pct, true
+}
+
+// parsePercent interpreta limites como "1%" ou "0.5"
+func parsePercent(v string) (float64, error) {
+	v = strings.TrimSuffix(strings.TrimSpace(v), "%")
+	pct, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", "."), 64)
+	if err != nil || pct < 0 || math.IsInf(pct, 0) {
+		return 0, fmt.Errorf("percentual inválido %q", v)
+	}
+	return pct, nil
+}
+
+// signed acrescenta o sinal de + às variações positivas
+func signed(v string) string {
+	if strings.HasPrefix(v, "-") {
+		return v
+	}
+	return "+" + v
+}
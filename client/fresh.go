@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+)
+
+// staleQuoteError indica que a cotação informada pelo servidor é mais antiga que
+// --require-fresh; o arquivo de saída não é sobrescrito
+type staleQuoteError struct {
+	age, limit time.Duration
+}
+
+func (e *staleQuoteError) Error() string {
+	if e.age < 0 {
+		return fmt.Sprintf("o servidor não informou o horário da cotação; exigida de no máximo %s", e.limit)
+	}
+	return fmt.Sprintf("cotação de %s atrás, mais antiga que o limite de %s; saída não atualizada", e.age.Round(time.Second), e.limit)
+}
+
+func (e *staleQuoteError) ExitCode() int { return 4 }
+
+// checkFresh compara o horário da cotação no servidor (timestamp) com o limite; sem timestamp
+// não há como garantir a idade e a cotação é tratada como antiga
+func checkFresh(quote model.Quote, limit time.Duration) error {
+	if quote.Timestamp == 0 {
+		return &staleQuoteError{age: -1, limit: limit}
+	}
+	if age := time.Since(time.Unix(quote.Timestamp, 0)); age > limit {
+		return &staleQuoteError{age: age, limit: limit}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// exitCoder é implementado pelos erros com código de saída próprio, distinto das falhas (1),
+// para que scripts possam tratá-los: 3 para --fail-on-change e 4 para --require-fresh
+type exitCoder interface {
+	ExitCode() int
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		var coded exitCoder
+		if errors.As(err, &coded) {
+			os.Exit(coded.ExitCode())
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+)
+
+// ConversionSchemaVersion versiona o JSON da conversão, que repete a resposta de /converter
+// com o campo schema_version:
+//
+//	{
+//	  "schema_version": 1,
+//	  "from": "USD", "to": "BRL", "amount": 100, "result": 580.5, "rate": 5.805,
+//	  "pair": "USD-BRL", "inverted": false, "provider": "awesomeapi",
+//	  "timestamp": 1738324800, "staleness_seconds": 3
+//	}
+//
+// Formato texto: "100 USD = 580.5 BRL (taxa 5.805)".
+// Formato CSV: cabeçalho from,to,amount,result,rate,pair,timestamp.
+const ConversionSchemaVersion = 1
+
+type Conversion struct {
+	SchemaVersion int `json:"schema_version"`
+	client.Conversion
+}
+
+func (c Conversion) WriteJSON(w io.Writer) error {
+	c.SchemaVersion = ConversionSchemaVersion
+	return json.NewEncoder(w).Encode(c)
+}
+
+func (c Conversion) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s %s = %s %s (taxa %s)\n", c.Amount, c.From, c.Result, c.To, c.Rate)
+	return err
+}
+
+func (c Conversion) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"from", "to", "amount", "result", "rate", "pair", "timestamp"})
+	cw.Write([]string{c.From, c.To, c.Amount.String(), c.Result.String(), c.Rate.String(), c.Pair, strconv.FormatInt(c.Timestamp, 10)})
+	cw.Flush()
+	return cw.Error()
+}
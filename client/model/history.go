@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+)
+
+// HistorySchemaVersion versiona o JSON do histórico, que repete a resposta de /historico
+// com o campo schema_version:
+//
+//	{
+//	  "schema_version": 1,
+//	  "pair": "USD-BRL",
+//	  "from": "2025-01-30T00:00:00Z",
+//	  "to": "2025-01-31T00:00:00Z",
+//	  "resolution": "hour",
+//	  "points": [
+//	    {"time": "2025-01-30T00:00:00Z", "open": 5.8, "high": 5.82, "low": 5.79, "close": 5.81,
+//	     "avg_bid": 5.805, "avg_ask": 5.806, "samples": 12}
+//	  ]
+//	}
+//
+// Formato texto: tabela com HORARIO, ABERTURA, MAXIMA, MINIMA, FECHAMENTO e AMOSTRAS.
+// Formato CSV: cabeçalho time,open,high,low,close,avg_bid,avg_ask,samples.
+const HistorySchemaVersion = 1
+
+type History struct {
+	SchemaVersion int `json:"schema_version"`
+	client.History
+}
+
+func (h History) WriteJSON(w io.Writer) error {
+	h.SchemaVersion = HistorySchemaVersion
+	return json.NewEncoder(w).Encode(h)
+}
+
+func (h History) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HORARIO\tABERTURA\tMAXIMA\tMINIMA\tFECHAMENTO\tAMOSTRAS\t")
+	for _, p := range h.Points {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t\n", p.Time.Local().Format("02/01/2006 15:04"), p.Open, p.High, p.Low, p.Close, p.Samples)
+	}
+	return tw.Flush()
+}
+
+func (h History) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "open", "high", "low", "close", "avg_bid", "avg_ask", "samples"})
+	for _, p := range h.Points {
+		cw.Write([]string{
+			p.Time.UTC().Format(time.RFC3339), p.Open.String(), p.High.String(), p.Low.String(),
+			p.Close.String(), p.AvgBid.String(), p.AvgAsk.String(), strconv.Itoa(p.Samples),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// QuoteSchemaVersion versiona o JSON da cotação única:
+//
+//	{
+//	  "schema_version": 1,
+//	  "pair": "USD-BRL",
+//	  "bid": "5.805",
+//	  "ask": "5.806",
+//	  "timestamp": 1738324800,
+//	  "fetched_at": "2025-01-31T12:00:00Z",
+//	  "stale": true,
+//	  "change": {
+//	    "previous_bid": "5.800",
+//	    "previous_fetched_at": "2025-01-31T11:00:00Z",
+//	    "delta": "0.0050",
+//	    "pct": "0.09"
+//	  }
+//	}
+//
+// stale só aparece quando o servidor estava indisponível e o valor veio do cache local;
+// change, quando há uma execução anterior do mesmo par para comparar. Os formatos texto e
+// CSV não incluem a variação, que o client exibe na saída de erro.
+const QuoteSchemaVersion = 1
+
+// CSVHeader é a primeira linha dos formatos CSV; o ticker acrescenta a coluna error
+var CSVHeader = []string{"pair", "bid", "ask", "timestamp"}
+
+type Quote struct {
+	SchemaVersion int       `json:"schema_version"`
+	Pair          string    `json:"pair"`
+	Bid           string    `json:"bid"`
+	Ask           string    `json:"ask"`
+	Timestamp     int64     `json:"timestamp"`
+	FetchedAt     time.Time `json:"fetched_at"`
+	Stale         bool      `json:"stale,omitempty"`
+	Change        *Change   `json:"change,omitempty"`
+}
+
+// Change compara a cotação com a da execução anterior; delta e pct (percentual) trazem o
+// sinal da variação
+type Change struct {
+	PreviousBid       string    `json:"previous_bid"`
+	PreviousFetchedAt time.Time `json:"previous_fetched_at"`
+	Delta             string    `json:"delta"`
+	Pct               string    `json:"pct"`
+}
+
+func NewQuote(pair, bid, ask string, timestamp int64) Quote {
+	return Quote{
+		SchemaVersion: QuoteSchemaVersion,
+		Pair:          pair,
+		Bid:           bid,
+		Ask:           ask,
+		Timestamp:     timestamp,
+		FetchedAt:     time.Now().UTC(),
+	}
+}
+
+// WriteText mantém o formato original do desafio, "Dolar: {bid}"; os demais pares usam o
+// código do par no lugar de "Dolar" (ex.: "EUR-BRL: {bid}")
+func (q Quote) WriteText(w io.Writer) error {
+	label := "Dolar"
+	if q.Pair != "" && q.Pair != "USD-BRL" {
+		label = q.Pair
+	}
+	_, err := fmt.Fprintf(w, "%s: {%s}", label, q.Bid)
+	return err
+}
+
+func (q Quote) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(q)
+}
+
+// WriteLog grava a cotação como uma linha do histórico local (--append):
+//
+//	2025-01-31T09:00:00-03:00 USD-BRL 5.805 5.806
+//
+// com o timestamp da cotação em RFC3339, o par, a compra e a venda separados por espaço.
+func (q Quote) WriteLog(w io.Writer) error {
+	_, err := fmt.Fprintln(w, logLine(q.Pair, q.Bid, q.Ask, q.Timestamp, q.FetchedAt))
+	return err
+}
+
+// Record é uma cotação como gravada nos destinos estruturados (ex.: SQLite)
+type Record struct {
+	Pair      string
+	Bid       string
+	Ask       string
+	Timestamp int64
+	FetchedAt time.Time
+}
+
+func (q Quote) Records() []Record {
+	return []Record{{Pair: q.Pair, Bid: q.Bid, Ask: q.Ask, Timestamp: q.Timestamp, FetchedAt: q.FetchedAt}}
+}
+
+func logLine(pair, bid, ask string, timestamp int64, fallback time.Time) string {
+	at := fallback
+	if timestamp != 0 {
+		at = time.Unix(timestamp, 0)
+	}
+	return fmt.Sprintf("%s %s %s %s", at.Local().Format(time.RFC3339), pair, bid, ask)
+}
+
+func (q Quote) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(CSVHeader)
+	cw.Write([]string{q.Pair, q.Bid, q.Ask, strconv.FormatInt(q.Timestamp, 10)})
+	cw.Flush()
+	return cw.Error()
+}
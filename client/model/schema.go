@@ -0,0 +1,32 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Schema é o JSON Schema obtido em /schema, gravado indentado. Por ser um documento JSON,
+// o formato texto grava o mesmo conteúdo e o CSV não se aplica
+type Schema struct {
+	Document json.RawMessage
+}
+
+func (s Schema) WriteJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, s.Document, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func (s Schema) WriteText(w io.Writer) error {
+	return s.WriteJSON(w)
+}
+
+func (s Schema) WriteCSV(io.Writer) error {
+	return errors.New("o schema não tem formato CSV; use --format json")
+}
@@ -0,0 +1,112 @@
+// Package model define os formatos de saída estáveis do client, para que parsers
+// externos não quebrem entre versões.
+//
+// Formato JSON (uma linha por execução, schema_version incrementado a cada mudança incompatível):
+//
+//	{
+//	  "schema_version": 1,
+//	  "generated_at": "2025-01-31T12:00:00Z",
+//	  "quotes": [
+//	    {"pair": "USD-BRL", "bid": "5.805", "ask": "5.806", "timestamp": 1738324800},
+//	    {"pair": "EUR-BRL", "error": "par não suportado pelo servidor"}
+//	  ]
+//	}
+//
+// Formato texto: tabela com colunas alinhadas PAR, COMPRA, VENDA e TIMESTAMP, uma linha por par.
+// Pares com erro exibem "-" nos valores e a mensagem de erro ao final da linha; os demais
+// podem trazer ao final a tendência recente (watch --sparkline), que só existe no texto.
+//
+// Formato CSV: cabeçalho pair,bid,ask,timestamp,error e uma linha por par.
+//
+// Histórico (--append) e destinos estruturados (Records): uma linha por par, no formato de
+// Quote.WriteLog; pares com erro são omitidos.
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const TickerSchemaVersion = 1
+
+type Ticker struct {
+	SchemaVersion int           `json:"schema_version"`
+	GeneratedAt   time.Time     `json:"generated_at"`
+	Quotes        []TickerQuote `json:"quotes"`
+}
+
+type TickerQuote struct {
+	Pair      string `json:"pair"`
+	Bid       string `json:"bid,omitempty"`
+	Ask       string `json:"ask,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Trend     string `json:"-"`
+}
+
+func NewTicker(quotes []TickerQuote) Ticker {
+	return Ticker{
+		SchemaVersion: TickerSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Quotes:        quotes,
+	}
+}
+
+func (t Ticker) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
+
+func (t Ticker) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PAR\tCOMPRA\tVENDA\tTIMESTAMP\t")
+	for _, q := range t.Quotes {
+		if q.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t%s\n", q.Pair, q.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", q.Pair, q.Bid, q.Ask, q.Timestamp, q.Trend)
+	}
+	return tw.Flush()
+}
+
+func (t Ticker) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append(CSVHeader, "error"))
+	for _, q := range t.Quotes {
+		timestamp := ""
+		if q.Timestamp != 0 {
+			timestamp = strconv.FormatInt(q.Timestamp, 10)
+		}
+		cw.Write([]string{q.Pair, q.Bid, q.Ask, timestamp, q.Error})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (t Ticker) WriteLog(w io.Writer) error {
+	for _, q := range t.Quotes {
+		if q.Error != "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, logLine(q.Pair, q.Bid, q.Ask, q.Timestamp, t.GeneratedAt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Records omite os pares com erro, como WriteLog
+func (t Ticker) Records() []Record {
+	var records []Record
+	for _, q := range t.Quotes {
+		if q.Error == "" {
+			records = append(records, Record{Pair: q.Pair, Bid: q.Bid, Ask: q.Ask, Timestamp: q.Timestamp, FetchedAt: t.GeneratedAt})
+		}
+	}
+	return records
+}
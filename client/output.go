@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	formatText = "txt"
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// output descreve para onde e em qual formato o resultado é gravado
+type output struct {
+	format string
+	path   string // "-" é a saída padrão
+
+	// Com appendLog cada execução acrescenta linhas de histórico ao arquivo em vez de
+	// sobrescrevê-lo; ao passar de maxSize bytes ele é renomeado para path.1 (os anteriores
+	// viram path.2, path.3...) e só os backups mais recentes são mantidos
+	appendLog bool
+	maxSize   int64 // 0 desativa a rotação
+	backups   int
+}
+
+// formatter é implementado pelos formatos estáveis de model
+type formatter interface {
+	WriteText(w io.Writer) error
+	WriteJSON(w io.Writer) error
+	WriteCSV(w io.Writer) error
+}
+
+// logFormatter é implementado pelos resultados que podem ser acrescentados ao histórico local
+type logFormatter interface {
+	WriteLog(w io.Writer) error
+}
+
+func (o output) validate() error {
+	switch o.format {
+	case formatText, formatJSON, formatCSV:
+	default:
+		return fmt.Errorf("formato inválido %q: use %s, %s ou %s", o.format, formatText, formatJSON, formatCSV)
+	}
+	if name, _ := o.sink(); o.appendLog && name != sinkFile && name != sinkStdout {
+		return fmt.Errorf("--append só se aplica a arquivos e à saída padrão, não ao destino %s", name)
+	}
+	return nil
+}
+
+// write grava v no destino de --output, escolhido entre os registrados em writers
+func (o output) write(v formatter) error {
+	w, err := o.newWriter()
+	if err != nil {
+		return err
+	}
+	return w.Write(v)
+}
+
+func (o output) writeTo(w io.Writer, v formatter) error {
+	switch o.format {
+	case formatJSON:
+		return v.WriteJSON(w)
+	case formatCSV:
+		return v.WriteCSV(w)
+	default:
+		return v.WriteText(w)
+	}
+}
+
+// appendTo acrescenta as linhas de histórico de v ao arquivo, rotacionando-o antes se
+// já tiver atingido o tamanho máximo
+func (o output) appendTo(v formatter) error {
+	lf, ok := v.(logFormatter)
+	if !ok {
+		return fmt.Errorf("--append não se aplica a este comando")
+	}
+
+	if err := o.rotate(); err != nil {
+		return fmt.Errorf("erro ao rotacionar %s: %w", o.path, err)
+	}
+	file, err := os.OpenFile(o.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	if err := lf.WriteLog(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (o output) rotate() error {
+	info, err := os.Stat(o.path)
+	if os.IsNotExist(err) || (err == nil && (o.maxSize <= 0 || info.Size() < o.maxSize)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.backups <= 0 {
+		return os.Remove(o.path)
+	}
+	// O mais antigo é sobrescrito pelo penúltimo
+	for i := o.backups - 1; i >= 1; i-- {
+		src := o.path + "." + strconv.Itoa(i)
+		if err := os.Rename(src, o.path+"."+strconv.Itoa(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(o.path, o.path+".1")
+}
+
+// parseSize interpreta tamanhos como "512K", "10MB" ou "1G" (potências de 1024); sem
+// sufixo, o valor é em bytes
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "B")
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("tamanho inválido %q", value)
+	}
+	return n * multiplier, nil
+}
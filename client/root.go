@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+	"github.com/spf13/cobra"
+)
+
+// api acessa o servidor em --server, configurado a partir das flags globais
+var api *client.CotacaoClient
+
+// options reúne as flags globais; cada uma tem uma variável de ambiente como padrão, e a
+// flag prevalece quando as duas são informadas
+type options struct {
+	server   string
+	pairList string
+	pairs    []string
+	timeout  time.Duration
+	retries  int
+	cacert   string
+	token    string
+	out      output
+	maxSize  string
+	timing   bool
+
+	failOnChange string
+	requireFresh time.Duration
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:   "cotacao",
+		Short: "Consulta cotações no servidor de câmbio",
+		Long: "Consulta cotações no servidor de câmbio. Sem subcomando equivale a \"cotacao get\", " +
+			"que grava a cotação em cotacao.txt.",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.setup(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGet(cmd, opts)
+		},
+	}
+	addGetFlags(root, opts)
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.server, "server", envOr("COTACAO_SERVER_URL", "http://localhost:8080"), "URL base do servidor (COTACAO_SERVER_URL); use https:// com --cacert se o certificado não for reconhecido pelo sistema")
+	flags.StringVar(&opts.pairList, "pair", envOr("COTACAO_PAIRS", "USD-BRL"), "par ou lista de pares separados por vírgula (COTACAO_PAIRS)")
+	flags.DurationVar(&opts.timeout, "timeout", envDuration("COTACAO_TIMEOUT", 300*time.Millisecond), "prazo total de cada consulta, incluindo as novas tentativas (COTACAO_TIMEOUT)")
+	flags.IntVar(&opts.retries, "retries", envInt("COTACAO_RETRIES", 3), "novas tentativas após uma falha transitória (COTACAO_RETRIES)")
+	flags.StringVar(&opts.cacert, "cacert", os.Getenv("COTACAO_CACERT"), "arquivo PEM com CAs adicionais para conexões HTTPS (COTACAO_CACERT)")
+	flags.StringVar(&opts.token, "token", os.Getenv("COTACAO_TOKEN"), "token JWT obtido em /auth/login, exigido pelo histórico (COTACAO_TOKEN)")
+	flags.BoolVar(&opts.timing, "debug-timing", envBool("COTACAO_DEBUG_TIMING"), "exibe em stderr onde o servidor gastou o tempo de cada cotação: provedor, cache, banco e total (COTACAO_DEBUG_TIMING)")
+	flags.StringVarP(&opts.out.format, "format", "f", formatText, "formato da saída: txt, json ou csv")
+	flags.StringVarP(&opts.out.path, "output", "o", "", "destino da saída: arquivo, - (saída padrão), URL http(s) para POST, sqlite:arquivo.db ou syslog: (padrão: cotacao.txt no get, saída padrão nos demais)")
+
+	root.AddCommand(newGetCmd(opts), newHistoryCmd(opts), newWatchCmd(opts), newConvertCmd(opts), newSchemaCmd(opts))
+	return root
+}
+
+// setup valida as flags globais e prepara o cliente HTTP
+func (o *options) setup(cmd *cobra.Command) error {
+	o.server = strings.TrimSuffix(o.server, "/")
+	o.pairs = parsePairs(o.pairList)
+	if !cmd.Flags().Changed("format") && os.Getenv("COTACAO_TICKER_FORMAT") == formatJSON {
+		o.out.format = formatJSON
+	}
+
+	switch {
+	case o.server == "":
+		return fmt.Errorf("informe a URL do servidor em --server")
+	case len(o.pairs) == 0:
+		return fmt.Errorf("informe ao menos um par em --pair")
+	case o.timeout <= 0:
+		return fmt.Errorf("--timeout deve ser positivo")
+	case o.retries < 0:
+		return fmt.Errorf("--retries não pode ser negativo")
+	}
+	if err := o.out.validate(); err != nil {
+		return err
+	}
+	if o.out.appendLog {
+		if o.out.format != formatText {
+			return fmt.Errorf("--append grava linhas de texto e não combina com --format %s", o.out.format)
+		}
+		size, err := parseSize(o.maxSize)
+		if err != nil {
+			return fmt.Errorf("--max-size: %w", err)
+		}
+		o.out.maxSize = size
+	}
+
+	hc, err := newHTTPClient(o.cacert)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar certificados: %w", err)
+	}
+	httpSinkClient = hc
+	clientOpts := []client.Option{client.WithHTTPClient(hc), client.WithToken(o.token), client.WithRetries(o.retries)}
+	if o.timing {
+		clientOpts = append(clientOpts, client.WithTiming(printTiming))
+	}
+	api, err = client.New(o.server, clientOpts...)
+	return err
+}
+
+// outputOr usa path como destino quando --output não foi informado
+func (o *options) outputOr(path string) output {
+	out := o.out
+	if out.path == "" {
+		out.path = path
+	}
+	return out
+}
+
+// parsePairs normaliza a lista de pares (ex.: "usd-brl, EUR-BRL")
+func parsePairs(list string) []string {
+	var pairs []string
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return d
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return fallback
+}
+
+func envBool(key string) bool {
+	b, _ := strconv.ParseBool(os.Getenv(key))
+	return b
+}
+
+// printTiming exibe o detalhamento de --debug-timing, para comparar com o --timeout
+func printTiming(t domain.Timing) {
+	fmt.Fprintf(os.Stderr, "Tempo no servidor: provedor %.2fms (cache %s), banco %.2fms, total %.2fms\n",
+		t.UpstreamMS, t.Cache, t.DBMS, t.TotalMS)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+)
+
+// Mensagens novas enfileiradas por processo conectado, além da última de cada par; quem não
+// lê a tempo é desconectado
+const socketClientBuffer = 64
+
+// Prazo de escrita de cada mensagem, para um processo travado não segurar a conexão
+const socketWriteTimeout = 5 * time.Second
+
+// quotePublisher serve as cotações do watch em um socket Unix local, uma por linha no JSON
+// de model.Quote: ao conectar, o processo recebe a última cotação de cada par e, em seguida,
+// cada nova consulta. Assim vários processos da máquina acompanham os pares sem que cada um
+// consulte o servidor. No Windows usa o AF_UNIX do próprio sistema (Windows 10 ou posterior)
+type quotePublisher struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	latest  map[string][]byte // última mensagem por par
+	order   []string          // pares na ordem da primeira cotação
+	clients map[*socketClient]struct{}
+}
+
+type socketClient struct {
+	conn     net.Conn
+	messages chan []byte
+}
+
+// listenQuotes cria o socket em path, substituindo um socket abandonado por outra execução;
+// falha se outro processo ainda o estiver usando ou se path não for um socket
+func listenQuotes(path string) (*quotePublisher, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s já existe e não é um socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("o socket %s está em uso por outro processo", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Só processos do mesmo usuário leem as cotações
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	p := &quotePublisher{
+		ln:      ln,
+		latest:  make(map[string][]byte),
+		clients: make(map[*socketClient]struct{}),
+	}
+	go p.accept()
+	return p, nil
+}
+
+func (p *quotePublisher) accept() {
+	for {
+		conn, err := p.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "Erro no socket de cotações: %v\n", err)
+			}
+			return
+		}
+
+		// A fila comporta a última cotação de cada par além das novas, para que o envio
+		// inicial nunca bloqueie com p.mu travado
+		p.mu.Lock()
+		c := &socketClient{conn: conn, messages: make(chan []byte, len(p.order)+socketClientBuffer)}
+		for _, pair := range p.order {
+			c.messages <- p.latest[pair]
+		}
+		p.clients[c] = struct{}{}
+		p.mu.Unlock()
+		go p.serve(c)
+	}
+}
+
+// serve grava as mensagens do processo até ele desconectar ou ficar para trás
+func (p *quotePublisher) serve(c *socketClient) {
+	defer p.drop(c)
+	for msg := range c.messages {
+		c.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+		if _, err := c.conn.Write(msg); err != nil {
+			return
+		}
+	}
+}
+
+func (p *quotePublisher) drop(c *socketClient) {
+	p.mu.Lock()
+	if _, ok := p.clients[c]; ok {
+		delete(p.clients, c)
+		close(c.messages)
+	}
+	p.mu.Unlock()
+	c.conn.Close()
+}
+
+// publish envia a cotação aos processos conectados e a guarda para os próximos
+func (p *quotePublisher) publish(q model.Quote) {
+	var buf bytes.Buffer
+	if err := q.WriteJSON(&buf); err != nil {
+		return
+	}
+	msg := buf.Bytes()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.latest[q.Pair]; !ok {
+		p.order = append(p.order, q.Pair)
+	}
+	p.latest[q.Pair] = msg
+	for c := range p.clients {
+		select {
+		case c.messages <- msg:
+		default:
+			// Fila cheia: o processo não está lendo
+			delete(p.clients, c)
+			close(c.messages)
+			c.conn.Close()
+		}
+	}
+}
+
+// Close desconecta os processos e remove o socket
+func (p *quotePublisher) Close() error {
+	err := p.ln.Close()
+	p.mu.Lock()
+	for c := range p.clients {
+		delete(p.clients, c)
+		close(c.messages)
+		c.conn.Close()
+	}
+	p.mu.Unlock()
+	return err
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+)
+
+// newTestPublisher abre o socket em um diretório temporário curto, dentro do limite de
+// tamanho do caminho de sockets Unix
+func newTestPublisher(t *testing.T) (*quotePublisher, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "q.sock")
+	p, err := listenQuotes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, path
+}
+
+func TestQuotePublisher(t *testing.T) {
+	tests := []struct {
+		name  string
+		pairs int
+	}{
+		{name: "poucos pares", pairs: 3},
+		{name: "mais pares que a fila", pairs: socketClientBuffer * 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, path := newTestPublisher(t)
+			var want []string
+			for i := range tt.pairs {
+				pair := fmt.Sprintf("P%03d-BRL", i)
+				want = append(want, pair)
+				p.publish(model.Quote{Pair: pair, Bid: "1.0"})
+			}
+
+			conn, err := net.DialTimeout("unix", path, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			lines := bufio.NewScanner(conn)
+			read := func() model.Quote {
+				t.Helper()
+				if !lines.Scan() {
+					t.Fatalf("conexão encerrada: %v", lines.Err())
+				}
+				var q model.Quote
+				if err := json.Unmarshal(lines.Bytes(), &q); err != nil {
+					t.Fatal(err)
+				}
+				return q
+			}
+
+			// Ao conectar chega a última cotação de cada par, na ordem da primeira cotação
+			for _, pair := range want {
+				if q := read(); q.Pair != pair {
+					t.Fatalf("par = %s, esperado %s", q.Pair, pair)
+				}
+			}
+			// Depois, as novas cotações
+			p.publish(model.Quote{Pair: want[0], Bid: "2.0"})
+			if q := read(); q.Pair != want[0] || q.Bid != "2.0" {
+				t.Errorf("cotação = %s %s, esperado %s 2.0", q.Pair, q.Bid, want[0])
+			}
+		})
+	}
+}
+
+func TestListenQuotesInUse(t *testing.T) {
+	_, path := newTestPublisher(t)
+	if _, err := listenQuotes(path); err == nil {
+		t.Error("segundo listenQuotes no mesmo socket não falhou")
+	}
+
+	file := filepath.Join(filepath.Dir(path), "arquivo")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenQuotes(file); err == nil {
+		t.Error("listenQuotes sobre um arquivo comum não falhou")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline desenha os valores com blocos de altura proporcional entre o mínimo e o máximo
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// bidHistory guarda as últimas cotações de compra de cada par para desenhar a sparkline
+type bidHistory struct {
+	points int
+	values map[string][]float64
+}
+
+func newBidHistory(points int) *bidHistory {
+	return &bidHistory{points: points, values: make(map[string][]float64)}
+}
+
+// add registra a cotação, descartando a mais antiga ao passar de points; valores que não
+// são números são ignorados
+func (h *bidHistory) add(pair, bid string) {
+	v, err := strconv.ParseFloat(bid, 64)
+	if err != nil {
+		return
+	}
+	values := append(h.values[pair], v)
+	h.values[pair] = values[max(0, len(values)-h.points):]
+}
+
+func (h *bidHistory) sparkline(pair string) string {
+	return sparkline(h.values[pair])
+}
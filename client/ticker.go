@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+)
+
+// runTicker consulta todos os pares em paralelo e grava o resultado no formato
+// estável definido em model.Ticker
+func runTicker(ctx context.Context, pairs []string, out output) error {
+	return out.write(fetchTicker(ctx, pairs))
+}
+
+func fetchTicker(ctx context.Context, pairs []string) model.Ticker {
+	quotes := make([]model.TickerQuote, len(pairs))
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quotes[i] = fetchTickerQuote(ctx, pair)
+		}()
+	}
+	wg.Wait()
+
+	return model.NewTicker(quotes)
+}
+
+func fetchTickerQuote(ctx context.Context, pair string) model.TickerQuote {
+	result := model.TickerQuote{Pair: pair}
+
+	// Servidores sem suporte a ?pair= ignoram o parâmetro e respondem só USDBRL; GetRate
+	// exige a chave do par pedido, então o ticker nunca exibe a cotação de outro par
+	quote, err := api.GetRate(ctx, pair)
+	if errors.Is(err, client.ErrPairNotSupported) {
+		result.Error = fmt.Sprintf("%v (servidor sem suporte ao parâmetro pair?)", err)
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Bid = quote.Bid
+	result.Ask = quote.Ask
+	result.Timestamp, _ = quote.Unix()
+	return result
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// newHTTPClient confia nos certificados do sistema (quando existirem), no bundle embutido e
+// no arquivo PEM de caFile
+func newHTTPClient(caFile string) (*http.Client, error) {
+	if caFile == "" && len(embeddedCAs) == 0 {
+		return http.DefaultClient, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if len(embeddedCAs) > 0 && !pool.AppendCertsFromPEM(embeddedCAs) {
+		return nil, fmt.Errorf("bundle de CAs embutido não contém certificados válidos")
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", caFile, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s não contém certificados PEM válidos", caFile)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
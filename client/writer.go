@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Writer grava um resultado em um destino. Os destinos são registrados por nome e escolhidos
+// pelo prefixo de --output, de modo que um novo destino não altera a consulta nem os formatos:
+//
+//	-o -                        saída padrão
+//	-o cotacao.txt              arquivo
+//	-o https://exemplo.com/in   POST do resultado formatado
+//	-o sqlite:cotacoes.db       uma linha por cotação em um banco SQLite
+//	-o syslog:                  syslog local (ou syslog://host:514)
+type Writer interface {
+	Write(v formatter) error
+}
+
+// writerFactory cria o destino para target, o restante de --output após "nome:"
+type writerFactory func(target string, o output) (Writer, error)
+
+const (
+	sinkStdout = "stdout"
+	sinkFile   = "file"
+)
+
+var writers = map[string]writerFactory{}
+
+// registerWriter é chamada no init do arquivo de cada destino
+func registerWriter(name string, factory writerFactory) {
+	if _, exists := writers[name]; exists {
+		panic("destino de saída registrado duas vezes: " + name)
+	}
+	writers[name] = factory
+}
+
+func init() {
+	registerWriter(sinkStdout, func(_ string, o output) (Writer, error) { return stdoutWriter{o}, nil })
+	registerWriter(sinkFile, func(path string, o output) (Writer, error) { return fileWriter{o, path}, nil })
+}
+
+// sink identifica o destino de --output: "nome:alvo" quando nome está registrado, a saída
+// padrão para "-" e arquivo nos demais casos (inclusive caminhos como C:\cotacao.txt)
+func (o output) sink() (name, target string) {
+	if o.path == "-" {
+		return sinkStdout, ""
+	}
+	if name, target, ok := strings.Cut(o.path, ":"); ok && len(name) > 1 {
+		if _, registered := writers[name]; registered {
+			return name, target
+		}
+	}
+	return sinkFile, o.path
+}
+
+func (o output) newWriter() (Writer, error) {
+	name, target := o.sink()
+	w, err := writers[name](target, o)
+	if err != nil {
+		return nil, fmt.Errorf("destino %s: %w", name, err)
+	}
+	return w, nil
+}
+
+type stdoutWriter struct {
+	out output
+}
+
+func (s stdoutWriter) Write(v formatter) error {
+	if s.out.appendLog {
+		lf, ok := v.(logFormatter)
+		if !ok {
+			return fmt.Errorf("--append não se aplica a este comando")
+		}
+		return lf.WriteLog(os.Stdout)
+	}
+	return s.out.writeTo(os.Stdout, v)
+}
+
+type fileWriter struct {
+	out  output
+	path string
+}
+
+func (f fileWriter) Write(v formatter) error {
+	if f.out.appendLog {
+		return f.out.appendTo(v)
+	}
+
+	file, err := os.Create(f.path)
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo: %w", err)
+	}
+	defer file.Close()
+	return f.out.writeTo(file, v)
+}
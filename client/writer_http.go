@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Prazo do POST para destinos HTTP, independente de --timeout, que vale para a consulta
+const httpSinkTimeout = 10 * time.Second
+
+// httpSinkClient usa os mesmos certificados (--cacert) das consultas ao servidor
+var httpSinkClient = http.DefaultClient
+
+var contentTypes = map[string]string{
+	formatText: "text/plain; charset=utf-8",
+	formatJSON: "application/json",
+	formatCSV:  "text/csv; charset=utf-8",
+}
+
+func init() {
+	for _, scheme := range []string{"http", "https"} {
+		registerWriter(scheme, func(target string, o output) (Writer, error) {
+			return httpWriter{out: o, url: scheme + ":" + target}, nil
+		})
+	}
+}
+
+// httpWriter envia o resultado formatado no corpo de um POST; respostas fora de 2xx são erro
+type httpWriter struct {
+	out output
+	url string
+}
+
+func (h httpWriter) Write(v formatter) error {
+	var body bytes.Buffer
+	if err := h.out.writeTo(&body, v); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpSinkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypes[h.out.format])
+
+	resp, err := httpSinkClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s respondeu com status %d", h.url, resp.StatusCode)
+	}
+	return nil
+}
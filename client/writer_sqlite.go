@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/guilhermeayusso/desafio-goexpert/1/client/model"
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSinkSchema = `CREATE TABLE IF NOT EXISTS cotacoes (
+    pair       TEXT NOT NULL,
+    bid        TEXT NOT NULL,
+    ask        TEXT NOT NULL,
+    timestamp  INTEGER NOT NULL,
+    fetched_at TEXT NOT NULL
+)`
+
+func init() {
+	registerWriter("sqlite", func(path string, _ output) (Writer, error) {
+		if path == "" {
+			return nil, fmt.Errorf("informe o arquivo do banco, ex.: sqlite:cotacoes.db")
+		}
+		return sqliteWriter{path: path}, nil
+	})
+}
+
+// recorder é implementado pelos resultados que podem ser gravados linha a linha
+type recorder interface {
+	Records() []model.Record
+}
+
+// sqliteWriter acrescenta uma linha por cotação à tabela cotacoes, independente de --format
+type sqliteWriter struct {
+	path string
+}
+
+func (s sqliteWriter) Write(v formatter) error {
+	r, ok := v.(recorder)
+	if !ok {
+		return fmt.Errorf("o destino sqlite não se aplica a este comando")
+	}
+
+	db, err := sql.Open("sqlite", s.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSinkSchema); err != nil {
+		return fmt.Errorf("erro ao criar a tabela cotacoes: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, rec := range r.Records() {
+		if _, err := tx.Exec("INSERT INTO cotacoes (pair, bid, ask, timestamp, fetched_at) VALUES (?, ?, ?, ?, ?)",
+			rec.Pair, rec.Bid, rec.Ask, rec.Timestamp, rec.FetchedAt.UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+func init() {
+	registerWriter("syslog", newSyslogWriter)
+}
+
+// newSyslogWriter conecta ao syslog local ("syslog:") ou remoto ("syslog://host:514", UDP)
+func newSyslogWriter(target string, o output) (Writer, error) {
+	addr := strings.TrimPrefix(target, "//")
+	network := ""
+	if addr != "" {
+		network = "udp"
+	}
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, "cotacao")
+	if err != nil {
+		return nil, err
+	}
+	return syslogWriter{out: o, w: w}, nil
+}
+
+// syslogWriter envia uma mensagem por linha: as do histórico (WriteLog) quando o resultado
+// as tiver, ou as do formato escolhido
+type syslogWriter struct {
+	out output
+	w   *syslog.Writer
+}
+
+func (s syslogWriter) Write(v formatter) error {
+	defer s.w.Close()
+
+	var buf bytes.Buffer
+	if lf, ok := v.(logFormatter); ok {
+		if err := lf.WriteLog(&buf); err != nil {
+			return err
+		}
+	} else if err := s.out.writeTo(&buf, v); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			if err := s.w.Info(line); err != nil {
+				return fmt.Errorf("erro ao enviar ao syslog: %w", err)
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+type ProviderQuote struct {
+	Provider  string           `json:"provider"`
+	Bid       *decimal.Decimal `json:"bid,omitempty"`
+	Ask       *decimal.Decimal `json:"ask,omitempty"`
+	Timestamp int64            `json:"timestamp,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// ProviderSpread resume a diferença entre os provedores: BidSpread é a distância entre o
+// maior e o menor bid e Arbitrage a diferença entre o maior bid e o menor ask
+type ProviderSpread struct {
+	BidSpread decimal.Decimal `json:"bid_spread"`
+	AskSpread decimal.Decimal `json:"ask_spread"`
+	Arbitrage decimal.Decimal `json:"arbitrage"`
+	BestBid   string          `json:"best_bid"`
+	BestAsk   string          `json:"best_ask"`
+}
+
+type CompareResponse struct {
+	Pair      string          `json:"pair"`
+	Providers []ProviderQuote `json:"providers"`
+	Spread    *ProviderSpread `json:"spread,omitempty"`
+}
+
+// compareProviders consulta todos os provedores da cadeia em paralelo, preservando a ordem configurada
+func compareProviders(ctx context.Context, pair string) []ProviderQuote {
+	results := make([]ProviderQuote, len(providerChain))
+
+	var wg sync.WaitGroup
+	for i, provider := range providerChain {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := ProviderQuote{Provider: provider.Name()}
+			quote, err := provider.Fetch(ctx, pair)
+			if err != nil {
+				providerErrors.WithLabelValues(provider.Name()).Inc()
+				result.Error = err.Error()
+			} else {
+				bid, ask := parseDecimal(quote.Bid), parseDecimal(quote.Ask)
+				result.Bid, result.Ask = &bid, &ask
+				result.Timestamp = quoteTimestamp(quote)
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// spreadOf calcula o spread entre os provedores que responderam; exige ao menos dois
+func spreadOf(quotes []ProviderQuote) *ProviderSpread {
+	var ok []ProviderQuote
+	for _, q := range quotes {
+		if q.Error == "" {
+			ok = append(ok, q)
+		}
+	}
+	if len(ok) < 2 {
+		return nil
+	}
+
+	maxBid, minBid, minAsk, maxAsk := ok[0], ok[0], ok[0], ok[0]
+	for _, q := range ok[1:] {
+		if q.Bid.GreaterThan(*maxBid.Bid) {
+			maxBid = q
+		}
+		if q.Bid.LessThan(*minBid.Bid) {
+			minBid = q
+		}
+		if q.Ask.LessThan(*minAsk.Ask) {
+			minAsk = q
+		}
+		if q.Ask.GreaterThan(*maxAsk.Ask) {
+			maxAsk = q
+		}
+	}
+
+	return &ProviderSpread{
+		BidSpread: maxBid.Bid.Sub(*minBid.Bid).Round(moneyScale),
+		AskSpread: maxAsk.Ask.Sub(*minAsk.Ask).Round(moneyScale),
+		Arbitrage: maxBid.Bid.Sub(*minAsk.Ask).Round(moneyScale),
+		BestBid:   maxBid.Provider,
+		BestAsk:   minAsk.Provider,
+	}
+}
+
+// CompareHandler compara a cotação do par entre todos os provedores configurados
+func CompareHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	pair := strings.ToUpper(r.URL.Query().Get("pair"))
+	if pair == "" {
+		pair = defaultPair
+	}
+	if isVirtualPair(pair) || !pairs.isEnabled(pair) {
+		writeError(w, http.StatusNotFound, "par não habilitado")
+		return
+	}
+
+	quotes := compareProviders(r.Context(), pair)
+	writeJSON(w, http.StatusOK, CompareResponse{Pair: pair, Providers: quotes, Spread: spreadOf(quotes)})
+}
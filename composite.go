@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// compositePair é um par virtual cujo valor é a média ponderada das cotações de vários
+// provedores para o par base, ex.: "USD-BRL.MIX" sobre "USD-BRL"
+type compositePair struct {
+	symbol  string
+	base    string
+	weights map[string]float64
+}
+
+var composites = map[string]compositePair{}
+
+// parseComposites interpreta COMPOSITE_PAIRS no formato
+// "USD-BRL.MIX=awesomeapi:0.7,outro:0.3;EUR-BRL.MIX=..."
+func parseComposites(spec string) (map[string]compositePair, error) {
+	result := make(map[string]compositePair)
+	for _, def := range strings.Split(spec, ";") {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+
+		symbol, components, ok := strings.Cut(def, "=")
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		base, _, hasTag := strings.Cut(symbol, ".")
+		if !ok || !hasTag || !pairPattern.MatchString(base) {
+			return nil, fmt.Errorf("par composto inválido %q: use BASE-COTADA.NOME=provedor:peso,...", def)
+		}
+
+		c := compositePair{symbol: symbol, base: base, weights: make(map[string]float64)}
+		for _, component := range strings.Split(components, ",") {
+			name, weightStr, ok := strings.Cut(strings.TrimSpace(component), ":")
+			weight, err := strconv.ParseFloat(weightStr, 64)
+			if !ok || err != nil || weight <= 0 {
+				return nil, fmt.Errorf("componente inválido %q em %s", component, symbol)
+			}
+			if _, exists := providers[name]; !exists {
+				return nil, fmt.Errorf("provedor desconhecido %q em %s", name, symbol)
+			}
+			c.weights[name] += weight
+		}
+		result[symbol] = c
+	}
+	return result, nil
+}
+
+// fetch consulta os provedores em paralelo e pondera bid/ask pelos pesos configurados;
+// provedores com falha são descartados e os pesos restantes renormalizados
+func (c compositePair) fetch(ctx context.Context) (*Quote, error) {
+	type result struct {
+		quote  *Quote
+		weight float64
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []result
+		errs    []error
+	)
+	for name, weight := range c.weights {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quote, err := providers[name].Fetch(ctx, c.base)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			results = append(results, result{quote: quote, weight: weight})
+		}()
+	}
+	wg.Wait()
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("nenhum provedor respondeu para %s: %w", c.symbol, errors.Join(errs...))
+	}
+	for _, err := range errs {
+		log.Printf("Par composto %s: ignorando provedor com falha: %v", c.symbol, err)
+	}
+
+	var bid, ask, totalWeight decimal.Decimal
+	var timestamp int64
+	for _, r := range results {
+		weight := decimal.NewFromFloat(r.weight)
+		bid = bid.Add(parseDecimal(r.quote.Bid).Mul(weight))
+		ask = ask.Add(parseDecimal(r.quote.Ask).Mul(weight))
+		totalWeight = totalWeight.Add(weight)
+		timestamp = max(timestamp, quoteTimestamp(r.quote))
+	}
+
+	first := results[0].quote
+	return &Quote{
+		Code:       first.Code,
+		Codein:     first.Codein,
+		Name:       first.Name + " (composto)",
+		Bid:        bid.Div(totalWeight).StringFixed(moneyScale),
+		Ask:        ask.Div(totalWeight).StringFixed(moneyScale),
+		Timestamp:  strconv.FormatInt(timestamp, 10),
+		CreateDate: first.CreateDate,
+		Provider:   "composite",
+	}, nil
+}
+
+// registerComposites garante que os pares compostos estejam cadastrados e habilitados
+func registerComposites() error {
+	for symbol := range composites {
+		if _, exists := pairs.get(symbol); exists {
+			continue
+		}
+		if _, err := pairs.save(PairDB{Symbol: symbol, Enabled: true}); err != nil {
+			return err
+		}
+		log.Printf("Par composto %s cadastrado", symbol)
+	}
+	return nil
+}
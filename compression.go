@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+var compressedResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_compressed_responses_total",
+	Help: "Respostas HTTP enviadas comprimidas, por codificação",
+}, []string{"encoding"})
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+	brotliWriters = sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}}
+)
+
+// CompressionMiddleware comprime as respostas com Brotli ou gzip, conforme o Accept-Encoding
+// do cliente. O início do corpo é retido até COMPRESSION_MIN_SIZE bytes: respostas menores,
+// com Content-Encoding próprio ou de tipos já comprimidos (COMPRESSION_EXCLUDED_TYPES) são
+// enviadas como estão
+func CompressionMiddleware(next http.Handler) http.Handler {
+	if cfg.CompressionMinSize < 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding escolhe a codificação de maior q aceita pelo cliente, preferindo Brotli
+// no empate; retorna "" quando nenhuma é aceita
+func negotiateEncoding(header string) string {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		switch name {
+		case encodingBrotli, encodingGzip:
+			weights[name] = q
+		case "*":
+			for _, enc := range []string{encodingBrotli, encodingGzip} {
+				if _, explicit := weights[enc]; !explicit {
+					weights[enc] = q
+				}
+			}
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range []string{encodingBrotli, encodingGzip} {
+		if q := weights[enc]; q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressibleType indica se o Content-Type não está entre os tipos excluídos
+func compressibleType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range cfg.CompressionExcludedTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(prefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+// compressWriter retém o corpo até decidir se comprime: ao atingir o tamanho mínimo, no
+// Flush ou no fim da resposta
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided {
+		return
+	}
+	cw.status = status
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cfg.CompressionMinSize {
+			return len(p), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide envia os cabeçalhos e o que estiver retido, comprimindo quando compress e a
+// resposta permitirem
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// detectado antes da compressão; depois dela o net/http veria só bytes comprimidos
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	// respostas parciais (Range) referem-se aos bytes sem compressão
+	if compress && h.Get("Content-Encoding") == "" && h.Get("Content-Range") == "" && compressibleType(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		switch cw.encoding {
+		case encodingBrotli:
+			bw := brotliWriters.Get().(*brotli.Writer)
+			bw.Reset(cw.ResponseWriter)
+			cw.encoder = bw
+		default:
+			gw := gzipWriters.Get().(*gzip.Writer)
+			gw.Reset(cw.ResponseWriter)
+			cw.encoder = gw
+		}
+		compressedResponses.WithLabelValues(cw.encoding).Inc()
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	buf := cw.buf
+	cw.buf = nil
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush envia o que estiver retido, comprimindo, para que respostas em streaming não fiquem
+// presas esperando o tamanho mínimo
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(len(cw.buf) > 0)
+	}
+	switch enc := cw.encoder.(type) {
+	case *gzip.Writer:
+		enc.Flush()
+	case *brotli.Writer:
+		enc.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		cw.decide(false)
+	}
+	switch enc := cw.encoder.(type) {
+	case *gzip.Writer:
+		enc.Close()
+		enc.Reset(io.Discard)
+		gzipWriters.Put(enc)
+	case *brotli.Writer:
+		enc.Close()
+		enc.Reset(io.Discard)
+		brotliWriters.Put(enc)
+	}
+}
+
+// Hijack entrega a conexão (upgrade para WebSocket); a resposta deixa de passar por aqui
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	cw.decided = true
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Requisições HTTP em andamento por rota.",
+	}, []string{"route"})
+
+	httpConcurrencyRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_concurrency_rejected_total",
+		Help: "Requisições recusadas com 503 por exceder o limite de concorrência da rota.",
+	}, []string{"route"})
+)
+
+// activeRequests conta as requisições em andamento em todas as rotas, para o relatório de
+// encerramento
+var activeRequests atomic.Int64
+
+// routeLimits guarda um semáforo por rota limitada; as demais rotas não têm limite
+var routeLimits = map[string]chan struct{}{}
+
+// parseConcurrencyLimits interpreta CONCURRENCY_LIMITS no formato "/historico=4,/alerts/backtest=2",
+// com a rota escrita como registrada no mux
+func parseConcurrencyLimits(specs []string) (map[string]chan struct{}, error) {
+	limits := make(map[string]chan struct{})
+	for _, spec := range specs {
+		route, limitStr, ok := strings.Cut(spec, "=")
+		route = strings.TrimSpace(route)
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if !ok || !strings.HasPrefix(route, "/") || err != nil || limit < 1 {
+			return nil, fmt.Errorf("limite inválido %q: use /rota=N, com N positivo", spec)
+		}
+		limits[route] = make(chan struct{}, limit)
+	}
+	return limits, nil
+}
+
+// ConcurrencyMiddleware conta as requisições em andamento por rota e recusa com 503 as que
+// excedem o limite configurado, para que rotas caras (histórico, backtest, importações) não
+// consumam os recursos de /cotacao. Requisições liberadas pela allowlist não são limitadas
+func ConcurrencyMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(mux, r)
+		if sem, limited := routeLimits[route]; limited && !isAllowlisted(r.Context()) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			default:
+				httpConcurrencyRejected.WithLabelValues(route).Inc()
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusServiceUnavailable, "limite de requisições simultâneas atingido para "+route)
+				return
+			}
+		}
+
+		inFlight := httpInFlight.WithLabelValues(route)
+		inFlight.Inc()
+		activeRequests.Add(1)
+		defer func() {
+			inFlight.Dec()
+			activeRequests.Add(-1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
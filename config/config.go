@@ -0,0 +1,433 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Port          string
+	DBPath        string
+	JWTSecret     string
+	JWTExpiration time.Duration
+	DevMode       bool // permite subir sem JWT_SECRET, com um segredo aleatório a cada execução
+
+	GeoIPCountryDBPath string
+	GeoIPASNDBPath     string
+
+	AdminEmails []string
+
+	OTLPEndpoint string
+	ServiceName  string
+
+	HoneypotPaths []string
+
+	AbuseWindow       time.Duration
+	AbuseMaxErrors    int
+	AbuseMaxThrottled int
+	AbuseBanDuration  time.Duration
+
+	UpstreamMaxIdleConns        int
+	UpstreamMaxIdleConnsPerHost int
+	UpstreamIdleConnTimeout     time.Duration
+	UpstreamDialTimeout         time.Duration
+	UpstreamTLSHandshakeTimeout time.Duration
+	UpstreamTLSSessionCacheSize int
+	UpstreamDisableCompression  bool
+	UpstreamBudget              time.Duration // prazo total da cadeia de failover; o que o provedor não usar fica para os seguintes
+
+	UpstreamDailyQuota int
+
+	PersistMode   string // sync ou batch
+	BatchSize     int
+	BatchInterval time.Duration
+
+	AwesomeAPIBaseURL string
+	PollInterval      time.Duration // padrão dos pares sem intervalo próprio; 0 não os consulta
+	PollJitter        int           // variação aleatória de cada intervalo, em % (0 a 50)
+
+	DiscoveryInterval time.Duration // 0 desabilita a descoberta de pares
+
+	AutoMigrate bool // aplica as migrações pendentes na inicialização
+
+	CompositePairs string // ex.: "USD-BRL.MIX=awesomeapi:0.7,outro:0.3"
+
+	RetentionRaw   time.Duration // 0 mantém os dados brutos indefinidamente
+	PruneInterval  time.Duration
+	AuditRetention time.Duration // 0 mantém o log de auditoria indefinidamente
+
+	RollupInterval time.Duration // 0 desabilita a consolidação contínua de agregados
+
+	MarkupType  string  // fixed ou percent
+	MarkupValue float64 // valor absoluto (fixed) ou percentual (percent) aplicado sobre o mercado
+
+	Providers          []string // cadeia de failover, em ordem de preferência
+	FrankfurterBaseURL string
+
+	PTAXBaseURL string
+
+	ProxyMode     bool          // expõe /proxy/last/{pair} no formato do AwesomeAPI
+	ProxyCacheTTL time.Duration // idade máxima da cotação em cache servida pelo proxy
+
+	DBMemoryFallback bool // usa banco em memória quando o diretório de dados é somente leitura
+
+	StorageBackend string // sqlite, memory (buffer circular, sem persistência), badger ou postgres
+	MemoryMaxRates int
+
+	BadgerPath string
+
+	PostgresDSN             string
+	PostgresPartitionsAhead int // partições mensais criadas além do mês atual
+
+	SQLiteDriver string // cgo ou purego; vazio usa o padrão do build
+
+	PublicBaseURL    string // URL pública do servidor, usada nos links das mensagens de alerta
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	TelegramBotToken string
+	TelegramAPIURL   string
+
+	SchemaDrift string
+
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+
+	BackpressureSlowRatio  float64
+	BackpressurePauseRatio float64
+	OutboxBacklogSlow      int
+	OutboxBacklogPause     int
+
+	IDStrategy string
+
+	HistoryCacheTTL        time.Duration // 0 desativa o cache das consultas de histórico por intervalo
+	HistoryCacheMaxEntries int
+
+	RedactFields []string // campos da cotação omitidos nas respostas a chamadas anônimas (ex.: varBid,pctChange)
+
+	CompressionMinSize       int      // respostas menores são enviadas sem compressão; negativo desativa
+	CompressionExcludedTypes []string // prefixos de Content-Type já comprimidos (ex.: image/,application/zip)
+
+	ConcurrencyLimits []string // rota=limite de requisições simultâneas, ex.: /historico=4,/alerts/backtest=2
+
+	CORSAllowedOrigins []string // vazio desativa o CORS; aceita "*" e curingas de subdomínio (https://*.exemplo.com)
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+	CORSMaxAge         time.Duration // tempo de cache do preflight no navegador
+
+	ExportDir       string        // arquivos CSV e Parquet gerados por /historico/export, nomeados pelo hash da consulta
+	ExportRetention time.Duration // arquivos não acessados há mais tempo são removidos
+
+	AlertRulesFile         string        // YAML com regras de alerta fora do banco; vazio desativa
+	AlertRulesPollInterval time.Duration // intervalo de verificação de alterações no arquivo
+
+	TLSCertFile          string // certificado e chave em PEM; recarregados quando os arquivos mudam
+	TLSKeyFile           string
+	TLSAutocertDomains   []string // domínios com certificado obtido do Let's Encrypt (exclusivo com TLSCertFile)
+	TLSAutocertEmail     string
+	TLSAutocertCacheDir  string
+	TLSAutocertDirectory string // URL do diretório ACME; vazio usa o Let's Encrypt de produção
+	TLSRedirectAddr      string // endereço do listener HTTP que redireciona para HTTPS (ex.: :80); vazio desativa
+
+	HTTPReadHeaderTimeout     time.Duration
+	HTTPReadTimeout           time.Duration
+	HTTPWriteTimeout          time.Duration // também limita a duração dos downloads de /historico/export
+	HTTPIdleTimeout           time.Duration
+	HTTPMaxHeaderBytes        int
+	HTTPKeepAlive             bool
+	HTTPH2C                   bool // HTTP/2 sem TLS, para clientes gRPC-gateway atrás de um proxy
+	HTTP2MaxConcurrentStreams int
+
+	AllowlistIPs  []string // IPs ou faixas CIDR isentos de limites e banimentos, além dos cadastrados em /admin/allowlist
+	AllowlistKeys []string // chaves aceitas em X-API-Key com a mesma isenção
+
+	QuoteCacheTTL             time.Duration // idade em que a cotação em cache é servida sem consultar o provedor; 0 consulta sempre
+	QuoteStaleWhileRevalidate time.Duration // após o QUOTE_CACHE_TTL, prazo em que a cotação vencida ainda é servida enquanto é atualizada
+
+	DerivedSeries string // ex.: "TOURISM_RATE=USD-BRL.ask*1.045"
+
+	ShareLinkSecret string        // chave HMAC dos links de compartilhamento; vazio deriva do JWT_SECRET
+	ShareLinkMaxTTL time.Duration // validade máxima de um link de compartilhamento
+
+	BusBackend   string   // kafka ou nats; vazio desativa a publicação das cotações no barramento
+	BusServers   []string // brokers do Kafka (host:9092) ou servidores do NATS (nats://host:4222)
+	BusTopic     string   // tópico do Kafka; no NATS, prefixo do subject {tópico}.{par}
+	BusFormat    string   // json ou protobuf
+	BusQueueSize int      // mensagens aguardando publicação; as excedentes são descartadas
+
+	MQTTBroker   string // tcp://host:1883, ssl://host:8883 ou ws://host/mqtt; vazio desativa o MQTT
+	MQTTTopic    string // prefixo dos tópicos: {tópico}/{par} com o bid e {tópico}/status
+	MQTTClientID string
+	MQTTUsername string
+	MQTTPassword string
+	MQTTQoS      int // 0, 1 ou 2
+
+	// Redis compartilhado pelas réplicas (redis://host:6379/0): cache de cotações e reserva das
+	// consultas do agendador. Vazio mantém ambos locais a cada réplica
+	RedisURL    string
+	RedisPrefix string // prefixo das chaves, para dividir o Redis com outras aplicações
+
+	LeaderElection string        // db ou redis; vazio faz cada instância rodar o agendador e os alertas
+	LeaderLeaseTTL time.Duration // validade da liderança, renovada a cada terço; é o tempo máximo sem líder após uma queda
+	InstanceID     string        // identifica a instância na eleição; vazio usa host:pid
+
+	JournalPath           string        // JSONL com as cotações que o banco recusou; vazio desativa
+	JournalReplayInterval time.Duration // intervalo entre as tentativas de regravar o journal
+}
+
+// Load lê a configuração das variáveis de ambiente, usando valores padrão quando ausentes
+func Load() *Config {
+	return &Config{
+		Port:          getEnv("PORT", "8080"),
+		DBPath:        getEnv("DB_PATH", "./data/exchange.db"),
+		JWTSecret:     getEnv("JWT_SECRET", ""),
+		JWTExpiration: getDuration("JWT_EXPIRATION", 24*time.Hour),
+		DevMode:       getBool("DEV_MODE", false),
+
+		GeoIPCountryDBPath: getEnv("GEOIP_COUNTRY_DB", ""),
+		GeoIPASNDBPath:     getEnv("GEOIP_ASN_DB", ""),
+
+		AdminEmails: getList("ADMIN_EMAILS"),
+
+		OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		ServiceName:  getEnv("OTEL_SERVICE_NAME", "cotacao-server"),
+
+		HoneypotPaths: getListOr("HONEYPOT_PATHS", []string{
+			"/wp-admin", "/wp-login.php", "/xmlrpc.php", "/.env", "/.git",
+			"/.aws", "/phpmyadmin", "/cgi-bin", "/vendor/phpunit", "/boaform",
+		}),
+
+		AbuseWindow:       getDuration("ABUSE_WINDOW", time.Minute),
+		AbuseMaxErrors:    getInt("ABUSE_MAX_ERRORS", 50),
+		AbuseMaxThrottled: getInt("ABUSE_MAX_THROTTLED", 20),
+		AbuseBanDuration:  getDuration("ABUSE_BAN_DURATION", 15*time.Minute),
+
+		UpstreamMaxIdleConns:        getInt("UPSTREAM_MAX_IDLE_CONNS", 100),
+		UpstreamMaxIdleConnsPerHost: getInt("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", 20),
+		UpstreamIdleConnTimeout:     getDuration("UPSTREAM_IDLE_CONN_TIMEOUT", 90*time.Second),
+		UpstreamDialTimeout:         getDuration("UPSTREAM_DIAL_TIMEOUT", 5*time.Second),
+		UpstreamTLSHandshakeTimeout: getDuration("UPSTREAM_TLS_HANDSHAKE_TIMEOUT", 5*time.Second),
+		UpstreamTLSSessionCacheSize: getInt("UPSTREAM_TLS_SESSION_CACHE_SIZE", 64),
+		UpstreamDisableCompression:  getBool("UPSTREAM_DISABLE_COMPRESSION", false),
+		UpstreamBudget:              getDuration("UPSTREAM_BUDGET", 250*time.Millisecond),
+
+		UpstreamDailyQuota: getInt("UPSTREAM_DAILY_QUOTA", 0),
+
+		PersistMode:   getEnv("PERSIST_MODE", "sync"),
+		BatchSize:     getInt("BATCH_SIZE", 100),
+		BatchInterval: getDuration("BATCH_INTERVAL", time.Second),
+
+		AwesomeAPIBaseURL: getEnv("AWESOMEAPI_BASE_URL", "https://economia.awesomeapi.com.br"),
+		PollInterval:      getDuration("POLL_INTERVAL", 0),
+		PollJitter:        getInt("POLL_JITTER", 10),
+
+		DiscoveryInterval: getDuration("DISCOVERY_INTERVAL", 0),
+
+		AutoMigrate: getBool("DB_AUTO_MIGRATE", true),
+
+		CompositePairs: getEnv("COMPOSITE_PAIRS", ""),
+
+		RetentionRaw:   getDuration("RETENTION_RAW", 90*24*time.Hour),
+		PruneInterval:  getDuration("PRUNE_INTERVAL", 24*time.Hour),
+		AuditRetention: getDuration("AUDIT_RETENTION", 90*24*time.Hour),
+
+		RollupInterval: getDuration("ROLLUP_INTERVAL", 5*time.Minute),
+
+		MarkupType:  getEnv("MARKUP_TYPE", "percent"),
+		MarkupValue: getFloat("MARKUP_VALUE", 0),
+
+		Providers:          getListOr("PROVIDERS", []string{"awesomeapi", "frankfurter"}),
+		FrankfurterBaseURL: getEnv("FRANKFURTER_BASE_URL", "https://api.frankfurter.app"),
+
+		PTAXBaseURL: getEnv("PTAX_BASE_URL", "https://olinda.bcb.gov.br/olinda/servico/PTAX/versao/v1/odata"),
+
+		ProxyMode:     getBool("PROXY_MODE", false),
+		ProxyCacheTTL: getDuration("PROXY_CACHE_TTL", time.Minute),
+
+		DBMemoryFallback: getBool("DB_MEMORY_FALLBACK", true),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", "sqlite"),
+		MemoryMaxRates: getInt("MEMORY_MAX_RATES", 10000),
+
+		BadgerPath: getEnv("BADGER_PATH", "data/badger"),
+
+		PostgresDSN:             getEnv("POSTGRES_DSN", ""),
+		PostgresPartitionsAhead: getInt("POSTGRES_PARTITIONS_AHEAD", 3),
+
+		SQLiteDriver: getEnv("SQLITE_DRIVER", ""),
+
+		PublicBaseURL:    getEnv("PUBLIC_BASE_URL", "http://localhost:"+getEnv("PORT", "8080")),
+		SMTPHost:         getEnv("SMTP_HOST", ""),
+		SMTPPort:         getInt("SMTP_PORT", 587),
+		SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:         getEnv("SMTP_FROM", ""),
+		TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramAPIURL:   getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+
+		SchemaDrift: getEnv("SCHEMA_DRIFT", "fail"),
+
+		OutboxPollInterval: getDuration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+		OutboxRetention:    getDuration("OUTBOX_RETENTION", 24*time.Hour),
+
+		BackpressureSlowRatio:  getFloat("BACKPRESSURE_SLOW_RATIO", 0.5),
+		BackpressurePauseRatio: getFloat("BACKPRESSURE_PAUSE_RATIO", 0.9),
+		OutboxBacklogSlow:      getInt("OUTBOX_BACKLOG_SLOW", 1000),
+		OutboxBacklogPause:     getInt("OUTBOX_BACKLOG_PAUSE", 10000),
+
+		IDStrategy: getEnv("ID_STRATEGY", "ulid"),
+
+		HistoryCacheTTL:        getDuration("HISTORY_CACHE_TTL", 5*time.Minute),
+		HistoryCacheMaxEntries: getInt("HISTORY_CACHE_MAX_ENTRIES", 1000),
+
+		RedactFields: getList("REDACT_FIELDS"),
+
+		CompressionMinSize: getInt("COMPRESSION_MIN_SIZE", 1024),
+		CompressionExcludedTypes: getListOr("COMPRESSION_EXCLUDED_TYPES", []string{
+			"image/", "video/", "audio/", "font/woff", "application/zip", "application/gzip",
+			"application/x-gzip", "application/x-brotli", "application/octet-stream",
+			"application/vnd.apache.parquet", "text/event-stream",
+		}),
+
+		ConcurrencyLimits: getList("CONCURRENCY_LIMITS"),
+
+		CORSAllowedOrigins: getList("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedMethods: getListOr("CORS_ALLOWED_METHODS", []string{"GET", "HEAD"}),
+		CORSAllowedHeaders: getListOr("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", "Accept-Language", "X-Request-ID", "X-API-Key"}),
+		CORSMaxAge:         getDuration("CORS_MAX_AGE", 10*time.Minute),
+
+		ExportDir:       getEnv("EXPORT_DIR", "./data/exports"),
+		ExportRetention: getDuration("EXPORT_RETENTION", 7*24*time.Hour),
+
+		AlertRulesFile:         getEnv("ALERT_RULES_FILE", ""),
+		AlertRulesPollInterval: getDuration("ALERT_RULES_POLL_INTERVAL", 5*time.Second),
+
+		TLSCertFile:          getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:           getEnv("TLS_KEY_FILE", ""),
+		TLSAutocertDomains:   getList("TLS_AUTOCERT_DOMAINS"),
+		TLSAutocertEmail:     getEnv("TLS_AUTOCERT_EMAIL", ""),
+		TLSAutocertCacheDir:  getEnv("TLS_AUTOCERT_CACHE_DIR", "./data/autocert"),
+		TLSAutocertDirectory: getEnv("TLS_AUTOCERT_DIRECTORY", ""),
+		TLSRedirectAddr:      getEnv("TLS_REDIRECT_ADDR", ""),
+
+		HTTPReadHeaderTimeout:     getDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		HTTPReadTimeout:           getDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		HTTPWriteTimeout:          getDuration("HTTP_WRITE_TIMEOUT", 2*time.Minute),
+		HTTPIdleTimeout:           getDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		HTTPMaxHeaderBytes:        getInt("HTTP_MAX_HEADER_BYTES", 64<<10),
+		HTTPKeepAlive:             getBool("HTTP_KEEP_ALIVE", true),
+		HTTPH2C:                   getBool("HTTP_H2C", false),
+		HTTP2MaxConcurrentStreams: getInt("HTTP2_MAX_CONCURRENT_STREAMS", 250),
+
+		AllowlistIPs:  getList("ALLOWLIST_IPS"),
+		AllowlistKeys: getList("ALLOWLIST_KEYS"),
+
+		QuoteCacheTTL:             getDuration("QUOTE_CACHE_TTL", 0),
+		QuoteStaleWhileRevalidate: getDuration("QUOTE_STALE_WHILE_REVALIDATE", 0),
+
+		DerivedSeries: getEnv("DERIVED_SERIES", ""),
+
+		ShareLinkSecret: getEnv("SHARE_LINK_SECRET", ""),
+		ShareLinkMaxTTL: getDuration("SHARE_LINK_MAX_TTL", 7*24*time.Hour),
+
+		BusBackend:   getEnv("BUS_BACKEND", ""),
+		BusServers:   getList("BUS_SERVERS"),
+		BusTopic:     getEnv("BUS_TOPIC", "cotacoes"),
+		BusFormat:    getEnv("BUS_FORMAT", "json"),
+		BusQueueSize: getInt("BUS_QUEUE_SIZE", 1000),
+
+		MQTTBroker:   getEnv("MQTT_BROKER", ""),
+		MQTTTopic:    getEnv("MQTT_TOPIC", "cotacoes"),
+		MQTTClientID: getEnv("MQTT_CLIENT_ID", "cotacao-server"),
+		MQTTUsername: getEnv("MQTT_USERNAME", ""),
+		MQTTPassword: getEnv("MQTT_PASSWORD", ""),
+		MQTTQoS:      getInt("MQTT_QOS", 1),
+
+		RedisURL:    getEnv("REDIS_URL", ""),
+		RedisPrefix: getEnv("REDIS_PREFIX", "cotacao:"),
+
+		LeaderElection: getEnv("LEADER_ELECTION", ""),
+		LeaderLeaseTTL: getDuration("LEADER_LEASE_TTL", 15*time.Second),
+		InstanceID:     getEnv("INSTANCE_ID", ""),
+
+		JournalPath:           getEnv("JOURNAL_PATH", "./data/pending-rates.jsonl"),
+		JournalReplayInterval: getDuration("JOURNAL_REPLAY_INTERVAL", 30*time.Second),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func getInt(key string, fallback int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
+	}
+	return i
+}
+
+func getFloat(key string, fallback float64) float64 {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+func getBool(key string, fallback bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+func getListOr(key string, fallback []string) []string {
+	if items := getList(key); len(items) > 0 {
+		return items
+	}
+	return fallback
+}
+
+// getList lê uma lista separada por vírgulas, ignorando itens vazios
+func getList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
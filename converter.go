@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Casas decimais da menor unidade de cada moeda; as demais usam 2
+var currencyDecimals = map[string]int32{
+	"JPY": 0, "KRW": 0, "CLP": 0, "PYG": 0,
+	"BTC": 8, "ETH": 8, "LTC": 8, "XRP": 8, "DOGE": 8,
+}
+
+type ConversionResponse struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Amount    decimal.Decimal `json:"amount"`
+	Result    decimal.Decimal `json:"result"`
+	Rate      decimal.Decimal `json:"rate"`
+	Pair      string          `json:"pair"`
+	Inverted  bool            `json:"inverted"`
+	Provider  string          `json:"provider,omitempty"`
+	Timestamp int64           `json:"timestamp"`
+	Staleness int64           `json:"staleness_seconds"`
+}
+
+func decimalsOf(currency string) int32 {
+	if d, ok := currencyDecimals[currency]; ok {
+		return d
+	}
+	return 2
+}
+
+// conversionQuote busca a cotação do par, recorrendo à última em cache se o provedor falhar
+func conversionQuote(ctx context.Context, pair string) (*Quote, error) {
+	quote, err := fetchAndPersist(ctx, pair)
+	if err != nil {
+		if entry, ok := cache.get(pair); ok {
+			logf(ctx, "Conversão usando cotação de %s em cache: %v", pair, err)
+			return entry.quote, nil
+		}
+		return nil, err
+	}
+	return quote, nil
+}
+
+// ConverterHandler converte ?amount= de ?from= para ?to= usando o par direto (vendendo a moeda
+// de origem pelo bid) ou o par inverso (comprando a moeda de destino pelo ask)
+func ConverterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	from := strings.ToUpper(strings.TrimSpace(q.Get("from")))
+	to := strings.ToUpper(strings.TrimSpace(q.Get("to")))
+	if from == "" || to == "" {
+		writeError(w, http.StatusBadRequest, "from e to são obrigatórios")
+		return
+	}
+	amount, err := decimal.NewFromString(q.Get("amount"))
+	if err != nil || amount.IsNegative() {
+		writeError(w, http.StatusBadRequest, "amount deve ser um número não negativo")
+		return
+	}
+
+	resp := ConversionResponse{From: from, To: to, Amount: amount}
+	if from == to {
+		resp.Rate = decimal.NewFromInt(1)
+		resp.Result = amount.Round(decimalsOf(to))
+		resp.Timestamp = time.Now().Unix()
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	resp.Pair = from + "-" + to
+	if !pairs.isEnabled(resp.Pair) {
+		resp.Pair, resp.Inverted = to+"-"+from, true
+		if !pairs.isEnabled(resp.Pair) {
+			writeError(w, http.StatusNotFound, "nenhum par habilitado entre "+from+" e "+to)
+			return
+		}
+	}
+
+	quote, err := conversionQuote(r.Context(), resp.Pair)
+	if err != nil {
+		logf(r.Context(), "Erro ao obter cotação para conversão: %v", err)
+		writeError(w, http.StatusServiceUnavailable, "cotação indisponível para "+resp.Pair)
+		return
+	}
+
+	if resp.Inverted {
+		ask := parseDecimal(quote.Ask)
+		if !ask.IsPositive() {
+			writeError(w, http.StatusServiceUnavailable, "cotação inválida para "+resp.Pair)
+			return
+		}
+		// Dividir pela cotação evita o erro de arredondamento de multiplicar pela inversa
+		resp.Result = amount.Div(ask).Round(decimalsOf(to))
+		resp.Rate = decimal.NewFromInt(1).Div(ask).Round(6)
+	} else {
+		resp.Rate = parseDecimal(quote.Bid)
+		resp.Result = amount.Mul(resp.Rate).Round(decimalsOf(to))
+	}
+
+	resp.Provider = quote.Provider
+	resp.Timestamp = quoteTimestamp(quote)
+	resp.Staleness = max(time.Now().Unix()-resp.Timestamp, 0)
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+// Cabeçalhos de resposta que o JavaScript do navegador pode ler
+var corsExposedHeaders = []string{requestIDHeader, "X-Total-Count", "Link", "Retry-After", domain.WireVersionHeader}
+
+// CORSMiddleware permite chamadas de navegadores nas origens de CORS_ALLOWED_ORIGINS e
+// responde aos preflights (OPTIONS com Access-Control-Request-Method) sem chegar aos
+// handlers. Sem origens configuradas, as respostas não recebem cabeçalhos de CORS
+func CORSMiddleware(next http.Handler) http.Handler {
+	if len(cfg.CORSAllowedOrigins) == 0 {
+		return next
+	}
+
+	methods := strings.Join(cfg.CORSAllowedMethods, ", ")
+	headers := strings.Join(cfg.CORSAllowedHeaders, ", ")
+	exposed := strings.Join(corsExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		w.Header().Add("Vary", "Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, ok := allowedOrigin(origin)
+		if !ok {
+			if preflight {
+				// sem os cabeçalhos de CORS o navegador bloqueia a requisição
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", allowed)
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", exposed)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", methods)
+		h.Set("Access-Control-Allow-Headers", headers)
+		h.Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowedOrigin retorna o valor de Access-Control-Allow-Origin para origin: "*" quando
+// qualquer origem é aceita, a própria origem quando ela está na lista ou casa com um curinga
+// de subdomínio ("https://*.exemplo.com")
+func allowedOrigin(origin string) (string, bool) {
+	if slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		return "*", true
+	}
+	origin = strings.ToLower(origin)
+	for _, pattern := range cfg.CORSAllowedOrigins {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+		if prefix, suffix, wildcard := strings.Cut(pattern, "*"); wildcard {
+			if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return origin, true
+			}
+			continue
+		}
+		if origin == pattern {
+			return origin, true
+		}
+	}
+	return "", false
+}
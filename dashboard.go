@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/dashboard"
+)
+
+var dashboardAssets = http.StripPrefix("/dashboard/", http.FileServerFS(dashboard.FS))
+
+// DashboardHandler serve o painel embutido em / e seus arquivos em /dashboard/. Como "/"
+// casa com qualquer caminho no mux, os demais continuam respondendo 404
+func DashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFileFS(w, r, dashboard.FS, "index.html")
+}
@@ -0,0 +1,8 @@
+// Package dashboard contém o painel web servido em /, embutido no binário: a página
+// consulta a própria API (/cotacao, /historico, /admin/providers) e não usa bibliotecas externas.
+package dashboard
+
+import "embed"
+
+//go:embed index.html dashboard.css dashboard.js
+var FS embed.FS
@@ -0,0 +1,279 @@
+// Painel de operação: cotação mais recente, gráfico do histórico e situação dos provedores.
+// O token obtido em /auth/login fica no sessionStorage e vale só para esta aba. Aberto por um
+// link de compartilhamento (query assinada com sig), mostra só o trecho compartilhado.
+"use strict";
+
+const REFRESH_MS = 30000;
+const $ = (id) => document.getElementById(id);
+
+const state = {
+  token: sessionStorage.getItem("cotacao-token") || "",
+  pair: "USD-BRL",
+  days: 30,
+  share: new URLSearchParams(location.search).has("sig") ? location.search : "",
+};
+
+async function api(path) {
+  const headers = { Accept: "application/json" };
+  if (state.token) headers.Authorization = "Bearer " + state.token;
+  const resp = await fetch(path, { headers });
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    const err = new Error(body.error || resp.statusText);
+    err.status = resp.status;
+    throw err;
+  }
+  return body;
+}
+
+function formatNumber(value, digits = 4) {
+  const n = Number(value);
+  return Number.isFinite(n) ? n.toLocaleString("pt-BR", { minimumFractionDigits: 2, maximumFractionDigits: digits }) : "–";
+}
+
+function formatTime(date, withDate = true) {
+  return date.toLocaleString("pt-BR", withDate ? { dateStyle: "short", timeStyle: "short" } : { timeStyle: "short" });
+}
+
+async function loadPairs() {
+  const select = $("pair");
+  try {
+    const pairs = await api("/pairs");
+    select.replaceChildren(...pairs.map((p) => new Option(p.symbol, p.symbol, false, p.symbol === state.pair)));
+  } catch {
+    select.replaceChildren(new Option(state.pair));
+  }
+}
+
+async function loadLatest() {
+  try {
+    const rates = await api("/cotacao?pair=" + encodeURIComponent(state.pair));
+    const quote = rates[state.pair.replace("-", "")];
+    $("bid").textContent = formatNumber(quote.bid);
+    $("ask").textContent = formatNumber(quote.ask);
+
+    const pct = Number(quote.pctChange);
+    const pctEl = $("pct");
+    pctEl.textContent = Number.isFinite(pct) ? (pct > 0 ? "+" : "") + pct.toFixed(2) + "%" : "–";
+    pctEl.className = "value " + (pct > 0 ? "up" : pct < 0 ? "down" : "");
+
+    const at = new Date(Number(quote.timestamp) * 1000);
+    $("quote-meta").textContent = `${quote.name || state.pair} · ${formatTime(at)}` + (quote.provider ? ` · via ${quote.provider}` : "");
+  } catch (err) {
+    $("quote-meta").textContent = "Cotação indisponível: " + err.message;
+  }
+}
+
+async function loadHistory() {
+  const from = Math.floor(Date.now() / 1000) - state.days * 86400;
+  const path = state.share
+    ? "/compartilhado/historico" + state.share
+    : `/historico?pair=${encodeURIComponent(state.pair)}&from=${from}`;
+  try {
+    const history = await api(path);
+    showLogin(false);
+    drawChart(history.points.map((p) => ({ time: new Date(p.time), value: Number(p.close) })));
+    $("chart-meta").textContent = `${history.points.length} pontos · resolução ${history.resolution}` +
+      (state.share ? ` · compartilhado de ${formatTime(new Date(history.from))} a ${formatTime(new Date(history.to))}` : "");
+  } catch (err) {
+    if (state.share) {
+      $("chart").replaceChildren();
+      $("chart-meta").textContent = err.status === 410 ? "Este link de compartilhamento expirou." : "Link de compartilhamento inválido.";
+      return;
+    }
+    if (err.status === 401) {
+      showLogin(true);
+      $("chart").replaceChildren();
+      $("chart-meta").textContent = "";
+      return;
+    }
+    $("chart-meta").textContent = "Histórico indisponível: " + err.message;
+  }
+}
+
+async function loadProviders() {
+  const body = $("providers").tBodies[0];
+  try {
+    const providers = await api("/admin/providers");
+    body.replaceChildren(...providers.map((p) => {
+      const row = document.createElement("tr");
+      const status = p.cache_only ? "cota esgotada (só cache)" : p.warning ? "perto do limite" : "ok";
+      for (const text of [p.name, p.calls_today, p.daily_quota > 0 ? p.daily_quota : "sem limite", status]) {
+        const cell = document.createElement("td");
+        cell.textContent = text;
+        row.append(cell);
+      }
+      row.lastChild.className = p.cache_only ? "down" : p.warning ? "" : "up";
+      return row;
+    }));
+    $("providers-meta").textContent = "";
+  } catch (err) {
+    body.replaceChildren();
+    $("providers-meta").textContent = err.status === 401 || err.status === 403
+      ? "Disponível para administradores."
+      : "Situação indisponível: " + err.message;
+  }
+}
+
+// drawChart desenha a série em SVG, com eixo de preços à esquerda e um cursor que mostra o
+// ponto mais próximo do mouse
+function drawChart(points) {
+  const container = $("chart");
+  container.replaceChildren();
+  if (points.length === 0) {
+    container.textContent = "Sem cotações no período.";
+    return;
+  }
+
+  const width = container.clientWidth || 800;
+  const height = container.clientHeight || 280;
+  const pad = { top: 10, right: 12, bottom: 24, left: 56 };
+  const values = points.map((p) => p.value);
+  let min = Math.min(...values);
+  let max = Math.max(...values);
+  if (min === max) {
+    min -= 0.01;
+    max += 0.01;
+  }
+  const t0 = points[0].time.getTime();
+  const t1 = Math.max(points[points.length - 1].time.getTime(), t0 + 1);
+  const x = (t) => pad.left + ((t - t0) / (t1 - t0)) * (width - pad.left - pad.right);
+  const y = (v) => pad.top + (1 - (v - min) / (max - min)) * (height - pad.top - pad.bottom);
+
+  const ns = "http://www.w3.org/2000/svg";
+  const el = (name, attrs, text) => {
+    const node = document.createElementNS(ns, name);
+    for (const [k, v] of Object.entries(attrs)) node.setAttribute(k, v);
+    if (text !== undefined) node.textContent = text;
+    return node;
+  };
+  const svg = el("svg", { viewBox: `0 0 ${width} ${height}` });
+
+  for (let i = 0; i <= 4; i++) {
+    const v = min + ((max - min) * i) / 4;
+    svg.append(el("line", { class: "grid", x1: pad.left, x2: width - pad.right, y1: y(v), y2: y(v) }));
+    svg.append(el("text", { class: "axis", x: pad.left - 6, y: y(v) + 4, "text-anchor": "end" }, formatNumber(v)));
+  }
+  const spanDays = (t1 - t0) / 86400000;
+  for (let i = 0; i <= 4; i++) {
+    const t = t0 + ((t1 - t0) * i) / 4;
+    const anchor = i === 0 ? "start" : i === 4 ? "end" : "middle";
+    svg.append(el("text", { class: "axis", x: x(t), y: height - 6, "text-anchor": anchor }, formatTime(new Date(t), spanDays > 1)));
+  }
+
+  const line = points.map((p, i) => `${i ? "L" : "M"}${x(p.time.getTime()).toFixed(1)},${y(p.value).toFixed(1)}`).join("");
+  const bottom = height - pad.bottom;
+  svg.append(el("path", { class: "area", d: `${line}L${x(t1)},${bottom}L${x(t0)},${bottom}Z` }));
+  svg.append(el("path", { class: "series", d: line }));
+
+  const cursor = el("line", { class: "cursor", y1: pad.top, y2: bottom, visibility: "hidden" });
+  svg.append(cursor);
+  const tooltip = document.createElement("div");
+  tooltip.className = "tooltip";
+  tooltip.hidden = true;
+
+  svg.addEventListener("mousemove", (ev) => {
+    const rect = svg.getBoundingClientRect();
+    const mx = ((ev.clientX - rect.left) / rect.width) * width;
+    let nearest = points[0];
+    for (const p of points) {
+      if (Math.abs(x(p.time.getTime()) - mx) < Math.abs(x(nearest.time.getTime()) - mx)) nearest = p;
+    }
+    const px = x(nearest.time.getTime());
+    cursor.setAttribute("x1", px);
+    cursor.setAttribute("x2", px);
+    cursor.setAttribute("visibility", "visible");
+    tooltip.hidden = false;
+    tooltip.textContent = `${formatTime(nearest.time)} · ${formatNumber(nearest.value)}`;
+    tooltip.style.left = Math.min((px / width) * rect.width + 8, rect.width - tooltip.offsetWidth) + "px";
+    tooltip.style.top = "0px";
+  });
+  svg.addEventListener("mouseleave", () => {
+    cursor.setAttribute("visibility", "hidden");
+    tooltip.hidden = true;
+  });
+
+  container.append(svg, tooltip);
+}
+
+function showLogin(visible) {
+  $("login").hidden = !visible;
+  $("logout").hidden = !state.token;
+  $("share").hidden = !state.token || visible;
+}
+
+// share gera um link de leitura para o par e o período do gráfico e o mostra para cópia
+async function share() {
+  const from = Math.floor(Date.now() / 1000) - state.days * 86400;
+  try {
+    const resp = await fetch("/historico/compartilhar", {
+      method: "POST",
+      headers: { "Content-Type": "application/json", Authorization: "Bearer " + state.token },
+      body: JSON.stringify({ pair: state.pair, from: String(from) }),
+    });
+    const body = await resp.json();
+    if (!resp.ok) throw new Error(body.error || resp.statusText);
+    window.prompt(`Link válido até ${formatTime(new Date(body.expires_at))}:`, body.dashboard_url);
+  } catch (err) {
+    $("chart-meta").textContent = "Não foi possível compartilhar: " + err.message;
+  }
+}
+
+async function login(ev) {
+  ev.preventDefault();
+  $("login-error").textContent = "";
+  try {
+    const resp = await fetch("/auth/login", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ email: $("email").value, password: $("password").value }),
+    });
+    const body = await resp.json();
+    if (!resp.ok) throw new Error(body.error || resp.statusText);
+    state.token = body.token;
+    sessionStorage.setItem("cotacao-token", state.token);
+    $("password").value = "";
+    refresh();
+  } catch (err) {
+    $("login-error").textContent = err.message;
+  }
+}
+
+function logout() {
+  state.token = "";
+  sessionStorage.removeItem("cotacao-token");
+  refresh();
+}
+
+function refresh() {
+  loadLatest();
+  loadHistory();
+  if (state.share) return;
+  loadProviders();
+  showLogin(!$("login").hidden);
+}
+
+$("pair").addEventListener("change", (ev) => {
+  state.pair = ev.target.value;
+  refresh();
+});
+$("range").addEventListener("change", (ev) => {
+  state.days = Number(ev.target.value);
+  loadHistory();
+});
+$("login").addEventListener("submit", login);
+$("logout").addEventListener("click", logout);
+$("share").addEventListener("click", share);
+
+state.days = Number($("range").value);
+if (state.share) {
+  // O trecho é fixo: sem troca de par, período ou login
+  state.pair = new URLSearchParams(state.share).get("pair") || state.pair;
+  $("pair").replaceChildren(new Option(state.pair));
+  for (const id of ["pair", "range"]) $(id).disabled = true;
+  $("providers").closest("section").hidden = true;
+  refresh();
+} else {
+  loadPairs().then(refresh);
+}
+setInterval(refresh, REFRESH_MS);
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/shopspring/decimal"
+)
+
+// Tipos de quarentena: parse quando um valor do provedor não pôde ser convertido (antes
+// gravado como zero) e invalid quando os valores convertidos não fazem sentido
+const (
+	quarantineParse   = "parse"
+	quarantineInvalid = "invalid"
+)
+
+const (
+	defaultDataQualityPeriod = 24 * time.Hour
+	maxDataQualityRows       = 100000 // por par
+	maxReportedGaps          = 100    // por par; gap_count traz o total
+)
+
+var quarantinedRates = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "rates_quarantined_total",
+	Help: "Cotações do provedor mantidas fora da série por valores inválidos, por tipo (parse ou invalid).",
+}, []string{"kind"})
+
+// QuarantinedRateDB guarda a cotação recebida do provedor que não entrou na série, com o
+// motivo, para que o problema fique visível em vez de virar zeros no histórico
+type QuarantinedRateDB struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Pair      string    `gorm:"type:varchar(21);not null" json:"pair"`
+	Kind      string    `gorm:"type:varchar(10);not null" json:"kind"`
+	Reason    string    `gorm:"type:varchar(255);not null" json:"reason"`
+	Payload   string    `gorm:"type:text;not null" json:"payload"`
+	RequestID string    `gorm:"type:varchar(128)" json:"request_id,omitempty"`
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// inspectQuote confere os valores que serão gravados, devolvendo o tipo e o motivo da
+// quarentena, ou kind vazio para uma cotação válida. Os motivos são fixos, para agrupar o
+// relatório; o valor recebido fica no payload
+func inspectQuote(quote *Quote) (kind, reason string) {
+	bid, err := decimal.NewFromString(quote.Bid)
+	if err != nil {
+		return quarantineParse, "bid não numérico"
+	}
+	ask, err := decimal.NewFromString(quote.Ask)
+	if err != nil {
+		return quarantineParse, "ask não numérico"
+	}
+	if _, err := quote.Unix(); err != nil {
+		return quarantineParse, "timestamp não numérico"
+	}
+	switch {
+	case !bid.IsPositive() || !ask.IsPositive():
+		return quarantineInvalid, "bid e ask devem ser positivos"
+	case ask.LessThan(bid):
+		return quarantineInvalid, "ask menor que bid"
+	}
+	return "", ""
+}
+
+// quarantine grava a cotação rejeitada; uma falha aqui só é registrada no log
+func quarantine(ctx context.Context, pair string, quote *Quote, kind, reason string) {
+	quarantinedRates.WithLabelValues(kind).Inc()
+	logf(ctx, "Cotação de %s em quarentena (%s): %s", pair, kind, reason)
+
+	payload, _ := json.Marshal(quote)
+	row := QuarantinedRateDB{
+		Pair: pair, Kind: kind, Reason: reason, Payload: string(payload),
+		RequestID: requestIDFromContext(ctx),
+	}
+	if err := db.WithContext(ctx).Create(&row).Error; err != nil {
+		logf(ctx, "Erro ao gravar cotação em quarentena: %v", err)
+	}
+}
+
+// DataGap é um trecho da série sem cotações maior que o intervalo esperado
+type DataGap struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Missing int64     `json:"missing"` // consultas que faltaram no trecho
+}
+
+type PairDataQuality struct {
+	Pair             string    `json:"pair"`
+	Interval         Duration  `json:"interval,omitempty"` // sem intervalo os gaps não são avaliados
+	Rows             int       `json:"rows"`
+	Truncated        bool      `json:"truncated,omitempty"` // mais de 100000 cotações; só as primeiras foram avaliadas
+	GapCount         int       `json:"gap_count"`
+	MissingIntervals int64     `json:"missing_intervals"`
+	Gaps             []DataGap `json:"gaps"`
+	Duplicates       int       `json:"duplicates"`
+	Quarantined      int64     `json:"quarantined"`
+	ParseFailures    int64     `json:"parse_failures"`
+}
+
+type DataQualityReport struct {
+	From              time.Time         `json:"from"`
+	To                time.Time         `json:"to"`
+	Pairs             []PairDataQuality `json:"pairs"`
+	Duplicates        int               `json:"duplicates"`
+	Quarantined       int64             `json:"quarantined"`
+	ParseFailures     int64             `json:"parse_failures"`
+	QuarantineReasons map[string]int64  `json:"quarantine_reasons"`
+}
+
+// dataQualityScope é o período, os pares e o intervalo avaliados pelo relatório
+type dataQualityScope struct {
+	from, to time.Time
+	pair     string // vazio: todos os pares habilitados
+	rows     []PairDB
+	interval time.Duration // zero: o intervalo de consulta de cada par
+}
+
+// intervalOf é o intervalo esperado entre as cotações do par
+func (s dataQualityScope) intervalOf(row PairDB) time.Duration {
+	if s.interval > 0 {
+		return s.interval
+	}
+	if row.PollInterval > 0 {
+		return time.Duration(row.PollInterval)
+	}
+	return cfg.PollInterval
+}
+
+// parseDataQualityScope lê ?from=, ?to= (padrão: últimas 24h), ?pair= e ?interval=,
+// respondendo 400 ou 404 quando inválidos
+func parseDataQualityScope(w http.ResponseWriter, r *http.Request) (dataQualityScope, bool) {
+	q := r.URL.Query()
+	scope := dataQualityScope{to: time.Now().UTC()}
+	if v := q.Get("to"); v != "" {
+		to, err := parseTimeParam(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "to inválido")
+			return scope, false
+		}
+		scope.to = to.UTC()
+	}
+	scope.from = scope.to.Add(-defaultDataQualityPeriod)
+	if v := q.Get("from"); v != "" {
+		from, err := parseTimeParam(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "from inválido")
+			return scope, false
+		}
+		scope.from = from.UTC()
+	}
+	if !scope.from.Before(scope.to) {
+		writeError(w, http.StatusBadRequest, "from deve ser anterior a to")
+		return scope, false
+	}
+
+	if v := q.Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Second {
+			writeError(w, http.StatusBadRequest, "interval deve ser uma duração de pelo menos 1s")
+			return scope, false
+		}
+		scope.interval = d
+	}
+
+	scope.rows = pairs.list(true)
+	scope.pair = strings.ToUpper(q.Get("pair"))
+	if scope.pair != "" {
+		row, ok := pairs.get(scope.pair)
+		if !ok {
+			writeError(w, http.StatusNotFound, "par não encontrado")
+			return scope, false
+		}
+		scope.rows = []PairDB{row}
+	}
+	return scope, true
+}
+
+// DataQualityHandler resume a qualidade da série em ?from= e ?to= (padrão: últimas 24h) por
+// par habilitado ou só em ?pair=: trechos sem cotações maiores que o intervalo de consulta
+// do par (ou ?interval=), cotações repetidas e cotações em quarentena
+func DataQualityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	scope, ok := parseDataQualityScope(w, r)
+	if !ok {
+		return
+	}
+	report := DataQualityReport{From: scope.from, To: scope.to, QuarantineReasons: map[string]int64{}}
+
+	quarantined, err := quarantineSummary(r.Context(), scope.pair, report.From, report.To)
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar quarentena: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	report.Pairs = make([]PairDataQuality, 0, len(scope.rows))
+	for _, row := range scope.rows {
+		quality, err := analyzeSeries(r.Context(), row, report.From, report.To, scope.intervalOf(row))
+		if err != nil {
+			logf(r.Context(), "Erro ao analisar a série de %s: %v", row.Symbol, err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		for _, s := range quarantined {
+			if s.Pair != row.Symbol {
+				continue
+			}
+			quality.Quarantined += s.Count
+			if s.Kind == quarantineParse {
+				quality.ParseFailures += s.Count
+			}
+		}
+		report.Pairs = append(report.Pairs, quality)
+		report.Duplicates += quality.Duplicates
+	}
+	for _, s := range quarantined {
+		report.Quarantined += s.Count
+		if s.Kind == quarantineParse {
+			report.ParseFailures += s.Count
+		}
+		report.QuarantineReasons[s.Reason] += s.Count
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// analyzeSeries percorre as cotações do par em ordem cronológica, contando as repetidas
+// (mesmo timestamp) e os trechos maiores que o dobro do intervalo, inclusive no início e
+// no fim do período. O início não é anterior ao cadastro do par
+func analyzeSeries(ctx context.Context, pair PairDB, from, to time.Time, interval time.Duration) (PairDataQuality, error) {
+	quality := PairDataQuality{Pair: pair.Symbol, Interval: Duration(interval), Gaps: []DataGap{}}
+	rows, err := rateRepo.Range(ctx, pair.Symbol, from, to, maxDataQualityRows)
+	if err != nil {
+		return quality, err
+	}
+	quality.Rows = len(rows)
+	quality.Truncated = len(rows) == maxDataQualityRows
+
+	if pair.CreatedAt.After(from) {
+		from = pair.CreatedAt.UTC().Truncate(time.Second)
+	}
+	if now := time.Now().UTC(); to.After(now) {
+		to = now
+	}
+
+	addGap := func(start, end time.Time) {
+		if interval <= 0 || end.Sub(start) <= 2*interval {
+			return
+		}
+		missing := int64(end.Sub(start)/interval) - 1
+		quality.GapCount++
+		quality.MissingIntervals += missing
+		if len(quality.Gaps) < maxReportedGaps {
+			quality.Gaps = append(quality.Gaps, DataGap{From: start, To: end, Missing: missing})
+		}
+	}
+
+	prev := from
+	for i, row := range rows {
+		at := time.Unix(row.Timestamp, 0).UTC()
+		if i > 0 && row.Timestamp == rows[i-1].Timestamp {
+			quality.Duplicates++
+			continue
+		}
+		addGap(prev, at)
+		prev = at
+	}
+	if !quality.Truncated {
+		addGap(prev, to)
+	}
+	return quality, nil
+}
+
+type quarantineCount struct {
+	Pair   string
+	Kind   string
+	Reason string
+	Count  int64
+}
+
+// quarantineSummary conta as cotações em quarentena no período por par, tipo e motivo; com
+// pair vazio considera todos os pares, inclusive os já desabilitados
+func quarantineSummary(ctx context.Context, pair string, from, to time.Time) ([]quarantineCount, error) {
+	var counts []quarantineCount
+	query := db.WithContext(ctx).Model(&QuarantinedRateDB{}).
+		Select("pair, kind, reason, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to)
+	if pair != "" {
+		query = query.Where("pair = ?", pair)
+	}
+	err := query.Group("pair, kind, reason").Scan(&counts).Error
+	return counts, err
+}
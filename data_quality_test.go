@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInspectQuote(t *testing.T) {
+	tests := []struct {
+		name       string
+		bid, ask   string
+		timestamp  string
+		wantKind   string
+		wantReason string
+	}{
+		{"válida", "5.80", "5.81", "1700000000", "", ""},
+		{"bid igual ao ask", "5.80", "5.80", "1700000000", "", ""},
+		{"bid não numérico", "abc", "5.81", "1700000000", quarantineParse, "bid não numérico"},
+		{"ask não numérico", "5.80", "", "1700000000", quarantineParse, "ask não numérico"},
+		{"timestamp não numérico", "5.80", "5.81", "ontem", quarantineParse, "timestamp não numérico"},
+		{"bid zero", "0", "5.81", "1700000000", quarantineInvalid, "bid e ask devem ser positivos"},
+		{"ask negativo", "5.80", "-1", "1700000000", quarantineInvalid, "bid e ask devem ser positivos"},
+		{"ask menor que bid", "5.80", "5.70", "1700000000", quarantineInvalid, "ask menor que bid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, reason := inspectQuote(&Quote{Bid: tt.bid, Ask: tt.ask, Timestamp: tt.timestamp})
+			if kind != tt.wantKind || reason != tt.wantReason {
+				t.Errorf("inspectQuote = (%q, %q), esperado (%q, %q)", kind, reason, tt.wantKind, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSeries(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	at := func(minutes ...int) []int64 {
+		var timestamps []int64
+		for _, m := range minutes {
+			timestamps = append(timestamps, from.Add(time.Duration(m)*time.Minute).Unix())
+		}
+		return timestamps
+	}
+
+	tests := []struct {
+		name        string
+		timestamps  []int64
+		interval    time.Duration
+		created     time.Time
+		wantGaps    int
+		wantMissing int64
+	}{
+		{"série completa", at(0, 10, 20, 30, 40, 50), 10 * time.Minute, time.Time{}, 0, 0},
+		{"atraso menor que o dobro do intervalo", at(0, 15, 30, 45, 55), 10 * time.Minute, time.Time{}, 0, 0},
+		{"gap no meio", at(0, 10, 40, 50), 10 * time.Minute, time.Time{}, 1, 2},
+		{"gaps no início e no fim", at(30), 10 * time.Minute, time.Time{}, 2, 4},
+		{"sem cotações", nil, 10 * time.Minute, time.Time{}, 1, 5},
+		{"início limitado ao cadastro do par", at(40, 50), 10 * time.Minute, from.Add(35 * time.Minute), 0, 0},
+		{"sem intervalo não avalia gaps", at(0, 50), 0, time.Time{}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			for _, ts := range tt.timestamps {
+				row := testRate("USD-BRL", ts, "5.1")
+				if err := rateRepo.Save(context.Background(), &row); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pair := PairDB{Symbol: "USD-BRL", CreatedAt: tt.created}
+			quality, err := analyzeSeries(context.Background(), pair, from, to, tt.interval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quality.Rows != len(tt.timestamps) {
+				t.Errorf("rows = %d, esperado %d", quality.Rows, len(tt.timestamps))
+			}
+			if quality.GapCount != tt.wantGaps || quality.MissingIntervals != tt.wantMissing {
+				t.Errorf("gaps = %d (%d intervalos), esperado %d (%d intervalos): %+v",
+					quality.GapCount, quality.MissingIntervals, tt.wantGaps, tt.wantMissing, quality.Gaps)
+			}
+		})
+	}
+}
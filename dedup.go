@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+// errDuplicateRate indica que o par já tem uma cotação gravada no mesmo timestamp; a mesma
+// cotação do provedor obtida de novo não é gravada outra vez
+var errDuplicateRate = errors.New("cotação já gravada para o par e timestamp")
+
+var duplicateRates = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "db_duplicate_rates_total",
+	Help: "Cotações descartadas por já existirem no banco (mesmo par e timestamp).",
+})
+
+type DedupResult struct {
+	Removed int64     `json:"removed"`
+	From    time.Time `json:"from"` // intervalo das cotações removidas
+	To      time.Time `json:"to"`
+}
+
+// deduplicator é implementado pelos backends que podem conter cotações repetidas gravadas
+// antes da restrição de unicidade; mantém a primeira gravação de cada par e timestamp
+type deduplicator interface {
+	Deduplicate(ctx context.Context) (DedupResult, error)
+}
+
+func (r *gormRateRepository) Deduplicate(ctx context.Context) (DedupResult, error) {
+	var result DedupResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		duplicates := tx.Model(&USDToBRLRateDB{}).
+			Where("id NOT IN (?)", tx.Model(&USDToBRLRateDB{}).Select("MIN(id)").Group("pair, timestamp"))
+
+		var bounds struct{ From, To int64 }
+		if err := duplicates.Session(&gorm.Session{}).
+			Select("COALESCE(MIN(timestamp), 0) AS `from`, COALESCE(MAX(timestamp), 0) AS `to`").
+			Scan(&bounds).Error; err != nil {
+			return err
+		}
+
+		deleted := duplicates.Delete(&USDToBRLRateDB{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		result.Removed = deleted.RowsAffected
+		if result.Removed == 0 {
+			return nil
+		}
+
+		// Os agregados foram calculados com as cotações repetidas
+		result.From, result.To = time.Unix(bounds.From, 0).UTC(), time.Unix(bounds.To, 0).UTC()
+		if _, err := rollup(tx, result.From, result.To.Add(time.Second), hourlyRollup, dailyRollup); err != nil {
+			return fmt.Errorf("erro ao consolidar agregados: %w", err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// DedupHandler remove as cotações repetidas (POST), mantendo a primeira de cada par e timestamp
+func DedupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	repo, ok := rateRepo.(deduplicator)
+	if !ok {
+		// O buffer em memória descarta as repetidas desde a gravação
+		writeJSON(w, http.StatusOK, DedupResult{})
+		return
+	}
+
+	result, err := repo.Deduplicate(r.Context())
+	if err != nil {
+		logf(r.Context(), "Erro ao remover cotações repetidas: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	if result.Removed > 0 {
+		historyResults.invalidateRange(result.From.Truncate(24*time.Hour), result.To.Add(time.Second))
+	}
+	recordAudit(r.Context(), newAuditEntry(r.Context(), auditDedup, "", result.Removed, ""))
+
+	logf(r.Context(), "Deduplicação: %d cotações repetidas removidas", result.Removed)
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const derivedProvider = "derived"
+
+var (
+	derivedNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,20}$`)
+	exprRefPattern     = regexp.MustCompile(`^([A-Za-z0-9]{2,10}-[A-Za-z0-9]{2,10})\.([A-Za-z]+)`)
+	exprNumberPattern  = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?`)
+)
+
+// quoteFields são os campos da cotação que as expressões podem usar
+var quoteFields = map[string]func(q *Quote) string{
+	"bid":  func(q *Quote) string { return q.Bid },
+	"ask":  func(q *Quote) string { return q.Ask },
+	"high": func(q *Quote) string { return q.High },
+	"low":  func(q *Quote) string { return q.Low },
+}
+
+// derivedPair é uma série calculada por uma expressão sobre cotações de pares nativos, ex.:
+// "TOURISM_RATE=USD-BRL.ask*1.045". É recalculada a cada nova cotação dos pares usados e
+// gravada como um par comum, com o valor em bid e ask; resultados não positivos vão para a
+// quarentena, como qualquer cotação inválida
+type derivedPair struct {
+	symbol string
+	expr   string
+	root   *exprNode
+	refs   []string // pares usados na expressão
+}
+
+var derivedPairs = map[string]derivedPair{}
+
+// parseDerivedPairs interpreta DERIVED_SERIES no formato
+// "TOURISM_RATE=USD-BRL.ask*1.045;SPREAD=USD-BRL.ask-USD-BRL.bid"
+func parseDerivedPairs(spec string) (map[string]derivedPair, error) {
+	result := make(map[string]derivedPair)
+	for _, def := range strings.Split(spec, ";") {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+
+		name, expr, ok := strings.Cut(def, "=")
+		symbol := strings.ToUpper(strings.TrimSpace(name))
+		if !ok || !derivedNamePattern.MatchString(symbol) {
+			return nil, fmt.Errorf("série derivada inválida %q: use NOME=expressão, com NOME de letras, dígitos e _", def)
+		}
+		if _, composite := composites[symbol]; composite {
+			return nil, fmt.Errorf("série derivada %s já definida em COMPOSITE_PAIRS", symbol)
+		}
+
+		expr = strings.TrimSpace(expr)
+		root, err := parseExpr(expr)
+		if err != nil {
+			return nil, fmt.Errorf("expressão inválida em %s: %w", symbol, err)
+		}
+		d := derivedPair{symbol: symbol, expr: expr, root: root}
+		root.walk(func(n *exprNode) {
+			if n.pair != "" && !slices.Contains(d.refs, n.pair) {
+				d.refs = append(d.refs, n.pair)
+			}
+		})
+		if len(d.refs) == 0 {
+			return nil, fmt.Errorf("a expressão de %s não usa nenhum par", symbol)
+		}
+		result[symbol] = d
+	}
+	return result, nil
+}
+
+// isVirtualPair indica os pares calculados pelo servidor (compostos e séries derivadas), que
+// não têm provedor nem histórico próprios
+func isVirtualPair(symbol string) bool {
+	_, composite := composites[symbol]
+	_, derived := derivedPairs[symbol]
+	return composite || derived
+}
+
+// compute avalia a expressão com as cotações dos pares usados; a cotação resultante tem o
+// horário da mais recente e a moeda do primeiro par
+func (d derivedPair) compute(quotes map[string]*Quote) (*Quote, error) {
+	value, err := d.root.eval(quotes)
+	if err != nil {
+		return nil, err
+	}
+
+	var timestamp int64
+	var latest *Quote
+	for _, ref := range d.refs {
+		if ts := quoteTimestamp(quotes[ref]); latest == nil || ts > timestamp {
+			timestamp, latest = ts, quotes[ref]
+		}
+	}
+
+	first := quotes[d.refs[0]]
+	v := value.StringFixed(moneyScale)
+	return &Quote{
+		Code:       first.Code,
+		Codein:     first.Codein,
+		Name:       d.symbol + " (derivada)",
+		High:       v,
+		Low:        v,
+		Bid:        v,
+		Ask:        v,
+		Timestamp:  strconv.FormatInt(timestamp, 10),
+		CreateDate: latest.CreateDate,
+		Provider:   derivedProvider,
+	}, nil
+}
+
+// fetch calcula a série na hora, com as cotações dos pares usados obtidas como em /cotacao
+func (d derivedPair) fetch(ctx context.Context) (*Quote, error) {
+	quotes := make(map[string]*Quote, len(d.refs))
+	for _, ref := range d.refs {
+		quote, err := cachedOrFresh(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("série derivada %s: %s: %w", d.symbol, ref, err)
+		}
+		quotes[ref] = quote
+	}
+	return d.compute(quotes)
+}
+
+// evaluateDerived recalcula as séries que usam o par com a nova cotação, completando os
+// demais pares com a última cotação em cache, e grava o resultado como uma cotação comum
+func evaluateDerived(ctx context.Context, pair string, quote *Quote) {
+	for _, d := range derivedPairs {
+		if !slices.Contains(d.refs, pair) || !pairs.isEnabled(d.symbol) {
+			continue
+		}
+
+		quotes := map[string]*Quote{pair: quote}
+		for _, ref := range d.refs {
+			if ref == pair {
+				continue
+			}
+			entry, ok := cache.get(ref)
+			if !ok {
+				logf(ctx, "Série derivada %s: sem cotação de %s em cache, cálculo adiado", d.symbol, ref)
+				quotes = nil
+				break
+			}
+			quotes[ref] = entry.quote
+		}
+		if quotes == nil {
+			continue
+		}
+
+		derived, err := d.compute(quotes)
+		if err != nil {
+			logf(ctx, "Erro ao calcular a série derivada %s: %v", d.symbol, err)
+			continue
+		}
+		persist(withRateSource(ctx, sourceDerived), d.symbol, derived)
+		cache.set(d.symbol, derived)
+	}
+}
+
+// registerDerivedPairs cadastra e habilita as séries derivadas, como os pares compostos
+func registerDerivedPairs() error {
+	for symbol, d := range derivedPairs {
+		for _, ref := range d.refs {
+			if !pairs.isEnabled(ref) {
+				log.Printf("Aviso: a série derivada %s usa %s, que não está habilitado; ela só será calculada sob demanda", symbol, ref)
+			}
+		}
+		if _, exists := pairs.get(symbol); exists {
+			continue
+		}
+		if _, err := pairs.save(PairDB{Symbol: symbol, Enabled: true}); err != nil {
+			return err
+		}
+		log.Printf("Série derivada %s = %s cadastrada", symbol, d.expr)
+	}
+	return nil
+}
+
+// exprNode é um nó da expressão: operação binária (op), número ou referência PAR.campo
+type exprNode struct {
+	op          byte
+	left, right *exprNode
+	value       decimal.Decimal
+	pair, field string
+}
+
+func (n *exprNode) walk(fn func(n *exprNode)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	n.left.walk(fn)
+	n.right.walk(fn)
+}
+
+func (n *exprNode) eval(quotes map[string]*Quote) (decimal.Decimal, error) {
+	if n.pair != "" {
+		quote, ok := quotes[n.pair]
+		if !ok {
+			return decimal.Zero, fmt.Errorf("sem cotação de %s", n.pair)
+		}
+		v, err := decimal.NewFromString(quoteFields[n.field](quote))
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("%s.%s não numérico", n.pair, n.field)
+		}
+		return v, nil
+	}
+	if n.op == 0 {
+		return n.value, nil
+	}
+
+	left, err := n.left.eval(quotes)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	right, err := n.right.eval(quotes)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	switch n.op {
+	case '+':
+		return left.Add(right), nil
+	case '-':
+		return left.Sub(right), nil
+	case '*':
+		return left.Mul(right), nil
+	default:
+		if right.IsZero() {
+			return decimal.Zero, fmt.Errorf("divisão por zero")
+		}
+		return left.Div(right), nil
+	}
+}
+
+// exprParser lê expressões com + - * /, parênteses, sinal negativo, números e referências
+// PAR.campo (bid, ask, high ou low), com a precedência usual
+type exprParser struct {
+	src string
+	pos int
+}
+
+func parseExpr(src string) (*exprNode, error) {
+	p := &exprParser{src: src}
+	node, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.src) {
+		return nil, fmt.Errorf("caractere inesperado %q na posição %d", p.src[p.pos], p.pos+1)
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consome o próximo caractere se ele estiver em ops
+func (p *exprParser) accept(ops string) (byte, bool) {
+	p.skipSpaces()
+	if p.pos < len(p.src) && strings.IndexByte(ops, p.src[p.pos]) >= 0 {
+		p.pos++
+		return p.src[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *exprParser) sum() (*exprNode, error) {
+	return p.binary("+-", p.term)
+}
+
+func (p *exprParser) term() (*exprNode, error) {
+	return p.binary("*/", p.unary)
+}
+
+func (p *exprParser) binary(ops string, operand func() (*exprNode, error)) (*exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) unary() (*exprNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{op: '-', left: &exprNode{}, right: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (*exprNode, error) {
+	if _, ok := p.accept("("); ok {
+		node, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("parêntese não fechado na posição %d", p.pos+1)
+		}
+		return node, nil
+	}
+
+	p.skipSpaces()
+	rest := p.src[p.pos:]
+	if m := exprRefPattern.FindStringSubmatch(rest); m != nil {
+		field := strings.ToLower(m[2])
+		if _, ok := quoteFields[field]; !ok {
+			return nil, fmt.Errorf("campo desconhecido %q em %s: use bid, ask, high ou low", m[2], m[0])
+		}
+		p.pos += len(m[0])
+		return &exprNode{pair: strings.ToUpper(m[1]), field: field}, nil
+	}
+	if m := exprNumberPattern.FindString(rest); m != "" {
+		p.pos += len(m)
+		return &exprNode{value: decimal.RequireFromString(m)}, nil
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("expressão incompleta")
+	}
+	return nil, fmt.Errorf("esperado número, PAR.campo ou ( na posição %d", p.pos+1)
+}
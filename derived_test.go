@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDerivedPairs(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		wantRefs map[string][]string
+		wantErr  string
+	}{
+		{
+			name:     "várias séries",
+			spec:     "tourism_rate = USD-BRL.ask * 1.045; CROSS=EUR-BRL.bid/usd-brl.bid",
+			wantRefs: map[string][]string{"TOURISM_RATE": {"USD-BRL"}, "CROSS": {"EUR-BRL", "USD-BRL"}},
+		},
+		{name: "vazio", spec: " ; ", wantRefs: map[string][]string{}},
+		{name: "nome com hífen", spec: "TOUR-RATE=USD-BRL.ask", wantErr: `série derivada inválida "TOUR-RATE=USD-BRL.ask": use NOME=expressão, com NOME de letras, dígitos e _`},
+		{name: "sem pares", spec: "FIXO=1.5*2", wantErr: "a expressão de FIXO não usa nenhum par"},
+		{name: "campo desconhecido", spec: "MID=USD-BRL.mid", wantErr: `expressão inválida em MID: campo desconhecido "mid" em USD-BRL.mid: use bid, ask, high ou low`},
+		{name: "parêntese aberto", spec: "TT=(USD-BRL.bid+1", wantErr: "expressão inválida em TT: parêntese não fechado na posição 15"},
+		{name: "operador repetido", spec: "TT=USD-BRL.bid**2", wantErr: "expressão inválida em TT: esperado número, PAR.campo ou ( na posição 13"},
+		{name: "expressão incompleta", spec: "TT=USD-BRL.bid+", wantErr: "expressão inválida em TT: expressão incompleta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDerivedPairs(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.wantRefs) {
+				t.Fatalf("séries = %v, esperado %v", got, tt.wantRefs)
+			}
+			for symbol, refs := range tt.wantRefs {
+				if !slices.Equal(got[symbol].refs, refs) {
+					t.Errorf("%s: pares = %v, esperado %v", symbol, got[symbol].refs, refs)
+				}
+			}
+		})
+	}
+}
+
+func TestDerivedPairCompute(t *testing.T) {
+	usd := &Quote{Code: "USD", Codein: "BRL", Bid: "5.00", Ask: "5.10", High: "5.20", Low: "4.90", Timestamp: "1700000100"}
+	eur := &Quote{Code: "EUR", Codein: "BRL", Bid: "5.50", Ask: "5.60", Timestamp: "1700000200"}
+
+	tests := []struct {
+		expr      string
+		want      string
+		wantErr   string
+		wantStamp string
+	}{
+		{expr: "USD-BRL.ask * 1.045", want: "5.3295", wantStamp: "1700000100"},
+		{expr: "USD-BRL.ask - USD-BRL.bid", want: "0.1000", wantStamp: "1700000100"},
+		{expr: "1 + 2 * USD-BRL.bid", want: "11.0000", wantStamp: "1700000100"},
+		{expr: "(1 + 2) * USD-BRL.bid", want: "15.0000", wantStamp: "1700000100"},
+		{expr: "-USD-BRL.low + 10", want: "5.1000", wantStamp: "1700000100"},
+		{expr: "EUR-BRL.bid / USD-BRL.bid", want: "1.1000", wantStamp: "1700000200"},
+		{expr: "USD-BRL.high / (USD-BRL.bid - 5)", wantErr: "divisão por zero"},
+		{expr: "EUR-BRL.high * 2", wantErr: "EUR-BRL.high não numérico"},
+		{expr: "JPY-BRL.bid * 2", wantErr: "sem cotação de JPY-BRL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			defs, err := parseDerivedPairs("TEST=" + tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			quote, err := defs["TEST"].compute(map[string]*Quote{"USD-BRL": usd, "EUR-BRL": eur})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Bid != tt.want || quote.Ask != tt.want {
+				t.Errorf("bid/ask = %s/%s, esperado %s", quote.Bid, quote.Ask, tt.want)
+			}
+			if quote.Timestamp != tt.wantStamp || quote.Provider != derivedProvider {
+				t.Errorf("timestamp = %s (%s), esperado %s (%s)", quote.Timestamp, quote.Provider, tt.wantStamp, derivedProvider)
+			}
+		})
+	}
+}
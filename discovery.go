@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pares já vistos no catálogo do provedor, para notificar apenas as novidades
+type DiscoveredPairDB struct {
+	Symbol       string    `gorm:"primaryKey;type:varchar(21)" json:"symbol"`
+	Name         string    `gorm:"type:varchar(255)" json:"name"`
+	DiscoveredAt time.Time `gorm:"not null" json:"discovered_at"`
+}
+
+// startDiscovery agenda a consulta periódica do catálogo de pares do provedor
+func startDiscovery(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	log.Printf("Descoberta de pares iniciada com intervalo de %s", interval)
+	runPeriodically(ctx, interval, func(ctx context.Context) {
+		if err := discoverPairs(ctx); err != nil {
+			log.Printf("Erro na descoberta de pares: %v", err)
+		}
+	})
+}
+
+// fetchAvailablePairs consulta o endpoint de pares disponíveis do AwesomeAPI
+func fetchAvailablePairs(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.AwesomeAPIBaseURL+"/json/available", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quota.increment()
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream respondeu com status %d", resp.StatusCode)
+	}
+
+	var available map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&available); err != nil {
+		return nil, err
+	}
+	return available, nil
+}
+
+// discoverPairs registra os pares inéditos do catálogo e notifica os administradores
+// sobre os que ainda não são rastreados
+func discoverPairs(ctx context.Context) error {
+	if quota.exhausted() {
+		return errQuotaExhausted
+	}
+
+	available, err := fetchAvailablePairs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var known []string
+	if err := db.WithContext(ctx).Model(&DiscoveredPairDB{}).Pluck("symbol", &known).Error; err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(known))
+	for _, symbol := range known {
+		seen[symbol] = true
+	}
+
+	var discovered []DiscoveredPairDB
+	var untracked []string
+	for symbol, name := range available {
+		if seen[symbol] {
+			continue
+		}
+		discovered = append(discovered, DiscoveredPairDB{Symbol: symbol, Name: name, DiscoveredAt: time.Now()})
+		if _, tracked := pairs.get(symbol); !tracked {
+			untracked = append(untracked, symbol)
+		}
+	}
+	if len(discovered) == 0 {
+		return nil
+	}
+
+	if err := db.WithContext(ctx).CreateInBatches(discovered, 100).Error; err != nil {
+		return err
+	}
+
+	if len(untracked) > 0 {
+		sort.Strings(untracked)
+		notifyAdmins("pairs_discovered", fmt.Sprintf("%d novos pares disponíveis no provedor: %s",
+			len(untracked), strings.Join(untracked, ", ")))
+	}
+	return nil
+}
+
+// AvailablePairsHandler lista os pares do catálogo do provedor ainda não rastreados
+func AvailablePairsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var discovered []DiscoveredPairDB
+	if err := db.WithContext(r.Context()).Order("symbol").Find(&discovered).Error; err != nil {
+		logf(r.Context(), "Erro ao consultar pares descobertos: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	untracked := make([]DiscoveredPairDB, 0, len(discovered))
+	for _, pair := range discovered {
+		if _, tracked := pairs.get(pair.Symbol); !tracked {
+			untracked = append(untracked, pair)
+		}
+	}
+	writeJSON(w, http.StatusOK, untracked)
+}
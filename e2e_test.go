@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+	"github.com/guilhermeayusso/goexpert/desafio/1/internal/testutil"
+)
+
+// e2eServer é o servidor real, montado por newApp e atendendo em uma porta aleatória
+type e2eServer struct {
+	t    *testing.T
+	url  string
+	http *http.Client
+}
+
+// startE2EServer sobe o servidor completo contra um AwesomeAPI falso. newHTTPServer registra
+// os handlers no http.DefaultServeMux, trocado por um novo a cada servidor
+func startE2EServer(t *testing.T) *e2eServer {
+	t.Helper()
+
+	savedMux := http.DefaultServeMux
+	http.DefaultServeMux = http.NewServeMux()
+	t.Cleanup(func() { http.DefaultServeMux = savedMux })
+
+	api := testutil.NewFakeAPI(t)
+	withConfig(t, func(c *config.Config) {
+		c.Port = "0"
+		dir := t.TempDir()
+		c.DBPath = filepath.Join(dir, "e2e.db")
+		c.JournalPath = filepath.Join(dir, "pending-rates.jsonl")
+		c.AwesomeAPIBaseURL = api.URL
+		c.Providers = []string{awesomeAPIProvider}
+		c.AdminEmails = []string{"admin@e2e.test"}
+		c.PersistMode = "sync"
+		c.QuoteCacheTTL = 0
+		c.QuoteStaleWhileRevalidate = 0
+	})
+
+	var srv *httpServer
+	app := newApp(&shutdownReport{}, &srv)
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := app.Stop(context.Background()); err != nil {
+			t.Errorf("erro ao parar o servidor: %v", err)
+		}
+	})
+
+	return &e2eServer{t: t, url: "http://" + srv.ln.Addr().String(), http: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// do faz a requisição e decodifica o corpo JSON em out, quando informado
+func (s *e2eServer) do(method, path, token string, body, out any) int {
+	s.t.Helper()
+
+	var reader bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reader).Encode(body)
+	}
+	req, err := http.NewRequest(method, s.url+path, &reader)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			s.t.Fatalf("%s %s: corpo inválido: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// login cadastra o usuário e retorna o token
+func (s *e2eServer) login(email string) string {
+	s.t.Helper()
+	creds := Credentials{Email: email, Password: "senha-e2e-123"}
+	if status := s.do(http.MethodPost, "/auth/register", "", creds, nil); status != http.StatusCreated {
+		s.t.Fatalf("cadastro: status %d", status)
+	}
+	var token TokenResponse
+	if status := s.do(http.MethodPost, "/auth/login", "", creds, &token); status != http.StatusOK {
+		s.t.Fatalf("login: status %d", status)
+	}
+	return token.Token
+}
+
+func TestEndToEnd(t *testing.T) {
+	s := startE2EServer(t)
+	token := s.login("user@e2e.test")
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("cotacao", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			setup      func(api *testutil.FakeAPI)
+			wantStatus int
+			wantBid    string
+			wantError  string
+		}{
+			{
+				name:       "timeout do upstream",
+				setup:      func(api *testutil.FakeAPI) { api.SetLatency(time.Second) },
+				wantStatus: http.StatusInternalServerError,
+				wantError:  "erro ao obter taxa de câmbio",
+			},
+			{
+				name:       "upstream com erro",
+				setup:      func(api *testutil.FakeAPI) { api.FailNext(1, http.StatusServiceUnavailable) },
+				wantStatus: http.StatusInternalServerError,
+				wantError:  "erro ao obter taxa de câmbio",
+			},
+			{
+				name:       "resposta malformada",
+				setup:      func(api *testutil.FakeAPI) { api.SetPayload("/last/USD-BRL", http.StatusOK, `{"USDBRL":`) },
+				wantStatus: http.StatusInternalServerError,
+				wantError:  "erro ao obter taxa de câmbio",
+			},
+			{
+				name:       "latência dentro do prazo",
+				setup:      func(api *testutil.FakeAPI) { api.SetLatency(20 * time.Millisecond) },
+				wantStatus: http.StatusOK,
+				wantBid:    "5.4321",
+			},
+			{
+				name:       "sucesso",
+				wantStatus: http.StatusOK,
+				wantBid:    "5.4321",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				api := testutil.NewFakeAPI(t)
+				api.SetQuote("USD-BRL", testutil.Quote("USD-BRL", "5.4321", now))
+				withConfig(t, func(c *config.Config) { c.AwesomeAPIBaseURL = api.URL })
+				if tt.setup != nil {
+					tt.setup(api)
+				}
+
+				var body map[string]any
+				status := s.do(http.MethodGet, "/cotacao", "", nil, &body)
+				if status != tt.wantStatus {
+					t.Fatalf("status = %d, esperado %d: %v", status, tt.wantStatus, body)
+				}
+				if tt.wantError != "" && body["error"] != tt.wantError {
+					t.Errorf("erro = %v, esperado %q", body["error"], tt.wantError)
+				}
+				if tt.wantBid != "" {
+					quote, _ := body["USDBRL"].(map[string]any)
+					if quote["bid"] != tt.wantBid {
+						t.Errorf("bid = %v, esperado %s", quote["bid"], tt.wantBid)
+					}
+				}
+				if api.Requests() == 0 {
+					t.Error("upstream não consultado")
+				}
+			})
+		}
+	})
+
+	t.Run("historico", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			path       string
+			token      string
+			wantStatus int
+			wantRows   int
+		}{
+			{"sem token", "/historico", "", http.StatusUnauthorized, -1},
+			{"últimas cotações", "/historico", token, http.StatusOK, 1},
+			{"intervalo", "/historico?resolution=raw&from=" + strconv.FormatInt(now.Add(-time.Hour).Unix(), 10) + "&to=" + strconv.FormatInt(now.Add(time.Minute).Unix(), 10), token, http.StatusOK, 1},
+			{"intervalo sem cotações", "/historico?resolution=raw&from=2020-01-01&to=2020-01-02", token, http.StatusOK, 0},
+			{"intervalo inválido", "/historico?from=ontem", token, http.StatusBadRequest, -1},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var body json.RawMessage
+				status := s.do(http.MethodGet, tt.path, tt.token, nil, &body)
+				if status != tt.wantStatus {
+					t.Fatalf("status = %d, esperado %d: %s", status, tt.wantStatus, body)
+				}
+				if tt.wantRows < 0 {
+					return
+				}
+
+				// As duas consultas bem-sucedidas gravaram a mesma cotação uma única vez
+				var rows []USDToBRLRateDB
+				var series HistoryResponse
+				if json.Unmarshal(body, &rows) != nil {
+					if err := json.Unmarshal(body, &series); err != nil {
+						t.Fatal(err)
+					}
+					rows = make([]USDToBRLRateDB, len(series.Points))
+				}
+				if len(rows) != tt.wantRows {
+					t.Errorf("cotações = %d, esperado %d: %s", len(rows), tt.wantRows, body)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Eventos lidos do banco por vez; cada lote é enviado ao cliente antes do próximo
+const eventExportBatchSize = 1000
+
+// EventRecord é uma linha do NDJSON de /events/export. Seq é o id do evento na outbox,
+// crescente na ordem de gravação, e serve de cursor para retomar a leitura com ?since=
+type EventRecord struct {
+	Seq         uint            `json:"seq"`
+	ID          string          `json:"id"`
+	Topic       string          `json:"topic"`
+	Pair        string          `json:"pair"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt *time.Time      `json:"published_at"` // entrega aos consumidores internos; null se pendente
+	Payload     json.RawMessage `json:"payload"`
+}
+
+func newEventRecord(e OutboxEventDB) EventRecord {
+	return EventRecord{
+		Seq: e.ID, ID: e.UID, Topic: e.Topic, Pair: e.Pair,
+		CreatedAt: e.CreatedAt.UTC(), PublishedAt: e.DispatchedAt, Payload: json.RawMessage(e.Payload),
+	}
+}
+
+// EventsExportHandler transmite o log de eventos da outbox em NDJSON, em ordem de seq, a
+// partir do evento seguinte a ?since= (padrão: desde o início), opcionalmente filtrado por
+// ?topic= e ?pair=. A leitura vai até o último evento existente no início da requisição;
+// sistemas externos reconstroem seu estado relendo do zero ou continuando do último seq
+// recebido. Eventos entregues são removidos após OUTBOX_RETENTION: X-Events-Oldest-Seq
+// indica o mais antigo ainda disponível, e um since anterior a ele significa lacuna
+func EventsExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	var since uint64
+	if v := q.Get("since"); v != "" {
+		var err error
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "since deve ser o seq de um evento (inteiro não negativo)")
+			return
+		}
+	}
+
+	base := db.WithContext(r.Context()).Model(&OutboxEventDB{})
+	if topic := q.Get("topic"); topic != "" {
+		base = base.Where("topic = ?", topic)
+	}
+	if pair := q.Get("pair"); pair != "" {
+		base = base.Where("pair = ?", strings.ToUpper(pair))
+	}
+
+	var bounds struct{ Oldest, Latest uint }
+	if err := db.WithContext(r.Context()).Model(&OutboxEventDB{}).
+		Select("COALESCE(MIN(id), 0) AS oldest, COALESCE(MAX(id), 0) AS latest").Scan(&bounds).Error; err != nil {
+		logf(r.Context(), "Erro ao consultar o log de eventos: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Events-Oldest-Seq", strconv.FormatUint(uint64(bounds.Oldest), 10))
+	w.Header().Set("X-Events-Latest-Seq", strconv.FormatUint(uint64(bounds.Latest), 10))
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	rc := http.NewResponseController(w)
+	after := uint(since)
+	for after < bounds.Latest {
+		var events []OutboxEventDB
+		err := base.Session(&gorm.Session{}).
+			Where("id > ? AND id <= ?", after, bounds.Latest).
+			Order("id").Limit(eventExportBatchSize).Find(&events).Error
+		if err != nil {
+			// O status já foi enviado: o cliente percebe a falha pelo corpo incompleto
+			logf(r.Context(), "Erro ao ler o log de eventos após %d: %v", after, err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		for _, e := range events {
+			if err := enc.Encode(newEventRecord(e)); err != nil {
+				return
+			}
+			after = e.ID
+		}
+		rc.Flush()
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEventsExportHandler(t *testing.T) {
+	seed := func(t *testing.T) {
+		t.Helper()
+		now := time.Now()
+		for i, pair := range []string{"USD-BRL", "EUR-BRL", "USD-BRL", "USD-BRL"} {
+			quote := testQuote("5.1", now.Add(time.Duration(i)*time.Second))
+			event, err := newRateEvent(pair, &quote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i == 3 {
+				event.Topic = "outro.topico"
+			}
+			if i < 2 {
+				event.DispatchedAt = &now
+			}
+			if err := db.Create(&event).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantSeqs   []uint
+	}{
+		{name: "log completo", wantStatus: http.StatusOK, wantSeqs: []uint{1, 2, 3, 4}},
+		{name: "a partir de um seq", query: "since=2", wantStatus: http.StatusOK, wantSeqs: []uint{3, 4}},
+		{name: "após o último", query: "since=4", wantStatus: http.StatusOK, wantSeqs: nil},
+		{name: "por par", query: "pair=usd-brl", wantStatus: http.StatusOK, wantSeqs: []uint{1, 3, 4}},
+		{name: "por tópico", query: "topic=rate.saved&since=1", wantStatus: http.StatusOK, wantSeqs: []uint{2, 3}},
+		{name: "since inválido", query: "since=-1", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			seed(t)
+
+			rec, ok := serve(t, EventsExportHandler, http.MethodGet, "/events/export?"+tt.query, "", tt.wantStatus)
+			if !ok {
+				return
+			}
+			if got := rec.Header().Get("X-Events-Latest-Seq"); got != "4" {
+				t.Errorf("X-Events-Latest-Seq = %s, esperado 4", got)
+			}
+
+			var seqs []uint
+			scanner := bufio.NewScanner(rec.Body)
+			for scanner.Scan() {
+				var record EventRecord
+				if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+					t.Fatalf("linha inválida %q: %v", scanner.Text(), err)
+				}
+				var quote Quote
+				if err := json.Unmarshal(record.Payload, &quote); err != nil || quote.Bid != "5.1" {
+					t.Errorf("payload do evento %d = %s", record.Seq, record.Payload)
+				}
+				if published := record.PublishedAt != nil; published != (record.Seq <= 2) {
+					t.Errorf("evento %d: published_at = %v", record.Seq, record.PublishedAt)
+				}
+				seqs = append(seqs, record.Seq)
+			}
+			if !slices.Equal(seqs, tt.wantSeqs) {
+				t.Errorf("seqs = %v, esperado %v", seqs, tt.wantSeqs)
+			}
+		})
+	}
+}
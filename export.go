@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cotações lidas do repositório por vez durante a geração do arquivo
+const exportPageSize = 5000
+
+var exportArtifacts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "export_artifacts_total",
+	Help: "Exportações de histórico por resultado: hit (arquivo reaproveitado) ou miss (gerado).",
+}, []string{"result"})
+
+var exportGroup singleflight.Group
+
+// Mesmos filtros e ordenações da listagem do histórico, sem paginação
+var exportListSpec = listSpec{
+	sortFields:     rateListSpec.sortFields,
+	defaultSort:    "timestamp:asc",
+	valueParam:     rateListSpec.valueParam,
+	defaultPerPage: exportPageSize,
+	maxPerPage:     exportPageSize,
+}
+
+// exportFormat descreve um formato de arquivo da exportação
+type exportFormat struct {
+	ext         string
+	contentType string
+	write       func(ctx context.Context, q listQuery, w io.Writer) error
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":     {ext: ".csv", contentType: "text/csv; charset=utf-8", write: writeCSV},
+	"parquet": {ext: ".parquet", contentType: "application/vnd.apache.parquet", write: writeParquet},
+}
+
+// ExportHandler exporta as cotações que atendem aos filtros de /historico (pair, since,
+// until, bid_min, bid_max e sort) em CSV ou, com ?format=parquet, em Parquet. O arquivo
+// gerado é guardado em EXPORT_DIR com o nome derivado do hash dos filtros e do estado do
+// intervalo (maior timestamp e total de cotações); uma exportação repetida sem cotações
+// novas no intervalo devolve o mesmo arquivo sem consultá-lo de novo
+func ExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "format deve ser csv ou parquet")
+		return
+	}
+
+	q, err := parseListQuery(r.URL.Query(), exportListSpec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, q, err := exportKey(r.Context(), q)
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar intervalo da exportação: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	path := filepath.Join(cfg.ExportDir, key+format.ext)
+	result := "hit"
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now) // marca o uso para a retenção
+	} else {
+		result = "miss"
+		_, err, _ := exportGroup.Do(key+format.ext, func() (any, error) {
+			return nil, writeExport(context.WithoutCancel(r.Context()), q, path, format)
+		})
+		if err != nil {
+			logf(r.Context(), "Erro ao gerar exportação %s: %v", key, err)
+			writeError(w, http.StatusInternalServerError, "erro ao gerar exportação")
+			return
+		}
+	}
+	exportArtifacts.WithLabelValues(result).Inc()
+
+	f, err := os.Open(path)
+	if err != nil {
+		logf(r.Context(), "Erro ao abrir exportação %s: %v", key, err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	filename := "cotacoes"
+	if q.Pair != "" {
+		filename += "-" + q.Pair
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"-"+key[:12]+format.ext))
+	w.Header().Set("ETag", strconv.Quote(key+format.ext))
+	w.Header().Set("X-Export-Cache", result)
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// exportKey calcula o hash que identifica o conteúdo da exportação. Sem until (ou com until
+// no futuro) o intervalo é fechado logo após a cotação mais recente, para que o arquivo
+// corresponda exatamente ao estado usado no hash; o total entra no hash para que cotações
+// importadas no meio do intervalo também gerem um novo arquivo
+func exportKey(ctx context.Context, q listQuery) (string, listQuery, error) {
+	latest := q
+	latest.Sort, latest.Desc, latest.Page, latest.PerPage = "timestamp", true, 1, 1
+	rows, total, err := rateRepo.List(ctx, latest)
+	if err != nil {
+		return "", q, err
+	}
+
+	var maxTimestamp int64
+	if len(rows) > 0 {
+		maxTimestamp = rows[0].Timestamp
+		if end := time.Unix(maxTimestamp+1, 0); q.Until.IsZero() || q.Until.After(end) {
+			q.Until = end
+		}
+	}
+
+	minValue, maxValue := "", ""
+	if q.MinValue != nil {
+		minValue = q.MinValue.String()
+	}
+	if q.MaxValue != nil {
+		maxValue = q.MaxValue.String()
+	}
+	canonical := strings.Join([]string{
+		q.Pair,
+		strconv.FormatInt(unixOrZero(q.Since), 10),
+		strconv.FormatInt(unixOrZero(q.Until), 10),
+		minValue, maxValue, q.Sort, q.direction(),
+		strconv.FormatInt(maxTimestamp, 10),
+		strconv.FormatInt(total, 10),
+	}, "|")
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:]), q, nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// writeExport grava o arquivo em um temporário e o renomeia para path ao final, para que
+// um arquivo incompleto nunca seja servido
+func writeExport(ctx context.Context, q listQuery, path string, format exportFormat) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	pruneExports()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := format.write(ctx, q, tmp); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// eachExportPage percorre as cotações da exportação em páginas de exportPageSize
+func eachExportPage(ctx context.Context, q listQuery, fn func(rows []USDToBRLRateDB) error) error {
+	q.PerPage = exportPageSize
+	for q.Page = 1; ; q.Page++ {
+		rows, _, err := rateRepo.List(ctx, q)
+		if err != nil {
+			return err
+		}
+		if err := fn(rows); err != nil {
+			return err
+		}
+		if len(rows) < q.PerPage {
+			return nil
+		}
+	}
+}
+
+func writeCSV(ctx context.Context, q listQuery, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "pair", "code", "bid", "ask", "uid"})
+	err := eachExportPage(ctx, q, func(rows []USDToBRLRateDB) error {
+		for _, row := range rows {
+			cw.Write([]string{
+				time.Unix(row.Timestamp, 0).UTC().Format(time.RFC3339),
+				row.Pair, row.Code, row.Bid.String(), row.Ask.String(), row.UID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// pruneExports remove os arquivos sem uso há mais de EXPORT_RETENTION
+func pruneExports() {
+	entries, err := os.ReadDir(cfg.ExportDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !isExportFile(entry.Name()) || time.Since(info.ModTime()) < cfg.ExportRetention {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cfg.ExportDir, entry.Name())); err == nil {
+			log.Printf("Exportação %s removida após %s sem uso", entry.Name(), cfg.ExportRetention)
+		}
+	}
+}
+
+func isExportFile(name string) bool {
+	for _, format := range exportFormats {
+		if strings.HasSuffix(name, format.ext) {
+			return true
+		}
+	}
+	return false
+}
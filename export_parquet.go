@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+// Cotações gravadas por row group; cada grupo é lido de uma vez pelo pandas e pelo Spark
+const parquetRowGroupSize = 100_000
+
+// parquetRate é a linha do arquivo Parquet. Os valores são DECIMAL(10,4), como no banco, e o
+// horário é um TIMESTAMP em UTC, lidos com os tipos certos sem conversão pelo pyarrow/Spark
+type parquetRate struct {
+	Timestamp time.Time `parquet:"timestamp,timestamp(millisecond:utc)"`
+	Pair      string    `parquet:"pair,dict"`
+	Code      string    `parquet:"code,dict"`
+	Bid       int64     `parquet:"bid,decimal(4:10)"`
+	Ask       int64     `parquet:"ask,decimal(4:10)"`
+	UID       string    `parquet:"uid"`
+}
+
+// writeParquet grava as cotações com compressão Snappy, a padrão do pandas e do Spark
+func writeParquet(ctx context.Context, q listQuery, w io.Writer) error {
+	pw := parquet.NewGenericWriter[parquetRate](w,
+		parquet.Compression(&snappy.Codec{}),
+		parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+	)
+
+	batch := make([]parquetRate, 0, exportPageSize)
+	err := eachExportPage(ctx, q, func(rows []USDToBRLRateDB) error {
+		batch = batch[:0]
+		for _, row := range rows {
+			batch = append(batch, parquetRate{
+				Timestamp: time.Unix(row.Timestamp, 0).UTC(),
+				Pair:      row.Pair,
+				Code:      row.Code,
+				// DECIMAL guarda o valor sem a vírgula: 5.1234 com escala 4 é 51234
+				Bid: row.Bid.Round(moneyScale).Shift(moneyScale).IntPart(),
+				Ask: row.Ask.Round(moneyScale).Shift(moneyScale).IntPart(),
+				UID: row.UID,
+			})
+		}
+		_, err := pw.Write(batch)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return pw.Close()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+	"github.com/parquet-go/parquet-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestExportFormats(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		query           string
+		wantStatus      int
+		wantContentType string
+		wantSuffix      string
+	}{
+		{name: "CSV por padrão", query: "pair=USD-BRL", wantStatus: http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8", wantSuffix: ".csv\""},
+		{name: "Parquet", query: "pair=USD-BRL&format=parquet", wantStatus: http.StatusOK,
+			wantContentType: "application/vnd.apache.parquet", wantSuffix: ".parquet\""},
+		{name: "formato desconhecido", query: "format=xlsx", wantStatus: http.StatusBadRequest},
+	}
+
+	newTestDB(t)
+	withConfig(t, func(c *config.Config) { c.ExportDir = t.TempDir() })
+	seedRates(t, rateRepo,
+		testRate("USD-BRL", base.Unix(), "5.1234"),
+		testRate("USD-BRL", base.Add(time.Hour).Unix(), "5.2"),
+		testRate("EUR-BRL", base.Unix(), "6.0"),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, ok := serve(t, ExportHandler, http.MethodGet, "/cotacao/export?"+tt.query, "", tt.wantStatus)
+			if !ok {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %s, esperado %s", got, tt.wantContentType)
+			}
+			if got := rec.Header().Get("Content-Disposition"); !strings.HasSuffix(got, tt.wantSuffix) {
+				t.Errorf("Content-Disposition = %s, esperado terminar em %s", got, tt.wantSuffix)
+			}
+
+			var bids []string
+			var times []time.Time
+			if strings.Contains(tt.query, "parquet") {
+				data := rec.Body.Bytes()
+				file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, field := range []struct{ name, logical string }{
+					{"timestamp", "TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)"},
+					{"bid", "DECIMAL(10,4)"},
+					{"pair", "STRING"},
+				} {
+					column, ok := file.Schema().Lookup(field.name)
+					if !ok {
+						t.Fatalf("coluna %s ausente", field.name)
+					}
+					if got := column.Node.Type().LogicalType().String(); got != field.logical {
+						t.Errorf("tipo de %s = %s, esperado %s", field.name, got, field.logical)
+					}
+				}
+
+				rows, err := parquet.Read[parquetRate](bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, row := range rows {
+					bids = append(bids, decimal.New(row.Bid, -moneyScale).String())
+					times = append(times, row.Timestamp.UTC())
+				}
+			} else {
+				records, err := csv.NewReader(rec.Body).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, record := range records[1:] {
+					at, _ := time.Parse(time.RFC3339, record[0])
+					bids, times = append(bids, record[3]), append(times, at)
+				}
+			}
+
+			if strings.Join(bids, ",") != "5.1234,5.2" {
+				t.Errorf("bids = %v, esperado [5.1234 5.2]", bids)
+			}
+			if len(times) != 2 || !times[0].Equal(base) || !times[1].Equal(base.Add(time.Hour)) {
+				t.Errorf("horários = %v", times)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Estados de um job de preenchimento de gaps
+const (
+	gapJobScheduled = "scheduled"
+	gapJobRunning   = "running"
+	gapJobDone      = "done"
+	gapJobFailed    = "failed"
+)
+
+const (
+	gapJobQueueSize = 100
+	maxGapJobs      = 50 // jobs mantidos para consulta em GET /admin/backfill-gaps
+)
+
+var gapBackfillRows = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gap_backfill_rows_total",
+	Help: "Cotações recebidas do histórico do provedor ao preencher gaps, por resultado (imported ou skipped).",
+}, []string{"result"})
+
+// GapBackfillJob preenche os gaps de um par detectados pelo relatório de qualidade,
+// consultando o histórico do provedor no período de cada gap
+type GapBackfillJob struct {
+	ID         string     `json:"id"`
+	Pair       string     `json:"pair"`
+	Status     string     `json:"status"`
+	Gaps       []DataGap  `json:"gaps"`
+	Filled     int        `json:"filled"` // gaps com ao menos uma cotação importada
+	Received   int        `json:"received"`
+	Imported   int        `json:"imported"`
+	Skipped    int        `json:"skipped"`
+	Error      string     `json:"error,omitempty"`
+	CreatedBy  string     `json:"created_by"` // quem agendou, registrado na auditoria da importação
+	RequestID  string     `json:"request_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type GapBackfillResponse struct {
+	Scheduled []GapBackfillJob `json:"scheduled"`
+	Pending   []string         `json:"pending,omitempty"` // pares com job ainda pendente (ou com a fila cheia)
+}
+
+// gapBackfiller executa os jobs em ordem, um por vez, para não consumir a cota do provedor
+// em rajadas. Os jobs ficam só em memória
+type gapBackfiller struct {
+	mu    sync.Mutex
+	jobs  []*GapBackfillJob // mais recentes no fim
+	queue chan *GapBackfillJob
+}
+
+var gapBackfills = &gapBackfiller{queue: make(chan *GapBackfillJob, gapJobQueueSize)}
+
+func (g *gapBackfiller) start(ctx context.Context) {
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job := <-g.queue:
+				g.run(ctx, job)
+			}
+		}
+	}()
+}
+
+// schedule enfileira o job do par, a menos que já exista um pendente para ele; devolve false
+// nesse caso ou com a fila cheia
+func (g *gapBackfiller) schedule(ctx context.Context, pair string, gaps []DataGap) (GapBackfillJob, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, job := range g.jobs {
+		if job.Pair == pair && (job.Status == gapJobScheduled || job.Status == gapJobRunning) {
+			return GapBackfillJob{}, false
+		}
+	}
+
+	job := &GapBackfillJob{
+		ID: newID(), Pair: pair, Status: gapJobScheduled, Gaps: gaps,
+		CreatedBy: auditActor(ctx), RequestID: requestIDFromContext(ctx), CreatedAt: time.Now().UTC(),
+	}
+	select {
+	case g.queue <- job:
+	default:
+		return GapBackfillJob{}, false
+	}
+	g.jobs = append(g.jobs, job)
+	if len(g.jobs) > maxGapJobs {
+		g.jobs = slices.Delete(g.jobs, 0, len(g.jobs)-maxGapJobs)
+	}
+	return *job, true
+}
+
+func (g *gapBackfiller) list() []GapBackfillJob {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	jobs := make([]GapBackfillJob, 0, len(g.jobs))
+	for i := len(g.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, *g.jobs[i])
+	}
+	return jobs
+}
+
+func (g *gapBackfiller) update(job *GapBackfillJob, fn func(job *GapBackfillJob)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	fn(job)
+}
+
+// run consulta o histórico de cada gap e importa só as cotações dentro dele; a importação
+// ignora timestamps já gravados, então repetir um job não duplica dados
+func (g *gapBackfiller) run(ctx context.Context, job *GapBackfillJob) {
+	g.update(job, func(job *GapBackfillJob) { job.Status = gapJobRunning })
+	// As cotações importadas são atribuídas a quem agendou o job
+	ctx = withAuditActor(context.WithValue(ctx, requestIDKey, job.RequestID), job.CreatedBy)
+	ctx = withRateSource(ctx, sourceGapBackfill)
+
+	var err error
+	for _, gap := range job.Gaps {
+		var quotes []Quote
+		if quotes, err = fetchDailyRange(ctx, job.Pair, gap.From, gap.To); err != nil {
+			break
+		}
+		quotes = slices.DeleteFunc(quotes, func(q Quote) bool {
+			ts, err := q.Unix()
+			if err != nil {
+				return true
+			}
+			at := time.Unix(ts, 0)
+			return !at.After(gap.From) || !at.Before(gap.To)
+		})
+
+		result := BackfillResult{Pair: job.Pair}
+		err = importRates(ctx, job.Pair, quotes, &result)
+		gapBackfillRows.WithLabelValues("imported").Add(float64(result.Imported))
+		gapBackfillRows.WithLabelValues("skipped").Add(float64(result.Skipped))
+		g.update(job, func(job *GapBackfillJob) {
+			job.Received += result.Received
+			job.Imported += result.Imported
+			job.Skipped += result.Skipped
+			if result.Imported > 0 {
+				job.Filled++
+			}
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	g.update(job, func(job *GapBackfillJob) {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+		job.Status = gapJobDone
+		if err != nil {
+			job.Status = gapJobFailed
+			job.Error = err.Error()
+		}
+	})
+	if err != nil {
+		log.Printf("Erro ao preencher gaps de %s: %v", job.Pair, err)
+		return
+	}
+	log.Printf("Gaps de %s preenchidos: %d de %d, %d cotações novas", job.Pair, job.Filled, len(job.Gaps), job.Imported)
+}
+
+// BackfillGapsHandler agenda, com POST, um job por par com gaps no relatório de
+// /admin/data-quality (mesmos parâmetros), que importa do histórico do provedor as cotações
+// que faltam em cada trecho. Pares compostos e séries derivadas não têm histórico no provedor.
+// Só os primeiros 100 gaps de cada par são agendados; uma nova chamada cobre os demais. GET
+// lista os jobs mais recentes
+func BackfillGapsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, gapBackfills.list())
+		return
+	case http.MethodPost:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	scope, ok := parseDataQualityScope(w, r)
+	if !ok {
+		return
+	}
+
+	resp := GapBackfillResponse{Scheduled: []GapBackfillJob{}}
+	for _, row := range scope.rows {
+		if isVirtualPair(row.Symbol) {
+			continue
+		}
+		quality, err := analyzeSeries(r.Context(), row, scope.from, scope.to, scope.intervalOf(row))
+		if err != nil {
+			logf(r.Context(), "Erro ao analisar a série de %s: %v", row.Symbol, err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		if len(quality.Gaps) == 0 {
+			continue
+		}
+		job, ok := gapBackfills.schedule(r.Context(), row.Symbol, quality.Gaps)
+		if !ok {
+			resp.Pending = append(resp.Pending, row.Symbol)
+			continue
+		}
+		resp.Scheduled = append(resp.Scheduled, job)
+	}
+
+	logf(r.Context(), "Preenchimento de gaps: %d jobs agendados", len(resp.Scheduled))
+	status := http.StatusOK
+	if len(resp.Scheduled) > 0 {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Bases MaxMind locais; nil quando o enriquecimento está desabilitado
+var geoCountryDB *geoip2.Reader
+var geoASNDB *geoip2.Reader
+
+type GeoInfo struct {
+	Country string
+	ASN     uint
+	ASOrg   string
+}
+
+func openGeoIP() {
+	if cfg.GeoIPCountryDBPath != "" {
+		reader, err := geoip2.Open(cfg.GeoIPCountryDBPath)
+		if err != nil {
+			log.Printf("Erro ao abrir base GeoIP de países, enriquecimento desabilitado: %v", err)
+		} else {
+			geoCountryDB = reader
+		}
+	}
+
+	if cfg.GeoIPASNDBPath != "" {
+		reader, err := geoip2.Open(cfg.GeoIPASNDBPath)
+		if err != nil {
+			log.Printf("Erro ao abrir base GeoIP de ASN, enriquecimento desabilitado: %v", err)
+		} else {
+			geoASNDB = reader
+		}
+	}
+}
+
+func closeGeoIP() {
+	if geoCountryDB != nil {
+		geoCountryDB.Close()
+	}
+	if geoASNDB != nil {
+		geoASNDB.Close()
+	}
+}
+
+// lookupGeo consulta país e ASN do IP nas bases locais, sem acesso à rede
+func lookupGeo(ipStr string) GeoInfo {
+	var info GeoInfo
+
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return info
+	}
+
+	if geoCountryDB != nil {
+		if country, err := geoCountryDB.Country(ip); err == nil {
+			info.Country = country.Country.IsoCode
+		}
+	}
+
+	if geoASNDB != nil {
+		if asn, err := geoASNDB.ASN(ip); err == nil {
+			info.ASN = asn.AutonomousSystemNumber
+			info.ASOrg = asn.AutonomousSystemOrganization
+		}
+	}
+
+	return info
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
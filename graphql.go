@@ -0,0 +1,360 @@
+package main
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/guilhermeayusso/goexpert/desafio/1/i18n"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+)
+
+const languageKey contextKey = "language"
+
+// PairQuote é a cotação de um par nas respostas GraphQL
+type PairQuote struct {
+	Pair string
+	Quote
+}
+
+// TimeRange é o intervalo informado em history e analytics, nos formatos de /historico
+type TimeRange struct {
+	From *string
+	To   *string
+}
+
+// HistoryAnalytics resume a série de um par no intervalo. Os campos de preço ficam nulos
+// quando não há pontos
+type HistoryAnalytics struct {
+	Pair       string           `json:"pair"`
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Resolution string           `json:"resolution"`
+	Points     int              `json:"points"`
+	Samples    int              `json:"samples"`
+	Open       *decimal.Decimal `json:"open,omitempty"`
+	Close      *decimal.Decimal `json:"close,omitempty"`
+	High       *decimal.Decimal `json:"high,omitempty"`
+	Low        *decimal.Decimal `json:"low,omitempty"`
+	Average    *decimal.Decimal `json:"average,omitempty"`
+	Change     *decimal.Decimal `json:"change,omitempty"`
+	ChangePct  *decimal.Decimal `json:"change_pct,omitempty"`
+	Volatility *decimal.Decimal `json:"volatility,omitempty"`
+}
+
+// MarshalDecimal e UnmarshalDecimal implementam o escalar Decimal como string, preservando
+// as casas decimais que um float perderia
+func MarshalDecimal(d decimal.Decimal) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(d.String()))
+	})
+}
+
+func UnmarshalDecimal(v any) (decimal.Decimal, error) {
+	switch v := v.(type) {
+	case string:
+		return decimal.NewFromString(v)
+	case int64:
+		return decimal.NewFromInt(v), nil
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	default:
+		return decimal.Zero, fmt.Errorf("Decimal inválido: %v", v)
+	}
+}
+
+// newGraphQLHandler monta o servidor GraphQL com consultas por GET/POST e assinaturas por
+// WebSocket (graphql-ws e graphql-transport-ws) ou SSE. As assinaturas por SSE estão
+// sujeitas a HTTP_WRITE_TIMEOUT; o WebSocket não
+func newGraphQLHandler() http.HandlerFunc {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: graphQLResolver{}}))
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              graphQLWebsocketInit,
+		Upgrader: websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			_, ok := allowedOrigin(origin)
+			return ok
+		}},
+	})
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+
+	authenticated := AuthMiddleware(srv.ServeHTTP)
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := requestLanguage(w, r)
+		r = r.WithContext(context.WithValue(r.Context(), languageKey, lang))
+
+		// navegadores não enviam Authorization no upgrade do WebSocket; o token vem no
+		// connection_init e é validado em graphQLWebsocketInit
+		if websocket.IsWebSocketUpgrade(r) && r.Header.Get("Authorization") == "" {
+			srv.ServeHTTP(w, r)
+			return
+		}
+		authenticated(w, r)
+	}
+}
+
+// graphQLWebsocketInit autentica a conexão WebSocket pelo campo authorization do
+// connection_init ("Bearer <token>"), exceto quando o upgrade já veio autenticado
+func graphQLWebsocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	if _, ok := userIDFromContext(ctx); ok {
+		return ctx, nil, nil
+	}
+	tokenString, found := strings.CutPrefix(payload.Authorization(), "Bearer ")
+	if !found || tokenString == "" {
+		return ctx, nil, errors.New("token ausente")
+	}
+	userID, err := parseToken(tokenString)
+	if err != nil || userID == 0 {
+		return ctx, nil, errors.New("token inválido")
+	}
+	return context.WithValue(ctx, userIDKey, userID), nil, nil
+}
+
+func graphQLLanguage(ctx context.Context) language.Tag {
+	if lang, ok := ctx.Value(languageKey).(language.Tag); ok {
+		return lang
+	}
+	return i18n.Match("")
+}
+
+type graphQLResolver struct{}
+
+func (graphQLResolver) Query() QueryResolver               { return graphQLQuery{} }
+func (graphQLResolver) Subscription() SubscriptionResolver { return graphQLSubscription{} }
+
+type graphQLQuery struct{}
+
+func (graphQLQuery) Rate(ctx context.Context, pair string) (*PairQuote, error) {
+	pair = strings.ToUpper(pair)
+	if !pairs.isEnabled(pair) {
+		return nil, errors.New("par não habilitado")
+	}
+
+	// Mesmo caminho de /cotacao: cache com stale-while-revalidate e campos de REDACT_FIELDS
+	quote, err := cachedOrFresh(ctx, pair)
+	if errors.Is(err, errQuotaExhausted) {
+		return nil, errors.New("cota diária do provedor esgotada e sem cotação em cache")
+	}
+	if err != nil {
+		logf(ctx, "Erro ao obter taxa de câmbio: %v", err)
+		return nil, errors.New("erro ao obter taxa de câmbio")
+	}
+	localized := localizeQuote(*quote, graphQLLanguage(ctx))
+	return &PairQuote{Pair: pair, Quote: redactQuote(localized, graphQLAuthenticated(ctx))}, nil
+}
+
+// graphQLAuthenticated indica se a consulta veio com um token válido, no cabeçalho ou no
+// connection_init do WebSocket
+func graphQLAuthenticated(ctx context.Context) bool {
+	_, ok := userIDFromContext(ctx)
+	return ok
+}
+
+func (graphQLQuery) History(ctx context.Context, pair string, rng *TimeRange, resolution string) (*HistoryResponse, error) {
+	resp, err := graphQLHistory(ctx, pair, rng, resolution)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (graphQLQuery) Analytics(ctx context.Context, pair string, rng *TimeRange, resolution string) (*HistoryAnalytics, error) {
+	resp, err := graphQLHistory(ctx, pair, rng, resolution)
+	if err != nil {
+		return nil, err
+	}
+	analytics := analyzeHistory(resp)
+	return &analytics, nil
+}
+
+func (graphQLQuery) Pairs(context.Context) ([]string, error) {
+	enabled := pairs.list(true)
+	symbols := make([]string, 0, len(enabled))
+	for _, pair := range enabled {
+		symbols = append(symbols, pair.Symbol)
+	}
+	return symbols, nil
+}
+
+// graphQLHistory carrega a série como em /historico?from=&to=, com a mesma validação e o
+// mesmo cache
+func graphQLHistory(ctx context.Context, pair string, rng *TimeRange, resolution string) (HistoryResponse, error) {
+	q := url.Values{"pair": {pair}, "resolution": {strings.ToLower(resolution)}}
+	if rng != nil && rng.From != nil {
+		q.Set("from", *rng.From)
+	}
+	if rng != nil && rng.To != nil {
+		q.Set("to", *rng.To)
+	}
+	resp, msg := parseHistoryRange(q)
+	if msg != "" {
+		return resp, errors.New(msg)
+	}
+
+	if points, ok := historyResults.get(resp); ok {
+		resp.Points = points
+		return resp, nil
+	}
+	points, err := loadHistory(ctx, resp)
+	if err != nil {
+		logf(ctx, "Erro ao consultar histórico: %v", err)
+		return resp, errors.New("erro interno")
+	}
+	resp.Points = points
+	historyResults.put(resp, points)
+	return resp, nil
+}
+
+// analyzeHistory calcula as estatísticas da série: a média é ponderada pelas amostras de
+// cada ponto e a volatilidade é o desvio padrão amostral das variações (%) entre
+// fechamentos consecutivos
+func analyzeHistory(resp HistoryResponse) HistoryAnalytics {
+	analytics := HistoryAnalytics{
+		Pair: resp.Pair, From: resp.From, To: resp.To, Resolution: resp.Resolution,
+		Points: len(resp.Points),
+	}
+	if len(resp.Points) == 0 {
+		return analytics
+	}
+
+	first, last := resp.Points[0], resp.Points[len(resp.Points)-1]
+	high, low := first.High, first.Low
+	var weighted decimal.Decimal
+	var returns []float64
+	for i, p := range resp.Points {
+		high = decimal.Max(high, p.High)
+		low = decimal.Min(low, p.Low)
+		weighted = weighted.Add(p.AvgBid.Mul(decimal.NewFromInt(int64(p.Samples))))
+		analytics.Samples += p.Samples
+		if i == 0 {
+			continue
+		}
+		if prev := resp.Points[i-1].Close; !prev.IsZero() {
+			pct, _ := p.Close.Sub(prev).Div(prev).Mul(decimal.NewFromInt(100)).Float64()
+			returns = append(returns, pct)
+		}
+	}
+
+	open, closing := first.Open, last.Close
+	change := closing.Sub(open)
+	analytics.Open, analytics.Close = &open, &closing
+	analytics.High, analytics.Low = &high, &low
+	analytics.Change = &change
+	if analytics.Samples > 0 {
+		average := weighted.Div(decimal.NewFromInt(int64(analytics.Samples))).Round(moneyScale)
+		analytics.Average = &average
+	}
+	if !open.IsZero() {
+		changePct := change.Div(open).Mul(decimal.NewFromInt(100)).Round(moneyScale)
+		analytics.ChangePct = &changePct
+	}
+	if len(returns) > 1 {
+		var sum, squares float64
+		for _, r := range returns {
+			sum += r
+		}
+		mean := sum / float64(len(returns))
+		for _, r := range returns {
+			squares += (r - mean) * (r - mean)
+		}
+		volatility := decimal.NewFromFloat(math.Sqrt(squares / float64(len(returns)-1))).Round(moneyScale)
+		analytics.Volatility = &volatility
+	}
+	return analytics
+}
+
+type graphQLSubscription struct{}
+
+// Quotes envia as cotações gravadas dos pares pedidos até o cliente cancelar a assinatura
+func (graphQLSubscription) Quotes(ctx context.Context, symbols []string) (<-chan *PairQuote, error) {
+	filter := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if !pairs.isEnabled(symbol) {
+			return nil, fmt.Errorf("par %s não habilitado", symbol)
+		}
+		filter[symbol] = true
+	}
+
+	lang := graphQLLanguage(ctx)
+	feed := liveQuotes.subscribe(filter)
+	out := make(chan *PairQuote)
+	go func() {
+		defer close(out)
+		defer liveQuotes.unsubscribe(feed)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case q := <-feed:
+				q.Quote = redactQuote(localizeQuote(q.Quote, lang), graphQLAuthenticated(ctx))
+				select {
+				case out <- &q:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// quoteFeed distribui as cotações gravadas aos assinantes de Subscription.quotes. Um
+// assinante lento perde cotações em vez de atrasar os demais
+type quoteFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan PairQuote]map[string]bool // pares de cada assinante; vazio = todos
+}
+
+var liveQuotes = &quoteFeed{subscribers: make(map[chan PairQuote]map[string]bool)}
+
+func (f *quoteFeed) subscribe(filter map[string]bool) chan PairQuote {
+	ch := make(chan PairQuote, 16)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribers[ch] = filter
+	return ch
+}
+
+func (f *quoteFeed) unsubscribe(ch chan PairQuote) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscribers, ch)
+}
+
+func (f *quoteFeed) publish(pair string, quote *Quote) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch, filter := range f.subscribers {
+		if len(filter) > 0 && !filter[pair] {
+			continue
+		}
+		select {
+		case ch <- PairQuote{Pair: pair, Quote: *quote}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+// rate segue o caminho de /cotacao: cache de QUOTE_CACHE_TTL e campos de REDACT_FIELDS
+func TestGraphQLRate(t *testing.T) {
+	newTestDB(t)
+	withConfig(t, func(c *config.Config) { c.QuoteCacheTTL = time.Minute })
+	var calls atomic.Int32
+	last := lastHandler("USD-BRL", testQuote("5.1", time.Now()))
+	newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		last(w, r)
+	})
+	saved := redactedFields
+	t.Cleanup(func() { redactedFields = saved })
+	redactedFields = map[string]bool{"high": true}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantHigh string
+	}{
+		{name: "anônima omite os campos", ctx: context.Background()},
+		{name: "autenticada recebe a cotação completa", ctx: context.WithValue(context.Background(), userIDKey, uint(1)), wantHigh: "5.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := graphQLQuery{}.Rate(tt.ctx, "usd-brl")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Bid != "5.1" || got.High != tt.wantHigh {
+				t.Errorf("cotação = bid %s, high %q; esperado 5.1, %q", got.Bid, got.High, tt.wantHigh)
+			}
+		})
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("chamadas ao upstream = %d, esperado 1 (a segunda vem do cache)", n)
+	}
+}
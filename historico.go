@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const defaultHistoryLimit = 100
+
+// Limite de pontos retornados em consultas por intervalo
+const maxHistoryPoints = 5000
+
+const (
+	resolutionRaw  = "raw"
+	resolutionHour = "hour"
+	resolutionDay  = "day"
+)
+
+type HistoryPoint struct {
+	Time    time.Time       `json:"time"`
+	Open    decimal.Decimal `json:"open"`
+	High    decimal.Decimal `json:"high"`
+	Low     decimal.Decimal `json:"low"`
+	Close   decimal.Decimal `json:"close"`
+	AvgBid  decimal.Decimal `json:"avg_bid"`
+	AvgAsk  decimal.Decimal `json:"avg_ask"`
+	Samples int             `json:"samples"`
+}
+
+type HistoryResponse struct {
+	Pair       string         `json:"pair"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Resolution string         `json:"resolution"`
+	Points     []HistoryPoint `json:"points"`
+}
+
+// GetHistoryHandler retorna as últimas cotações persistidas no banco ou, quando informado
+// um intervalo (from/to), a série do par na resolução adequada ao tamanho do intervalo. Os
+// horários saem em RFC3339, em UTC ou no fuso de ?tz=
+func GetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("from") != "" || q.Get("to") != "" || q.Get("resolution") != "" {
+		getHistoryRange(w, r)
+		return
+	}
+	loc, ok := requestLocation(w, r)
+	if !ok {
+		return
+	}
+
+	// limit é o nome antigo de per_page
+	if v := q.Get("limit"); v != "" && q.Get("per_page") == "" {
+		q.Set("per_page", v)
+	}
+	list, err := parseListQuery(q, rateListSpec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rates, total, err := rateRepo.List(r.Context(), list)
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar histórico: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	for i := range rates {
+		rates[i].withTime(loc)
+	}
+	writePageHeaders(w, r, list, total)
+	writeJSON(w, http.StatusOK, rates)
+}
+
+func getHistoryRange(w http.ResponseWriter, r *http.Request) {
+	resp, msg := parseHistoryRange(r.URL.Query())
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	loc, ok := requestLocation(w, r)
+	if !ok {
+		return
+	}
+
+	if points, ok := historyResults.get(resp); ok {
+		resp.Points = points
+		resp.in(loc)
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	var err error
+	if resp.Points, err = loadHistory(r.Context(), resp); err != nil {
+		logf(r.Context(), "Erro ao consultar histórico: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	historyResults.put(resp, resp.Points)
+
+	resp.in(loc)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseHistoryRange interpreta pair/from/to/resolution, retornando a mensagem de erro para o
+// cliente quando algum parâmetro é inválido
+func parseHistoryRange(q url.Values) (HistoryResponse, string) {
+	now := time.Now().UTC()
+
+	resp := HistoryResponse{Pair: strings.ToUpper(q.Get("pair")), From: now.Add(-24 * time.Hour), To: now}
+	if resp.Pair == "" {
+		resp.Pair = defaultPair
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if resp.From, err = parseTimeParam(v); err != nil {
+			return resp, "from inválido: use RFC3339, YYYY-MM-DD ou Unix timestamp"
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if resp.To, err = parseTimeParam(v); err != nil {
+			return resp, "to inválido: use RFC3339, YYYY-MM-DD ou Unix timestamp"
+		}
+	}
+	if !resp.From.Before(resp.To) {
+		return resp, "from deve ser anterior a to"
+	}
+
+	resp.Resolution = q.Get("resolution")
+	if resp.Resolution == "" || resp.Resolution == "auto" {
+		resp.Resolution = chooseResolution(resp.To.Sub(resp.From))
+	}
+	switch resp.Resolution {
+	case resolutionRaw, resolutionHour, resolutionDay:
+	default:
+		return resp, "resolution deve ser auto, raw, hour ou day"
+	}
+	return resp, ""
+}
+
+// loadHistory carrega os pontos do intervalo na resolução já validada
+func loadHistory(ctx context.Context, resp HistoryResponse) ([]HistoryPoint, error) {
+	switch resp.Resolution {
+	case resolutionHour:
+		return hourlyHistory(ctx, resp)
+	case resolutionDay:
+		return dailyHistory(ctx, resp)
+	default:
+		return rawHistory(ctx, resp)
+	}
+}
+
+// chooseResolution usa dados brutos para intervalos curtos e agregados para os longos
+func chooseResolution(span time.Duration) string {
+	switch {
+	case span <= 48*time.Hour:
+		return resolutionRaw
+	case span <= 60*24*time.Hour:
+		return resolutionHour
+	default:
+		return resolutionDay
+	}
+}
+
+func parseTimeParam(v string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func rawHistory(ctx context.Context, resp HistoryResponse) ([]HistoryPoint, error) {
+	rows, err := rateRepo.Range(ctx, resp.Pair, resp.From, resp.To, maxHistoryPoints)
+
+	points := make([]HistoryPoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, HistoryPoint{
+			Time: time.Unix(row.Timestamp, 0).UTC(),
+			Open: row.Bid, High: row.Bid, Low: row.Bid, Close: row.Bid,
+			AvgBid: row.Bid, AvgAsk: row.Ask, Samples: 1,
+		})
+	}
+	return points, err
+}
+
+func hourlyHistory(ctx context.Context, resp HistoryResponse) ([]HistoryPoint, error) {
+	if cfg.StorageBackend != storageSQLite {
+		return bucketHistory(ctx, resp, time.Hour)
+	}
+
+	const layout = "2006-01-02 15:04"
+
+	var rows []RateHourlyDB
+	err := db.WithContext(ctx).
+		Where("pair = ? AND hour >= ? AND hour < ?", resp.Pair, resp.From.UTC().Truncate(time.Hour).Format(layout), resp.To.UTC().Format(layout)).
+		Order("hour").Limit(maxHistoryPoints).Find(&rows).Error
+
+	points := make([]HistoryPoint, 0, len(rows))
+	for _, row := range rows {
+		t, _ := time.Parse(layout, row.Hour)
+		points = append(points, HistoryPoint{
+			Time: t, Open: row.OpenBid, High: row.HighBid, Low: row.LowBid, Close: row.CloseBid,
+			AvgBid: row.AvgBid, AvgAsk: row.AvgAsk, Samples: row.Samples,
+		})
+	}
+	return points, err
+}
+
+func dailyHistory(ctx context.Context, resp HistoryResponse) ([]HistoryPoint, error) {
+	if cfg.StorageBackend != storageSQLite {
+		return bucketHistory(ctx, resp, 24*time.Hour)
+	}
+
+	var rows []RateDailyDB
+	err := db.WithContext(ctx).
+		Where("pair = ? AND day >= ? AND day <= ?", resp.Pair, resp.From.UTC().Format(time.DateOnly), resp.To.UTC().Format(time.DateOnly)).
+		Order("day").Limit(maxHistoryPoints).Find(&rows).Error
+
+	points := make([]HistoryPoint, 0, len(rows))
+	for _, row := range rows {
+		t, _ := time.Parse(time.DateOnly, row.Day)
+		points = append(points, HistoryPoint{
+			Time: t, Open: row.OpenBid, High: row.HighBid, Low: row.LowBid, Close: row.CloseBid,
+			AvgBid: row.AvgBid, AvgAsk: row.AvgAsk, Samples: row.Samples,
+		})
+	}
+	return points, err
+}
+
+// bucketHistory agrega as cotações brutas por período em memória, para os backends que não
+// mantêm as tabelas de agregados
+func bucketHistory(ctx context.Context, resp HistoryResponse, period time.Duration) ([]HistoryPoint, error) {
+	rows, err := rateRepo.Range(ctx, resp.Pair, resp.From.UTC().Truncate(period), resp.To, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []HistoryPoint
+	var bidSum, askSum decimal.Decimal
+	finish := func() {
+		if n := len(points); n > 0 {
+			samples := decimal.NewFromInt(int64(points[n-1].Samples))
+			points[n-1].AvgBid = bidSum.Div(samples).Round(moneyScale)
+			points[n-1].AvgAsk = askSum.Div(samples).Round(moneyScale)
+		}
+	}
+
+	for _, row := range rows {
+		t := time.Unix(row.Timestamp, 0).UTC().Truncate(period)
+		if n := len(points); n == 0 || !points[n-1].Time.Equal(t) {
+			finish()
+			if n == maxHistoryPoints {
+				return points, nil
+			}
+			points = append(points, HistoryPoint{Time: t, Open: row.Bid, High: row.Bid, Low: row.Bid})
+			bidSum, askSum = decimal.Zero, decimal.Zero
+		}
+
+		p := &points[len(points)-1]
+		p.High = decimal.Max(p.High, row.Bid)
+		p.Low = decimal.Min(p.Low, row.Bid)
+		p.Close = row.Bid
+		p.Samples++
+		bidSum = bidSum.Add(row.Bid)
+		askSum = askSum.Add(row.Ask)
+	}
+	finish()
+
+	if points == nil {
+		points = []HistoryPoint{}
+	}
+	return points, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var historyCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "history_cache_requests_total",
+	Help: "Consultas de histórico por intervalo atendidas pelo cache (hit) ou pelo banco (miss).",
+}, []string{"result"})
+
+type historyCacheKey struct {
+	pair       string
+	from, to   int64
+	resolution string
+}
+
+type historyCacheEntry struct {
+	points    []HistoryPoint
+	lo, hi    int64 // timestamps cobertos pelos pontos, de lo (inclusive) a hi (exclusive)
+	expiresAt time.Time
+}
+
+// historyCache guarda o resultado das consultas de histórico por intervalo, pedidas
+// repetidamente pelos dashboards. Uma cotação gravada dentro do intervalo de uma entrada a
+// invalida, assim como a consolidação e a retenção, que alteram os agregados
+type historyCache struct {
+	mu         sync.Mutex
+	entries    map[historyCacheKey]historyCacheEntry
+	ttl        time.Duration
+	maxEntries int
+}
+
+var historyResults = &historyCache{entries: make(map[historyCacheKey]historyCacheEntry)}
+
+func (c *historyCache) configure(ttl time.Duration, maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl, c.maxEntries = ttl, maxEntries
+}
+
+func (c *historyCache) enabled() bool {
+	return c.ttl > 0 && c.maxEntries > 0
+}
+
+func (c *historyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func historyKey(resp HistoryResponse) historyCacheKey {
+	return historyCacheKey{pair: resp.Pair, from: resp.From.Unix(), to: resp.To.Unix(), resolution: resp.Resolution}
+}
+
+// historyBounds retorna os timestamps lidos pela consulta: os agregados cobrem o período
+// inteiro de from, e o diário inclui o dia de to
+func historyBounds(resp HistoryResponse) (int64, int64) {
+	from, to := resp.From.UTC(), resp.To.UTC()
+	switch resp.Resolution {
+	case resolutionHour:
+		from = from.Truncate(time.Hour)
+	case resolutionDay:
+		from = from.Truncate(24 * time.Hour)
+		to = to.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+	return from.Unix(), to.Unix()
+}
+
+func (c *historyCache) get(resp HistoryResponse) ([]HistoryPoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled() {
+		return nil, false
+	}
+
+	key := historyKey(resp)
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		historyCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	historyCacheRequests.WithLabelValues("hit").Inc()
+	return entry.points, true
+}
+
+func (c *historyCache) put(resp HistoryResponse, points []HistoryPoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled() {
+		return
+	}
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	lo, hi := historyBounds(resp)
+	c.entries[historyKey(resp)] = historyCacheEntry{points: points, lo: lo, hi: hi, expiresAt: now.Add(c.ttl)}
+}
+
+// evictLocked remove as entradas expiradas e, se o cache continuar cheio, a que expira primeiro
+func (c *historyCache) evictLocked(now time.Time) {
+	var oldest historyCacheKey
+	var oldestAt time.Time
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestAt.IsZero() || entry.expiresAt.Before(oldestAt) {
+			oldest, oldestAt = key, entry.expiresAt
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// invalidate remove as entradas do par cujo intervalo contém timestamp
+func (c *historyCache) invalidate(pair string, timestamp int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if key.pair == pair && timestamp >= entry.lo && timestamp < entry.hi {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// invalidateRange remove as entradas de todos os pares que cruzam [from, to)
+func (c *historyCache) invalidateRange(from, to time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.lo < to.Unix() && from.Unix() < entry.hi {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HoneypotMiddleware responde de imediato a caminhos típicos de scanners (/wp-admin, /.env...),
+// sem passar por tracing, logs, métricas de latência ou contabilização de abuso
+func HoneypotMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.ToLower(r.URL.Path)
+		for _, prefix := range cfg.HoneypotPaths {
+			if strings.HasPrefix(path, prefix) {
+				honeypotHits.WithLabelValues(prefix).Inc()
+				w.Header().Set("Connection", "close")
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,44 @@
+// Package i18n contém os nomes localizados das moedas, embutidos no binário, e a escolha do
+// idioma a partir do cabeçalho Accept-Language.
+package i18n
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed currencies.json
+var currenciesJSON []byte
+
+// O primeiro idioma é o padrão, usado quando o cabeçalho não casa com nenhum outro
+var supported = []language.Tag{language.BrazilianPortuguese, language.English}
+
+var matcher = language.NewMatcher(supported)
+
+// currencies mapeia o código da moeda para o nome em cada idioma suportado
+var currencies map[string]map[string]string
+
+func init() {
+	if err := json.Unmarshal(currenciesJSON, &currencies); err != nil {
+		panic("i18n: currencies.json inválido: " + err.Error())
+	}
+}
+
+// Match escolhe o idioma suportado mais adequado ao cabeçalho Accept-Language
+func Match(acceptLanguage string) language.Tag {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := matcher.Match(tags...)
+	return supported[index]
+}
+
+// CurrencyName retorna o nome da moeda no idioma informado
+func CurrencyName(code string, lang language.Tag) (string, bool) {
+	names, ok := currencies[code]
+	if !ok {
+		return "", false
+	}
+	name, ok := names[lang.String()]
+	return name, ok
+}
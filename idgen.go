@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Estratégias de ID_STRATEGY; ambas geram identificadores ordenáveis pelo tempo, que podem
+// ser mesclados entre instâncias e exportados sem colisão
+const (
+	idULID   = "ulid"
+	idUUIDv7 = "uuidv7"
+)
+
+// idStrategy descreve uma estratégia: o gerador, a partir do instante do registro, e o
+// tamanho do texto gerado, que distingue os formatos no banco
+type idStrategy struct {
+	generate func(at time.Time) string
+	length   int
+}
+
+var idStrategies = map[string]idStrategy{
+	// ulid.DefaultEntropy é monotônica: IDs gerados no mesmo milissegundo seguem crescentes
+	idULID: {length: ulid.EncodedSize, generate: func(at time.Time) string {
+		return ulid.MustNew(ulid.Timestamp(at), ulid.DefaultEntropy()).String()
+	}},
+	idUUIDv7: {length: 36, generate: func(at time.Time) string {
+		id, err := uuid.NewV7()
+		if err != nil {
+			// Só falha se o gerador aleatório do sistema falhar
+			panic(err)
+		}
+		// Os 48 bits iniciais são os milissegundos do UUIDv7
+		var ms [8]byte
+		binary.BigEndian.PutUint64(ms[:], uint64(at.UnixMilli()))
+		copy(id[:6], ms[2:])
+		return id.String()
+	}},
+}
+
+// currentIDStrategy é a estratégia de ID_STRATEGY
+var currentIDStrategy = idULID
+
+// newID gera o identificador global de cotações, eventos e jobs; o id inteiro continua sendo
+// a chave local de cada banco e não aparece na API
+func newID() string {
+	return idStrategies[currentIDStrategy].generate(time.Now())
+}
+
+func setIDStrategy(strategy string) error {
+	if _, ok := idStrategies[strategy]; !ok {
+		return fmt.Errorf("ID_STRATEGY deve ser %s ou %s", idULID, idUUIDv7)
+	}
+	currentIDStrategy = strategy
+	return nil
+}
+
+// idTime extrai o instante de um ULID ou UUIDv7
+func idTime(id string) (time.Time, bool) {
+	if u, err := ulid.ParseStrict(id); err == nil {
+		return ulid.Time(u.Time()), true
+	}
+	if u, err := uuid.Parse(id); err == nil && u.Version() == 7 {
+		var ms [8]byte
+		copy(ms[2:], u[:6])
+		return time.UnixMilli(int64(binary.BigEndian.Uint64(ms[:]))), true
+	}
+	return time.Time{}, false
+}
+
+// idColumn é uma coluna de IDs globais; at dá o instante do registro em milissegundos, usado
+// quando o ID atual não o informa, e renamed propaga a troca de um ID para quem o referencia
+type idColumn struct {
+	table, column, at string
+	renamed           func(tx *gorm.DB, old, new string) error
+}
+
+var idColumns = []idColumn{
+	{table: "usd_to_brl_rate_dbs", column: "uid", at: "`timestamp` * 1000",
+		renamed: func(tx *gorm.DB, old, new string) error {
+			return tx.Model(&OutboxIntentDB{}).Where("rate_uid = ?", old).Update("rate_uid", new).Error
+		}},
+	{table: "outbox_event_dbs", column: "uid", at: "CAST(strftime('%s', `created_at`) AS integer) * 1000"},
+	{table: "outbox_intent_dbs", column: "event_uid", at: "CAST(strftime('%s', `created_at`) AS integer) * 1000"},
+}
+
+const idBackfillBatchSize = 500
+
+// backfillIDs grava, com a estratégia de ID_STRATEGY, os IDs globais que faltam ou que estão
+// em outro formato: os UUIDv7 gerados pela migração 000014 com ID_STRATEGY=ulid, ou os de
+// uma estratégia anterior. O instante de cada ID é mantido, e com ele a ordem dos registros
+func backfillIDs(conn *gorm.DB) (total int, err error) {
+	strategy := idStrategies[currentIDStrategy]
+	for _, col := range idColumns {
+		var afterID uint
+		for {
+			var rows []struct {
+				ID  uint
+				UID string
+				At  int64
+			}
+			err := conn.Table(col.table).
+				Select(fmt.Sprintf("id, coalesce(`%s`, '') AS uid, %s AS at", col.column, col.at)).
+				Where(fmt.Sprintf("id > ? AND (`%[1]s` IS NULL OR length(`%[1]s`) <> ?)", col.column), afterID, strategy.length).
+				Order("id").Limit(idBackfillBatchSize).Scan(&rows).Error
+			if err != nil {
+				return total, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			err = conn.Transaction(func(tx *gorm.DB) error {
+				for _, row := range rows {
+					at, ok := idTime(row.UID)
+					if !ok {
+						at = time.UnixMilli(row.At)
+					}
+					id := strategy.generate(at)
+					if err := tx.Table(col.table).Where("id = ?", row.ID).Update(col.column, id).Error; err != nil {
+						return err
+					}
+					if col.renamed != nil && row.UID != "" {
+						if err := col.renamed(tx, row.UID, id); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return total, err
+			}
+			total += len(rows)
+			afterID = rows[len(rows)-1].ID
+		}
+	}
+	return total, nil
+}
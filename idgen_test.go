@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackfillIDs(t *testing.T) {
+	conn := newTestDB(t)
+	t.Cleanup(func() { currentIDStrategy = idULID })
+
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	uuidAt := func(at time.Time) string { return idStrategies[idUUIDv7].generate(at) }
+	rates := []USDToBRLRateDB{
+		{UID: uuidAt(base), Pair: "USD-BRL", Timestamp: base.Unix()},
+		{UID: uuidAt(base.Add(time.Minute)), Pair: "USD-BRL", Timestamp: base.Add(time.Minute).Unix()},
+		{UID: idStrategies[idULID].generate(base.Add(2 * time.Minute)), Pair: "USD-BRL", Timestamp: base.Add(2 * time.Minute).Unix()},
+	}
+	if err := conn.Create(&rates).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Linha sem uid, como as gravadas antes da migração por uma versão antiga
+	if err := conn.Exec("INSERT INTO usd_to_brl_rate_dbs (pair, code, bid, ask, timestamp, create_date) VALUES ('USD-BRL', 'USD', 5, 5, ?, ?)",
+		base.Add(3*time.Minute).Unix(), base).Error; err != nil {
+		t.Fatal(err)
+	}
+	intent := OutboxIntentDB{RateUID: rates[0].UID, Pair: "USD-BRL", Timestamp: base.Unix(), EventUID: uuidAt(base), Topic: topicRateSaved, Payload: "{}"}
+	if err := conn.Create(&intent).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		want     int
+		length   int
+	}{
+		{name: "UUIDv7 e uids vazios viram ULID", strategy: idULID, want: 4, length: 26},
+		{name: "segunda execução não altera nada", strategy: idULID, length: 26},
+		{name: "troca de estratégia regrava todos", strategy: idUUIDv7, want: 5, length: 36},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setIDStrategy(tt.strategy); err != nil {
+				t.Fatal(err)
+			}
+			n, err := backfillIDs(conn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want {
+				t.Errorf("IDs gravados = %d, esperado %d", n, tt.want)
+			}
+
+			var rows []USDToBRLRateDB
+			if err := conn.Order("id").Find(&rows).Error; err != nil {
+				t.Fatal(err)
+			}
+			uids := make([]string, len(rows))
+			for i, row := range rows {
+				uids[i] = row.UID
+				if len(row.UID) != tt.length {
+					t.Errorf("uid %q da cotação %d fora do formato %s", row.UID, row.ID, tt.strategy)
+				}
+				if at, ok := idTime(row.UID); !ok || at.Unix() != row.Timestamp {
+					t.Errorf("uid %q da cotação %d com instante %v, esperado %d", row.UID, row.ID, at, row.Timestamp)
+				}
+			}
+			if !slices.IsSorted(uids) {
+				t.Errorf("uids fora da ordem das cotações: %v", uids)
+			}
+
+			var got OutboxIntentDB
+			if err := conn.First(&got, intent.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if got.RateUID != rows[0].UID || len(got.EventUID) != tt.length {
+				t.Errorf("intenção com rate_uid %q e event_uid %q, esperado %q no formato %s", got.RateUID, got.EventUID, rows[0].UID, tt.strategy)
+			}
+		})
+	}
+}
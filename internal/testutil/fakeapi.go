@@ -0,0 +1,199 @@
+// Package testutil contém utilitários compartilhados pelos testes, como um AwesomeAPI falso
+// em que cada teste controla as cotações, a latência e as falhas.
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+// FakeAPI é um AwesomeAPI falso que responde /last/{pares} e /json/daily/{par}/{dias} com
+// as cotações configuradas. Respostas fixas por caminho, latência e falhas podem ser
+// alteradas a qualquer momento, inclusive com o servidor em uso
+type FakeAPI struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	quotes   map[string]domain.Quote   // última cotação por par (USD-BRL)
+	daily    map[string][]domain.Quote // histórico diário por par, do mais recente ao mais antigo
+	payloads map[string]payload        // respostas fixas pelo caminho
+	latency  time.Duration
+	failures []int // status das próximas respostas com erro, em ordem
+
+	requests atomic.Int64
+}
+
+type payload struct {
+	status int
+	body   string
+}
+
+// NewFakeAPI sobe o servidor falso, encerrado ao final do teste
+func NewFakeAPI(t testing.TB) *FakeAPI {
+	t.Helper()
+	f := &FakeAPI{
+		quotes:   make(map[string]domain.Quote),
+		daily:    make(map[string][]domain.Quote),
+		payloads: make(map[string]payload),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// Quote é uma cotação válida no formato do AwesomeAPI, com bid e ask iguais
+func Quote(pair, bid string, at time.Time) domain.Quote {
+	code, codein, _ := strings.Cut(pair, "-")
+	return domain.Quote{
+		Code: code, Codein: codein, Name: code + "/" + codein,
+		High: bid, Low: bid, VarBid: "0", PctChange: "0",
+		Bid: bid, Ask: bid, Timestamp: strconv.FormatInt(at.Unix(), 10),
+		CreateDate: at.UTC().Format(time.DateTime),
+	}
+}
+
+// SetQuote define a cotação retornada por /last/{pair}
+func (f *FakeAPI) SetQuote(pair string, quote domain.Quote) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.quotes[strings.ToUpper(pair)] = quote
+}
+
+// SetDaily define o histórico retornado por /json/daily/{pair}/{dias}, do mais recente ao
+// mais antigo, como no AwesomeAPI
+func (f *FakeAPI) SetDaily(pair string, quotes ...domain.Quote) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.daily[strings.ToUpper(pair)] = quotes
+}
+
+// SetPayload fixa a resposta de um caminho (ex.: "/last/USD-BRL"), ignorando as cotações
+// configuradas; útil para corpos malformados ou fora do contrato
+func (f *FakeAPI) SetPayload(path string, status int, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.payloads[path] = payload{status, body}
+}
+
+// SetLatency atrasa todas as respostas seguintes
+func (f *FakeAPI) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// FailNext faz as próximas n requisições responderem com status
+func (f *FakeAPI) FailNext(n, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for range n {
+		f.failures = append(f.failures, status)
+	}
+}
+
+// Requests é o total de requisições recebidas
+func (f *FakeAPI) Requests() int64 {
+	return f.requests.Load()
+}
+
+func (f *FakeAPI) serve(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+
+	f.mu.Lock()
+	latency := f.latency
+	var failure int
+	if len(f.failures) > 0 {
+		failure, f.failures = f.failures[0], f.failures[1:]
+	}
+	fixed, hasFixed := f.payloads[r.URL.Path]
+	f.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case failure != 0:
+		http.Error(w, http.StatusText(failure), failure)
+	case hasFixed:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(fixed.status)
+		fmt.Fprint(w, fixed.body)
+	case strings.HasPrefix(r.URL.Path, "/last/"):
+		f.serveLast(w, strings.TrimPrefix(r.URL.Path, "/last/"))
+	case strings.HasPrefix(r.URL.Path, "/json/daily/"):
+		f.serveDaily(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveLast responde um ou mais pares separados por vírgula, como o AwesomeAPI
+func (f *FakeAPI) serveLast(w http.ResponseWriter, list string) {
+	f.mu.Lock()
+	rate := make(domain.ExchangeRate)
+	for _, pair := range strings.Split(strings.ToUpper(list), ",") {
+		if quote, ok := f.quotes[pair]; ok {
+			rate[domain.PairKey(pair)] = quote
+		}
+	}
+	f.mu.Unlock()
+
+	if len(rate) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"status": 404, "code": "CoinNotExists", "message": "moeda nao encontrada " + list})
+		return
+	}
+	writeJSON(w, http.StatusOK, rate)
+}
+
+// serveDaily responde os últimos {dias} do histórico, filtrados por start_date e end_date
+// (YYYYMMDD) quando informados
+func (f *FakeAPI) serveDaily(w http.ResponseWriter, r *http.Request) {
+	pair, v, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/json/daily/"), "/")
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 {
+		days = 1
+	}
+	start, end := r.URL.Query().Get("start_date"), r.URL.Query().Get("end_date")
+
+	f.mu.Lock()
+	all := f.daily[strings.ToUpper(pair)]
+	f.mu.Unlock()
+
+	quotes := []domain.Quote{}
+	for _, quote := range all {
+		if len(quotes) == days {
+			break
+		}
+		unix, err := quote.Unix()
+		if err != nil {
+			continue
+		}
+		day := time.Unix(unix, 0).UTC().Format("20060102")
+		if (start != "" && day < start) || (end != "" && day > end) {
+			continue
+		}
+		quotes = append(quotes, quote)
+	}
+	writeJSON(w, http.StatusOK, quotes)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
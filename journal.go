@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prazo de cada regravação; maior que persistTimeout, pois o banco acabou de voltar
+const journalReplayTimeout = 5 * time.Second
+
+var (
+	journalPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "rate_journal_pending",
+		Help: "Cotações no journal local aguardando a volta do banco.",
+	})
+
+	journalEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_journal_entries_total",
+		Help: "Cotações do journal local por resultado: journaled (gravação no banco falhou), replayed (regravada) ou corrupt (linha ilegível, descartada).",
+	}, []string{"result"})
+)
+
+// journalEntry é uma linha do journal: a cotação e o contexto de auditoria da gravação que
+// falhou, restaurado na regravação
+type journalEntry struct {
+	Pair      string    `json:"pair"`
+	Quote     *Quote    `json:"quote"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	FailedAt  time.Time `json:"failed_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// rateJournal guarda em um arquivo JSONL, só com acréscimos e sincronizado a cada escrita, as
+// cotações que o banco não aceitou, e as regrava periodicamente quando ele volta. Para regravar,
+// o arquivo é renomeado para .replay, e novas falhas continuam indo para um journal novo; o que
+// o banco ainda recusar volta ao journal. A regravação é idempotente (cotações repetidas são
+// ignoradas), então uma queda no meio dela só repete cotações
+type rateJournal struct {
+	path    string
+	mu      sync.Mutex // serializa as escritas e a troca do arquivo
+	replays sync.Mutex // uma regravação por vez
+	pending atomic.Int64
+}
+
+// journal é nil com JOURNAL_PATH vazio ou no modo somente memória
+var journal *rateJournal
+
+func openRateJournal(path string) (*rateJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	j := &rateJournal{path: path}
+	for _, name := range []string{j.replayPath(), path} {
+		entries, _, err := readJournal(name)
+		if err != nil {
+			return nil, err
+		}
+		j.pending.Add(int64(len(entries)))
+	}
+	journalPending.Set(float64(j.pending.Load()))
+	return j, nil
+}
+
+func (j *rateJournal) replayPath() string {
+	return j.path + ".replay"
+}
+
+// append acrescenta as cotações ao journal, retornando só depois de sincronizadas no disco
+func (j *rateJournal) append(entries ...journalEntry) error {
+	if err := j.write(entries); err != nil {
+		return err
+	}
+	j.pending.Add(int64(len(entries)))
+	journalPending.Add(float64(len(entries)))
+	journalEntries.WithLabelValues("journaled").Add(float64(len(entries)))
+	return nil
+}
+
+func (j *rateJournal) write(entries []journalEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replay regrava as cotações do journal em ordem, parando na primeira recusa do banco
+func (j *rateJournal) replay(ctx context.Context) (replayed int, err error) {
+	j.replays.Lock()
+	defer j.replays.Unlock()
+
+	// Uma regravação interrompida pelo encerramento ou por uma queda deixa o .replay, retomado
+	// antes do journal atual
+	if _, err := os.Stat(j.replayPath()); errors.Is(err, fs.ErrNotExist) {
+		if j.pending.Load() == 0 {
+			return 0, nil
+		}
+		j.mu.Lock()
+		err = os.Rename(j.path, j.replayPath())
+		j.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	entries, corrupt, err := readJournal(j.replayPath())
+	if err != nil {
+		return 0, err
+	}
+	if corrupt > 0 {
+		log.Printf("Journal: %d linhas ilegíveis descartadas", corrupt)
+		journalEntries.WithLabelValues("corrupt").Add(float64(corrupt))
+	}
+
+	for i, entry := range entries {
+		if err = entry.save(ctx); err != nil {
+			if err := j.write(entries[i:]); err != nil {
+				return replayed, err
+			}
+			break
+		}
+		replayed++
+		j.pending.Add(-1)
+		journalPending.Dec()
+		journalEntries.WithLabelValues("replayed").Inc()
+	}
+	if removeErr := os.Remove(j.replayPath()); removeErr != nil {
+		return replayed, removeErr
+	}
+	return replayed, err
+}
+
+func (e journalEntry) save(ctx context.Context) error {
+	ctx = withRateSource(withAuditActor(ctx, e.CreatedBy), e.Source)
+	if e.RequestID != "" {
+		ctx = context.WithValue(ctx, requestIDKey, e.RequestID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, journalReplayTimeout)
+	defer cancel()
+	return SaveExchangeRate(ctx, e.Pair, e.Quote)
+}
+
+// readJournal lê as linhas do arquivo, que pode não existir. Linhas ilegíveis, como a última
+// de uma escrita interrompida, são contadas e ignoradas
+func readJournal(path string) (entries []journalEntry, corrupt int, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Quote == nil {
+			corrupt++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, corrupt, scanner.Err()
+}
+
+// journalRate guarda no journal a cotação que o banco não gravou
+func journalRate(ctx context.Context, pair string, quote *Quote, cause error) {
+	if journal == nil {
+		return
+	}
+	err := journal.append(journalEntry{
+		Pair: pair, Quote: quote,
+		CreatedBy: auditActor(ctx), Source: rateSourceFromContext(ctx), RequestID: requestIDFromContext(ctx),
+		FailedAt: time.Now().UTC(), Error: cause.Error(),
+	})
+	if err != nil {
+		logf(ctx, "Erro ao guardar a cotação de %s no journal, cotação perdida: %v", pair, err)
+		return
+	}
+	logf(ctx, "Cotação de %s guardada no journal até o banco voltar", pair)
+}
+
+// journalBatch guarda no journal as cotações de um lote que falhou, retornando quantas foram
+// guardadas
+func journalBatch(rows []batchRow, cause error) int {
+	if journal == nil {
+		return 0
+	}
+	entries := make([]journalEntry, 0, len(rows))
+	for _, row := range rows {
+		var quote Quote
+		if err := json.Unmarshal([]byte(row.event.Payload), &quote); err != nil {
+			continue
+		}
+		entries = append(entries, journalEntry{
+			Pair: row.rate.Pair, Quote: &quote, CreatedBy: row.rate.CreatedBy, Source: row.rate.Source,
+			FailedAt: time.Now().UTC(), Error: cause.Error(),
+		})
+	}
+	if err := journal.append(entries...); err != nil {
+		log.Printf("Erro ao guardar o lote de %d cotações no journal, cotações perdidas: %v", len(entries), err)
+		return 0
+	}
+	return len(entries)
+}
+
+// startJournalReplay tenta regravar o journal a cada intervalo
+func startJournalReplay(ctx context.Context, interval time.Duration) {
+	runPeriodically(ctx, interval, func(ctx context.Context) {
+		replayed, err := journal.replay(ctx)
+		if replayed > 0 {
+			log.Printf("Journal: %d cotações regravadas no banco", replayed)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Journal: %d cotações aguardando o banco: %v", journal.pending.Load(), err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// downRateRepository recusa as gravações enquanto down estiver marcado, como um banco fora
+// do ar
+type downRateRepository struct {
+	RateRepository
+	down *atomic.Bool
+}
+
+func (r downRateRepository) Save(ctx context.Context, row *USDToBRLRateDB) error {
+	if r.down.Load() {
+		return errors.New("database is down")
+	}
+	return r.RateRepository.Save(ctx, row)
+}
+
+func TestRateJournal(t *testing.T) {
+	conn := newTestDB(t)
+	var down atomic.Bool
+	rateRepo = downRateRepository{RateRepository: rateRepo, down: &down}
+
+	path := filepath.Join(t.TempDir(), "pending-rates.jsonl")
+	var err error
+	if journal, err = openRateJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { journal = nil })
+
+	now := time.Now()
+	ctx := withAuditActor(context.Background(), "user:7")
+	persistQuote := func(bid string, at time.Time) {
+		quote := testQuote(bid, at)
+		persist(ctx, "USD-BRL", &quote)
+	}
+
+	// Os passos dependem dos anteriores
+	tests := []struct {
+		name         string
+		down         bool
+		step         func()
+		wantReplayed int
+		wantErr      bool
+		wantPending  int64
+		wantRows     int64
+	}{
+		{name: "banco fora guarda as cotações no journal", down: true, step: func() {
+			persistQuote("5.1", now.Add(-2*time.Minute))
+			persistQuote("5.2", now.Add(-time.Minute))
+		}, wantPending: 2},
+		{name: "regravação com o banco fora mantém as cotações", down: true, wantErr: true, wantPending: 2},
+		{name: "banco de volta regrava as cotações", wantReplayed: 2, wantRows: 2},
+		{name: "regravação sem pendências não faz nada", wantRows: 2},
+		{name: "lote que falhou vai para o journal", down: true, step: func() {
+			quote := testQuote("5.3", now)
+			event, err := newRateEvent("USD-BRL", &quote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row := batchRow{rate: newRateRow(ctx, "USD-BRL", &quote), event: event}
+			if n := journalBatch([]batchRow{row}, errors.New("database is down")); n != 1 {
+				t.Fatalf("journalBatch = %d, esperado 1", n)
+			}
+		}, wantPending: 1, wantRows: 2},
+		{name: "regravação interrompida é retomada e ignora linhas ilegíveis", step: func() {
+			if err := os.Rename(path, journal.replayPath()); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.OpenFile(journal.replayPath(), os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.WriteString(`{"pair":"USD-BRL","quote":{"bid":`)
+			f.Close()
+		}, wantReplayed: 1, wantRows: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			down.Store(tt.down)
+			if tt.step != nil {
+				tt.step()
+			}
+			if tt.step == nil || !tt.down {
+				replayed, err := journal.replay(context.Background())
+				if (err != nil) != tt.wantErr || replayed != tt.wantReplayed {
+					t.Fatalf("replay = %d, %v; esperado %d (erro %v)", replayed, err, tt.wantReplayed, tt.wantErr)
+				}
+				if _, err := os.Stat(journal.replayPath()); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf(".replay ainda existe: %v", err)
+				}
+			}
+
+			if got := journal.pending.Load(); got != tt.wantPending {
+				t.Errorf("pendentes = %d, esperado %d", got, tt.wantPending)
+			}
+			entries, _, err := readJournal(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(entries)) != tt.wantPending {
+				t.Errorf("linhas no journal = %d, esperado %d", len(entries), tt.wantPending)
+			}
+
+			var rows []USDToBRLRateDB
+			if err := conn.Order("timestamp").Find(&rows).Error; err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(rows)) != tt.wantRows {
+				t.Fatalf("cotações gravadas = %d, esperado %d", len(rows), tt.wantRows)
+			}
+			for _, row := range rows {
+				if row.CreatedBy != "user:7" {
+					t.Errorf("cotação %s gravada por %q, esperado user:7", row.Bid, row.CreatedBy)
+				}
+			}
+		})
+	}
+}
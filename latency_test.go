@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+// setupCacheHit prepara /cotacao para responder do cache, sem consultar o provedor
+func setupCacheHit(t testing.TB) {
+	t.Helper()
+	newTestDB(t)
+	newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("upstream consultado com a cotação em cache")
+	})
+	withConfig(t, func(c *config.Config) { c.QuoteCacheTTL = time.Hour })
+	quote := testQuote("5.8050", time.Now())
+	cache.set("USD-BRL", &quote)
+}
+
+// setupFetch prepara /cotacao para consultar o provedor falso e gravar a cotação a cada
+// requisição; após a primeira, a gravação é descartada como repetida
+func setupFetch(t testing.TB) {
+	t.Helper()
+	newTestDB(t)
+	newTestUpstream(t, lastHandler("USD-BRL", testQuote("5.8050", time.Now())))
+}
+
+func serveQuote(t testing.TB) {
+	rec := httptest.NewRecorder()
+	GetExchangeRateHandler(rec, httptest.NewRequest(http.MethodGet, "/cotacao", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body)
+	}
+}
+
+// TestGetExchangeRateHandlerLatencyBudget falha quando o p99 do handler passa do orçamento,
+// para que regressões no caminho de busca e gravação apareçam antes da produção
+func TestGetExchangeRateHandlerLatencyBudget(t *testing.T) {
+	if testing.Short() || raceDetector {
+		t.Skip("latência só é medida sem -short e sem -race")
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(t testing.TB)
+		samples int
+		budget  time.Duration // p99 máximo
+	}{
+		{"cotação em cache", setupCacheHit, 5000, 2 * time.Millisecond},
+		{"busca no provedor e gravação", setupFetch, 1000, 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(t)
+			for range tt.samples / 10 {
+				serveQuote(t)
+			}
+
+			latencies := make([]time.Duration, tt.samples)
+			for i := range latencies {
+				start := time.Now()
+				serveQuote(t)
+				latencies[i] = time.Since(start)
+			}
+			slices.Sort(latencies)
+
+			p50, p99 := latencies[tt.samples/2], latencies[tt.samples*99/100]
+			t.Logf("p50 %s, p99 %s", p50, p99)
+			if p99 > tt.budget {
+				t.Errorf("p99 de %s acima do orçamento de %s", p99, tt.budget)
+			}
+		})
+	}
+}
+
+func BenchmarkGetExchangeRateHandlerCacheHit(b *testing.B) {
+	setupCacheHit(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		serveQuote(b)
+	}
+}
+
+func BenchmarkGetExchangeRateHandlerFetch(b *testing.B) {
+	setupFetch(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		serveQuote(b)
+	}
+}
+
+func BenchmarkGetExchangeRateHandlerCacheHitParallel(b *testing.B) {
+	setupCacheHit(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rec := httptest.NewRecorder()
+			GetExchangeRateHandler(rec, httptest.NewRequest(http.MethodGet, "/cotacao", nil))
+			if rec.Code != http.StatusOK {
+				b.Errorf("status = %d", rec.Code)
+				return
+			}
+		}
+	})
+}
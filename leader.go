@@ -0,0 +1,340 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Backends de eleição em LEADER_ELECTION
+const (
+	leaderElectionDB    = "db"
+	leaderElectionRedis = "redis"
+)
+
+// Nome da liderança disputada no banco: a dos jobs em segundo plano
+const leaderLeaseName = "jobs"
+
+// LeaderInfo descreve a liderança vigente
+type LeaderInfo struct {
+	Holder    string    `json:"holder"`
+	Since     time.Time `json:"since"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// leaderStore guarda a liderança com prazo (lease): quem a detém renova antes de vencer, e
+// uma liderança vencida pode ser assumida por qualquer instância
+type leaderStore interface {
+	// acquire assume a liderança livre ou vencida, ou renova a de holder, e retorna a vigente
+	acquire(ctx context.Context, holder string, ttl time.Duration) (LeaderInfo, error)
+	// release encerra a liderança de holder; a de outra instância não é afetada
+	release(ctx context.Context, holder string) error
+	current(ctx context.Context) (LeaderInfo, bool, error)
+}
+
+// leaderElector disputa a liderança entre as réplicas; só a líder roda o agendador e a
+// avaliação de alertas. A liderança é renovada a cada terço de LEADER_LEASE_TTL: se a líder
+// cair, outra assume quando o prazo vence; se ela não conseguir renovar, para os jobs antes
+// do vencimento, para que duas instâncias nunca liderem ao mesmo tempo
+type leaderElector struct {
+	store   leaderStore // nil sem eleição: a instância lidera sozinha
+	backend string
+	id      string
+	ttl     time.Duration
+
+	mu         sync.Mutex
+	leading    bool
+	renewedAt  time.Time
+	cancelJobs context.CancelFunc
+}
+
+// leader é a eleição desta instância
+var leader = &leaderElector{}
+
+func newLeaderElector() (*leaderElector, error) {
+	id := cfg.InstanceID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = host + ":" + strconv.Itoa(os.Getpid())
+	}
+	e := &leaderElector{backend: cfg.LeaderElection, id: id, ttl: cfg.LeaderLeaseTTL}
+
+	switch cfg.LeaderElection {
+	case "":
+		return e, nil
+	case leaderElectionDB:
+		e.store = &dbLeaderStore{db: db}
+	case leaderElectionRedis:
+		if redisClient == nil {
+			return nil, fmt.Errorf("LEADER_ELECTION=redis requer REDIS_URL")
+		}
+		e.store = &redisLeaderStore{client: redisClient, key: cfg.RedisPrefix + "leader"}
+	default:
+		return nil, fmt.Errorf("backend de eleição desconhecido %q", cfg.LeaderElection)
+	}
+	if e.ttl < 3*time.Second {
+		return nil, fmt.Errorf("LEADER_LEASE_TTL deve ser de pelo menos 3s")
+	}
+	return e, nil
+}
+
+func (e *leaderElector) isLeader() bool {
+	if e.store == nil {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// start roda jobs enquanto a instância liderar, com um contexto cancelado quando ela deixa
+// de liderar; sem eleição, roda jobs de imediato
+func (e *leaderElector) start(ctx context.Context, jobs func(context.Context)) {
+	if e.store == nil {
+		jobs(ctx)
+		return
+	}
+	log.Printf("Eleição de líder via %s como %s (prazo de %s)", e.backend, e.id, e.ttl)
+	e.campaign(ctx, jobs)
+	runPeriodically(ctx, e.ttl/3, func(ctx context.Context) { e.campaign(ctx, jobs) })
+}
+
+// campaign assume ou renova a liderança, iniciando ou parando os jobs conforme o resultado
+func (e *leaderElector) campaign(ctx context.Context, jobs func(context.Context)) {
+	storeCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+	current, err := e.store.acquire(storeCtx, e.id, e.ttl)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case err != nil:
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Erro na eleição de líder: %v", err)
+		if e.leading && time.Since(e.renewedAt) >= e.ttl*2/3 {
+			e.stepDownLocked("liderança não renovada")
+		}
+	case current.Holder == e.id:
+		e.renewedAt = time.Now()
+		if !e.leading {
+			e.leading = true
+			var jobsCtx context.Context
+			jobsCtx, e.cancelJobs = context.WithCancel(ctx)
+			log.Printf("Instância %s eleita líder", e.id)
+			jobs(jobsCtx)
+		}
+	case e.leading:
+		e.stepDownLocked("liderança assumida por " + current.Holder)
+	}
+}
+
+func (e *leaderElector) stepDownLocked(reason string) {
+	e.leading = false
+	e.cancelJobs()
+	log.Printf("Instância %s deixou de liderar: %s", e.id, reason)
+}
+
+// release devolve a liderança no encerramento, depois que os jobs pararam, para que outra
+// instância assuma sem esperar o prazo vencer
+func (e *leaderElector) release() error {
+	if e.store == nil {
+		return nil
+	}
+	e.mu.Lock()
+	leading := e.leading
+	if leading {
+		e.stepDownLocked("encerramento")
+	}
+	e.mu.Unlock()
+	if !leading {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return e.store.release(ctx, e.id)
+}
+
+// LeaderStatus é a resposta de /admin/leader
+type LeaderStatus struct {
+	Election string      `json:"election"` // db, redis ou disabled
+	Instance string      `json:"instance"` // instância que respondeu
+	Leading  bool        `json:"leading"`  // se a instância que respondeu lidera
+	Leader   *LeaderInfo `json:"leader"`   // null sem eleição ou enquanto ninguém lidera
+	LeaseTTL Duration    `json:"lease_ttl,omitempty"`
+}
+
+// LeaderHandler mostra quem lidera os jobs em segundo plano. Qualquer réplica responde: a
+// liderança é lida do banco ou do Redis, não do estado local
+func LeaderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := LeaderStatus{
+		Election: cmp.Or(leader.backend, "disabled"),
+		Instance: leader.id,
+		Leading:  leader.isLeader(),
+	}
+	if leader.store != nil {
+		status.LeaseTTL = Duration(leader.ttl)
+		info, ok, err := leader.store.current(r.Context())
+		if err != nil {
+			logf(r.Context(), "Erro ao consultar a liderança: %v", err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		if ok {
+			status.Leader = &info
+		}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// LeaderLeaseDB é a liderança no banco, uma linha por nome. Os prazos são calculados pelo
+// relógio de cada instância, que precisam estar sincronizados
+type LeaderLeaseDB struct {
+	Name       string    `gorm:"type:varchar(50);primaryKey"`
+	Holder     string    `gorm:"type:varchar(100);not null"`
+	AcquiredAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+}
+
+type dbLeaderStore struct {
+	db *gorm.DB
+}
+
+func (s *dbLeaderStore) acquire(ctx context.Context, holder string, ttl time.Duration) (LeaderInfo, error) {
+	now := time.Now().UTC()
+	var lease LeaderLeaseDB
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		renewed := tx.Model(&LeaderLeaseDB{}).Where("name = ? AND holder = ?", leaderLeaseName, holder).
+			Update("expires_at", now.Add(ttl))
+		if renewed.Error != nil {
+			return renewed.Error
+		}
+		if renewed.RowsAffected == 0 {
+			taken := tx.Model(&LeaderLeaseDB{}).Where("name = ? AND expires_at < ?", leaderLeaseName, now).
+				Updates(map[string]any{"holder": holder, "acquired_at": now, "expires_at": now.Add(ttl)})
+			if taken.Error != nil {
+				return taken.Error
+			}
+			// Na primeira eleição a linha ainda não existe
+			if taken.RowsAffected == 0 {
+				err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&LeaderLeaseDB{
+					Name: leaderLeaseName, Holder: holder, AcquiredAt: now, ExpiresAt: now.Add(ttl),
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Where("name = ?", leaderLeaseName).First(&lease).Error
+	})
+	return leaseInfo(lease), err
+}
+
+func (s *dbLeaderStore) release(ctx context.Context, holder string) error {
+	return s.db.WithContext(ctx).Model(&LeaderLeaseDB{}).Where("name = ? AND holder = ?", leaderLeaseName, holder).
+		Update("expires_at", time.Now().UTC().Add(-time.Second)).Error
+}
+
+func (s *dbLeaderStore) current(ctx context.Context) (LeaderInfo, bool, error) {
+	var lease LeaderLeaseDB
+	err := s.db.WithContext(ctx).Where("name = ? AND expires_at > ?", leaderLeaseName, time.Now().UTC()).First(&lease).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return LeaderInfo{}, false, nil
+	}
+	return leaseInfo(lease), err == nil, err
+}
+
+func leaseInfo(lease LeaderLeaseDB) LeaderInfo {
+	return LeaderInfo{Holder: lease.Holder, Since: lease.AcquiredAt.UTC(), ExpiresAt: lease.ExpiresAt.UTC()}
+}
+
+// redisLeaderStore guarda a liderança em um hash (holder, since) com expiração. As operações
+// são scripts Lua, atômicos no Redis, e o prazo é contado pelo relógio do próprio Redis
+type redisLeaderStore struct {
+	client *redis.Client
+	key    string
+}
+
+var (
+	redisLeaderAcquire = redis.NewScript(`
+local holder = redis.call("HGET", KEYS[1], "holder")
+if not holder then
+	redis.call("HSET", KEYS[1], "holder", ARGV[1], "since", ARGV[2])
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+elseif holder == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return {redis.call("HGET", KEYS[1], "holder"), redis.call("HGET", KEYS[1], "since"), redis.call("PTTL", KEYS[1])}`)
+
+	redisLeaderRelease = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "holder") == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	redisLeaderCurrent = redis.NewScript(`
+local holder = redis.call("HGET", KEYS[1], "holder")
+if not holder then
+	return {}
+end
+return {holder, redis.call("HGET", KEYS[1], "since"), redis.call("PTTL", KEYS[1])}`)
+)
+
+func (s *redisLeaderStore) acquire(ctx context.Context, holder string, ttl time.Duration) (LeaderInfo, error) {
+	result, err := redisLeaderAcquire.Run(ctx, s.client, []string{s.key},
+		holder, time.Now().UnixMilli(), ttl.Milliseconds()).Slice()
+	if err != nil {
+		return LeaderInfo{}, err
+	}
+	return redisLeaderInfo(result)
+}
+
+func (s *redisLeaderStore) release(ctx context.Context, holder string) error {
+	return redisLeaderRelease.Run(ctx, s.client, []string{s.key}, holder).Err()
+}
+
+func (s *redisLeaderStore) current(ctx context.Context) (LeaderInfo, bool, error) {
+	result, err := redisLeaderCurrent.Run(ctx, s.client, []string{s.key}).Slice()
+	if err != nil || len(result) == 0 {
+		return LeaderInfo{}, false, err
+	}
+	info, err := redisLeaderInfo(result)
+	return info, err == nil, err
+}
+
+// redisLeaderInfo interpreta {holder, since em ms Unix, PTTL em ms}
+func redisLeaderInfo(result []any) (LeaderInfo, error) {
+	if len(result) != 3 {
+		return LeaderInfo{}, fmt.Errorf("resposta inesperada do Redis: %v", result)
+	}
+	holder, _ := result[0].(string)
+	sinceText, _ := result[1].(string)
+	ttl, _ := result[2].(int64)
+	since, err := strconv.ParseInt(sinceText, 10, 64)
+	if err != nil {
+		return LeaderInfo{}, fmt.Errorf("início da liderança inválido no Redis: %w", err)
+	}
+	return LeaderInfo{
+		Holder:    holder,
+		Since:     time.UnixMilli(since).UTC(),
+		ExpiresAt: time.Now().Add(time.Duration(ttl) * time.Millisecond).UTC(),
+	}, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLeaderElection(t *testing.T) {
+	conn := newTestDB(t)
+	store := &dbLeaderStore{db: conn}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// jobs guarda o contexto dos jobs de cada instância, cancelado quando ela deixa de liderar
+	jobs := map[string]context.Context{}
+	electors := map[string]*leaderElector{}
+	for _, id := range []string{"a", "b"} {
+		electors[id] = &leaderElector{store: store, backend: leaderElectionDB, id: id, ttl: time.Minute}
+	}
+	campaign := func(id string) {
+		electors[id].campaign(ctx, func(ctx context.Context) { jobs[id] = ctx })
+	}
+
+	// Os passos dependem dos anteriores
+	tests := []struct {
+		name       string
+		step       func()
+		wantLeader string // vazio: ninguém lidera
+		wantJobs   map[string]bool
+	}{
+		{name: "primeira instância é eleita", step: func() { campaign("a") },
+			wantLeader: "a", wantJobs: map[string]bool{"a": true}},
+		{name: "segunda instância aguarda", step: func() { campaign("b") },
+			wantLeader: "a", wantJobs: map[string]bool{"a": true}},
+		{name: "renovação mantém a líder", step: func() { campaign("a"); campaign("b") },
+			wantLeader: "a", wantJobs: map[string]bool{"a": true}},
+		{name: "líder caída perde a liderança ao vencer o prazo", step: func() {
+			conn.Model(&LeaderLeaseDB{}).Where("name = ?", leaderLeaseName).Update("expires_at", time.Now().UTC().Add(-time.Second))
+			campaign("b")
+		}, wantLeader: "b", wantJobs: map[string]bool{"a": true, "b": true}},
+		{name: "antiga líder para os jobs ao ver a nova", step: func() { campaign("a") },
+			wantLeader: "b", wantJobs: map[string]bool{"b": true}},
+		{name: "encerramento devolve a liderança", step: func() {
+			if err := electors["b"].release(); err != nil {
+				t.Fatal(err)
+			}
+		}, wantJobs: map[string]bool{}},
+		{name: "outra instância assume sem esperar o prazo", step: func() { campaign("a") },
+			wantLeader: "a", wantJobs: map[string]bool{"a": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step()
+
+			info, ok, err := store.current(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := map[bool]string{true: info.Holder}[ok]; got != tt.wantLeader {
+				t.Errorf("líder = %q, esperado %q", got, tt.wantLeader)
+			}
+			for id, e := range electors {
+				running := jobs[id] != nil && jobs[id].Err() == nil
+				if running != tt.wantJobs[id] || e.isLeader() != tt.wantJobs[id] {
+					t.Errorf("instância %s: jobs rodando = %v, lidera = %v", id, running, e.isLeader())
+				}
+			}
+		})
+	}
+}
+
+func TestLeaderHandler(t *testing.T) {
+	conn := newTestDB(t)
+	saved := leader
+	t.Cleanup(func() { leader = saved })
+
+	tests := []struct {
+		name        string
+		elector     *leaderElector
+		campaign    bool
+		wantLeading bool
+		wantHolder  string // vazio: leader null
+	}{
+		{name: "sem eleição", elector: &leaderElector{id: "a"}, wantLeading: true},
+		{name: "ninguém lidera", elector: &leaderElector{store: &dbLeaderStore{db: conn}, backend: leaderElectionDB, id: "a", ttl: time.Minute}},
+		{name: "instância líder", elector: &leaderElector{store: &dbLeaderStore{db: conn}, backend: leaderElectionDB, id: "a", ttl: time.Minute},
+			campaign: true, wantLeading: true, wantHolder: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leader = tt.elector
+			if tt.campaign {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				leader.campaign(ctx, func(context.Context) {})
+			}
+
+			rec, _ := serve(t, LeaderHandler, http.MethodGet, "/admin/leader", "", http.StatusOK)
+			status := decodeJSON[LeaderStatus](t, rec)
+			if status.Instance != "a" || status.Leading != tt.wantLeading {
+				t.Errorf("instância/lidera = %s/%v, esperado a/%v", status.Instance, status.Leading, tt.wantLeading)
+			}
+			switch {
+			case tt.wantHolder == "" && status.Leader != nil:
+				t.Errorf("leader = %+v, esperado null", status.Leader)
+			case tt.wantHolder != "" && (status.Leader == nil || status.Leader.Holder != tt.wantHolder):
+				t.Errorf("leader = %+v, esperado %s", status.Leader, tt.wantHolder)
+			case status.Leader != nil && !status.Leader.ExpiresAt.After(time.Now()):
+				t.Errorf("expires_at = %v no passado", status.Leader.ExpiresAt)
+			}
+		})
+	}
+}
+
+// Uma instância eleita de novo reinicia os mesmos agendador e avaliador de alertas
+func TestLeaderJobsRestart(t *testing.T) {
+	conn := newTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	store := &dbLeaderStore{db: conn}
+	elector := &leaderElector{store: store, backend: leaderElectionDB, id: "a", ttl: time.Minute}
+	sched := newScheduler(time.Minute, 10)
+	engine := &alertEngine{quotes: make(chan alertQuote, 1), alerts: make(map[uint]AlertDB),
+		evaluators: make(map[uint]*alertEvaluator), fileRules: make(map[string]fileAlert)}
+	jobs := func(ctx context.Context) {
+		engine.start(ctx)
+		sched.start(ctx)
+	}
+	expire := func() {
+		conn.Model(&LeaderLeaseDB{}).Where("name = ?", leaderLeaseName).Update("expires_at", time.Now().UTC().Add(-time.Second))
+	}
+
+	// Os passos dependem dos anteriores
+	tests := []struct {
+		name        string
+		step        func()
+		wantRunning bool
+	}{
+		{name: "eleita inicia os jobs", step: func() { elector.campaign(ctx, jobs) }, wantRunning: true},
+		{name: "deposta para os jobs", step: func() {
+			expire()
+			if _, err := store.acquire(ctx, "b", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			elector.campaign(ctx, jobs)
+		}},
+		{name: "eleita de novo reinicia os jobs", step: func() {
+			expire()
+			elector.campaign(ctx, jobs)
+		}, wantRunning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step()
+
+			// Os jobs da liderança anterior param em segundo plano
+			running := func() (bool, bool) { return sched.status().Running, engine.running.Load() }
+			deadline := time.Now().Add(2 * time.Second)
+			for scheduler, alerts := running(); (scheduler != tt.wantRunning || alerts != tt.wantRunning) && time.Now().Before(deadline); scheduler, alerts = running() {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if scheduler, alerts := running(); scheduler != tt.wantRunning || alerts != tt.wantRunning {
+				t.Errorf("agendador/alertas rodando = %v/%v, esperado %v", scheduler, alerts, tt.wantRunning)
+			}
+		})
+	}
+}
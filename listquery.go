@@ -0,0 +1,230 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// listSpec descreve os parâmetros aceitos por uma listagem: os campos ordenáveis (parâmetro →
+// coluna), a ordenação padrão e o nome do filtro de faixa de valores ({valor}_min/{valor}_max)
+type listSpec struct {
+	sortFields     map[string]string
+	defaultSort    string
+	valueParam     string
+	defaultPerPage int
+	maxPerPage     int
+}
+
+// listQuery são os parâmetros já validados de uma listagem:
+//
+//	?page=2&per_page=50&sort=timestamp:desc&pair=USD-BRL&since=2025-01-01&until=2025-02-01&bid_min=5.5
+//
+// since e until aceitam os formatos de from/to e delimitam [since, until)
+type listQuery struct {
+	Page     int
+	PerPage  int
+	Sort     string // coluna
+	Desc     bool
+	Pair     string
+	Since    time.Time
+	Until    time.Time
+	MinValue *decimal.Decimal
+	MaxValue *decimal.Decimal
+}
+
+func (q listQuery) offset() int {
+	return (q.Page - 1) * q.PerPage
+}
+
+func (q listQuery) direction() string {
+	if q.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// parseListQuery valida os parâmetros de paginação, ordenação e filtro segundo spec
+func parseListQuery(v url.Values, spec listSpec) (listQuery, error) {
+	q := listQuery{Page: 1, PerPage: spec.defaultPerPage, Pair: strings.ToUpper(v.Get("pair"))}
+
+	if s := v.Get("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("page deve ser um inteiro positivo")
+		}
+		q.Page = n
+	}
+	if s := v.Get("per_page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > spec.maxPerPage {
+			return q, fmt.Errorf("per_page deve estar entre 1 e %d", spec.maxPerPage)
+		}
+		q.PerPage = n
+	}
+
+	sort := v.Get("sort")
+	if sort == "" {
+		sort = spec.defaultSort
+	}
+	field, dir, _ := strings.Cut(sort, ":")
+	column, ok := spec.sortFields[field]
+	if !ok {
+		fields := make([]string, 0, len(spec.sortFields))
+		for name := range spec.sortFields {
+			fields = append(fields, name)
+		}
+		slices.Sort(fields)
+		return q, fmt.Errorf("sort deve ser um de %s, com :asc ou :desc", strings.Join(fields, ", "))
+	}
+	switch dir {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("direção de sort inválida %q: use asc ou desc", dir)
+	}
+	q.Sort = column
+
+	var err error
+	if s := v.Get("since"); s != "" {
+		if q.Since, err = parseTimeParam(s); err != nil {
+			return q, fmt.Errorf("since inválido: use RFC3339, YYYY-MM-DD ou Unix timestamp")
+		}
+	}
+	if s := v.Get("until"); s != "" {
+		if q.Until, err = parseTimeParam(s); err != nil {
+			return q, fmt.Errorf("until inválido: use RFC3339, YYYY-MM-DD ou Unix timestamp")
+		}
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return q, fmt.Errorf("since deve ser anterior a until")
+	}
+
+	if spec.valueParam != "" {
+		for _, bound := range []struct {
+			suffix string
+			target **decimal.Decimal
+		}{{"_min", &q.MinValue}, {"_max", &q.MaxValue}} {
+			s := v.Get(spec.valueParam + bound.suffix)
+			if s == "" {
+				continue
+			}
+			d, err := decimal.NewFromString(s)
+			if err != nil {
+				return q, fmt.Errorf("%s%s deve ser um número", spec.valueParam, bound.suffix)
+			}
+			*bound.target = &d
+		}
+		if q.MinValue != nil && q.MaxValue != nil && q.MinValue.GreaterThan(*q.MaxValue) {
+			return q, fmt.Errorf("%s_min deve ser menor ou igual a %s_max", spec.valueParam, spec.valueParam)
+		}
+	}
+	return q, nil
+}
+
+// writePageHeaders informa o total de itens em X-Total-Count e os links da paginação (first,
+// prev, next e last) em Link, preservando os demais parâmetros da requisição
+func writePageHeaders(w http.ResponseWriter, r *http.Request, q listQuery, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+	last := max(int(math.Ceil(float64(total)/float64(q.PerPage))), 1)
+	link := func(page int, rel string) string {
+		u := *r.URL
+		params := u.Query()
+		params.Del("limit")
+		params.Set("page", strconv.Itoa(page))
+		params.Set("per_page", strconv.Itoa(q.PerPage))
+		u.RawQuery = params.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if q.Page > 1 {
+		links = append(links, link(min(q.Page-1, last), "prev"))
+	}
+	if q.Page < last {
+		links = append(links, link(q.Page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// Campos ordenáveis das cotações; os nomes das colunas são os mesmos em todos os backends
+var rateListSpec = listSpec{
+	sortFields:     map[string]string{"timestamp": "timestamp", "bid": "bid", "ask": "ask"},
+	defaultSort:    "timestamp:desc",
+	valueParam:     "bid",
+	defaultPerPage: defaultHistoryLimit,
+	maxPerPage:     1000,
+}
+
+// rateConditions monta o filtro das cotações em SQL, com "?" no lugar dos argumentos
+func (q listQuery) rateConditions() (string, []any) {
+	conds := []string{"1 = 1"}
+	var args []any
+	if q.Pair != "" {
+		conds, args = append(conds, "pair = ?"), append(args, q.Pair)
+	}
+	if !q.Since.IsZero() {
+		conds, args = append(conds, "timestamp >= ?"), append(args, q.Since.Unix())
+	}
+	if !q.Until.IsZero() {
+		conds, args = append(conds, "timestamp < ?"), append(args, q.Until.Unix())
+	}
+	if q.MinValue != nil {
+		conds, args = append(conds, "bid >= ?"), append(args, *q.MinValue)
+	}
+	if q.MaxValue != nil {
+		conds, args = append(conds, "bid <= ?"), append(args, *q.MaxValue)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// pageRates aplica o filtro, a ordenação e a paginação às cotações já carregadas, nos
+// backends sem consultas (memória e Badger); retorna a página e o total filtrado
+func pageRates(rows []USDToBRLRateDB, q listQuery) ([]USDToBRLRateDB, int64) {
+	filtered := rows[:0:0]
+	for _, row := range rows {
+		switch {
+		case q.Pair != "" && row.Pair != q.Pair,
+			!q.Since.IsZero() && row.Timestamp < q.Since.Unix(),
+			!q.Until.IsZero() && row.Timestamp >= q.Until.Unix(),
+			q.MinValue != nil && row.Bid.LessThan(*q.MinValue),
+			q.MaxValue != nil && row.Bid.GreaterThan(*q.MaxValue):
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+
+	slices.SortStableFunc(filtered, func(a, b USDToBRLRateDB) int {
+		var c int
+		switch q.Sort {
+		case "bid":
+			c = a.Bid.Cmp(b.Bid)
+		case "ask":
+			c = a.Ask.Cmp(b.Ask)
+		default:
+			c = cmp.Compare(a.Timestamp, b.Timestamp)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if q.Desc {
+			return -c
+		}
+		return c
+	})
+
+	total := int64(len(filtered))
+	start := min(q.offset(), len(filtered))
+	return filtered[start:min(start+q.PerPage, len(filtered))], total
+}
@@ -0,0 +1,165 @@
+// Comando loadtest gera carga contínua contra um endpoint do servidor e resume as latências
+// observadas, falhando quando o p99 ou a taxa de erros passam dos limites informados.
+//
+//	go run ./loadtest -url http://localhost:8080/cotacao -duration 30s -concurrency 50
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+type result struct {
+	latency time.Duration
+	status  int // 0 em erros de rede
+}
+
+func main() {
+	target := flag.String("url", "http://localhost:8080/cotacao", "endpoint testado")
+	duration := flag.Duration("duration", 30*time.Second, "duração do teste")
+	concurrency := flag.Int("concurrency", 20, "requisições simultâneas")
+	rate := flag.Int("rate", 0, "limite de requisições por segundo somando todos os workers; 0 não limita")
+	timeout := flag.Duration("timeout", 5*time.Second, "prazo de cada requisição")
+	token := flag.String("token", "", "JWT enviado em Authorization, para endpoints autenticados")
+	maxP99 := flag.Duration("max-p99", 0, "p99 máximo aceito; 0 não verifica")
+	maxErrors := flag.Float64("max-errors", 0.01, "fração máxima de respostas com erro (status >= 500 ou falha de rede)")
+	flag.Parse()
+
+	if *concurrency < 1 {
+		log.Fatal("concurrency deve ser positivo")
+	}
+
+	client := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	// Com -rate, os workers retiram uma ficha por requisição
+	var tokens <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	var mu sync.Mutex
+	var results []result
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range *concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []result
+			for {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-ctx.Done():
+					}
+				}
+				if ctx.Err() != nil {
+					break
+				}
+				local = append(local, send(client, *target, *token))
+			}
+			mu.Lock()
+			results = append(results, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if len(results) == 0 {
+		log.Fatal("nenhuma requisição concluída")
+	}
+	p99, errRate := report(os.Stdout, results, elapsed)
+
+	failed := false
+	if *maxP99 > 0 && p99 > *maxP99 {
+		fmt.Printf("FALHA: p99 de %s acima do limite de %s\n", p99, *maxP99)
+		failed = true
+	}
+	if errRate > *maxErrors {
+		fmt.Printf("FALHA: %.2f%% de erros, acima do limite de %.2f%%\n", errRate*100, *maxErrors*100)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// send faz uma requisição; falhas de rede e prazos vencidos ficam com status 0. As requisições
+// em andamento ao fim do teste são concluídas e contadas
+func send(client *http.Client, target, token string) result {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{latency: time.Since(start)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return result{latency: time.Since(start), status: resp.StatusCode}
+}
+
+// report imprime o resumo e retorna o p99 e a fração de erros
+func report(w io.Writer, results []result, elapsed time.Duration) (time.Duration, float64) {
+	latencies := make([]time.Duration, len(results))
+	statuses := make(map[int]int)
+	var errors int
+	for i, r := range results {
+		latencies[i] = r.latency
+		statuses[r.status]++
+		if r.status == 0 || r.status >= 500 {
+			errors++
+		}
+	}
+	slices.Sort(latencies)
+
+	fmt.Fprintf(w, "Requisições: %d em %s (%.1f req/s)\n", len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds())
+	fmt.Fprintf(w, "Latência: min %s  p50 %s  p90 %s  p99 %s  max %s\n",
+		latencies[0], percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99), latencies[len(latencies)-1])
+
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	fmt.Fprint(w, "Status:")
+	for _, code := range codes {
+		label := fmt.Sprint(code)
+		if code == 0 {
+			label = "erro de rede"
+		}
+		fmt.Fprintf(w, "  %s: %d", label, statuses[code])
+	}
+	fmt.Fprintln(w)
+
+	return percentile(latencies, 99), float64(errors) / float64(len(results))
+}
+
+// percentile usa o método do ranque mais próximo sobre as latências já ordenadas
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/i18n"
+	"golang.org/x/text/language"
+)
+
+// requestLanguage escolhe o idioma da resposta a partir de Accept-Language (pt-BR por
+// padrão, como no AwesomeAPI) e o informa nos cabeçalhos
+func requestLanguage(w http.ResponseWriter, r *http.Request) language.Tag {
+	lang := i18n.Match(r.Header.Get("Accept-Language"))
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", lang.String())
+	return lang
+}
+
+// localizeQuote substitui o nome informado pelo provedor pelo nome das moedas no idioma;
+// moedas fora do conjunto embutido mantêm o nome do provedor
+func localizeQuote(quote Quote, lang language.Tag) Quote {
+	base, okBase := i18n.CurrencyName(quote.Code, lang)
+	counter, okCounter := i18n.CurrencyName(quote.Codein, lang)
+	if okBase && okCounter {
+		quote.Name = base + "/" + counter
+	}
+	return quote
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	cfg = config.Load()
+	cfg.JWTSecret = "test-secret"
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testDBs numera os bancos em memória, para que cada teste tenha o seu
+var testDBs atomic.Int64
+
+// newTestDB abre um SQLite em memória exclusivo do teste, com as migrações aplicadas, e
+// reinicia o estado do pacote que depende do banco (pares, cache, cota)
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	driver, _, err := sqliteDriver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTestDBWith(t, driver)
+}
+
+// newTestDBWith é o newTestDB com o driver SQLite informado; pula o teste quando o driver não
+// foi compilado no binário
+func newTestDBWith(t testing.TB, driver string) *gorm.DB {
+	t.Helper()
+
+	info, ok := sqliteDrivers[driver]
+	if !ok {
+		t.Skipf("driver SQLite %s indisponível neste build", driver)
+	}
+	dsn := fmt.Sprintf("file:test%d?mode=memory&cache=shared", testDBs.Add(1))
+	conn, err := gorm.Open(info.open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uma única conexão evita "database table is locked" no cache compartilhado
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	m, err := newMigratorFor(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMigrations(m); err != nil {
+		t.Fatal(err)
+	}
+
+	db = conn
+	rateRepo = &gormRateRepository{db: conn}
+	batcher = nil
+	memoryOnly = false
+	cache = &quoteCache{entries: make(map[string]cachedQuote)}
+	quota = &quotaTracker{provider: awesomeAPIProvider}
+	pairs = &pairRegistry{pairs: make(map[string]PairDB)}
+	if err := pairs.load(); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// withConfig altera a configuração durante o teste, restaurando-a ao final
+func withConfig(t testing.TB, change func(c *config.Config)) {
+	t.Helper()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+	change(cfg)
+}
+
+// newTestUpstream sobe um AwesomeAPI falso e o torna o único provedor
+func newTestUpstream(t testing.TB, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	withConfig(t, func(c *config.Config) { c.AwesomeAPIBaseURL = srv.URL })
+	savedClient, savedChain := upstreamClient, providerChain
+	t.Cleanup(func() { upstreamClient, providerChain = savedClient, savedChain })
+	upstreamClient = srv.Client()
+	providerChain = []RateProvider{awesomeAPI{}}
+	return srv
+}
+
+// testQuote é uma cotação válida de USD-BRL no formato do AwesomeAPI
+func testQuote(bid string, at time.Time) Quote {
+	return Quote{
+		Code: "USD", Codein: "BRL", Name: "Dólar Americano/Real Brasileiro",
+		High: "5.9", Low: "5.7", VarBid: "0.01", PctChange: "0.2",
+		Bid: bid, Ask: bid, Timestamp: strconv.FormatInt(at.Unix(), 10),
+		CreateDate: at.Format(time.DateTime),
+	}
+}
+
+// lastHandler responde /last/{par} com a cotação informada
+func lastHandler(pair string, quote Quote) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/last/"+pair {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ExchangeRate{domain.PairKey(pair): quote})
+	}
+}
+
+// serve executa a requisição no handler e confere o status. ok indica uma resposta 2xx,
+// cujo corpo o teste ainda confere; nas demais o status é tudo o que há para conferir
+func serve(t testing.TB, handler http.HandlerFunc, method, target, body string, wantStatus int) (rec *httptest.ResponseRecorder, ok bool) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(method, target, reader))
+	if rec.Code != wantStatus {
+		t.Fatalf("status = %d, esperado %d: %s", rec.Code, wantStatus, rec.Body)
+	}
+	return rec, rec.Code >= 200 && rec.Code < 300
+}
+
+// decodeJSON decodifica o corpo JSON da resposta
+func decodeJSON[T any](t testing.TB, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("resposta inválida %s: %v", rec.Body, err)
+	}
+	return v
+}
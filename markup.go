@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	markupFixed   = "fixed"
+	markupPercent = "percent"
+)
+
+type PriceQuote struct {
+	Bid decimal.Decimal `json:"bid"`
+	Ask decimal.Decimal `json:"ask"`
+}
+
+type Markup struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+type InternalRateResponse struct {
+	Pair      string     `json:"pair"`
+	Market    PriceQuote `json:"market"`
+	Internal  PriceQuote `json:"internal"`
+	Markup    Markup     `json:"markup"`
+	Timestamp int64      `json:"timestamp"`
+}
+
+// applyMarkup alarga o spread: a venda (ask) sobe e a compra (bid) desce pelo markup configurado
+func applyMarkup(market PriceQuote, m Markup) PriceQuote {
+	value := decimal.NewFromFloat(m.Value)
+
+	var internal PriceQuote
+	switch m.Type {
+	case markupFixed:
+		internal = PriceQuote{Bid: market.Bid.Sub(value), Ask: market.Ask.Add(value)}
+	default:
+		factor := value.Div(decimal.NewFromInt(100))
+		internal = PriceQuote{Bid: market.Bid.Mul(decimal.NewFromInt(1).Sub(factor)), Ask: market.Ask.Mul(decimal.NewFromInt(1).Add(factor))}
+	}
+	return PriceQuote{Bid: decimal.Max(internal.Bid, decimal.Zero).Round(moneyScale), Ask: internal.Ask.Round(moneyScale)}
+}
+
+// InternalRateHandler retorna a cotação de mercado e a taxa interna com o markup aplicado
+func InternalRateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	pair, quote, ok := quoteForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	setQuoteCacheHeaders(w, pair, quote)
+	markup := Markup{Type: cfg.MarkupType, Value: cfg.MarkupValue}
+	market := PriceQuote{Bid: parseDecimal(quote.Bid), Ask: parseDecimal(quote.Ask)}
+	writeJSON(w, http.StatusOK, InternalRateResponse{
+		Pair:      pair,
+		Market:    market,
+		Internal:  applyMarkup(market, markup),
+		Markup:    markup,
+		Timestamp: quoteTimestamp(quote),
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latência das requisições HTTP por rota e status.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .2, .3, .5, 1, 2.5},
+	}, []string{"route", "method", "status"})
+
+	dbWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_writes_total",
+		Help: "Gravações de cotações no banco por resultado (ok, queued, timeout, error, disabled).",
+	}, []string{"result"})
+
+	honeypotHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "honeypot_hits_total",
+		Help: "Requisições a caminhos típicos de scanners, respondidas antes do pipeline normal.",
+	}, []string{"pattern"})
+)
+
+// MetricsMiddleware registra a latência por rota; caminhos não registrados no mux
+// são agrupados em "other" para limitar a cardinalidade
+func MetricsMiddleware(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		mux.ServeHTTP(rec, r)
+		httpRequestDuration.WithLabelValues(routeOf(mux, r), r.Method, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeOf retorna o padrão do mux que atende r, ou "other" para caminhos não registrados
+// (o padrão "/" do painel casa com todos eles)
+func routeOf(mux *http.ServeMux, r *http.Request) string {
+	if _, pattern := mux.Handler(r); pattern != "" && (pattern != "/" || r.URL.Path == "/") {
+		return pattern
+	}
+	return "other"
+}
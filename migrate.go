@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/guilhermeayusso/goexpert/desafio/1/migrations"
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+func newMigrator() (*migrate.Migrate, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return newMigratorFor(sqlDB)
+}
+
+func newMigratorFor(sqlDB *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := newSQLiteMigrations(sqlDB)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", source, "sqlite", driver)
+}
+
+// expectedSchemaVersion retorna a versão da última migração embutida no binário
+func expectedSchemaVersion() (uint, error) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		latest = max(latest, uint(v))
+	}
+	return latest, nil
+}
+
+func runMigrations(m *migrate.Migrate) error {
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// checkSchemaVersion recusa schemas sujos, desatualizados ou mais novos que o binário
+func checkSchemaVersion(m *migrate.Migrate) error {
+	expected, err := expectedSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("banco sem migrações aplicadas; execute \"migrate up\" (versão esperada %d)", expected)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("schema na versão %d está sujo (migração interrompida); corrija e execute \"migrate force %d\"", version, version)
+	case version < expected:
+		return fmt.Errorf("schema na versão %d, esperada %d; execute \"migrate up\"", version, expected)
+	case version > expected:
+		return fmt.Errorf("schema na versão %d é mais novo que o suportado por este binário (%d)", version, expected)
+	}
+	return nil
+}
+
+// migrateCommand implementa o subcomando "migrate up|down [n]|version|force <versão>"
+func migrateCommand(args []string) {
+	m, err := newMigrator()
+	if err != nil {
+		log.Fatal("failed to create migrator: ", err)
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "uso: migrate up | down [n] | version | force <versão>")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "up":
+		err = runMigrations(m)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				log.Fatal("número de passos inválido: ", args[1])
+			}
+		}
+		err = m.Steps(-steps)
+	case "force":
+		if len(args) < 2 {
+			log.Fatal("informe a versão: migrate force <versão>")
+		}
+		v, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			log.Fatal("versão inválida: ", args[1])
+		}
+		err = m.Force(v)
+	case "version":
+	default:
+		log.Fatalf("subcomando de migração desconhecido: %s", args[0])
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		log.Fatal("migration failed: ", err)
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		log.Println("Nenhuma migração aplicada.")
+		return
+	}
+	if err != nil {
+		log.Fatal("failed to read schema version: ", err)
+	}
+	log.Printf("Schema na versão %d (sujo: %t)", version, dirty)
+}
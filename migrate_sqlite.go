@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// Tabela de versão do golang-migrate, a mesma do driver sqlite3 dele, para que bancos
+// migrados antes continuem reconhecidos
+const migrationsTable = "schema_migrations"
+
+// sqliteMigrations aplica as migrações sobre a conexão já aberta, com qualquer um dos drivers
+// SQLite. Os drivers sqlite3 e sqlite do golang-migrate importam o mattn/go-sqlite3 e o
+// modernc.org/sqlite, que levariam o CGO ao build em Go puro ou registrariam o driver "sqlite"
+// duas vezes; este segue o mesmo comportamento, cada migração em uma transação
+type sqliteMigrations struct {
+	db     *sql.DB
+	locked atomic.Bool
+}
+
+func newSQLiteMigrations(db *sql.DB) (*sqliteMigrations, error) {
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (version uint64,dirty bool);
+CREATE UNIQUE INDEX IF NOT EXISTS version_unique ON ` + migrationsTable + ` (version);`)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteMigrations{db: db}, nil
+}
+
+// Open não é usado: o migrator recebe a conexão por NewWithInstance
+func (m *sqliteMigrations) Open(string) (database.Driver, error) {
+	return nil, errors.New("abra o banco antes e use newSQLiteMigrations")
+}
+
+// Close não fecha a conexão, que é do GORM
+func (m *sqliteMigrations) Close() error { return nil }
+
+func (m *sqliteMigrations) Lock() error {
+	if !m.locked.CompareAndSwap(false, true) {
+		return database.ErrLocked
+	}
+	return nil
+}
+
+func (m *sqliteMigrations) Unlock() error {
+	if !m.locked.CompareAndSwap(true, false) {
+		return database.ErrNotLocked
+	}
+	return nil
+}
+
+func (m *sqliteMigrations) Run(migration io.Reader) error {
+	query, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	return m.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(string(query)); err != nil {
+			return &database.Error{OrigErr: err, Query: query}
+		}
+		return nil
+	})
+}
+
+func (m *sqliteMigrations) SetVersion(version int, dirty bool) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM " + migrationsTable); err != nil {
+			return err
+		}
+		// Como no golang-migrate, a versão nula suja também é gravada, para que uma migração
+		// down da primeira versão que falhe não deixe o banco sem versão
+		if version >= 0 || (version == database.NilVersion && dirty) {
+			_, err := tx.Exec("INSERT INTO "+migrationsTable+" (version, dirty) VALUES (?, ?)", version, dirty)
+			return err
+		}
+		return nil
+	})
+}
+
+func (m *sqliteMigrations) Version() (version int, dirty bool, err error) {
+	err = m.db.QueryRow("SELECT version, dirty FROM "+migrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// Drop apaga todas as tabelas
+func (m *sqliteMigrations) Drop() error {
+	rows, err := m.db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if _, err := m.db.Exec(fmt.Sprintf("DROP TABLE `%s`", table)); err != nil {
+			return err
+		}
+	}
+	_, err = m.db.Exec("VACUUM")
+	return err
+}
+
+func (m *sqliteMigrations) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `usd_to_brl_rate_dbs`;
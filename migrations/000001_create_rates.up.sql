@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `usd_to_brl_rate_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `code` varchar(10) NOT NULL,
+    `bid` decimal(10,4) NOT NULL,
+    `ask` decimal(10,4) NOT NULL,
+    `timestamp` integer NOT NULL,
+    `create_date` datetime NOT NULL
+);
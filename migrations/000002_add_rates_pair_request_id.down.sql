@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_request_id`;
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_pair`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `request_id`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `pair`;
@@ -0,0 +1,4 @@
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `pair` varchar(21) NOT NULL DEFAULT "USD-BRL";
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `request_id` varchar(128);
+CREATE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_pair` ON `usd_to_brl_rate_dbs`(`pair`);
+CREATE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_request_id` ON `usd_to_brl_rate_dbs`(`request_id`);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `access_log_dbs`;
+DROP TABLE IF EXISTS `user_dbs`;
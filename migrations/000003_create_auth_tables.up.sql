@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `user_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `email` varchar(255) NOT NULL,
+    `password_hash` text NOT NULL,
+    `is_admin` numeric NOT NULL DEFAULT false,
+    `created_at` datetime NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_user_dbs_email` ON `user_dbs`(`email`);
+
+CREATE TABLE IF NOT EXISTS `access_log_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `method` varchar(10) NOT NULL,
+    `path` varchar(255) NOT NULL,
+    `ip` varchar(45),
+    `country` varchar(2),
+    `asn` integer,
+    `as_org` varchar(255),
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_access_log_dbs_user_id` ON `access_log_dbs`(`user_id`);
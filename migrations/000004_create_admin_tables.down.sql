@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `notification_dbs`;
+DROP TABLE IF EXISTS `discovered_pair_dbs`;
+DROP TABLE IF EXISTS `pair_dbs`;
+DROP TABLE IF EXISTS `provider_usage_dbs`;
+DROP TABLE IF EXISTS `ban_dbs`;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS `ban_dbs` (
+    `ip` varchar(45),
+    `reason` varchar(255) NOT NULL,
+    `expires_at` datetime NOT NULL,
+    `created_at` datetime NOT NULL,
+    PRIMARY KEY (`ip`)
+);
+CREATE INDEX IF NOT EXISTS `idx_ban_dbs_expires_at` ON `ban_dbs`(`expires_at`);
+
+CREATE TABLE IF NOT EXISTS `provider_usage_dbs` (
+    `provider` varchar(50),
+    `day` varchar(10),
+    `calls` integer NOT NULL,
+    PRIMARY KEY (`provider`, `day`)
+);
+
+CREATE TABLE IF NOT EXISTS `pair_dbs` (
+    `symbol` varchar(21),
+    `enabled` numeric NOT NULL DEFAULT true,
+    `created_at` datetime NOT NULL,
+    `updated_at` datetime NOT NULL,
+    PRIMARY KEY (`symbol`)
+);
+
+CREATE TABLE IF NOT EXISTS `discovered_pair_dbs` (
+    `symbol` varchar(21),
+    `name` varchar(255),
+    `discovered_at` datetime NOT NULL,
+    PRIMARY KEY (`symbol`)
+);
+
+CREATE TABLE IF NOT EXISTS `notification_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `kind` varchar(50) NOT NULL,
+    `message` text NOT NULL,
+    `read` numeric NOT NULL DEFAULT false,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_notification_dbs_kind` ON `notification_dbs`(`kind`);
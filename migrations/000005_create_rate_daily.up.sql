@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `rate_daily_dbs` (
+    `pair` varchar(21) NOT NULL,
+    `day` varchar(10) NOT NULL,
+    `open_bid` decimal(10,4) NOT NULL,
+    `high_bid` decimal(10,4) NOT NULL,
+    `low_bid` decimal(10,4) NOT NULL,
+    `close_bid` decimal(10,4) NOT NULL,
+    `avg_bid` decimal(10,4) NOT NULL,
+    `avg_ask` decimal(10,4) NOT NULL,
+    `samples` integer NOT NULL,
+    PRIMARY KEY (`pair`, `day`)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `trade_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `pair` varchar(21) NOT NULL,
+    `side` varchar(4) NOT NULL,
+    `amount` decimal(18,4) NOT NULL,
+    `rate` decimal(10,4) NOT NULL,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_trade_dbs_user_id` ON `trade_dbs`(`user_id`);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `ptax_rate_dbs`;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `ptax_rate_dbs` (
+    `currency` varchar(10) NOT NULL,
+    `date` varchar(10) NOT NULL,
+    `buy` decimal(10,4) NOT NULL,
+    `sell` decimal(10,4) NOT NULL,
+    `quoted_at` datetime NOT NULL,
+    `created_at` datetime NOT NULL,
+    PRIMARY KEY (`currency`, `date`)
+);
@@ -0,0 +1,2 @@
+-- O arredondamento não é reversível; nada a desfazer
+SELECT 1;
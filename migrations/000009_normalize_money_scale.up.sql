@@ -0,0 +1,9 @@
+-- Valores gravados como float64 podem ter ruído além da 4ª casa (ex.: 5.80499999);
+-- com decimal no código, normaliza as linhas existentes para a escala das colunas
+UPDATE `usd_to_brl_rate_dbs` SET `bid` = ROUND(`bid`, 4), `ask` = ROUND(`ask`, 4);
+UPDATE `rate_daily_dbs` SET `open_bid` = ROUND(`open_bid`, 4), `high_bid` = ROUND(`high_bid`, 4), `low_bid` = ROUND(`low_bid`, 4),
+    `close_bid` = ROUND(`close_bid`, 4), `avg_bid` = ROUND(`avg_bid`, 4), `avg_ask` = ROUND(`avg_ask`, 4);
+UPDATE `rate_hourly_dbs` SET `open_bid` = ROUND(`open_bid`, 4), `high_bid` = ROUND(`high_bid`, 4), `low_bid` = ROUND(`low_bid`, 4),
+    `close_bid` = ROUND(`close_bid`, 4), `avg_bid` = ROUND(`avg_bid`, 4), `avg_ask` = ROUND(`avg_ask`, 4);
+UPDATE `trade_dbs` SET `amount` = ROUND(`amount`, 4), `rate` = ROUND(`rate`, 4);
+UPDATE `ptax_rate_dbs` SET `buy` = ROUND(`buy`, 4), `sell` = ROUND(`sell`, 4);
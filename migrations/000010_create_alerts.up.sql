@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `alert_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `pair` varchar(21) NOT NULL,
+    `condition` varchar(20) NOT NULL,
+    `threshold` decimal(10,4) NOT NULL,
+    `cooldown` integer NOT NULL DEFAULT 0,
+    `webhook_url` varchar(2048) NOT NULL,
+    `secret` varchar(128) NOT NULL,
+    `last_fired_at` datetime,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_alert_dbs_user_id` ON `alert_dbs`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_alert_dbs_pair` ON `alert_dbs`(`pair`);
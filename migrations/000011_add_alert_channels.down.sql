@@ -0,0 +1,2 @@
+ALTER TABLE `alert_dbs` DROP COLUMN `target`;
+ALTER TABLE `alert_dbs` DROP COLUMN `channel`;
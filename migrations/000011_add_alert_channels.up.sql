@@ -0,0 +1,2 @@
+ALTER TABLE `alert_dbs` ADD COLUMN `channel` varchar(20) NOT NULL DEFAULT 'webhook';
+ALTER TABLE `alert_dbs` ADD COLUMN `target` varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE `alert_dbs` DROP COLUMN `updated_at`;
+ALTER TABLE `alert_dbs` DROP COLUMN `enabled`;
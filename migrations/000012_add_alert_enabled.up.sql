@@ -0,0 +1,2 @@
+ALTER TABLE `alert_dbs` ADD COLUMN `enabled` numeric NOT NULL DEFAULT true;
+ALTER TABLE `alert_dbs` ADD COLUMN `updated_at` datetime;
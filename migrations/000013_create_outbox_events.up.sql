@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `outbox_event_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `topic` varchar(50) NOT NULL,
+    `pair` varchar(21) NOT NULL,
+    `payload` text NOT NULL,
+    `attempts` integer NOT NULL DEFAULT 0,
+    `created_at` datetime NOT NULL,
+    `dispatched_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_outbox_event_dbs_dispatched_at` ON `outbox_event_dbs`(`dispatched_at`);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS `idx_outbox_event_dbs_uid`;
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_uid`;
+ALTER TABLE `outbox_event_dbs` DROP COLUMN `uid`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `uid`;
@@ -0,0 +1,18 @@
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `uid` varchar(36);
+ALTER TABLE `outbox_event_dbs` ADD COLUMN `uid` varchar(36);
+
+-- Linhas existentes recebem UUIDv7 derivados do próprio horário (48 bits de milissegundos,
+-- versão 7, variante 10 e o restante aleatório), preservando a ordem temporal dos IDs
+UPDATE `usd_to_brl_rate_dbs` SET `uid` = (
+    SELECT substr(t, 1, 8) || '-' || substr(t, 9, 4) || '-7' || substr(r, 1, 3) || '-' ||
+           substr('89ab', 1 + abs(random()) % 4, 1) || substr(r, 4, 3) || '-' || substr(r, 7, 12)
+    FROM (SELECT printf('%012x', `timestamp` * 1000) AS t, lower(hex(randomblob(16))) AS r)
+) WHERE `uid` IS NULL;
+UPDATE `outbox_event_dbs` SET `uid` = (
+    SELECT substr(t, 1, 8) || '-' || substr(t, 9, 4) || '-7' || substr(r, 1, 3) || '-' ||
+           substr('89ab', 1 + abs(random()) % 4, 1) || substr(r, 4, 3) || '-' || substr(r, 7, 12)
+    FROM (SELECT printf('%012x', CAST(strftime('%s', `created_at`) AS integer) * 1000) AS t, lower(hex(randomblob(16))) AS r)
+) WHERE `uid` IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_uid` ON `usd_to_brl_rate_dbs`(`uid`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_outbox_event_dbs_uid` ON `outbox_event_dbs`(`uid`);
@@ -0,0 +1,2 @@
+-- Só remove o índice único: as gravações repetidas apagadas pela migração up não voltam
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp`;
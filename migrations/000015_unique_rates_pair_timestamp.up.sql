@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp`;
+-- A mesma cotação do provedor (par e timestamp) podia ser gravada várias vezes; mantém a
+-- primeira gravação de cada uma antes de criar o índice único.
+-- DESTRUTIVO: as gravações repetidas são apagadas e a migração down não as restaura. Elas
+-- repetem par e timestamp da gravação mantida, mas podem diferir em bid/ask, origem e
+-- auditoria; para conservá-las, copie-as antes de migrar com
+--   SELECT * FROM `usd_to_brl_rate_dbs` WHERE `id` NOT IN (
+--       SELECT MIN(`id`) FROM `usd_to_brl_rate_dbs` GROUP BY `pair`, `timestamp`);
+DELETE FROM `usd_to_brl_rate_dbs` WHERE `id` NOT IN (
+    SELECT MIN(`id`) FROM `usd_to_brl_rate_dbs` GROUP BY `pair`, `timestamp`
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp` ON `usd_to_brl_rate_dbs`(`pair`, `timestamp`);
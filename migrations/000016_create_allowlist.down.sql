@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `allowlist_entry_dbs`;
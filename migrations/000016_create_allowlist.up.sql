@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `allowlist_entry_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `kind` varchar(10) NOT NULL,
+    `value` varchar(64) NOT NULL,
+    `note` varchar(255) NOT NULL DEFAULT '',
+    `created_at` datetime NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_allowlist_entry_dbs_value` ON `allowlist_entry_dbs`(`value`);
@@ -0,0 +1,2 @@
+ALTER TABLE `pair_dbs` DROP COLUMN `poll_interval`;
+ALTER TABLE `pair_dbs` DROP COLUMN `provider`;
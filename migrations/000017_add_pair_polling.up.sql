@@ -0,0 +1,2 @@
+ALTER TABLE `pair_dbs` ADD COLUMN `provider` varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE `pair_dbs` ADD COLUMN `poll_interval` integer NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `quarantined_rate_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `pair` varchar(21) NOT NULL,
+    `kind` varchar(10) NOT NULL,
+    `reason` varchar(255) NOT NULL,
+    `payload` text NOT NULL,
+    `request_id` varchar(128),
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_quarantined_rate_dbs_created_at` ON `quarantined_rate_dbs`(`created_at`);
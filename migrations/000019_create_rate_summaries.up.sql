@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `rate_summary_dbs` (
+    `pair` varchar(21) NOT NULL,
+    `resolution` varchar(3) NOT NULL,
+    `bucket` integer NOT NULL,
+    `count` integer NOT NULL,
+    `min_bid` decimal(10,4) NOT NULL,
+    `max_bid` decimal(10,4) NOT NULL,
+    `sum_bid` decimal(18,4) NOT NULL,
+    PRIMARY KEY (`pair`, `resolution`, `bucket`)
+);
+INSERT INTO `rate_summary_dbs` (`pair`, `resolution`, `bucket`, `count`, `min_bid`, `max_bid`, `sum_bid`)
+SELECT `pair`, '1m', `timestamp` / 60 * 60, COUNT(*), MIN(`bid`), MAX(`bid`), SUM(`bid`)
+FROM `usd_to_brl_rate_dbs` GROUP BY `pair`, `timestamp` / 60;
+INSERT INTO `rate_summary_dbs` (`pair`, `resolution`, `bucket`, `count`, `min_bid`, `max_bid`, `sum_bid`)
+SELECT `pair`, '5m', `timestamp` / 300 * 300, COUNT(*), MIN(`bid`), MAX(`bid`), SUM(`bid`)
+FROM `usd_to_brl_rate_dbs` GROUP BY `pair`, `timestamp` / 300;
+INSERT INTO `rate_summary_dbs` (`pair`, `resolution`, `bucket`, `count`, `min_bid`, `max_bid`, `sum_bid`)
+SELECT `pair`, '1h', `timestamp` / 3600 * 3600, COUNT(*), MIN(`bid`), MAX(`bid`), SUM(`bid`)
+FROM `usd_to_brl_rate_dbs` GROUP BY `pair`, `timestamp` / 3600;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `audit_log_dbs`;
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_deleted_at`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `deleted_at`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `provider`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `source`;
+ALTER TABLE `usd_to_brl_rate_dbs` DROP COLUMN `created_by`;
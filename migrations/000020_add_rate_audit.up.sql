@@ -0,0 +1,17 @@
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `created_by` varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `source` varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `provider` varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE `usd_to_brl_rate_dbs` ADD COLUMN `deleted_at` datetime;
+CREATE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_deleted_at` ON `usd_to_brl_rate_dbs`(`deleted_at`);
+CREATE TABLE IF NOT EXISTS `audit_log_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `action` varchar(20) NOT NULL,
+    `actor` varchar(100) NOT NULL,
+    `request_id` varchar(128),
+    `pair` varchar(21),
+    `affected` integer NOT NULL,
+    `details` text,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_dbs_created_at` ON `audit_log_dbs`(`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_dbs_action` ON `audit_log_dbs`(`action`);
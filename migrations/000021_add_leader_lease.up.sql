@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `leader_lease_dbs` (
+    `name` varchar(50) PRIMARY KEY,
+    `holder` varchar(100) NOT NULL,
+    `acquired_at` datetime NOT NULL,
+    `expires_at` datetime NOT NULL
+);
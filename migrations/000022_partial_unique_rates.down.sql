@@ -0,0 +1,9 @@
+-- Sem a condição as cotações removidas voltam ao índice único; as removidas que repetem outra
+-- cotação do mesmo par e timestamp são apagadas de vez (destrutivo)
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp`;
+DELETE FROM `usd_to_brl_rate_dbs` WHERE `deleted_at` IS NOT NULL AND EXISTS (
+    SELECT 1 FROM `usd_to_brl_rate_dbs` AS o
+    WHERE o.`pair` = `usd_to_brl_rate_dbs`.`pair` AND o.`timestamp` = `usd_to_brl_rate_dbs`.`timestamp` AND o.`id` <> `usd_to_brl_rate_dbs`.`id`
+        AND (o.`deleted_at` IS NULL OR o.`id` < `usd_to_brl_rate_dbs`.`id`)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp` ON `usd_to_brl_rate_dbs`(`pair`, `timestamp`);
@@ -0,0 +1,4 @@
+-- As cotações removidas (deleted_at) deixam de ocupar o índice único, para que o backfill
+-- possa gravar de novo as cotações removidas pela deduplicação
+DROP INDEX IF EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp`;
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_usd_to_brl_rate_dbs_pair_timestamp` ON `usd_to_brl_rate_dbs`(`pair`, `timestamp`) WHERE `deleted_at` IS NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `outbox_intent_dbs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `rate_uid` varchar(36) NOT NULL,
+    `pair` varchar(21) NOT NULL,
+    `timestamp` integer NOT NULL,
+    `event_uid` varchar(36) NOT NULL,
+    `topic` varchar(50) NOT NULL,
+    `payload` text NOT NULL,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_outbox_intent_dbs_created_at` ON `outbox_intent_dbs`(`created_at`);
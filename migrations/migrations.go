@@ -0,0 +1,8 @@
+// Package migrations contém as migrações versionadas do schema, embutidas no binário.
+// Cada versão possui um arquivo NNNNNN_descricao.up.sql e o respectivo .down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/shopspring/decimal"
+)
+
+// Tópico de status, abaixo de MQTT_TOPIC: "online" ao conectar e "offline" ao encerrar ou,
+// pela mensagem de última vontade (LWT), quando o broker perde a conexão
+const mqttStatusTopic = "status"
+
+const mqttPublishTimeout = 5 * time.Second
+
+var errMQTTDisconnected = errors.New("broker MQTT desconectado")
+
+var mqttPublishes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "mqtt_publishes_total",
+	Help: "Bids enviados ao broker MQTT por resultado: published, unchanged (igual ao último publicado) ou error.",
+}, []string{"result"})
+
+// mqttTicker mantém no broker MQTT o bid mais recente de cada par, para mostradores (ESP32 e
+// afins) que assinam {MQTT_TOPIC}/{par} e recebem só o valor, em texto. As mensagens são
+// retidas: um mostrador que liga recebe na hora o último bid, sem esperar a próxima cotação.
+// O bid só é publicado quando muda; os que não saíram por queda da conexão são enviados ao
+// reconectar
+type mqttTicker struct {
+	client mqtt.Client
+	topic  string
+	qos    byte
+
+	latest      map[string]string // último bid recebido de cada par
+	sent        map[string]string // último bid confirmado pelo broker
+	reconnected chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// mqttDisplay é nil quando MQTT_BROKER está vazio
+var mqttDisplay *mqttTicker
+
+func newMQTTTicker() (*mqttTicker, error) {
+	if cfg.MQTTBroker == "" {
+		return nil, nil
+	}
+	if u, err := url.Parse(cfg.MQTTBroker); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("MQTT_BROKER deve ser uma URL como tcp://host:1883")
+	}
+	if cfg.MQTTQoS < 0 || cfg.MQTTQoS > 2 {
+		return nil, fmt.Errorf("MQTT_QOS deve ser 0, 1 ou 2")
+	}
+
+	t := newTicker(cfg.MQTTTopic, byte(cfg.MQTTQoS))
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.MQTTBroker).
+		SetClientID(cfg.MQTTClientID).
+		SetUsername(cfg.MQTTUsername).
+		SetPassword(cfg.MQTTPassword).
+		SetWill(t.statusTopic(), "offline", t.qos, true).
+		// Sem o broker a inicialização continua; a conexão é tentada de novo em segundo plano
+		SetConnectRetry(true).
+		SetAutoReconnect(true).
+		SetOnConnectHandler(func(mqtt.Client) { t.notifyConnected() }).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("Conexão com o broker MQTT perdida: %v", err)
+		})
+	t.client = mqtt.NewClient(opts)
+	return t, nil
+}
+
+func newTicker(topic string, qos byte) *mqttTicker {
+	return &mqttTicker{
+		topic:       topic,
+		qos:         qos,
+		latest:      make(map[string]string),
+		sent:        make(map[string]string),
+		reconnected: make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+func (t *mqttTicker) statusTopic() string {
+	return t.topic + "/" + mqttStatusTopic
+}
+
+// notifyConnected é chamado pelo cliente MQTT a cada conexão; as publicações ficam na
+// goroutine de start, que é a dona dos mapas
+func (t *mqttTicker) notifyConnected() {
+	select {
+	case t.reconnected <- struct{}{}:
+	default:
+	}
+}
+
+// start conecta ao broker e passa a acompanhar as cotações gravadas, como um assinante de
+// Subscription.quotes
+func (t *mqttTicker) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	feed := liveQuotes.subscribe(nil)
+	t.client.Connect()
+
+	go func() {
+		defer close(t.done)
+		defer liveQuotes.unsubscribe(feed)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case q := <-feed:
+				t.observe(q.Pair, q.Quote.Bid)
+			case <-t.reconnected:
+				t.connected()
+			}
+		}
+	}()
+}
+
+// connected anuncia o status e publica os bids que mudaram enquanto a conexão estava fora
+func (t *mqttTicker) connected() {
+	if err := t.publish(t.statusTopic(), "online"); err != nil {
+		log.Printf("Erro ao publicar o status no MQTT: %v", err)
+	}
+	for pair, bid := range t.latest {
+		if t.sent[pair] != bid {
+			t.send(pair, bid)
+		}
+	}
+}
+
+func (t *mqttTicker) observe(pair, bid string) {
+	// Provedores diferentes escrevem o mesmo valor de formas diferentes (5.12 e 5.1200)
+	if d, err := decimal.NewFromString(bid); err == nil {
+		bid = d.String()
+	}
+	t.latest[pair] = bid
+	if t.sent[pair] == bid {
+		mqttPublishes.WithLabelValues("unchanged").Inc()
+		return
+	}
+	t.send(pair, bid)
+}
+
+func (t *mqttTicker) send(pair, bid string) {
+	err := t.publish(t.topic+"/"+pair, bid)
+	if err != nil {
+		mqttPublishes.WithLabelValues("error").Inc()
+		// A queda da conexão já foi registrada; o bid sai ao reconectar
+		if !errors.Is(err, errMQTTDisconnected) {
+			log.Printf("Erro ao publicar o bid de %s no MQTT: %v", pair, err)
+		}
+		return
+	}
+	t.sent[pair] = bid
+	mqttPublishes.WithLabelValues("published").Inc()
+}
+
+// publish envia uma mensagem retida e aguarda a confirmação; desconectado, falha sem deixar
+// a mensagem na fila do cliente, para não publicar um bid antigo depois do atual
+func (t *mqttTicker) publish(topic, payload string) error {
+	if !t.client.IsConnectionOpen() {
+		return errMQTTDisconnected
+	}
+	token := t.client.Publish(topic, t.qos, true, payload)
+	if !token.WaitTimeout(mqttPublishTimeout) {
+		return fmt.Errorf("broker MQTT não confirmou a publicação em %s", mqttPublishTimeout)
+	}
+	return token.Error()
+}
+
+// close para de acompanhar as cotações e anuncia "offline" antes de desconectar: a mensagem
+// de última vontade só é enviada pelo broker quando a conexão cai
+func (t *mqttTicker) close() error {
+	t.cancel()
+	<-t.done
+	err := t.publish(t.statusTopic(), "offline")
+	t.client.Disconnect(250)
+	if errors.Is(err, errMQTTDisconnected) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+type fakeToken struct{ err error }
+
+func (t fakeToken) Wait() bool                     { return true }
+func (t fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (t fakeToken) Done() <-chan struct{}          { ch := make(chan struct{}); close(ch); return ch }
+func (t fakeToken) Error() error                   { return t.err }
+
+// fakeMQTTClient registra as publicações como "tópico=valor"; os demais métodos do cliente
+// não são usados pelo mqttTicker
+type fakeMQTTClient struct {
+	mqtt.Client
+	connected bool
+	fail      bool
+	published []string
+}
+
+func (c *fakeMQTTClient) IsConnectionOpen() bool { return c.connected }
+
+func (c *fakeMQTTClient) Publish(topic string, qos byte, retained bool, payload any) mqtt.Token {
+	if c.fail {
+		return fakeToken{err: errors.New("not authorized")}
+	}
+	if !retained || qos != 1 {
+		return fakeToken{err: errors.New("esperado retained com QoS 1")}
+	}
+	c.published = append(c.published, topic+"="+payload.(string))
+	return fakeToken{}
+}
+
+func TestMQTTTicker(t *testing.T) {
+	client := &fakeMQTTClient{}
+	ticker := newTicker("cotacoes", 1)
+	ticker.client = client
+
+	// Os passos dependem dos anteriores: o ticker guarda o último bid publicado
+	tests := []struct {
+		name          string
+		disconnected  bool
+		fail          bool
+		reconnect     bool
+		pair, bid     string
+		wantPublished []string
+	}{
+		{name: "primeiro bid", pair: "USD-BRL", bid: "5.1000",
+			wantPublished: []string{"cotacoes/USD-BRL=5.1"}},
+		{name: "bid igual não é publicado", pair: "USD-BRL", bid: "5.1"},
+		{name: "outro par", pair: "EUR-BRL", bid: "6.2",
+			wantPublished: []string{"cotacoes/EUR-BRL=6.2"}},
+		{name: "desconectado", disconnected: true, pair: "USD-BRL", bid: "5.2"},
+		{name: "reconexão publica status e bid pendente", reconnect: true,
+			wantPublished: []string{"cotacoes/status=online", "cotacoes/USD-BRL=5.2"}},
+		{name: "falha na publicação", fail: true, pair: "USD-BRL", bid: "5.3"},
+		{name: "bid repetido após falha", pair: "USD-BRL", bid: "5.3",
+			wantPublished: []string{"cotacoes/USD-BRL=5.3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.connected, client.fail, client.published = !tt.disconnected, tt.fail, nil
+			if tt.reconnect {
+				ticker.connected()
+			} else {
+				ticker.observe(tt.pair, tt.bid)
+			}
+			if strings.Join(client.published, ",") != strings.Join(tt.wantPublished, ",") {
+				t.Errorf("publicado %v, esperado %v", client.published, tt.wantPublished)
+			}
+		})
+	}
+}
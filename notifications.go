@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Notificações destinadas aos administradores, consultadas via /admin/notifications
+type NotificationDB struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kind      string    `gorm:"type:varchar(50);index;not null" json:"kind"`
+	Message   string    `gorm:"type:text;not null" json:"message"`
+	Read      bool      `gorm:"not null;default:false" json:"read"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+func notifyAdmins(kind, message string) {
+	log.Printf("Notificação (%s): %s", kind, message)
+	if err := db.Create(&NotificationDB{Kind: kind, Message: message}).Error; err != nil {
+		log.Printf("Erro ao registrar notificação: %v", err)
+	}
+}
+
+// NotificationsHandler lista as notificações não lidas (GET, ?all=true para todas)
+// ou marca uma notificação como lida (POST ?id=)
+func NotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := db.WithContext(r.Context()).Order("created_at desc").Limit(200)
+		if r.URL.Query().Get("all") != "true" {
+			query = query.Where("read = ?", false)
+		}
+
+		var notifications []NotificationDB
+		if err := query.Find(&notifications).Error; err != nil {
+			logf(r.Context(), "Erro ao consultar notificações: %v", err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		writeJSON(w, http.StatusOK, notifications)
+	case http.MethodPost:
+		id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "parâmetro id inválido")
+			return
+		}
+		if err := db.WithContext(r.Context()).Model(&NotificationDB{}).Where("id = ?", id).Update("read", true).Error; err != nil {
+			logf(r.Context(), "Erro ao marcar notificação %d como lida: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
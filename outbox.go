@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+// Evento gravado junto com cada cotação persistida; o payload é a Quote em JSON
+const topicRateSaved = "rate.saved"
+
+// Eventos lidos por ciclo do despachante e tentativas de entrega antes de o evento ser
+// deixado de lado (permanece na tabela, sem dispatched_at, para inspeção)
+const (
+	outboxBatchSize   = 100
+	outboxMaxAttempts = 10
+)
+
+var outboxDispatched = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "outbox_events_dispatched_total",
+	Help: "Eventos da outbox entregues aos consumidores por tópico e resultado.",
+}, []string{"topic", "result"})
+
+// OutboxEventDB é um evento gravado na mesma transação do dado que o originou; só eventos
+// confirmados no banco chegam aos consumidores
+type OutboxEventDB struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	UID          string `gorm:"type:varchar(36);uniqueIndex"`
+	Topic        string `gorm:"type:varchar(50);not null"`
+	Pair         string `gorm:"type:varchar(21);not null"`
+	Payload      string `gorm:"type:text;not null"`
+	Attempts     int    `gorm:"not null;default:0"`
+	CreatedAt    time.Time
+	DispatchedAt *time.Time `gorm:"index"`
+}
+
+func newRateEvent(pair string, quote *Quote) (OutboxEventDB, error) {
+	payload, err := json.Marshal(quote)
+	if err != nil {
+		return OutboxEventDB{}, err
+	}
+	return OutboxEventDB{UID: newID(), Topic: topicRateSaved, Pair: pair, Payload: string(payload)}, nil
+}
+
+// Idade mínima das intenções resolvidas pela recuperação, para não disputar com uma gravação
+// ainda em andamento
+const outboxIntentGrace = time.Minute
+
+// OutboxIntentDB é a intenção de gravar uma cotação em um backend fora do SQLite (memória,
+// Badger, Postgres), onde a cotação e o evento não cabem na mesma transação. A intenção é
+// gravada antes da cotação e trocada pelo evento depois dela; se o processo cair no meio, a
+// recuperação procura a cotação pelo uid e cria o evento ou descarta a intenção
+type OutboxIntentDB struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	RateUID   string    `gorm:"type:varchar(36);not null"`
+	Pair      string    `gorm:"type:varchar(21);not null"`
+	Timestamp int64     `gorm:"not null"`
+	EventUID  string    `gorm:"type:varchar(36);not null"`
+	Topic     string    `gorm:"type:varchar(50);not null"`
+	Payload   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"not null;index"`
+}
+
+func (i OutboxIntentDB) event() OutboxEventDB {
+	return OutboxEventDB{UID: i.EventUID, Topic: i.Topic, Pair: i.Pair, Payload: i.Payload}
+}
+
+// saveWithIntent grava a cotação no backend entre a intenção e o evento. Uma cotação repetida
+// descarta a intenção; nas demais falhas a cotação pode ter sido gravada mesmo assim (um prazo
+// esgotado no Postgres, por exemplo), e a intenção fica para a recuperação
+func saveWithIntent(ctx context.Context, row *USDToBRLRateDB, event *OutboxEventDB) error {
+	intent := OutboxIntentDB{
+		RateUID: row.UID, Pair: row.Pair, Timestamp: row.Timestamp,
+		EventUID: event.UID, Topic: event.Topic, Payload: event.Payload,
+	}
+	if err := db.WithContext(ctx).Create(&intent).Error; err != nil {
+		return err
+	}
+
+	err := rateRepo.Save(ctx, row)
+	ctx = context.WithoutCancel(ctx)
+	if errors.Is(err, errDuplicateRate) {
+		if err := db.WithContext(ctx).Delete(&intent).Error; err != nil {
+			logf(ctx, "Erro ao descartar a intenção %d da outbox: %v", intent.ID, err)
+		}
+		return errDuplicateRate
+	}
+	if err != nil {
+		return err
+	}
+
+	// A cotação já está gravada: uma falha aqui fica para a recuperação, que cria o evento
+	if err := confirmIntent(ctx, intent, row); err != nil {
+		logf(ctx, "Erro ao gravar o evento da cotação %s; a recuperação da outbox o criará: %v", row.UID, err)
+	}
+	*event = intent.event()
+	return nil
+}
+
+// confirmIntent troca a intenção pelo evento da outbox e pelo registro de auditoria
+func confirmIntent(ctx context.Context, intent OutboxIntentDB, row *USDToBRLRateDB) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event := intent.event()
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		audit := insertAudit(row)
+		if err := tx.Create(&audit).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&intent).Error
+	})
+}
+
+// recoverIntents resolve as intenções anteriores a before: com a cotação gravada no backend,
+// cria o evento; sem ela, descarta a intenção
+func recoverIntents(ctx context.Context, before time.Time) (confirmed, discarded int, err error) {
+	var intents []OutboxIntentDB
+	if err := db.WithContext(ctx).Where("created_at < ?", before).Order("id").Find(&intents).Error; err != nil {
+		return 0, 0, err
+	}
+	for _, intent := range intents {
+		rows, err := rateRepo.Range(ctx, intent.Pair, time.Unix(intent.Timestamp, 0), time.Unix(intent.Timestamp+1, 0), 1)
+		if err != nil {
+			return confirmed, discarded, err
+		}
+		if len(rows) == 1 && rows[0].UID == intent.RateUID {
+			if err := confirmIntent(ctx, intent, &rows[0]); err != nil {
+				return confirmed, discarded, err
+			}
+			confirmed++
+			continue
+		}
+		if err := db.WithContext(ctx).Delete(&intent).Error; err != nil {
+			return confirmed, discarded, err
+		}
+		discarded++
+	}
+	if confirmed > 0 {
+		outbox.notify()
+	}
+	return confirmed, discarded, nil
+}
+
+// outboxHandler consome um evento; em caso de erro o evento é tentado de novo no próximo ciclo
+type outboxHandler func(ctx context.Context, event OutboxEventDB) error
+
+// outboxDispatcher entrega aos consumidores os eventos já confirmados, na ordem de gravação.
+// Cada gravação acorda o despachante; o intervalo cobre eventos deixados por falhas ou por
+// uma execução anterior
+type outboxDispatcher struct {
+	wake     chan struct{}
+	handlers map[string]outboxHandler
+}
+
+var outbox = &outboxDispatcher{wake: make(chan struct{}, 1)}
+
+func init() {
+	// Fora da declaração de outbox porque os consumidores gravam cotações (séries derivadas),
+	// o que cria um ciclo de inicialização com a própria outbox
+	outbox.handlers = map[string]outboxHandler{
+		topicRateSaved: observeRateEvent,
+	}
+}
+
+// notify avisa que há eventos novos, sem bloquear quem gravou
+func (d *outboxDispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// start inicia o despachante e a remoção periódica dos eventos entregues há mais de retention
+func (d *outboxDispatcher) start(ctx context.Context, interval, retention time.Duration) {
+	// As intenções deixadas pela execução anterior são resolvidas já; as desta, só depois
+	// de outboxIntentGrace
+	d.recover(ctx, time.Now())
+	runPeriodically(ctx, outboxIntentGrace, func(ctx context.Context) {
+		d.recover(ctx, time.Now().Add(-outboxIntentGrace))
+	})
+
+	if retention > 0 {
+		runPeriodically(ctx, time.Hour, func(ctx context.Context) {
+			if _, err := pruneOutbox(ctx, time.Now().Add(-retention)); err != nil {
+				log.Printf("Erro ao limpar a outbox: %v", err)
+			}
+		})
+	}
+
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		d.dispatch(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.wake:
+			case <-ticker.C:
+			}
+			d.dispatch(ctx)
+		}
+	}()
+}
+
+func (d *outboxDispatcher) recover(ctx context.Context, before time.Time) {
+	confirmed, discarded, err := recoverIntents(ctx, before)
+	if err != nil {
+		log.Printf("Erro ao recuperar intenções da outbox: %v", err)
+	}
+	if confirmed > 0 || discarded > 0 {
+		log.Printf("Outbox: %d eventos criados e %d intenções descartadas na recuperação", confirmed, discarded)
+	}
+}
+
+// dispatch entrega os eventos pendentes em lotes, em ordem de id; os que falharem ficam para
+// o próximo ciclo
+func (d *outboxDispatcher) dispatch(ctx context.Context) {
+	var afterID uint
+	for ctx.Err() == nil {
+		var events []OutboxEventDB
+		err := db.WithContext(ctx).
+			Where("dispatched_at IS NULL AND attempts < ? AND id > ?", outboxMaxAttempts, afterID).
+			Order("id").Limit(outboxBatchSize).Find(&events).Error
+		if err != nil {
+			log.Printf("Erro ao ler eventos da outbox: %v", err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		for _, event := range events {
+			afterID = event.ID
+			d.deliver(ctx, event)
+		}
+	}
+}
+
+func (d *outboxDispatcher) deliver(ctx context.Context, event OutboxEventDB) {
+	var err error
+	if handle, ok := d.handlers[event.Topic]; ok {
+		err = handle(ctx, event)
+	}
+
+	if err != nil {
+		outboxDispatched.WithLabelValues(event.Topic, "error").Inc()
+		log.Printf("Erro ao entregar evento %d (%s) da outbox: %v", event.ID, event.Topic, err)
+		if err := db.Model(&OutboxEventDB{ID: event.ID}).Update("attempts", event.Attempts+1).Error; err != nil {
+			log.Printf("Erro ao atualizar evento %d da outbox: %v", event.ID, err)
+		}
+		return
+	}
+
+	outboxDispatched.WithLabelValues(event.Topic, "ok").Inc()
+	now := time.Now()
+	if err := db.Model(&OutboxEventDB{ID: event.ID}).Updates(map[string]any{
+		"attempts":      event.Attempts + 1,
+		"dispatched_at": now,
+	}).Error; err != nil {
+		log.Printf("Erro ao marcar evento %d da outbox como entregue: %v", event.ID, err)
+	}
+}
+
+// pruneOutbox remove os eventos entregues antes de before
+func pruneOutbox(ctx context.Context, before time.Time) (int64, error) {
+	result := db.WithContext(ctx).Where("dispatched_at IS NOT NULL AND dispatched_at < ?", before).Delete(&OutboxEventDB{})
+	return result.RowsAffected, result.Error
+}
+
+func observeRateEvent(ctx context.Context, event OutboxEventDB) error {
+	var quote Quote
+	if err := json.Unmarshal([]byte(event.Payload), &quote); err != nil {
+		return err
+	}
+	alertsEngine.observe(event.Pair, &quote)
+	liveQuotes.publish(event.Pair, &quote)
+	evaluateDerived(ctx, event.Pair, &quote)
+	messageBus.enqueue(event)
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Nos backends fora do SQLite a cotação e o evento passam por uma intenção na outbox
+func TestSaveWithIntent(t *testing.T) {
+	conn := newTestDB(t)
+	var down atomic.Bool
+	rateRepo = downRateRepository{RateRepository: newMemoryRateRepository(10), down: &down}
+
+	now := time.Now()
+	// Os passos dependem dos anteriores
+	tests := []struct {
+		name        string
+		bid         string
+		at          time.Time
+		down        bool
+		wantErr     bool
+		wantEvents  int64
+		wantAudit   int64
+		wantIntents int64
+	}{
+		{name: "cotação nova gera o evento", bid: "5.1", at: now, wantEvents: 1, wantAudit: 1},
+		{name: "cotação repetida não gera evento", bid: "5.1", at: now, wantEvents: 1, wantAudit: 1},
+		{name: "falha no backend mantém a intenção para a recuperação", bid: "5.2", at: now.Add(time.Minute), down: true, wantErr: true, wantEvents: 1, wantAudit: 1, wantIntents: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			down.Store(tt.down)
+			quote := testQuote(tt.bid, tt.at)
+			if err := SaveExchangeRate(context.Background(), "USD-BRL", &quote); (err != nil) != tt.wantErr {
+				t.Fatalf("erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+			for table, want := range map[string]int64{
+				"outbox_event_dbs": tt.wantEvents, "audit_log_dbs": tt.wantAudit, "outbox_intent_dbs": tt.wantIntents,
+			} {
+				var got int64
+				if err := conn.Table(table).Count(&got).Error; err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%s = %d, esperado %d", table, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverIntents(t *testing.T) {
+	conn := newTestDB(t)
+	rateRepo = newMemoryRateRepository(10)
+
+	now := time.Now()
+	saved := USDToBRLRateDB{UID: "rate-saved", Pair: "USD-BRL", Timestamp: now.Unix()}
+	if err := rateRepo.Save(context.Background(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	// Uma cotação de outro uid no instante da intenção não confirma a intenção
+	other := USDToBRLRateDB{UID: "rate-other", Pair: "USD-BRL", Timestamp: now.Unix() - 60}
+	if err := rateRepo.Save(context.Background(), &other); err != nil {
+		t.Fatal(err)
+	}
+
+	intents := []OutboxIntentDB{
+		{RateUID: "rate-saved", Pair: "USD-BRL", Timestamp: saved.Timestamp, EventUID: "event-saved", Topic: topicRateSaved, Payload: "{}"},
+		{RateUID: "rate-lost", Pair: "USD-BRL", Timestamp: other.Timestamp, EventUID: "event-lost", Topic: topicRateSaved, Payload: "{}"},
+		{RateUID: "rate-missing", Pair: "USD-BRL", Timestamp: now.Unix() - 120, EventUID: "event-missing", Topic: topicRateSaved, Payload: "{}"},
+	}
+	if err := conn.Create(&intents).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		before        time.Time
+		wantConfirmed int
+		wantDiscarded int
+		wantEvents    []string
+	}{
+		{name: "intenções recentes aguardam", before: now.Add(-outboxIntentGrace)},
+		{name: "cotação gravada gera o evento e as demais são descartadas", before: time.Now().Add(time.Second), wantConfirmed: 1, wantDiscarded: 2, wantEvents: []string{"event-saved"}},
+		{name: "sem intenções não faz nada", before: time.Now().Add(time.Second), wantEvents: []string{"event-saved"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmed, discarded, err := recoverIntents(context.Background(), tt.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if confirmed != tt.wantConfirmed || discarded != tt.wantDiscarded {
+				t.Fatalf("recuperação = %d criados, %d descartados; esperado %d, %d", confirmed, discarded, tt.wantConfirmed, tt.wantDiscarded)
+			}
+			var events []string
+			if err := conn.Model(&OutboxEventDB{}).Order("id").Pluck("uid", &events).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(events, tt.wantEvents) {
+				t.Errorf("eventos = %v, esperado %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var pairPattern = regexp.MustCompile(`^[A-Z0-9]{2,10}-[A-Z0-9]{2,10}$`)
+
+// PairDB é um par rastreado pelo agendador. Provider vazio usa a cadeia de PROVIDERS e
+// PollInterval zero usa POLL_INTERVAL
+type PairDB struct {
+	Symbol       string    `gorm:"primaryKey;type:varchar(21)" json:"symbol"`
+	Provider     string    `gorm:"type:varchar(20);not null;default:''" json:"provider,omitempty"`
+	PollInterval Duration  `gorm:"not null;default:0" json:"poll_interval,omitempty"`
+	Enabled      bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// pairRegistry mantém em memória os pares rastreados, espelhando a tabela de pares
+type pairRegistry struct {
+	mu    sync.RWMutex
+	pairs map[string]PairDB
+}
+
+var pairs = &pairRegistry{pairs: make(map[string]PairDB)}
+
+// load carrega os pares do banco, cadastrando o par padrão na primeira execução
+func (p *pairRegistry) load() error {
+	var rows []PairDB
+	if err := db.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		row := PairDB{Symbol: defaultPair, Enabled: true}
+		if err := db.Create(&row).Error; err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, row := range rows {
+		p.pairs[row.Symbol] = row
+	}
+	return nil
+}
+
+func (p *pairRegistry) isEnabled(symbol string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pairs[symbol].Enabled
+}
+
+func (p *pairRegistry) list(onlyEnabled bool) []PairDB {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make([]PairDB, 0, len(p.pairs))
+	for _, row := range p.pairs {
+		if onlyEnabled && !row.Enabled {
+			continue
+		}
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result
+}
+
+func (p *pairRegistry) enabledSymbols() []string {
+	var symbols []string
+	for _, row := range p.list(true) {
+		symbols = append(symbols, row.Symbol)
+	}
+	return symbols
+}
+
+// save persiste o par e atualiza o registro; pares desabilitados saem do cache
+func (p *pairRegistry) save(row PairDB) (PairDB, error) {
+	if err := db.Save(&row).Error; err != nil {
+		return row, err
+	}
+
+	p.mu.Lock()
+	p.pairs[row.Symbol] = row
+	p.mu.Unlock()
+
+	if !row.Enabled {
+		cache.delete(row.Symbol)
+	}
+	return row, nil
+}
+
+// remove apaga o par do banco e do registro; as cotações já gravadas são mantidas
+func (p *pairRegistry) remove(symbol string) error {
+	if err := db.Delete(&PairDB{Symbol: symbol}).Error; err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	delete(p.pairs, symbol)
+	p.mu.Unlock()
+
+	cache.delete(symbol)
+	return nil
+}
+
+func (p *pairRegistry) get(symbol string) (PairDB, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	row, ok := p.pairs[symbol]
+	return row, ok
+}
+
+// PairRequest é o corpo de POST e PATCH; os campos omitidos mantêm o valor atual
+type PairRequest struct {
+	Symbol       string    `json:"symbol"`
+	Provider     *string   `json:"provider"`
+	PollInterval *Duration `json:"poll_interval"`
+	Enabled      *bool     `json:"enabled"`
+}
+
+// apply copia para o par os campos informados, validando o provedor e o intervalo
+func (req PairRequest) apply(row *PairDB) error {
+	if req.Provider != nil {
+		name := strings.TrimSpace(*req.Provider)
+		if _, ok := providers[name]; name != "" && !ok {
+			return fmt.Errorf("provedor desconhecido %q", name)
+		}
+		if isVirtualPair(row.Symbol) && name != "" {
+			return fmt.Errorf("o par %s é calculado pelo servidor e não aceita provedor", row.Symbol)
+		}
+		row.Provider = name
+	}
+	if req.PollInterval != nil {
+		interval := time.Duration(*req.PollInterval)
+		if interval != 0 && interval < time.Second {
+			return fmt.Errorf("poll_interval deve ser de pelo menos 1s, ou 0 para usar POLL_INTERVAL")
+		}
+		row.PollInterval = *req.PollInterval
+	}
+	if req.Enabled != nil {
+		row.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func decodePairRequest(w http.ResponseWriter, r *http.Request) (PairRequest, bool) {
+	var req PairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return req, false
+	}
+	return req, true
+}
+
+// PairsHandler lista os pares habilitados (GET) ou cadastra um novo par (POST, apenas
+// administradores)
+func PairsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, pairs.list(true))
+	case http.MethodPost:
+		AdminMiddleware(createPair)(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// PairHandler atende /pairs/{symbol}: GET consulta um par habilitado; PATCH altera provedor,
+// intervalo ou enabled e DELETE remove o par, ambos apenas para administradores
+func PairHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimPrefix(r.URL.Path, "/pairs/"))
+	switch r.Method {
+	case http.MethodGet:
+		row, ok := pairs.get(symbol)
+		if !ok || !row.Enabled {
+			writeError(w, http.StatusNotFound, "par não encontrado")
+			return
+		}
+		writeJSON(w, http.StatusOK, row)
+	case http.MethodPatch:
+		AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			req, ok := decodePairRequest(w, r)
+			if ok {
+				req.Symbol = symbol
+				updatePair(w, r, req)
+			}
+		})(w, r)
+	case http.MethodDelete:
+		AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			deletePair(w, r, symbol)
+		})(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// AdminPairsHandler lista todos os pares (GET), cadastra um novo par (POST) ou altera um
+// par existente (PATCH, com o par em symbol)
+func AdminPairsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, pairs.list(false))
+	case http.MethodPost:
+		createPair(w, r)
+	case http.MethodPatch:
+		if req, ok := decodePairRequest(w, r); ok {
+			updatePair(w, r, req)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func createPair(w http.ResponseWriter, r *http.Request) {
+	var req PairRequest
+	if !decodeValidated(w, r, "pair", &req) {
+		return
+	}
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if !pairPattern.MatchString(req.Symbol) {
+		writeError(w, http.StatusBadRequest, "symbol deve seguir o formato MOEDA-MOEDA, ex.: USD-BRL")
+		return
+	}
+	if _, exists := pairs.get(req.Symbol); exists {
+		writeError(w, http.StatusConflict, "par já cadastrado")
+		return
+	}
+	savePair(w, r, req, PairDB{Symbol: req.Symbol, Enabled: true}, http.StatusCreated)
+}
+
+func updatePair(w http.ResponseWriter, r *http.Request, req PairRequest) {
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	existing, exists := pairs.get(req.Symbol)
+	if !exists {
+		writeError(w, http.StatusNotFound, "par não encontrado")
+		return
+	}
+	savePair(w, r, req, existing, http.StatusOK)
+}
+
+func savePair(w http.ResponseWriter, r *http.Request, req PairRequest, row PairDB, status int) {
+	if err := req.apply(&row); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	row, err := pairs.save(row)
+	if err != nil {
+		logf(r.Context(), "Erro ao salvar par %s: %v", row.Symbol, err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	logf(r.Context(), "Par %s salvo (habilitado: %t, provedor: %q, intervalo: %s)",
+		row.Symbol, row.Enabled, row.Provider, time.Duration(row.PollInterval))
+	writeJSON(w, status, row)
+}
+
+// deletePair remove o par; os de COMPOSITE_PAIRS e DERIVED_SERIES seriam recadastrados na
+// próxima inicialização, então só podem ser desabilitados
+func deletePair(w http.ResponseWriter, r *http.Request, symbol string) {
+	if _, exists := pairs.get(symbol); !exists {
+		writeError(w, http.StatusNotFound, "par não encontrado")
+		return
+	}
+	if isVirtualPair(symbol) {
+		writeError(w, http.StatusConflict, "par definido em COMPOSITE_PAIRS ou DERIVED_SERIES; desabilite-o em vez de removê-lo")
+		return
+	}
+
+	if err := pairs.remove(symbol); err != nil {
+		logf(r.Context(), "Erro ao remover par %s: %v", symbol, err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	logf(r.Context(), "Par %s removido", symbol)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	upstreamPayloadsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_payloads_rejected_total",
+		Help: "Respostas de provedor rejeitadas pela validação, por provedor e campo.",
+	}, []string{"provider", "field"})
+	upstreamUnknownFields = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_schema_unknown_fields_total",
+		Help: "Campos do AwesomeAPI fora do schema esperado, sinal de mudança no formato.",
+	}, []string{"field"})
+)
+
+// awesomeAPISchemaVersion identifica o formato da resposta do AwesomeAPI assumido pela
+// validação: os campos de awesomeAPIRequiredFields são obrigatórios e os de Quote, conhecidos.
+// Campos novos não invalidam a resposta, mas são registrados; uma mudança incompatível no
+// upstream exige revisar os campos e incrementar a versão
+const awesomeAPISchemaVersion = 1
+
+var awesomeAPIRequiredFields = []string{"code", "codein", "bid", "ask", "timestamp"}
+
+var awesomeAPIKnownFields = []string{
+	"code", "codein", "name", "high", "low", "varBid", "pctChange", "bid", "ask", "timestamp", "create_date",
+}
+
+// Limites de plausibilidade do timestamp: cotações do futuro, além da diferença tolerada
+// entre relógios, ou anteriores a 2000 indicam unidade errada (ex.: milissegundos) ou lixo
+const maxQuoteClockSkew = 5 * time.Minute
+
+var minQuoteTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// unknownFieldsSeen evita repetir no log o mesmo campo novo a cada consulta
+var unknownFieldsSeen sync.Map
+
+// errInvalidPayload é a causa comum das respostas de provedor rejeitadas; os detalhes ficam
+// em *PayloadError
+var errInvalidPayload = errors.New("payload inválido do provedor")
+
+// Motivos de rejeição de um campo
+const (
+	payloadEmpty       = "vazio"
+	payloadNotNumeric  = "não numérico"
+	payloadNotFinite   = "não finito"
+	payloadNotPositive = "deve ser positivo"
+	payloadMissing     = "ausente na resposta"
+	payloadMalformed   = "JSON malformado"
+	payloadFuture      = "no futuro"
+	payloadTooOld      = "anterior a 2000"
+)
+
+// PayloadError descreve o campo rejeitado na resposta de um provedor. É comparável com
+// errors.Is a errInvalidPayload
+type PayloadError struct {
+	Field  string // campo da cotação ou o par ausente; vazio quando o corpo inteiro é inválido
+	Value  string // valor recebido, truncado
+	Reason string
+}
+
+func (e *PayloadError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%v: %s", errInvalidPayload, e.Reason)
+	}
+	if e.Value == "" {
+		return fmt.Sprintf("%v: %s %s", errInvalidPayload, e.Field, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s %q %s", errInvalidPayload, e.Field, e.Value, e.Reason)
+}
+
+func (e *PayloadError) Is(target error) bool {
+	return target == errInvalidPayload
+}
+
+// quarantineKind classifica a rejeição como na quarentena: valores ausentes ou que não são
+// números (quarantineParse) ou valores fora do plausível (quarantineInvalid)
+func (e *PayloadError) quarantineKind() string {
+	switch e.Reason {
+	case payloadNotPositive, payloadFuture, payloadTooOld:
+		return quarantineInvalid
+	}
+	return quarantineParse
+}
+
+// quarantineReason é o motivo sem o valor, para agrupar o relatório de qualidade
+func (e *PayloadError) quarantineReason() string {
+	return strings.TrimSpace(e.Field + " " + e.Reason)
+}
+
+// parseAwesomePayload decodifica a resposta de /last/{par} do AwesomeAPI e extrai o par,
+// conferindo os campos contra o schema awesomeAPISchemaVersion; a cotação ainda precisa
+// passar por validateQuote
+func parseAwesomePayload(body []byte, pair string) (*Quote, error) {
+	var rate map[string]json.RawMessage
+	if err := json.Unmarshal(body, &rate); err != nil {
+		return nil, &PayloadError{Reason: payloadMalformed + ": " + err.Error()}
+	}
+	data, ok := rate[domain.PairKey(pair)]
+	if !ok || string(data) == "null" {
+		return nil, &PayloadError{Field: pair, Reason: payloadMissing}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, &PayloadError{Field: pair, Reason: payloadMalformed + ": " + err.Error()}
+	}
+	for _, name := range awesomeAPIRequiredFields {
+		if _, ok := fields[name]; !ok {
+			return nil, &PayloadError{Field: name, Reason: payloadMissing}
+		}
+	}
+	for name := range fields {
+		if !slices.Contains(awesomeAPIKnownFields, name) {
+			noteUnknownField(name)
+		}
+	}
+
+	var quote Quote
+	if err := json.Unmarshal(data, &quote); err != nil {
+		return nil, &PayloadError{Field: pair, Reason: payloadMalformed + ": " + err.Error()}
+	}
+	return &quote, nil
+}
+
+// noteUnknownField registra um campo fora do schema esperado, uma vez por campo no log
+func noteUnknownField(name string) {
+	upstreamUnknownFields.WithLabelValues(name).Inc()
+	if _, seen := unknownFieldsSeen.LoadOrStore(name, struct{}{}); !seen {
+		log.Printf("Aviso: campo %q fora do schema v%d do AwesomeAPI; o formato do upstream pode ter mudado", name, awesomeAPISchemaVersion)
+	}
+}
+
+// rejectPayload contabiliza a resposta rejeitada do provedor e, quando a cotação chegou a
+// ser decodificada, a envia para a quarentena
+func rejectPayload(ctx context.Context, provider, pair string, quote *Quote, err *PayloadError) {
+	field := err.Field
+	if field == pair {
+		field = "pair"
+	}
+	upstreamPayloadsRejected.WithLabelValues(provider, field).Inc()
+	if quote != nil {
+		quarantine(ctx, pair, quote, err.quarantineKind(), err.quarantineReason())
+	}
+}
+
+// validateQuote rejeita cotações sem code/codein, com bid, ask ou timestamp vazios, não
+// numéricos, não finitos ou não positivos, que antes viravam zero silenciosamente na
+// gravação, e com timestamp implausível
+func validateQuote(quote *Quote) error {
+	for _, field := range []struct{ name, value string }{{"code", quote.Code}, {"codein", quote.Codein}} {
+		if strings.TrimSpace(field.value) == "" {
+			return &PayloadError{Field: field.name, Reason: payloadEmpty}
+		}
+	}
+	for _, field := range []struct{ name, value string }{{"bid", quote.Bid}, {"ask", quote.Ask}} {
+		if err := validatePositive(field.name, field.value); err != nil {
+			return err
+		}
+	}
+
+	if quote.Timestamp == "" {
+		return &PayloadError{Field: "timestamp", Reason: payloadEmpty}
+	}
+	unix, err := quote.Unix()
+	if err != nil {
+		return &PayloadError{Field: "timestamp", Value: truncateValue(quote.Timestamp), Reason: payloadNotNumeric}
+	}
+	switch {
+	case unix <= 0:
+		return &PayloadError{Field: "timestamp", Value: quote.Timestamp, Reason: payloadNotPositive}
+	case unix > time.Now().Add(maxQuoteClockSkew).Unix():
+		return &PayloadError{Field: "timestamp", Value: quote.Timestamp, Reason: payloadFuture}
+	case unix < minQuoteTime.Unix():
+		return &PayloadError{Field: "timestamp", Value: quote.Timestamp, Reason: payloadTooOld}
+	}
+	return nil
+}
+
+func validatePositive(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return &PayloadError{Field: field, Value: value, Reason: payloadEmpty}
+	}
+	switch strings.TrimLeft(strings.ToLower(value), "+-") {
+	case "nan", "inf", "infinity":
+		return &PayloadError{Field: field, Value: value, Reason: payloadNotFinite}
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return &PayloadError{Field: field, Value: truncateValue(value), Reason: payloadNotNumeric}
+	}
+	if !d.IsPositive() {
+		return &PayloadError{Field: field, Value: value, Reason: payloadNotPositive}
+	}
+	return nil
+}
+
+// truncateValue limita o valor citado no erro, que vai para logs e respostas
+func truncateValue(v string) string {
+	const limit = 32
+	if len(v) <= limit {
+		return v
+	}
+	return v[:limit] + "…"
+}
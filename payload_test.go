@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+)
+
+func TestValidateQuote(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name       string
+		edit       func(q *Quote)
+		wantField  string
+		wantReason string
+	}{
+		{name: "cotação válida"},
+		{name: "bid vazio", edit: func(q *Quote) { q.Bid = "" }, wantField: "bid", wantReason: payloadEmpty},
+		{name: "bid só com espaços", edit: func(q *Quote) { q.Bid = "  " }, wantField: "bid", wantReason: payloadEmpty},
+		{name: "bid NaN", edit: func(q *Quote) { q.Bid = "NaN" }, wantField: "bid", wantReason: payloadNotFinite},
+		{name: "bid infinito", edit: func(q *Quote) { q.Bid = "+Inf" }, wantField: "bid", wantReason: payloadNotFinite},
+		{name: "bid negativo", edit: func(q *Quote) { q.Bid = "-5.1" }, wantField: "bid", wantReason: payloadNotPositive},
+		{name: "bid zero", edit: func(q *Quote) { q.Bid = "0.0000" }, wantField: "bid", wantReason: payloadNotPositive},
+		{name: "bid não numérico", edit: func(q *Quote) { q.Bid = "5,12" }, wantField: "bid", wantReason: payloadNotNumeric},
+		{name: "ask vazio", edit: func(q *Quote) { q.Ask = "" }, wantField: "ask", wantReason: payloadEmpty},
+		{name: "ask negativo", edit: func(q *Quote) { q.Ask = "-1" }, wantField: "ask", wantReason: payloadNotPositive},
+		{name: "timestamp vazio", edit: func(q *Quote) { q.Timestamp = "" }, wantField: "timestamp", wantReason: payloadEmpty},
+		{name: "timestamp não numérico", edit: func(q *Quote) { q.Timestamp = "ontem" }, wantField: "timestamp", wantReason: payloadNotNumeric},
+		{name: "timestamp zero", edit: func(q *Quote) { q.Timestamp = "0" }, wantField: "timestamp", wantReason: payloadNotPositive},
+		{name: "timestamp em milissegundos", edit: func(q *Quote) { q.Timestamp = "1700000000000" }, wantField: "timestamp", wantReason: payloadFuture},
+		{name: "timestamp anterior a 2000", edit: func(q *Quote) { q.Timestamp = "946684799" }, wantField: "timestamp", wantReason: payloadTooOld},
+		{name: "code vazio", edit: func(q *Quote) { q.Code = "" }, wantField: "code", wantReason: payloadEmpty},
+		{name: "codein vazio", edit: func(q *Quote) { q.Codein = " " }, wantField: "codein", wantReason: payloadEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := testQuote("5.1234", now)
+			if tt.edit != nil {
+				tt.edit(&quote)
+			}
+
+			err := validateQuote(&quote)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("erro inesperado: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, errInvalidPayload) {
+				t.Fatalf("erro = %v, esperado errInvalidPayload", err)
+			}
+			var payloadErr *PayloadError
+			if !errors.As(err, &payloadErr) {
+				t.Fatalf("erro %T não é *PayloadError", err)
+			}
+			if payloadErr.Field != tt.wantField || payloadErr.Reason != tt.wantReason {
+				t.Errorf("campo/motivo = %s/%s, esperado %s/%s", payloadErr.Field, payloadErr.Reason, tt.wantField, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestParseAwesomePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantBid     string
+		wantErr     string
+		wantUnknown string
+	}{
+		{
+			name:    "resposta válida",
+			body:    `{"USDBRL":{"code":"USD","codein":"BRL","bid":"5.1234","ask":"5.1240","timestamp":"1700000000"}}`,
+			wantBid: "5.1234",
+		},
+		{
+			name:        "campo fora do schema é aceito e registrado",
+			body:        `{"USDBRL":{"code":"USD","codein":"BRL","bid":"5.1234","ask":"5.1240","timestamp":"1700000000","mid":"5.1237"}}`,
+			wantBid:     "5.1234",
+			wantUnknown: "mid",
+		},
+		{
+			name:    "campo obrigatório ausente",
+			body:    `{"USDBRL":{"code":"USD","codein":"BRL","ask":"5.1240","timestamp":"1700000000"}}`,
+			wantErr: "payload inválido do provedor: bid ausente na resposta",
+		},
+		{
+			name:    "par nulo",
+			body:    `{"USDBRL":null}`,
+			wantErr: "payload inválido do provedor: USD-BRL ausente na resposta",
+		},
+		{
+			name:    "par não é um objeto",
+			body:    `{"USDBRL":"5.1234"}`,
+			wantErr: "payload inválido do provedor: USD-BRL JSON malformado",
+		},
+		{
+			name:    "corpo vazio",
+			body:    ``,
+			wantErr: "payload inválido do provedor: JSON malformado: unexpected end of JSON input",
+		},
+		{
+			name:    "bid numérico em vez de texto",
+			body:    `{"USDBRL":{"code":"USD","codein":"BRL","bid":5.1234,"ask":"5.1240","timestamp":"1700000000"}}`,
+			wantErr: "payload inválido do provedor: USD-BRL JSON malformado: json: cannot unmarshal number",
+		},
+		{
+			name:    "par ausente",
+			body:    `{"EURBRL":{"bid":"6.0"}}`,
+			wantErr: "payload inválido do provedor: USD-BRL ausente na resposta",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unknownBefore float64
+			if tt.wantUnknown != "" {
+				unknownBefore = testutil.ToFloat64(upstreamUnknownFields.WithLabelValues(tt.wantUnknown))
+			}
+
+			quote, err := parseAwesomePayload([]byte(tt.body), "USD-BRL")
+			if tt.wantErr != "" {
+				// A mensagem do encoding/json varia entre versões do Go; compara o início
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, errInvalidPayload) {
+					t.Errorf("erro %v não é errInvalidPayload", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Bid != tt.wantBid {
+				t.Errorf("bid = %s, esperado %s", quote.Bid, tt.wantBid)
+			}
+			if tt.wantUnknown != "" {
+				if got := testutil.ToFloat64(upstreamUnknownFields.WithLabelValues(tt.wantUnknown)) - unknownBefore; got != 1 {
+					t.Errorf("campo %s registrado %v vezes, esperado 1", tt.wantUnknown, got)
+				}
+			}
+		})
+	}
+}
+
+// FuzzParseAwesomePayload garante que nenhuma resposta do provedor derruba o decodificador e
+// que toda cotação aceita tem bid, ask e timestamp positivos
+func FuzzParseAwesomePayload(f *testing.F) {
+	for _, seed := range []string{
+		`{"USDBRL":{"code":"USD","codein":"BRL","bid":"5.1234","ask":"5.1240","timestamp":"1700000000"}}`,
+		`{"USDBRL":{"code":"USD","codein":"BRL","bid":"NaN","ask":"Inf","timestamp":"1700000000"}}`,
+		`{"USDBRL":{"code":"USD","codein":"BRL","bid":"-1","ask":"0","timestamp":"-5"}}`,
+		`{"USDBRL":{"code":"USD","codein":"BRL","bid":"1e400","ask":"5","timestamp":"99999999999999999999"}}`,
+		`{"USDBRL":{"code":"","codein":"","bid":"","ask":"","timestamp":""}}`,
+		`{"USDBRL":{"code":"USD","codein":"BRL","bid":"5","ask":"5","timestamp":"1700000000000"}}`,
+		`{"USDBRL":null}`,
+		`{"USDBRL":`,
+		`[]`,
+		``,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		quote, err := parseAwesomePayload(body, "USD-BRL")
+		if err == nil {
+			err = validateQuote(quote)
+		}
+		if err != nil {
+			if !errors.Is(err, errInvalidPayload) {
+				t.Fatalf("erro %v não é errInvalidPayload", err)
+			}
+			return
+		}
+
+		for _, v := range []string{quote.Bid, quote.Ask} {
+			d, err := decimal.NewFromString(v)
+			if err != nil || !d.IsPositive() {
+				t.Fatalf("valor aceito %q não é um número positivo", v)
+			}
+		}
+		if unix, err := quote.Unix(); err != nil || unix <= 0 {
+			t.Fatalf("timestamp aceito %q inválido", quote.Timestamp)
+		}
+		if unix, _ := quote.Unix(); unix < minQuoteTime.Unix() || unix > time.Now().Add(maxQuoteClockSkew).Unix() {
+			t.Fatalf("timestamp aceito %q implausível", quote.Timestamp)
+		}
+	})
+}
+
+// FuzzValidateQuote exercita a validação de bid diretamente, sem o custo do JSON
+func FuzzValidateQuote(f *testing.F) {
+	for _, seed := range []string{"5.1234", "0", "-0.0001", "NaN", "-inf", "Infinity", "1e-9", "1e400", " 5", "5,1", "0x10", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, bid string) {
+		quote := testQuote(bid, time.Unix(1700000000, 0))
+		err := validateQuote(&quote)
+		if err != nil {
+			var payloadErr *PayloadError
+			if !errors.As(err, &payloadErr) {
+				t.Fatalf("erro %T não é *PayloadError", err)
+			}
+			return
+		}
+		if d, err := decimal.NewFromString(bid); err != nil || !d.IsPositive() {
+			t.Fatalf("bid aceito %q não é um número positivo", bid)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+// Package client é o SDK em Go da API de cotações, usado pelo CLI e disponível para outros
+// programas que consomem o servidor:
+//
+//	c, err := client.New("http://localhost:8080", client.WithToken(token))
+//	quote, err := c.GetRate(ctx, "USD-BRL")
+//
+// Todas as chamadas respeitam o prazo e o cancelamento do contexto. Falhas transitórias
+// (rede, 5xx e 429) são repetidas com espera exponencial enquanto houver prazo; as demais
+// são retornadas como *APIError, comparável com errors.Is aos erros sentinela do pacote.
+//
+// O pacote só depende de net/http e compila também para js/wasm, em que as requisições usam
+// o fetch do navegador; o subdiretório wasm o expõe ao JavaScript. Novas dependências devem
+// manter essa compatibilidade (sem os, arquivos ou sockets).
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 25 * time.Millisecond
+)
+
+// CotacaoClient acessa a API de um servidor; é seguro para uso concorrente
+type CotacaoClient struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	retries    int
+	backoff    time.Duration
+	onTiming   func(domain.Timing)
+}
+
+type Option func(*CotacaoClient)
+
+// WithHTTPClient usa um *http.Client próprio (ex.: com CAs adicionais ou proxy)
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *CotacaoClient) { c.httpClient = hc }
+}
+
+// WithToken envia o token JWT obtido em /auth/login, exigido pelo histórico
+func WithToken(token string) Option {
+	return func(c *CotacaoClient) { c.token = token }
+}
+
+// WithRetries define quantas novas tentativas são feitas após uma falha transitória
+func WithRetries(n int) Option {
+	return func(c *CotacaoClient) { c.retries = n }
+}
+
+// WithBackoff define a espera antes da primeira nova tentativa, dobrada a cada falha
+func WithBackoff(d time.Duration) Option {
+	return func(c *CotacaoClient) { c.backoff = d }
+}
+
+// WithTiming pede ao servidor o detalhamento do tempo de cada cotação (cabeçalho
+// X-Debug-Timing) e o entrega a fn, para diagnosticar consultas lentas
+func WithTiming(fn func(domain.Timing)) Option {
+	return func(c *CotacaoClient) { c.onTiming = fn }
+}
+
+// New cria o cliente para o servidor em baseURL (ex.: "https://cotacao.exemplo.com")
+func New(baseURL string, opts ...Option) (*CotacaoClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("URL do servidor inválida %q", baseURL)
+	}
+
+	c := &CotacaoClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retries < 0 {
+		return nil, fmt.Errorf("o número de novas tentativas não pode ser negativo")
+	}
+	return c, nil
+}
+
+// GetRate retorna a cotação atual do par (ex.: "USD-BRL")
+func (c *CotacaoClient) GetRate(ctx context.Context, pair string) (domain.Quote, error) {
+	var body map[string]json.RawMessage
+	if err := c.getJSON(ctx, "/cotacao", url.Values{"pair": {pair}}, &body); err != nil {
+		return domain.Quote{}, err
+	}
+	if raw, ok := body[domain.TimingKey]; ok && c.onTiming != nil {
+		var timing domain.Timing
+		if json.Unmarshal(raw, &timing) == nil {
+			c.onTiming(timing)
+		}
+	}
+
+	raw, ok := body[domain.PairKey(pair)]
+	if !ok {
+		return domain.Quote{}, ErrPairNotSupported
+	}
+	var quote domain.Quote
+	if err := json.Unmarshal(raw, &quote); err != nil {
+		return domain.Quote{}, fmt.Errorf("erro ao fazer parse da resposta: %w", err)
+	}
+	return quote, nil
+}
+
+// getJSON faz GET em path e decodifica a resposta 200 em v, repetindo falhas transitórias
+func (c *CotacaoClient) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		body, err := c.get(ctx, target)
+		if err == nil {
+			if err := json.Unmarshal(body, v); err != nil {
+				return fmt.Errorf("erro ao fazer parse da resposta: %w", err)
+			}
+			return nil
+		}
+		if !IsTemporary(err) || ctx.Err() != nil || attempt >= c.retries {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *CotacaoClient) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.onTiming != nil {
+		req.Header.Set(domain.TimingHeader, "1")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+		var payload struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &payload) == nil {
+			apiErr.Message = payload.Error
+		}
+		return nil, apiErr
+	}
+	if err := domain.CheckWireVersion(resp.Header.Get(domain.WireVersionHeader)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncompatibleVersion, err)
+	}
+	return body, nil
+}
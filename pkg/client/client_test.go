@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+// response é uma resposta do servidor falso; as tentativas além da lista repetem a última
+type response struct {
+	status  int
+	body    string
+	version string
+}
+
+const usdBody = `{"USDBRL":{"code":"USD","codein":"BRL","bid":"5.1","ask":"5.2","timestamp":"1700000000"},"timing":{"total_ms":12}}`
+
+func TestGetRate(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []response
+		wantBid      string
+		wantErr      error // comparado com errors.Is
+		wantAnyErr   bool  // erro sem sentinela, como o de parse
+		wantAttempts int32
+	}{
+		{name: "cotação do par", responses: []response{{status: 200, body: usdBody}}, wantBid: "5.1", wantAttempts: 1},
+		{name: "falha transitória é repetida", responses: []response{{status: 503, body: `{"error":"indisponível"}`}, {status: 200, body: usdBody}},
+			wantBid: "5.1", wantAttempts: 2},
+		{name: "limite persistente esgota as tentativas", responses: []response{{status: 429}}, wantErr: ErrRateLimited, wantAttempts: 2},
+		{name: "par não habilitado não é repetido", responses: []response{{status: 404, body: `{"error":"par não habilitado"}`}},
+			wantErr: ErrNotFound, wantAttempts: 1},
+		{name: "token inválido", responses: []response{{status: 401}}, wantErr: ErrUnauthorized, wantAttempts: 1},
+		{name: "resposta sem o par", responses: []response{{status: 200, body: `{"EURBRL":{"bid":"6.0"}}`}},
+			wantErr: ErrPairNotSupported, wantAttempts: 1},
+		{name: "versão de fio incompatível", responses: []response{{status: 200, body: usdBody, version: "2"}},
+			wantErr: ErrIncompatibleVersion, wantAttempts: 1},
+		{name: "corpo inválido", responses: []response{{status: 200, body: `{`}}, wantAnyErr: true, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				if r.URL.Path != "/cotacao" || r.URL.Query().Get("pair") != "USD-BRL" {
+					t.Errorf("requisição = %s, esperado /cotacao?pair=USD-BRL", r.URL)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer segredo" {
+					t.Errorf("Authorization = %q", got)
+				}
+				if r.Header.Get(domain.TimingHeader) != "1" {
+					t.Errorf("%s ausente", domain.TimingHeader)
+				}
+				resp := tt.responses[min(n, len(tt.responses))-1]
+				if resp.version != "" {
+					w.Header().Set(domain.WireVersionHeader, resp.version)
+				}
+				w.Header().Set("X-Request-ID", "req-1")
+				w.WriteHeader(resp.status)
+				w.Write([]byte(resp.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			var timing *domain.Timing
+			c, err := New(srv.URL+"/", WithToken("segredo"), WithRetries(1), WithBackoff(time.Millisecond),
+				WithTiming(func(got domain.Timing) { timing = &got }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			quote, err := c.GetRate(context.Background(), "USD-BRL")
+
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("erro = %v, esperado %v", err, tt.wantErr)
+			case tt.wantAnyErr && err == nil:
+				t.Fatal("esperado erro")
+			case tt.wantErr == nil && !tt.wantAnyErr && err != nil:
+				t.Fatal(err)
+			}
+			if quote.Bid != tt.wantBid {
+				t.Errorf("bid = %q, esperado %q", quote.Bid, tt.wantBid)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("tentativas = %d, esperado %d", got, tt.wantAttempts)
+			}
+			if tt.wantBid != "" && timing == nil {
+				t.Error("detalhamento de tempo não entregue")
+			}
+
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.RequestID != "req-1" {
+				t.Errorf("request id = %q, esperado req-1", apiErr.RequestID)
+			}
+		})
+	}
+}
+
+// Sem prazo para a espera a falha é devolvida sem nova tentativa
+func TestGetRateDeadline(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL, WithBackoff(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := c.GetRate(ctx, "USD-BRL"); !IsTemporary(err) {
+		t.Errorf("erro = %v, esperado falha transitória", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("tentativas = %d, esperado 1", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		opts    []Option
+		wantErr bool
+	}{
+		{baseURL: "http://localhost:8080"},
+		{baseURL: "https://cotacao.exemplo.com/"},
+		{baseURL: "localhost:8080", wantErr: true},
+		{baseURL: "ftp://cotacao.exemplo.com", wantErr: true},
+		{baseURL: "http://", wantErr: true},
+		{baseURL: "http://localhost:8080", opts: []Option{WithRetries(-1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.baseURL, tt.opts...); (err != nil) != tt.wantErr {
+			t.Errorf("New(%q) erro = %v, esperado erro %v", tt.baseURL, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	from := time.Unix(1700000000, 0).UTC()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/historico" || query.Get("pair") != "USD-BRL" || query.Get("from") != "1700000000" ||
+			query.Get("to") != "1700003600" || query.Get("resolution") != ResolutionHour {
+			t.Errorf("requisição = %s", r.URL)
+		}
+		w.Write([]byte(`{"pair":"USD-BRL","resolution":"hour","points":[{"time":"2023-11-14T22:00:00Z","open":5.12345678,"close":5.2,"samples":3}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := c.GetHistory(context.Background(), HistoryQuery{
+		Pair: "USD-BRL", From: from, To: from.Add(time.Hour), Resolution: ResolutionHour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Os valores chegam como json.Number, sem perder casas decimais
+	if len(history.Points) != 1 || history.Points[0].Open != "5.12345678" || history.Points[0].Samples != 3 {
+		t.Errorf("pontos = %+v", history.Points)
+	}
+}
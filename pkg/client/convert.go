@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// Conversion é a resposta de /converter
+type Conversion struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Amount    json.Number `json:"amount"`
+	Result    json.Number `json:"result"`
+	Rate      json.Number `json:"rate"`
+	Pair      string      `json:"pair"`
+	Inverted  bool        `json:"inverted"`
+	Provider  string      `json:"provider,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+	Staleness int64       `json:"staleness_seconds"`
+}
+
+// Convert converte amount (decimal em texto, ex.: "100.50") da moeda from para to
+func (c *CotacaoClient) Convert(ctx context.Context, amount, from, to string) (Conversion, error) {
+	var conversion Conversion
+	err := c.getJSON(ctx, "/converter", url.Values{"amount": {amount}, "from": {from}, "to": {to}}, &conversion)
+	return conversion, err
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrUnauthorized indica token ausente, inválido ou expirado (401)
+	ErrUnauthorized = errors.New("autenticação necessária")
+	// ErrNotFound indica recurso ou par não habilitado no servidor (404)
+	ErrNotFound = errors.New("não encontrado")
+	// ErrRateLimited indica que o servidor limitou as requisições (429)
+	ErrRateLimited = errors.New("limite de requisições atingido")
+	// ErrPairNotSupported indica que a resposta não contém o par pedido
+	ErrPairNotSupported = errors.New("par não suportado pelo servidor")
+	// ErrIncompatibleVersion indica um servidor com formato de fio incompatível com este pacote
+	ErrIncompatibleVersion = errors.New("servidor incompatível")
+)
+
+// APIError é uma resposta do servidor diferente de 200; errors.Is a compara com os erros
+// sentinela correspondentes ao status (ErrUnauthorized, ErrNotFound, ErrRateLimited)
+type APIError struct {
+	StatusCode int
+	Message    string // campo error do corpo, quando houver
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// Temporary indica se vale tentar de novo: respostas 5xx ou 429
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsTemporary indica se a falha é transitória: erros de rede, prazos esgotados e respostas
+// 5xx ou 429. Respostas definitivas do servidor e incompatibilidades não são
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	return !errors.Is(err, ErrPairNotSupported) && !errors.Is(err, ErrIncompatibleVersion)
+}
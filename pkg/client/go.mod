@@ -0,0 +1,7 @@
+module github.com/guilhermeayusso/goexpert/desafio/1/pkg/client
+
+go 1.23.6
+
+require github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain v0.0.0
+
+replace github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain => ../domain
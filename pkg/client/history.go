@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Resoluções aceitas por GetHistory; vazia deixa a escolha para o servidor
+const (
+	ResolutionRaw  = "raw"
+	ResolutionHour = "hour"
+	ResolutionDay  = "day"
+)
+
+// HistoryQuery descreve o intervalo consultado; From e To zerados usam as últimas 24 horas
+type HistoryQuery struct {
+	Pair       string
+	From       time.Time
+	To         time.Time
+	Resolution string
+}
+
+// History é a resposta de /historico para um intervalo
+type History struct {
+	Pair       string         `json:"pair"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Resolution string         `json:"resolution"`
+	Points     []HistoryPoint `json:"points"`
+}
+
+// Os valores são mantidos como json.Number para não perder casas decimais
+type HistoryPoint struct {
+	Time    time.Time   `json:"time"`
+	Open    json.Number `json:"open"`
+	High    json.Number `json:"high"`
+	Low     json.Number `json:"low"`
+	Close   json.Number `json:"close"`
+	AvgBid  json.Number `json:"avg_bid"`
+	AvgAsk  json.Number `json:"avg_ask"`
+	Samples int         `json:"samples"`
+}
+
+// GetHistory retorna a série do par no intervalo; exige WithToken
+func (c *CotacaoClient) GetHistory(ctx context.Context, q HistoryQuery) (History, error) {
+	query := url.Values{}
+	if q.Pair != "" {
+		query.Set("pair", q.Pair)
+	}
+	from := q.From
+	if from.IsZero() {
+		from = time.Now().Add(-24 * time.Hour)
+	}
+	query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	if !q.To.IsZero() {
+		query.Set("to", strconv.FormatInt(q.To.Unix(), 10))
+	}
+	if q.Resolution != "" {
+		query.Set("resolution", q.Resolution)
+	}
+
+	var history History
+	err := c.getJSON(ctx, "/historico", query, &history)
+	return history, err
+}
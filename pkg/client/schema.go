@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// GetSchema retorna o JSON Schema dos formatos de resposta publicado pelo servidor em /schema
+func (c *CotacaoClient) GetSchema(ctx context.Context) (json.RawMessage, error) {
+	var schema json.RawMessage
+	err := c.getJSON(ctx, "/schema", nil, &schema)
+	return schema, err
+}
+
+// GetResourceSchema retorna o JSON Schema de um recurso (quote, history, stats, error ou os
+// corpos aceitos, como alert e trade), publicado em /schema/{resource}
+func (c *CotacaoClient) GetResourceSchema(ctx context.Context, resource string) (json.RawMessage, error) {
+	var schema json.RawMessage
+	err := c.getJSON(ctx, "/schema/"+url.PathEscape(resource), nil, &schema)
+	return schema, err
+}
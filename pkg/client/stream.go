@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+// RateUpdate é o resultado de uma consulta do par em StreamRates; Err é preenchido quando a
+// consulta falhou, e o acompanhamento continua no próximo ciclo
+type RateUpdate struct {
+	Pair  string
+	Quote domain.Quote
+	Err   error
+	At    time.Time
+}
+
+// StreamRates consulta os pares a cada interval, o primeiro ciclo imediatamente, e envia um
+// RateUpdate por par e ciclo. O canal é fechado quando ctx termina. Cada consulta tem como
+// prazo o próprio intervalo
+func (c *CotacaoClient) StreamRates(ctx context.Context, pairs []string, interval time.Duration) <-chan RateUpdate {
+	updates := make(chan RateUpdate, len(pairs))
+
+	go func() {
+		defer close(updates)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if !c.pollRates(ctx, pairs, interval, updates) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return updates
+}
+
+// pollRates consulta os pares em paralelo e envia os resultados na ordem de pairs; retorna
+// false se ctx terminou
+func (c *CotacaoClient) pollRates(parent context.Context, pairs []string, timeout time.Duration, updates chan<- RateUpdate) bool {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	results := make([]RateUpdate, len(pairs))
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quote, err := c.GetRate(ctx, pair)
+			results[i] = RateUpdate{Pair: pair, Quote: quote, Err: err, At: time.Now()}
+		}()
+	}
+	wg.Wait()
+
+	for _, update := range results {
+		if parent.Err() != nil {
+			return false
+		}
+		select {
+		case updates <- update:
+		case <-parent.Done():
+			return false
+		}
+	}
+	return true
+}
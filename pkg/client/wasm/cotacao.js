@@ -0,0 +1,51 @@
+// Wrapper do SDK compilado para WebAssembly. Exige o wasm_exec.js da mesma versão do Go
+// carregado antes (make wasm copia os dois arquivos para dist/wasm):
+//
+//   <script src="wasm_exec.js"></script>
+//   <script type="module">
+//     import { load } from "./cotacao.js";
+//     const cotacao = await load("cotacao.wasm");
+//     const c = cotacao.createClient("https://cotacao.exemplo.com", { token });
+//     const quote = await c.getRate("USD-BRL");
+//   </script>
+
+let loading;
+
+/**
+ * Carrega o módulo uma única vez por página.
+ * @param {string|URL} url endereço do cotacao.wasm
+ */
+export function load(url = new URL("cotacao.wasm", import.meta.url)) {
+  loading ??= (async () => {
+    if (typeof globalThis.Go !== "function") {
+      throw new Error("wasm_exec.js não carregado");
+    }
+    const go = new Go();
+    const { instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject);
+    // run só termina se o programa Go sair; os métodos ficam disponíveis logo após o início
+    go.run(instance);
+    return { createClient };
+  })();
+  return loading;
+}
+
+/**
+ * Cria o cliente da API.
+ * @param {string} baseURL ex.: "https://cotacao.exemplo.com"
+ * @param {{token?: string, retries?: number, backoffMs?: number, timeoutMs?: number}} [options]
+ * @returns {{
+ *   getRate(pair: string): Promise<object>,
+ *   getHistory(query?: {pair?: string, from?: Date|string|number, to?: Date|string|number, resolution?: "raw"|"hour"|"day"}): Promise<object>,
+ *   convert(amount: number|string, from: string, to: string): Promise<object>,
+ *   getSchema(resource?: string): Promise<object>,
+ * }}
+ * As Promises são rejeitadas com um Error com status e requestId (respostas da API) e
+ * temporary (falha transitória, já repetida pelo SDK).
+ */
+function createClient(baseURL, options = {}) {
+  const client = globalThis.cotacaoSDK.newClient(baseURL, options);
+  if (client instanceof Error) {
+    throw client;
+  }
+  return client;
+}
@@ -0,0 +1,175 @@
+//go:build js && wasm
+
+// Comando wasm expõe o SDK ao JavaScript quando compilado com GOOS=js GOARCH=wasm (make
+// wasm). O carregamento e a criação do cliente ficam em cotacao.js:
+//
+//	const cotacao = await load("cotacao.wasm")
+//	const c = cotacao.createClient("https://cotacao.exemplo.com", { token })
+//	const quote = await c.getRate("USD-BRL")
+//
+// Os métodos retornam Promises com os mesmos modelos do SDK, convertidos para objetos JS
+// pelo JSON (mesmos nomes de campo; os decimais de json.Number viram number), e mantêm as
+// novas tentativas do SDK. As falhas rejeitam
+// a Promise com um Error que traz status e requestId (respostas da API) e temporary.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"syscall/js"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/client"
+)
+
+// Prazo padrão de cada chamada, incluindo as novas tentativas
+const defaultTimeout = 10 * time.Second
+
+func main() {
+	js.Global().Set("cotacaoSDK", js.ValueOf(map[string]any{
+		"newClient": js.FuncOf(newClient),
+	}))
+	// O programa precisa continuar vivo para atender as chamadas do JavaScript
+	select {}
+}
+
+// newClient(baseURL, {token, retries, backoffMs, timeoutMs}) retorna o objeto com os
+// métodos da API ou, se as opções forem inválidas, um Error (lançado por cotacao.js)
+func newClient(_ js.Value, args []js.Value) any {
+	opts := arg(args, 1)
+	var options []client.Option
+	if v := field(opts, "token"); v.Type() == js.TypeString {
+		options = append(options, client.WithToken(v.String()))
+	}
+	if v := field(opts, "retries"); v.Type() == js.TypeNumber {
+		options = append(options, client.WithRetries(v.Int()))
+	}
+	if v := field(opts, "backoffMs"); v.Type() == js.TypeNumber {
+		options = append(options, client.WithBackoff(time.Duration(v.Float()*float64(time.Millisecond))))
+	}
+	timeout := defaultTimeout
+	if v := field(opts, "timeoutMs"); v.Type() == js.TypeNumber {
+		timeout = time.Duration(v.Float() * float64(time.Millisecond))
+	}
+
+	c, err := client.New(arg(args, 0).String(), options...)
+	if err != nil {
+		return jsError(err)
+	}
+	call := func(fn func(ctx context.Context, args []js.Value) (any, error)) js.Func {
+		return js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return promise(timeout, func(ctx context.Context) (any, error) { return fn(ctx, args) })
+		})
+	}
+
+	return js.ValueOf(map[string]any{
+		// getRate(pair)
+		"getRate": call(func(ctx context.Context, args []js.Value) (any, error) {
+			return c.GetRate(ctx, arg(args, 0).String())
+		}),
+		// getHistory({pair, from, to, resolution}); from e to aceitam Date, texto RFC3339 ou
+		// milissegundos
+		"getHistory": call(func(ctx context.Context, args []js.Value) (any, error) {
+			q := arg(args, 0)
+			query := client.HistoryQuery{Resolution: stringField(q, "resolution"), Pair: stringField(q, "pair")}
+			var err error
+			if query.From, err = jsTime(field(q, "from")); err != nil {
+				return nil, err
+			}
+			if query.To, err = jsTime(field(q, "to")); err != nil {
+				return nil, err
+			}
+			return c.GetHistory(ctx, query)
+		}),
+		// convert(amount, from, to); amount pode ser número ou texto
+		"convert": call(func(ctx context.Context, args []js.Value) (any, error) {
+			amount := arg(args, 0)
+			if amount.Type() == js.TypeNumber {
+				amount = js.Global().Call("String", amount)
+			}
+			return c.Convert(ctx, amount.String(), arg(args, 1).String(), arg(args, 2).String())
+		}),
+		// getSchema(resource?) retorna o JSON Schema de todos os recursos ou de um só
+		"getSchema": call(func(ctx context.Context, args []js.Value) (any, error) {
+			if resource := arg(args, 0); resource.Type() == js.TypeString {
+				return c.GetResourceSchema(ctx, resource.String())
+			}
+			return c.GetSchema(ctx)
+		}),
+	})
+}
+
+// promise executa fn fora da thread do JavaScript, já que as chamadas HTTP bloqueiam, e
+// resolve a Promise com o resultado convertido pelo JSON
+func promise(timeout time.Duration, fn func(ctx context.Context) (any, error)) js.Value {
+	executor := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			v, err := fn(ctx)
+			if err == nil {
+				var body []byte
+				if body, err = json.Marshal(v); err == nil {
+					resolve.Invoke(js.Global().Get("JSON").Call("parse", string(body)))
+					return
+				}
+			}
+			reject.Invoke(jsError(err))
+		}()
+		return nil
+	})
+	// O construtor da Promise chama o executor antes de retornar
+	defer executor.Release()
+	return js.Global().Get("Promise").New(executor)
+}
+
+func jsError(err error) js.Value {
+	e := js.Global().Get("Error").New(err.Error())
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		e.Set("status", apiErr.StatusCode)
+		e.Set("requestId", apiErr.RequestID)
+	}
+	e.Set("temporary", client.IsTemporary(err))
+	return e
+}
+
+func jsTime(v js.Value) (time.Time, error) {
+	switch v.Type() {
+	case js.TypeUndefined, js.TypeNull:
+		return time.Time{}, nil
+	case js.TypeNumber:
+		return time.UnixMilli(int64(v.Float())), nil
+	case js.TypeString:
+		return time.Parse(time.RFC3339, v.String())
+	}
+	if v.InstanceOf(js.Global().Get("Date")) {
+		return time.UnixMilli(int64(v.Call("getTime").Float())), nil
+	}
+	return time.Time{}, errors.New("data inválida: use Date, texto RFC3339 ou milissegundos")
+}
+
+// arg retorna o argumento i ou undefined, quando a chamada passou menos argumentos
+func arg(args []js.Value, i int) js.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return js.Undefined()
+}
+
+func field(obj js.Value, name string) js.Value {
+	if obj.Type() != js.TypeObject {
+		return js.Undefined()
+	}
+	return obj.Get(name)
+}
+
+func stringField(obj js.Value, name string) string {
+	if v := field(obj, name); v.Type() == js.TypeString {
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,3 @@
+module github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain
+
+go 1.23.6
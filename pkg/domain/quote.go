@@ -0,0 +1,83 @@
+// Package domain define o formato de fio compartilhado entre o servidor e o client: a
+// cotação no formato do AwesomeAPI, indexada pelo par sem hífen, e os helpers para
+// interpretá-la.
+//
+// O formato é versionado por WireVersion, enviada pelo servidor no cabeçalho
+// WireVersionHeader. A versão só muda em alterações incompatíveis (campo removido ou com
+// tipo alterado); campos novos não mudam a versão e são ignorados por clients antigos.
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	WireVersion       = 1
+	WireVersionHeader = "X-Wire-Version"
+)
+
+// Quote segue o formato do AwesomeAPI; os valores numéricos trafegam como texto
+type Quote struct {
+	Code       string `json:"code"`
+	Codein     string `json:"codein"`
+	Name       string `json:"name"`
+	High       string `json:"high"`
+	Low        string `json:"low"`
+	VarBid     string `json:"varBid"`
+	PctChange  string `json:"pctChange"`
+	Bid        string `json:"bid"`
+	Ask        string `json:"ask"`
+	Timestamp  string `json:"timestamp"`
+	CreateDate string `json:"create_date"`
+	Provider   string `json:"provider,omitempty"` // provedor que atendeu a cotação
+	// Timestamp em RFC3339, no fuso pedido com ?tz= (UTC por padrão); preenchido pelo
+	// servidor nas respostas, ausente nas do AwesomeAPI
+	QuotedAt string `json:"quoted_at,omitempty"`
+}
+
+// ExchangeRate segue o formato do AwesomeAPI, indexado pelo par sem hífen: {"USDBRL": {...}}
+type ExchangeRate map[string]Quote
+
+// PairKey converte o par para a chave da resposta: "USD-BRL" -> "USDBRL"
+func PairKey(pair string) string {
+	return strings.ReplaceAll(pair, "-", "")
+}
+
+// Lookup retorna a cotação do par na resposta
+func (r ExchangeRate) Lookup(pair string) (Quote, bool) {
+	quote, ok := r[PairKey(pair)]
+	return quote, ok
+}
+
+// Unix interpreta o timestamp da cotação (segundos Unix em texto)
+func (q Quote) Unix() (int64, error) {
+	return strconv.ParseInt(q.Timestamp, 10, 64)
+}
+
+// Time interpreta o timestamp da cotação como horário em UTC
+func (q Quote) Time() (time.Time, error) {
+	unix, err := q.Unix()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0).UTC(), nil
+}
+
+// CheckWireVersion valida a versão recebida no cabeçalho; a ausência do cabeçalho indica
+// um servidor anterior ao versionamento, compatível com a versão 1
+func CheckWireVersion(header string) error {
+	if header == "" {
+		return nil
+	}
+	version, err := strconv.Atoi(header)
+	if err != nil {
+		return fmt.Errorf("versão do formato inválida %q", header)
+	}
+	if version != WireVersion {
+		return fmt.Errorf("versão do formato %d incompatível com a suportada (%d)", version, WireVersion)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExchangeRateLookup(t *testing.T) {
+	var rate ExchangeRate
+	body := `{"USDBRL":{"code":"USD","codein":"BRL","bid":"5.1","timestamp":"1700000000"}}`
+	if err := json.Unmarshal([]byte(body), &rate); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pair    string
+		wantOK  bool
+		wantBid string
+	}{
+		{"USD-BRL", true, "5.1"},
+		{"USDBRL", true, "5.1"},
+		{"EUR-BRL", false, ""},
+	}
+	for _, tt := range tests {
+		quote, ok := rate.Lookup(tt.pair)
+		if ok != tt.wantOK || quote.Bid != tt.wantBid {
+			t.Errorf("Lookup(%s) = (%q, %v), esperado (%q, %v)", tt.pair, quote.Bid, ok, tt.wantBid, tt.wantOK)
+		}
+	}
+}
+
+func TestQuoteUnix(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		want      int64
+		wantErr   bool
+	}{
+		{"1700000000", 1700000000, false},
+		{"", 0, true},
+		{"2023-11-14", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Quote{Timestamp: tt.timestamp}.Unix()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Unix(%q) = (%d, %v), esperado %d (erro: %v)", tt.timestamp, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestQuoteTime(t *testing.T) {
+	got, err := Quote{Timestamp: "1700000000"}.Time()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2023-11-14T22:13:20Z"; got.Format(time.RFC3339) != want {
+		t.Errorf("Time() = %s, esperado %s", got.Format(time.RFC3339), want)
+	}
+	if _, err := (Quote{Timestamp: "ontem"}).Time(); err == nil {
+		t.Error("Time() de timestamp inválido sem erro")
+	}
+}
+
+func TestCheckWireVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		wantErr bool
+	}{
+		{"", false},
+		{"1", false},
+		{"2", true},
+		{"v1", true},
+	}
+	for _, tt := range tests {
+		if err := CheckWireVersion(tt.header); (err != nil) != tt.wantErr {
+			t.Errorf("CheckWireVersion(%q) = %v, esperado erro: %v", tt.header, err, tt.wantErr)
+		}
+	}
+}
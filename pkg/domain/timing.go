@@ -0,0 +1,19 @@
+package domain
+
+const (
+	// TimingHeader pede ao servidor o detalhamento do tempo gasto na requisição
+	TimingHeader = "X-Debug-Timing"
+	// TimingKey é a chave do detalhamento na resposta de /cotacao, ao lado das cotações
+	TimingKey = "timing"
+)
+
+// Timing detalha onde o servidor gastou o tempo da requisição, em milissegundos. Cache
+// indica a origem da cotação: miss (consultada no provedor), shared (consulta
+// compartilhada com requisições concorrentes), hit (cache dentro da validade ou com a cota
+// do provedor esgotada) ou stale (cache vencido, servido enquanto é atualizado)
+type Timing struct {
+	UpstreamMS float64 `json:"upstream_ms"`
+	Cache      string  `json:"cache"`
+	DBMS       float64 `json:"db_ms"`
+	TotalMS    float64 `json:"total_ms"`
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RateProvider é uma fonte de cotações
+type RateProvider interface {
+	Name() string
+	Fetch(ctx context.Context, pair string) (*Quote, error)
+}
+
+var (
+	providerRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_requests_total",
+		Help: "Tentativas de obter cotações por provedor, com sucesso ou não.",
+	}, []string{"provider"})
+
+	providerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_errors_total",
+		Help: "Falhas ao obter cotações por provedor.",
+	}, []string{"provider"})
+)
+
+type awesomeAPI struct{}
+
+func (awesomeAPI) Name() string { return awesomeAPIProvider }
+
+func (awesomeAPI) Fetch(ctx context.Context, pair string) (*Quote, error) {
+	if quota.exhausted() {
+		return nil, errQuotaExhausted
+	}
+	return GetExchangeRate(ctx, pair)
+}
+
+// providers indexa os provedores disponíveis pelo nome usado na configuração
+var providers = map[string]RateProvider{
+	awesomeAPIProvider:  awesomeAPI{},
+	frankfurterProvider: frankfurter{},
+	ptaxProvider:        ptax{},
+}
+
+// providerChain define a ordem de failover entre os provedores
+var providerChain []RateProvider
+
+func parseProviderChain(names []string) ([]RateProvider, error) {
+	chain := make([]RateProvider, 0, len(names))
+	for _, name := range names {
+		provider, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("provedor desconhecido %q", name)
+		}
+		chain = append(chain, provider)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("nenhum provedor configurado")
+	}
+	return chain, nil
+}
+
+// chainFor devolve a cadeia de failover do par: o provedor cadastrado no par vem primeiro,
+// seguido dos demais provedores de PROVIDERS
+func chainFor(pair string) []RateProvider {
+	row, _ := pairs.get(pair)
+	preferred, ok := providers[row.Provider]
+	if !ok {
+		return providerChain
+	}
+
+	chain := []RateProvider{preferred}
+	for _, provider := range providerChain {
+		if provider.Name() != preferred.Name() {
+			chain = append(chain, provider)
+		}
+	}
+	return chain
+}
+
+// fetchQuote obtém a cotação do par, compondo os provedores quando o par é composto,
+// calculando a expressão das séries derivadas ou percorrendo a cadeia de failover até o
+// primeiro provedor que responder. Sem prazo no contexto, a busca toda dura no máximo
+// UPSTREAM_BUDGET: cada provedor mantém o próprio timeout (200ms no AwesomeAPI), e o que
+// sobrar do prazo fica para o failover
+func fetchQuote(ctx context.Context, pair string) (*Quote, error) {
+	if _, ok := ctx.Deadline(); !ok && cfg.UpstreamBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.UpstreamBudget)
+		defer cancel()
+	}
+	if composite, ok := composites[pair]; ok {
+		return composite.fetch(ctx)
+	}
+	if derived, ok := derivedPairs[pair]; ok {
+		return derived.fetch(ctx)
+	}
+
+	var errs []error
+	for _, provider := range chainFor(pair) {
+		providerRequests.WithLabelValues(provider.Name()).Inc()
+		quote, err := provider.Fetch(ctx, pair)
+		if err == nil {
+			quote.Provider = provider.Name()
+			if err = validateQuote(quote); err == nil {
+				return quote, nil
+			}
+		}
+		// Respostas inválidas contam como falha do provedor e ficam no relatório de qualidade
+		var payloadErr *PayloadError
+		if errors.As(err, &payloadErr) {
+			rejectPayload(ctx, provider.Name(), pair, quote, payloadErr)
+		}
+
+		providerErrors.WithLabelValues(provider.Name()).Inc()
+		logf(ctx, "Provedor %s falhou para %s: %v", provider.Name(), pair, err)
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const frankfurterProvider = "frankfurter"
+
+// frankfurter consulta as taxas de referência do BCE (api.frankfurter.app), sem chave de API;
+// como há uma única taxa por par, compra e venda são iguais
+type frankfurter struct{}
+
+type frankfurterResponse struct {
+	Base  string                     `json:"base"`
+	Date  string                     `json:"date"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+func (frankfurter) Name() string { return frankfurterProvider }
+
+func (frankfurter) Fetch(ctx context.Context, pair string) (*Quote, error) {
+	from, to, ok := strings.Cut(pair, "-")
+	if !ok {
+		return nil, fmt.Errorf("par inválido %q", pair)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/latest?from=%s&to=%s", cfg.FrankfurterBaseURL, url.QueryEscape(from), url.QueryEscape(to))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("frankfurter respondeu com status %d", resp.StatusCode)
+	}
+
+	var body frankfurterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	rate, ok := body.Rates[to]
+	if !ok {
+		return nil, fmt.Errorf("par %s ausente na resposta do frankfurter", pair)
+	}
+
+	date, err := time.Parse(time.DateOnly, body.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	value := rate.String()
+	return &Quote{
+		Code:       from,
+		Codein:     to,
+		Name:       from + "/" + to + " (BCE)",
+		Bid:        value,
+		Ask:        value,
+		Timestamp:  strconv.FormatInt(date.Unix(), 10),
+		CreateDate: date.Format(time.DateTime),
+	}, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const ptaxProvider = "ptax"
+
+// Dias anteriores consultados quando a data não tem boletim (fins de semana e feriados)
+const ptaxLookbackDays = 7
+
+var errPTAXNotFound = errors.New("sem PTAX para a data")
+
+// Cotação oficial de fechamento (PTAX) do Banco Central para uma moeda contra o real
+type PTAXRateDB struct {
+	Currency  string          `gorm:"primaryKey;type:varchar(10)" json:"currency"`
+	Date      string          `gorm:"primaryKey;type:varchar(10)" json:"date"`
+	Buy       decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"buy"`
+	Sell      decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"sell"`
+	QuotedAt  time.Time       `gorm:"not null" json:"quoted_at"`
+	CreatedAt time.Time       `gorm:"not null" json:"-"`
+}
+
+type ptaxResponse struct {
+	Value []struct {
+		CotacaoCompra   decimal.Decimal `json:"cotacaoCompra"`
+		CotacaoVenda    decimal.Decimal `json:"cotacaoVenda"`
+		DataHoraCotacao string          `json:"dataHoraCotacao"`
+		TipoBoletim     string          `json:"tipoBoletim"`
+	} `json:"value"`
+}
+
+// ptax usa a PTAX de fechamento mais recente como cotação; só atende pares contra o real
+type ptax struct{}
+
+func (ptax) Name() string { return ptaxProvider }
+
+func (ptax) Fetch(ctx context.Context, pair string) (*Quote, error) {
+	currency, quoted, _ := strings.Cut(pair, "-")
+	if quoted != "BRL" {
+		return nil, fmt.Errorf("PTAX disponível apenas para pares contra BRL, recebido %s", pair)
+	}
+
+	rate, err := latestPTAX(ctx, currency, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quote{
+		Code:       currency,
+		Codein:     "BRL",
+		Name:       currency + "/BRL (PTAX)",
+		Bid:        rate.Buy.String(),
+		Ask:        rate.Sell.String(),
+		Timestamp:  strconv.FormatInt(rate.QuotedAt.Unix(), 10),
+		CreateDate: rate.QuotedAt.Format(time.DateTime),
+	}, nil
+}
+
+// latestPTAX retorna a PTAX da data ou, na falta de boletim, a do dia útil anterior mais próximo
+func latestPTAX(ctx context.Context, currency string, date time.Time) (*PTAXRateDB, error) {
+	for i := range ptaxLookbackDays {
+		rate, err := ptaxFor(ctx, currency, date.AddDate(0, 0, -i))
+		if errors.Is(err, errPTAXNotFound) {
+			continue
+		}
+		return rate, err
+	}
+	return nil, errPTAXNotFound
+}
+
+// ptaxFor consulta a PTAX de fechamento da data, primeiro no banco e depois no Banco Central;
+// datas passadas são imutáveis e por isso persistidas
+func ptaxFor(ctx context.Context, currency string, date time.Time) (*PTAXRateDB, error) {
+	day := date.Format(time.DateOnly)
+
+	var stored PTAXRateDB
+	err := db.WithContext(ctx).Where("currency = ? AND date = ?", currency, day).Take(&stored).Error
+	if err == nil {
+		return &stored, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	rate, err := fetchPTAX(ctx, currency, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rate).Error; err != nil {
+		logf(ctx, "Erro ao gravar PTAX de %s em %s: %v", currency, day, err)
+	}
+	return rate, nil
+}
+
+func fetchPTAX(ctx context.Context, currency string, date time.Time) (*PTAXRateDB, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("@moeda", "'"+currency+"'")
+	params.Set("@dataCotacao", "'"+date.Format("01-02-2006")+"'")
+	params.Set("$format", "json")
+	endpoint := cfg.PTAXBaseURL + "/CotacaoMoedaDia(moeda=@moeda,dataCotacao=@dataCotacao)?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("PTAX respondeu com status %d", resp.StatusCode)
+	}
+
+	var body ptaxResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	// O boletim de fechamento é a PTAX oficial; os intermediários são ignorados
+	for _, v := range body.Value {
+		if v.TipoBoletim != "Fechamento" {
+			continue
+		}
+		quotedAt, err := time.Parse("2006-01-02 15:04:05.000", v.DataHoraCotacao)
+		if err != nil {
+			return nil, err
+		}
+		return &PTAXRateDB{
+			Currency: currency,
+			Date:     date.Format(time.DateOnly),
+			Buy:      v.CotacaoCompra,
+			Sell:     v.CotacaoVenda,
+			QuotedAt: quotedAt,
+		}, nil
+	}
+	return nil, errPTAXNotFound
+}
+
+// PTAXHandler retorna a PTAX oficial de compra e venda da moeda (?currency=, padrão USD) na
+// data informada (?date=YYYY-MM-DD, padrão hoje)
+func PTAXHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	currency := strings.ToUpper(r.URL.Query().Get("currency"))
+	if currency == "" {
+		currency = "USD"
+	}
+
+	date := time.Now()
+	if v := r.URL.Query().Get("date"); v != "" {
+		var err error
+		if date, err = time.Parse(time.DateOnly, v); err != nil {
+			writeError(w, http.StatusBadRequest, "date deve estar no formato YYYY-MM-DD")
+			return
+		}
+	}
+
+	rate, err := ptaxFor(r.Context(), currency, date)
+	if errors.Is(err, errPTAXNotFound) {
+		writeError(w, http.StatusNotFound, "sem PTAX de fechamento para a data")
+		return
+	}
+	if err != nil {
+		logf(r.Context(), "Erro ao obter PTAX: %v", err)
+		writeError(w, http.StatusBadGateway, "erro ao consultar o Banco Central")
+		return
+	}
+	writeJSON(w, http.StatusOK, rate)
+}
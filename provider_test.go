@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubProvider devolve sempre a mesma cotação ou o mesmo erro, contando as chamadas
+type stubProvider struct {
+	name   string
+	quote  Quote
+	err    error
+	calls  int
+	budget time.Duration // prazo recebido na última chamada
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Fetch(ctx context.Context, _ string) (*Quote, error) {
+	p.calls++
+	if deadline, ok := ctx.Deadline(); ok {
+		p.budget = time.Until(deadline)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	quote := p.quote
+	return &quote, nil
+}
+
+func TestParseProviderChain(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    []string
+		wantErr string
+	}{
+		{names: []string{"awesomeapi"}, want: []string{"awesomeapi"}},
+		{names: []string{"frankfurter", "awesomeapi"}, want: []string{"frankfurter", "awesomeapi"}},
+		{names: []string{"awesomeapi", "desconhecido"}, wantErr: `provedor desconhecido "desconhecido"`},
+		{names: nil, wantErr: "nenhum provedor configurado"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.names, ","), func(t *testing.T) {
+			chain, err := parseProviderChain(tt.names)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := providerNames(chain); !slices.Equal(got, tt.want) {
+				t.Errorf("cadeia = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainFor(t *testing.T) {
+	newTestDB(t)
+	withProviders(t, &stubProvider{name: "a"}, &stubProvider{name: "b"}, &stubProvider{name: "c"})
+	if _, err := pairs.save(PairDB{Symbol: "EUR-BRL", Enabled: true, Provider: "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pair string
+		want []string
+	}{
+		{"USD-BRL", []string{"a", "b", "c"}},
+		{"EUR-BRL", []string{"b", "a", "c"}},
+		{"XXX-YYY", []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		if got := providerNames(chainFor(tt.pair)); !slices.Equal(got, tt.want) {
+			t.Errorf("chainFor(%s) = %v, esperado %v", tt.pair, got, tt.want)
+		}
+	}
+}
+
+func TestFetchQuoteFailover(t *testing.T) {
+	quote := testQuote("5.8", time.Now())
+	failure := errors.New("indisponível")
+
+	tests := []struct {
+		name         string
+		providers    []*stubProvider
+		wantProvider string
+		wantErr      []string
+		wantIs       error
+		wantCalls    []int
+		wantRejected float64 // respostas de "a" rejeitadas pelo bid
+	}{
+		{
+			name:         "primeiro provedor responde",
+			providers:    []*stubProvider{{name: "a", quote: quote}, {name: "b", quote: quote}},
+			wantProvider: "a",
+			wantCalls:    []int{1, 0},
+		},
+		{
+			name:         "failover para o segundo",
+			providers:    []*stubProvider{{name: "a", err: failure}, {name: "b", quote: quote}},
+			wantProvider: "b",
+			wantCalls:    []int{1, 1},
+		},
+		{
+			name:         "cotação inválida faz failover",
+			providers:    []*stubProvider{{name: "a", quote: testQuote("NaN", time.Now())}, {name: "b", quote: quote}},
+			wantProvider: "b",
+			wantCalls:    []int{1, 1},
+			wantRejected: 1,
+		},
+		{
+			name:      "todos falham",
+			providers: []*stubProvider{{name: "a", err: failure}, {name: "b", err: errQuotaExhausted}},
+			wantErr:   []string{"a: indisponível", "b: " + errQuotaExhausted.Error()},
+			wantIs:    errQuotaExhausted,
+			wantCalls: []int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			withProviders(t, tt.providers...)
+			rejected := testutil.ToFloat64(upstreamPayloadsRejected.WithLabelValues("a", "bid"))
+
+			got, err := fetchQuote(context.Background(), "USD-BRL")
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatal("esperado erro")
+				}
+				for _, msg := range tt.wantErr {
+					if !strings.Contains(err.Error(), msg) {
+						t.Errorf("erro %q não contém %q", err, msg)
+					}
+				}
+				if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+					t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Provider != tt.wantProvider {
+					t.Errorf("provedor = %q, esperado %q", got.Provider, tt.wantProvider)
+				}
+			}
+			if got := testutil.ToFloat64(upstreamPayloadsRejected.WithLabelValues("a", "bid")) - rejected; got != tt.wantRejected {
+				t.Errorf("rejeições = %v, esperado %v", got, tt.wantRejected)
+			}
+			for i, p := range tt.providers {
+				if p.calls != tt.wantCalls[i] {
+					t.Errorf("chamadas a %s = %d, esperado %d", p.name, p.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+// Cada provedor mantém o próprio timeout dentro de UPSTREAM_BUDGET, e o que sobrar do prazo
+// fica para o failover
+func TestFetchQuoteBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		budget       time.Duration
+		fallback     bool
+		wantProvider string
+		min, max     time.Duration // duração da busca
+		wantFallback time.Duration // prazo aproximado recebido pelo failover
+	}{
+		{name: "AwesomeAPI sozinho mantém os 200ms", budget: 250 * time.Millisecond,
+			min: awesomeAPITimeout, max: 240 * time.Millisecond},
+		{name: "failover recebe o que sobra do prazo", budget: 300 * time.Millisecond, fallback: true, wantProvider: "b",
+			min: awesomeAPITimeout, max: 280 * time.Millisecond, wantFallback: 100 * time.Millisecond},
+		{name: "prazo menor que o contrato encurta o AwesomeAPI", budget: 100 * time.Millisecond,
+			max: 140 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			withConfig(t, func(c *config.Config) { c.UpstreamBudget = tt.budget })
+			// AwesomeAPI que não responde
+			newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() })
+			fallback := &stubProvider{name: "b", quote: testQuote("5.8", time.Now())}
+			if tt.fallback {
+				providerChain = append(providerChain, fallback)
+			}
+
+			start := time.Now()
+			got, err := fetchQuote(context.Background(), "USD-BRL")
+			elapsed := time.Since(start)
+			if tt.wantProvider == "" {
+				if err == nil {
+					t.Fatalf("cotação de %s, esperado erro", got.Provider)
+				}
+			} else if err != nil || got.Provider != tt.wantProvider {
+				t.Fatalf("fetchQuote = %v, %v; esperado provedor %s", got, err, tt.wantProvider)
+			}
+			if elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("busca levou %v, esperado entre %v e %v", elapsed, tt.min, tt.max)
+			}
+			if tt.fallback && (fallback.budget < tt.wantFallback/2 || fallback.budget > tt.wantFallback) {
+				t.Errorf("prazo do failover = %v, esperado até %v", fallback.budget, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestGetExchangeRateParsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantBid string
+		wantErr string
+	}{
+		{
+			name:    "resposta válida",
+			status:  http.StatusOK,
+			body:    `{"USDBRL":{"code":"USD","codein":"BRL","bid":"5.1234","ask":"5.1240","timestamp":"1700000000"}}`,
+			wantBid: "5.1234",
+		},
+		{
+			name:    "status de erro",
+			status:  http.StatusTooManyRequests,
+			body:    `{}`,
+			wantErr: "upstream respondeu com status 429",
+		},
+		{
+			name:    "JSON inválido",
+			status:  http.StatusOK,
+			body:    `{"USDBRL":`,
+			wantErr: "payload inválido do provedor: JSON malformado: unexpected end of JSON input",
+		},
+		{
+			name:    "par ausente",
+			status:  http.StatusOK,
+			body:    `{"EURBRL":{"bid":"6.0"}}`,
+			wantErr: "payload inválido do provedor: USD-BRL ausente na resposta",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			quote, err := GetExchangeRate(context.Background(), "USD-BRL")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Bid != tt.wantBid {
+				t.Errorf("bid = %s, esperado %s", quote.Bid, tt.wantBid)
+			}
+		})
+	}
+}
+
+// withProviders substitui os provedores disponíveis e a cadeia de failover durante o teste
+func withProviders(t *testing.T, stubs ...*stubProvider) {
+	t.Helper()
+	savedProviders, savedChain := providers, providerChain
+	t.Cleanup(func() { providers, providerChain = savedProviders, savedChain })
+
+	providers = map[string]RateProvider{}
+	providerChain = nil
+	for _, stub := range stubs {
+		providers[stub.name] = stub
+		providerChain = append(providerChain, stub)
+	}
+}
+
+func providerNames(chain []RateProvider) []string {
+	names := make([]string, len(chain))
+	for i, p := range chain {
+		names[i] = p.Name()
+	}
+	return names
+}
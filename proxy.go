@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+const proxyPrefix = "/proxy/last/"
+
+// awesomeAPIError reproduz o corpo de erro do AwesomeAPI para pares inexistentes
+type awesomeAPIError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ProxyHandler espelha o endpoint /last/{pares} do AwesomeAPI, servindo as cotações do cache
+// deste servidor; integrações escritas para o AwesomeAPI só precisam trocar a URL base
+func ProxyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbols := strings.Split(strings.TrimPrefix(r.URL.Path, proxyPrefix), ",")
+	lang := requestLanguage(w, r)
+	rates := make(map[string]any, len(symbols))
+	for _, symbol := range symbols {
+		pair := strings.ToUpper(strings.TrimSpace(symbol))
+		if !pairs.isEnabled(pair) {
+			writeJSON(w, http.StatusNotFound, awesomeAPIError{
+				Status:  http.StatusNotFound,
+				Code:    "CoinNotExists",
+				Message: "moeda nao encontrada " + pair,
+			})
+			return
+		}
+
+		quote, err := proxyQuote(r, pair)
+		if err != nil {
+			logf(r.Context(), "Erro ao obter cotação de %s para o proxy: %v", pair, err)
+			writeJSON(w, http.StatusServiceUnavailable, awesomeAPIError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "ServiceUnavailable",
+				Message: "cotacao indisponivel " + pair,
+			})
+			return
+		}
+
+		// O campo provider não existe no formato do AwesomeAPI
+		mirrored := *quote
+		mirrored.Provider = ""
+		rates[domain.PairKey(pair)] = publicQuote(r, localizeQuote(mirrored, lang))
+	}
+
+	writeJSON(w, http.StatusOK, rates)
+}
+
+// proxyQuote usa o cache enquanto a cotação for recente e busca no provedor caso contrário
+func proxyQuote(r *http.Request, pair string) (*Quote, error) {
+	if entry, ok := cache.get(pair); ok && time.Since(entry.fetchedAt) <= cfg.ProxyCacheTTL {
+		return entry.quote, nil
+	}
+	return fetchAndPersist(r.Context(), pair)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm/clause"
+)
+
+const awesomeAPIProvider = "awesomeapi"
+
+// Percentual da cota diária a partir do qual um aviso é emitido
+const quotaWarningThreshold = 0.8
+
+// A contagem fica em memória e é gravada no banco a cada quotaFlushInterval, fora do caminho
+// das requisições; um reinício perde no máximo as chamadas desse intervalo
+const (
+	quotaFlushInterval = 5 * time.Second
+	quotaFlushTimeout  = 2 * time.Second
+)
+
+var errQuotaExhausted = errors.New("cota diária do provedor esgotada")
+
+var (
+	upstreamCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_calls_total",
+		Help: "Chamadas realizadas aos provedores de cotação.",
+	}, []string{"provider"})
+
+	upstreamQuotaRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upstream_quota_remaining",
+		Help: "Chamadas restantes na cota diária do provedor (-1 quando ilimitada).",
+	}, []string{"provider"})
+)
+
+// Contagem diária de chamadas por provedor, persistida para sobreviver a reinícios
+type ProviderUsageDB struct {
+	Provider string `gorm:"primaryKey;type:varchar(50)"`
+	Day      string `gorm:"primaryKey;type:varchar(10)"` // YYYY-MM-DD em UTC
+	Calls    int    `gorm:"not null"`
+}
+
+type ProviderStatus struct {
+	Name       string `json:"name"`
+	DailyQuota int    `json:"daily_quota"`
+	CallsToday int    `json:"calls_today"`
+	Remaining  int    `json:"remaining"`
+	Warning    bool   `json:"warning"`
+	CacheOnly  bool   `json:"cache_only"`
+}
+
+type quotaTracker struct {
+	mu       sync.Mutex
+	provider string
+	limit    int
+	day      string
+	calls    int
+	warned   bool
+	dirty    bool             // contagem do dia ainda não gravada
+	previous *ProviderUsageDB // contagem do dia anterior ainda não gravada
+}
+
+var quota = &quotaTracker{provider: awesomeAPIProvider}
+
+func today() string {
+	return time.Now().UTC().Format(time.DateOnly)
+}
+
+// load restaura a contagem do dia corrente a partir do banco
+func (q *quotaTracker) load(limit int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.limit = limit
+	q.day = today()
+
+	var usage ProviderUsageDB
+	err := db.Where("provider = ? AND day = ?", q.provider, q.day).Limit(1).Find(&usage).Error
+	if err != nil {
+		return err
+	}
+	q.calls = usage.Calls
+	q.updateGauge()
+	return nil
+}
+
+// rollover zera o contador na virada do dia; deve ser chamado com o lock adquirido
+func (q *quotaTracker) rollover() {
+	if d := today(); d != q.day {
+		if q.dirty {
+			q.previous = &ProviderUsageDB{Provider: q.provider, Day: q.day, Calls: q.calls}
+			q.dirty = false
+		}
+		q.day = d
+		q.calls = 0
+		q.warned = false
+	}
+}
+
+func (q *quotaTracker) exhausted() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	return q.limit > 0 && q.calls >= q.limit
+}
+
+// increment contabiliza uma chamada ao upstream. Só a memória é alterada: o total é gravado
+// por flush, e nem isso quando a cota é ilimitada
+func (q *quotaTracker) increment() {
+	upstreamCalls.WithLabelValues(q.provider).Inc()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	q.calls++
+	if q.limit <= 0 {
+		return
+	}
+	q.dirty = true
+	if !q.warned && float64(q.calls) >= quotaWarningThreshold*float64(q.limit) {
+		q.warned = true
+		log.Printf("Aviso: %d de %d chamadas diárias ao provedor %s já utilizadas", q.calls, q.limit, q.provider)
+	}
+	q.updateGauge()
+}
+
+// flush grava no banco as contagens alteradas desde a última gravação
+func (q *quotaTracker) flush(ctx context.Context) error {
+	q.mu.Lock()
+	var rows []ProviderUsageDB
+	if q.previous != nil {
+		rows = append(rows, *q.previous)
+	}
+	if q.dirty {
+		rows = append(rows, ProviderUsageDB{Provider: q.provider, Day: q.day, Calls: q.calls})
+	}
+	q.previous, q.dirty = nil, false
+	q.mu.Unlock()
+	if len(rows) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, quotaFlushTimeout)
+	defer cancel()
+	err := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"calls"}),
+	}).Create(&rows).Error
+	if err != nil {
+		// A próxima gravação leva a contagem atual do dia; a do dia anterior é descartada
+		q.mu.Lock()
+		if rows[len(rows)-1].Day == q.day {
+			q.dirty = true
+		}
+		q.mu.Unlock()
+	}
+	return err
+}
+
+// startQuotaFlush grava a contagem a cada quotaFlushInterval
+func startQuotaFlush(ctx context.Context) {
+	runPeriodically(ctx, quotaFlushInterval, func(ctx context.Context) {
+		if err := quota.flush(ctx); err != nil {
+			log.Printf("Erro ao persistir uso do provedor %s: %v", quota.provider, err)
+		}
+	})
+}
+
+func (q *quotaTracker) remaining() int {
+	if q.limit <= 0 {
+		return -1
+	}
+	return max(q.limit-q.calls, 0)
+}
+
+func (q *quotaTracker) updateGauge() {
+	upstreamQuotaRemaining.WithLabelValues(q.provider).Set(float64(q.remaining()))
+}
+
+func (q *quotaTracker) status() ProviderStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+
+	return ProviderStatus{
+		Name:       q.provider,
+		DailyQuota: q.limit,
+		CallsToday: q.calls,
+		Remaining:  q.remaining(),
+		Warning:    q.limit > 0 && float64(q.calls) >= quotaWarningThreshold*float64(q.limit),
+		CacheOnly:  q.limit > 0 && q.calls >= q.limit,
+	}
+}
+
+// ProvidersHandler expõe o consumo da cota diária de cada provedor
+func ProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, []ProviderStatus{quota.status()})
+}
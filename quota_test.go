@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// A contagem só chega ao banco pelo flush, e nunca com a cota ilimitada
+func TestQuotaFlush(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      int
+		calls      int
+		wantStored int // chamadas gravadas após o flush; 0 sem linha
+	}{
+		{name: "cota ilimitada não grava", limit: 0, calls: 3},
+		{name: "cota limitada grava no flush", limit: 10, calls: 3, wantStored: 3},
+		{name: "sem chamadas não grava", limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := newTestDB(t)
+			if err := quota.load(tt.limit); err != nil {
+				t.Fatal(err)
+			}
+			for range tt.calls {
+				quota.increment()
+			}
+
+			stored := func() int {
+				var usage ProviderUsageDB
+				if err := conn.Where("provider = ?", quota.provider).Limit(1).Find(&usage).Error; err != nil {
+					t.Fatal(err)
+				}
+				return usage.Calls
+			}
+			if got := stored(); got != 0 {
+				t.Fatalf("chamadas gravadas antes do flush = %d, esperado 0", got)
+			}
+			if err := quota.flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := stored(); got != tt.wantStored {
+				t.Errorf("chamadas gravadas = %d, esperado %d", got, tt.wantStored)
+			}
+			if got := quota.status().CallsToday; got != tt.calls {
+				t.Errorf("chamadas do dia = %d, esperado %d", got, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package main
+
+// raceDetector indica os testes compilados com -race, em que as latências não são representativas
+const raceDetector = true
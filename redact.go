@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// redactedFields são os campos da cotação omitidos nas respostas a chamadas anônimas
+// (REDACT_FIELDS); quem envia um token válido recebe a cotação completa
+var redactedFields map[string]bool
+
+// parseRedactedFields valida os nomes contra as tags JSON de Quote
+func parseRedactedFields(names []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	t := reflect.TypeOf(Quote{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+
+	fields := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !known[name] {
+			return nil, fmt.Errorf("campo %q não existe na cotação", name)
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// isAuthenticated indica se a requisição traz um token válido, sem exigi-lo
+func isAuthenticated(r *http.Request) bool {
+	tokenString, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || tokenString == "" {
+		return false
+	}
+	userID, err := parseToken(tokenString)
+	return err == nil && userID != 0
+}
+
+// publicQuote retorna a cotação como deve ser servida ao chamador: completa para chamadas
+// autenticadas e sem os campos de REDACT_FIELDS para as anônimas
+func publicQuote(r *http.Request, quote Quote) any {
+	if len(redactedFields) == 0 || isAuthenticated(r) {
+		return quote
+	}
+
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return quote
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return quote
+	}
+	for name := range redactedFields {
+		delete(fields, name)
+	}
+	return fields
+}
+
+// redactQuote é o publicQuote das respostas tipadas (GraphQL): os campos de REDACT_FIELDS
+// voltam vazios para quem não está autenticado
+func redactQuote(quote Quote, authenticated bool) Quote {
+	if len(redactedFields) == 0 || authenticated {
+		return quote
+	}
+	v := reflect.ValueOf(&quote).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if redactedFields[name] {
+			v.Field(i).SetZero()
+		}
+	}
+	return quote
+}
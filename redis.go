@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+// Prazo de cada operação no Redis; esgotado, a réplica segue com o próprio estado
+const redisTimeout = 200 * time.Millisecond
+
+var redisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "redis_errors_total",
+	Help: "Falhas nas operações com o Redis por operação (cache, lock); a réplica segue com o estado local.",
+}, []string{"op"})
+
+// redisClient é a conexão de REDIS_URL, nil sem Redis; usada também pela eleição de líder
+var redisClient *redis.Client
+
+// redisFailing evita repetir no log a mesma indisponibilidade a cada operação
+var redisFailing atomic.Bool
+
+// openRedis conecta ao Redis de REDIS_URL. Sem resposta na inicialização o servidor não sobe;
+// depois disso as falhas são toleradas: o cache volta a ser o da réplica e o agendador
+// consulta o provedor sem reservar o par
+func openRedis(url string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL inválida: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// reportRedis registra o resultado de uma operação, com uma linha de log quando o Redis
+// deixa de responder e outra quando volta
+func reportRedis(op string, err error) {
+	if err == nil {
+		if redisFailing.CompareAndSwap(true, false) {
+			log.Printf("Redis voltou a responder")
+		}
+		return
+	}
+	redisErrors.WithLabelValues(op).Inc()
+	if redisFailing.CompareAndSwap(false, true) {
+		log.Printf("Erro no Redis (%s), usando o estado local da réplica: %v", op, err)
+	}
+}
+
+// redisQuoteCache guarda a última cotação de cada par no Redis, em JSON, para que as
+// réplicas sirvam a cotação obtida por qualquer uma delas
+type redisQuoteCache struct {
+	client *redis.Client
+	prefix string
+}
+
+type redisCachedQuote struct {
+	Quote     *Quote    `json:"quote"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func (c *redisQuoteCache) key(pair string) string {
+	return c.prefix + "quote:" + pair
+}
+
+func (c *redisQuoteCache) load(ctx context.Context, pair string) (cachedQuote, bool, error) {
+	data, err := c.client.Get(ctx, c.key(pair)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return cachedQuote{}, false, nil
+	}
+	if err != nil {
+		return cachedQuote{}, false, err
+	}
+	var entry redisCachedQuote
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Quote == nil {
+		return cachedQuote{}, false, fmt.Errorf("cotação de %s inválida no Redis: %w", pair, err)
+	}
+	return cachedQuote{quote: entry.Quote, fetchedAt: entry.FetchedAt}, true, nil
+}
+
+// store grava sem expiração, como o cache local: a cotação vencida ainda atende a cota
+// esgotada e o stale-while-revalidate
+func (c *redisQuoteCache) store(ctx context.Context, pair string, entry cachedQuote) error {
+	data, err := json.Marshal(redisCachedQuote{Quote: entry.quote, FetchedAt: entry.fetchedAt})
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, c.key(pair), data, 0).Err()
+}
+
+func (c *redisQuoteCache) remove(ctx context.Context, pair string) error {
+	return c.client.Del(ctx, c.key(pair)).Err()
+}
+
+// redisPollLock reserva a consulta de um par com SET NX: a réplica que grava a chave consulta
+// o provedor, e a chave expira sozinha ao fim da reserva, mesmo se a réplica cair
+type redisPollLock struct {
+	client *redis.Client
+	prefix string
+	owner  string // identifica a réplica que reservou, para diagnóstico
+}
+
+func (l *redisPollLock) acquire(ctx context.Context, pair string, lease time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, l.prefix+"poll:"+pair, l.owner, lease).Result()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSharedCache faz o papel do Redis entre duas réplicas no mesmo processo
+type fakeSharedCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedQuote
+	err     error
+}
+
+func (c *fakeSharedCache) load(_ context.Context, pair string) (cachedQuote, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return cachedQuote{}, false, c.err
+	}
+	entry, ok := c.entries[pair]
+	return entry, ok, nil
+}
+
+func (c *fakeSharedCache) store(_ context.Context, pair string, entry cachedQuote) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.entries[pair] = entry
+	}
+	return c.err
+}
+
+func (c *fakeSharedCache) remove(_ context.Context, pair string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, pair)
+	return c.err
+}
+
+type fakePollLock struct {
+	mu   sync.Mutex
+	held map[string]time.Time
+	err  error
+}
+
+func (l *fakePollLock) acquire(_ context.Context, pair string, lease time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false, l.err
+	}
+	if until, ok := l.held[pair]; ok && time.Now().Before(until) {
+		return false, nil
+	}
+	l.held[pair] = time.Now().Add(lease)
+	return true, nil
+}
+
+func TestSharedQuoteCache(t *testing.T) {
+	shared := &fakeSharedCache{entries: make(map[string]cachedQuote)}
+	replicaA := &quoteCache{entries: make(map[string]cachedQuote), shared: shared}
+	replicaB := &quoteCache{entries: make(map[string]cachedQuote), shared: shared}
+
+	older := testQuote("5.0", time.Now())
+	replicaB.entries["USD-BRL"] = cachedQuote{quote: &older, fetchedAt: time.Now().Add(-time.Minute)}
+	newer := testQuote("5.1", time.Now())
+	replicaA.set("USD-BRL", &newer)
+
+	tests := []struct {
+		name      string
+		redisErr  error
+		removeByA bool
+		wantBid   string // vazio: sem cotação
+		wantSameB bool   // a leitura seguinte devolve o mesmo ponteiro
+	}{
+		{name: "réplica adota a cotação mais recente do Redis", wantBid: "5.1", wantSameB: true},
+		{name: "Redis indisponível usa o cache local", redisErr: errors.New("connection refused"), wantBid: "5.1"},
+		{name: "remoção vale para todas as réplicas", removeByA: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared.err = tt.redisErr
+			if tt.removeByA {
+				replicaA.delete("USD-BRL")
+			}
+
+			entry, ok := replicaB.get("USD-BRL")
+			if tt.wantBid == "" {
+				if ok {
+					t.Fatalf("cotação %s ainda em cache", entry.quote.Bid)
+				}
+				if _, ok := shared.entries["USD-BRL"]; ok {
+					t.Error("cotação ainda no Redis")
+				}
+				return
+			}
+			if !ok || entry.quote.Bid != tt.wantBid {
+				t.Fatalf("cotação = %+v (%v), esperado bid %s", entry.quote, ok, tt.wantBid)
+			}
+			if again, _ := replicaB.get("USD-BRL"); tt.wantSameB && again.quote != entry.quote {
+				t.Error("a segunda leitura devolveu outra cotação")
+			}
+		})
+	}
+}
+
+func TestSchedulerPollLock(t *testing.T) {
+	tests := []struct {
+		name          string
+		lockErr       error
+		wantCalls     int32
+		wantElsewhere int64
+	}{
+		{name: "uma réplica consulta por intervalo", wantCalls: 1, wantElsewhere: 1},
+		{name: "sem Redis as duas consultam", lockErr: errors.New("connection refused"), wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			var calls atomic.Int32
+			upstream := lastHandler("USD-BRL", testQuote("5.2", time.Now()))
+			newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				upstream(w, r)
+			})
+			saved := pollLock
+			t.Cleanup(func() { pollLock = saved })
+			pollLock = &fakePollLock{held: make(map[string]time.Time), err: tt.lockErr}
+
+			var elsewhere int64
+			for range 2 {
+				s := newScheduler(time.Minute, 10)
+				s.next["USD-BRL"] = time.Now().Add(-time.Second)
+				s.poll(context.Background())
+				elsewhere += s.status().Elsewhere
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("consultas ao provedor = %d, esperado %d", got, tt.wantCalls)
+			}
+			if elsewhere != tt.wantElsewhere {
+				t.Errorf("polled_elsewhere = %d, esperado %d", elsewhere, tt.wantElsewhere)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RefreshResult é o resultado da atualização de um par; Quote é omitida em caso de falha
+type RefreshResult struct {
+	Pair  string `json:"pair"`
+	Quote *Quote `json:"quote,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type RefreshResponse struct {
+	Refreshed int             `json:"refreshed"`
+	Failed    int             `json:"failed"`
+	Results   []RefreshResult `json:"results"`
+}
+
+// RefreshHandler força a busca no upstream e a gravação da cotação de ?pair= ou, sem ele, de
+// todos os pares habilitados, ignorando o cache; útil após um incidente no provedor.
+// Responde 502 quando nenhum par pôde ser atualizado
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbols := pairs.enabledSymbols()
+	if pair := strings.ToUpper(r.URL.Query().Get("pair")); pair != "" {
+		if !pairs.isEnabled(pair) {
+			writeError(w, http.StatusNotFound, "par não habilitado")
+			return
+		}
+		symbols = []string{pair}
+	}
+
+	resp := RefreshResponse{Results: make([]RefreshResult, len(symbols))}
+	var wg sync.WaitGroup
+	for i, pair := range symbols {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := RefreshResult{Pair: pair}
+			quote, err := fetchFresh(r.Context(), pair)
+			if err != nil {
+				logf(r.Context(), "Atualização manual: erro ao obter cotação de %s: %v", pair, err)
+				result.Error = err.Error()
+			} else {
+				result.Quote = quote
+			}
+			resp.Results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		if result.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Refreshed++
+		}
+	}
+	logf(r.Context(), "Atualização manual: %d pares atualizados, %d falhas", resp.Refreshed, resp.Failed)
+
+	status := http.StatusOK
+	if resp.Refreshed == 0 && resp.Failed > 0 {
+		status = http.StatusBadGateway
+	}
+	writeJSON(w, status, resp)
+}
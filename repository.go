@@ -0,0 +1,211 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	storageSQLite = "sqlite"
+	storageMemory = "memory"
+)
+
+// RateRepository abstrai o armazenamento das cotações brutas
+type RateRepository interface {
+	// Save grava a cotação ou retorna errDuplicateRate se o par já tiver uma no mesmo timestamp
+	Save(ctx context.Context, row *USDToBRLRateDB) error
+	// Latest retorna as cotações mais recentes de todos os pares, da mais nova para a mais antiga
+	Latest(ctx context.Context, limit int) ([]USDToBRLRateDB, error)
+	// Range retorna as cotações do par em [from, to), em ordem cronológica
+	Range(ctx context.Context, pair string, from, to time.Time, limit int) ([]USDToBRLRateDB, error)
+	// List retorna a página de cotações que atende aos filtros e o total de cotações filtradas
+	List(ctx context.Context, q listQuery) ([]USDToBRLRateDB, int64, error)
+}
+
+var rateRepo RateRepository
+
+// outboxRepository é implementado pelos backends que gravam a cotação e o evento da outbox
+// na mesma transação; nos demais a gravação passa por uma intenção na outbox (saveWithIntent)
+type outboxRepository interface {
+	SaveWithEvent(ctx context.Context, row *USDToBRLRateDB, event *OutboxEventDB) error
+}
+
+// maintainedRepository é implementado pelos backends com manutenção periódica própria,
+// como a criação e a remoção de partições no Postgres
+type maintainedRepository interface {
+	startMaintenance(ctx context.Context)
+}
+
+func newRateRepository() (RateRepository, error) {
+	switch cfg.StorageBackend {
+	case storageSQLite:
+		return &gormRateRepository{db: db}, nil
+	case storageMemory:
+		if cfg.MemoryMaxRates <= 0 {
+			return nil, fmt.Errorf("MEMORY_MAX_RATES deve ser positivo")
+		}
+		return newMemoryRateRepository(cfg.MemoryMaxRates), nil
+	case storageBadger:
+		return newBadgerRateRepository(cfg.BadgerPath, cfg.RetentionRaw)
+	case storagePostgres:
+		if cfg.PostgresPartitionsAhead < 0 {
+			return nil, fmt.Errorf("POSTGRES_PARTITIONS_AHEAD não pode ser negativo")
+		}
+		return newPostgresRateRepository(cfg.PostgresDSN, cfg.PostgresPartitionsAhead, cfg.RetentionRaw)
+	default:
+		return nil, fmt.Errorf("backend de armazenamento desconhecido %q", cfg.StorageBackend)
+	}
+}
+
+type gormRateRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRateRepository) Save(ctx context.Context, row *USDToBRLRateDB) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return insertRate(tx, row)
+	})
+}
+
+func (r *gormRateRepository) SaveWithEvent(ctx context.Context, row *USDToBRLRateDB, event *OutboxEventDB) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := insertRate(tx, row); err != nil {
+			return err
+		}
+		audit := insertAudit(row)
+		if err := tx.Create(&audit).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// insertRate grava a cotação ignorando o conflito no índice único de (pair, timestamp) e
+// atualiza os resumos; deve ser chamada dentro de uma transação
+func insertRate(tx *gorm.DB, row *USDToBRLRateDB) error {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errDuplicateRate
+	}
+	return addToSummaries(tx, []USDToBRLRateDB{*row})
+}
+
+func (r *gormRateRepository) Latest(ctx context.Context, limit int) ([]USDToBRLRateDB, error) {
+	var rows []USDToBRLRateDB
+	err := r.db.WithContext(ctx).Order("timestamp desc").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+func (r *gormRateRepository) Range(ctx context.Context, pair string, from, to time.Time, limit int) ([]USDToBRLRateDB, error) {
+	var rows []USDToBRLRateDB
+	err := r.db.WithContext(ctx).
+		Where("pair = ? AND timestamp >= ? AND timestamp < ?", pair, from.Unix(), to.Unix()).
+		Order("timestamp").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+func (r *gormRateRepository) List(ctx context.Context, q listQuery) ([]USDToBRLRateDB, int64, error) {
+	where, args := q.rateConditions()
+	tx := r.db.WithContext(ctx).Model(&USDToBRLRateDB{}).Where(where, args...)
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []USDToBRLRateDB
+	err := tx.Order(fmt.Sprintf("%s %s, id %[2]s", q.Sort, q.direction())).
+		Offset(q.offset()).Limit(q.PerPage).Find(&rows).Error
+	return rows, total, err
+}
+
+// memoryRateRepository guarda as últimas cotações em um buffer circular; ao atingir o
+// tamanho máximo, cada nova cotação substitui a mais antiga
+type memoryRateRepository struct {
+	mu     sync.RWMutex
+	rows   []USDToBRLRateDB
+	next   int // posição da próxima gravação
+	full   bool
+	lastID uint
+	stored map[rateKey]bool // cotações presentes no buffer, para descartar as repetidas
+}
+
+type rateKey struct {
+	pair      string
+	timestamp int64
+}
+
+func newMemoryRateRepository(size int) *memoryRateRepository {
+	return &memoryRateRepository{rows: make([]USDToBRLRateDB, size), stored: make(map[rateKey]bool)}
+}
+
+func (m *memoryRateRepository) Save(_ context.Context, row *USDToBRLRateDB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := rateKey{row.Pair, row.Timestamp}
+	if m.stored[key] {
+		return errDuplicateRate
+	}
+	if m.full {
+		evicted := m.rows[m.next]
+		delete(m.stored, rateKey{evicted.Pair, evicted.Timestamp})
+	}
+	m.stored[key] = true
+
+	m.lastID++
+	row.ID = m.lastID
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = time.Now()
+	}
+
+	m.rows[m.next] = *row
+	m.next = (m.next + 1) % len(m.rows)
+	if m.next == 0 {
+		m.full = true
+	}
+	return nil
+}
+
+// snapshot copia as cotações armazenadas, da mais antiga para a mais nova
+func (m *memoryRateRepository) snapshot() []USDToBRLRateDB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.full {
+		return slices.Clone(m.rows[:m.next])
+	}
+	return append(slices.Clone(m.rows[m.next:]), m.rows[:m.next]...)
+}
+
+func (m *memoryRateRepository) Latest(_ context.Context, limit int) ([]USDToBRLRateDB, error) {
+	rows := m.snapshot()
+	slices.Reverse(rows)
+	slices.SortStableFunc(rows, func(a, b USDToBRLRateDB) int { return cmp.Compare(b.Timestamp, a.Timestamp) })
+	return rows[:min(limit, len(rows))], nil
+}
+
+func (m *memoryRateRepository) List(_ context.Context, q listQuery) ([]USDToBRLRateDB, int64, error) {
+	rows, total := pageRates(m.snapshot(), q)
+	return rows, total, nil
+}
+
+func (m *memoryRateRepository) Range(_ context.Context, pair string, from, to time.Time, limit int) ([]USDToBRLRateDB, error) {
+	var rows []USDToBRLRateDB
+	for _, row := range m.snapshot() {
+		if row.Pair == pair && row.Timestamp >= from.Unix() && row.Timestamp < to.Unix() {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b USDToBRLRateDB) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	return rows[:min(limit, len(rows))], nil
+}
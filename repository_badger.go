@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const storageBadger = "badger"
+
+// Prefixos das chaves: "r/{par}/{timestamp}{id}" indexa por par em ordem cronológica e
+// "t/{timestamp}{id}" mantém a ordem global usada por Latest
+var (
+	badgerPairPrefix = []byte("r/")
+	badgerTimePrefix = []byte("t/")
+)
+
+// badgerRateRepository guarda as cotações no Badger, sem CGO; o TTL das entradas aplica a
+// política de retenção dos dados brutos
+type badgerRateRepository struct {
+	db  *badger.DB
+	ids *badger.Sequence
+	ttl time.Duration
+}
+
+func newBadgerRateRepository(path string, ttl time.Duration) (*badgerRateRepository, error) {
+	opts := badger.DefaultOptions(path).WithLogger(nil)
+	bdb, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := bdb.GetSequence([]byte("seq/rates"), 100)
+	if err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	return &badgerRateRepository{db: bdb, ids: ids, ttl: ttl}, nil
+}
+
+// orderKey codifica timestamp e id em big-endian, para que a ordem das chaves seja a cronológica
+func orderKey(prefix []byte, timestamp int64, id uint) []byte {
+	key := make([]byte, len(prefix)+16)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(timestamp))
+	binary.BigEndian.PutUint64(key[len(prefix)+8:], uint64(id))
+	return key
+}
+
+func pairPrefix(pair string) []byte {
+	return append(append([]byte{}, badgerPairPrefix...), pair+"/"...)
+}
+
+func (b *badgerRateRepository) Save(ctx context.Context, row *USDToBRLRateDB) error {
+	id, err := b.ids.Next()
+	if err != nil {
+		return err
+	}
+	// Sequence começa em 0, mas o id 0 é tratado como ausente pelo restante do código
+	row.ID = uint(id) + 1
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = time.Now()
+	}
+
+	value, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		// Qualquer chave do par no mesmo timestamp é a mesma cotação, com outro id
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = orderKey(pairPrefix(row.Pair), row.Timestamp, 0)[:len(pairPrefix(row.Pair))+8]
+		it := txn.NewIterator(opts)
+		it.Rewind()
+		exists := it.Valid()
+		it.Close()
+		if exists {
+			return errDuplicateRate
+		}
+
+		for _, key := range [][]byte{
+			orderKey(pairPrefix(row.Pair), row.Timestamp, row.ID),
+			orderKey(badgerTimePrefix, row.Timestamp, row.ID),
+		} {
+			entry := badger.NewEntry(key, value)
+			if b.ttl > 0 {
+				entry = entry.WithTTL(b.ttl)
+			}
+			if err := txn.SetEntry(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *badgerRateRepository) Latest(ctx context.Context, limit int) ([]USDToBRLRateDB, error) {
+	var rows []USDToBRLRateDB
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		opts.Prefix = badgerTimePrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// Na iteração reversa a busca parte da maior chave possível do prefixo
+		seek := append(append([]byte{}, badgerTimePrefix...), 0xFF)
+		for it.Seek(seek); it.Valid() && len(rows) < limit; it.Next() {
+			row, err := decodeRate(it.Item())
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// List percorre todas as cotações, pois o Badger só ordena pelas chaves
+func (b *badgerRateRepository) List(ctx context.Context, q listQuery) ([]USDToBRLRateDB, int64, error) {
+	var rows []USDToBRLRateDB
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = badgerTimePrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			row, err := decodeRate(it.Item())
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := pageRates(rows, q)
+	return page, total, nil
+}
+
+func (b *badgerRateRepository) Range(ctx context.Context, pair string, from, to time.Time, limit int) ([]USDToBRLRateDB, error) {
+	prefix := pairPrefix(pair)
+	end := orderKey(prefix, to.Unix(), 0)
+
+	var rows []USDToBRLRateDB
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(orderKey(prefix, from.Unix(), 0)); it.Valid() && len(rows) < limit; it.Next() {
+			if string(it.Item().Key()) >= string(end) {
+				break
+			}
+			row, err := decodeRate(it.Item())
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// Deduplicate percorre as chaves de cada par em ordem e remove as que repetem o timestamp da
+// anterior, junto com a respectiva chave da ordem global
+func (b *badgerRateRepository) Deduplicate(ctx context.Context) (DedupResult, error) {
+	var (
+		result    DedupResult
+		duplicate [][]byte
+	)
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = badgerPairPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var previous []byte
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			key := it.Item().KeyCopy(nil)
+			// A chave termina com timestamp e id; sem o id, identifica par e timestamp
+			current := key[:len(key)-8]
+			if string(current) != string(previous) {
+				previous = current
+				continue
+			}
+
+			timestamp := int64(binary.BigEndian.Uint64(current[len(current)-8:]))
+			id := uint(binary.BigEndian.Uint64(key[len(key)-8:]))
+			duplicate = append(duplicate, key, orderKey(badgerTimePrefix, timestamp, id))
+
+			at := time.Unix(timestamp, 0).UTC()
+			if result.From.IsZero() || at.Before(result.From) {
+				result.From = at
+			}
+			if at.After(result.To) {
+				result.To = at
+			}
+			result.Removed++
+		}
+		return nil
+	})
+	if err != nil || len(duplicate) == 0 {
+		return result, err
+	}
+
+	batch := b.db.NewWriteBatch()
+	defer batch.Cancel()
+	for _, key := range duplicate {
+		if err := batch.Delete(key); err != nil {
+			return DedupResult{}, err
+		}
+	}
+	if err := batch.Flush(); err != nil {
+		return DedupResult{}, err
+	}
+	return result, nil
+}
+
+func (b *badgerRateRepository) Close() error {
+	b.ids.Release()
+	return b.db.Close()
+}
+
+func decodeRate(item *badger.Item) (USDToBRLRateDB, error) {
+	var row USDToBRLRateDB
+	err := item.Value(func(v []byte) error {
+		return json.Unmarshal(v, &row)
+	})
+	return row, err
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const storagePostgres = "postgres"
+
+// A tabela rates é particionada por mês pelo timestamp (Unix, em segundos): cada partição
+// rates_yYYYYmMM cobre um mês em UTC, e rates_default recebe o que cair fora delas
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS rates (
+    id          bigserial,
+    uid         varchar(36) NOT NULL,
+    code        varchar(10) NOT NULL,
+    pair        varchar(21) NOT NULL DEFAULT 'USD-BRL',
+    bid         numeric(10,4) NOT NULL,
+    ask         numeric(10,4) NOT NULL,
+    timestamp   bigint NOT NULL,
+    request_id  varchar(128),
+    create_date timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (timestamp, id)
+) PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS rates_default PARTITION OF rates DEFAULT;
+ALTER TABLE rates ADD COLUMN IF NOT EXISTS created_by varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE rates ADD COLUMN IF NOT EXISTS source varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE rates ADD COLUMN IF NOT EXISTS provider varchar(20) NOT NULL DEFAULT '';
+`
+
+// Colunas lidas pelas consultas, na ordem do Scan de query
+const postgresRateColumns = "id, uid, code, pair, bid, ask, timestamp, COALESCE(request_id, ''), created_by, source, provider, create_date"
+
+var partitionNamePattern = regexp.MustCompile(`^rates_y(\d{4})m(\d{2})$`)
+
+// postgresRateRepository guarda as cotações em uma tabela particionada por mês; a manutenção
+// periódica cria as partições dos próximos meses e descarta as que saíram da retenção, o que
+// mantém os índices pequenos mesmo com anos de cotações
+type postgresRateRepository struct {
+	db        *sql.DB
+	ahead     int
+	retention time.Duration
+}
+
+func newPostgresRateRepository(dsn string, ahead int, retention time.Duration) (*postgresRateRepository, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("POSTGRES_DSN é obrigatório para o backend %s", storagePostgres)
+	}
+	pdb, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &postgresRateRepository{db: pdb, ahead: ahead, retention: retention}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := pdb.ExecContext(ctx, postgresSchema); err != nil {
+		pdb.Close()
+		return nil, fmt.Errorf("erro ao criar a tabela rates: %w", err)
+	}
+	if err := repo.maintainPartitions(ctx); err != nil {
+		pdb.Close()
+		return nil, err
+	}
+	if err := repo.ensureUniqueIndex(ctx); err != nil {
+		pdb.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// ensureUniqueIndex cria o índice único de (pair, timestamp), que inclui a chave de partição
+// como o Postgres exige. Tabelas com cotações repetidas gravadas antes dele ficam com o
+// índice comum até a deduplicação (POST /admin/dedup)
+func (p *postgresRateRepository) ensureUniqueIndex(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, "CREATE UNIQUE INDEX IF NOT EXISTS rates_pair_timestamp_key ON rates (pair, timestamp)")
+	if err == nil {
+		_, err = p.db.ExecContext(ctx, "DROP INDEX IF EXISTS rates_pair_timestamp_idx")
+		return err
+	}
+
+	log.Printf("AVISO: a tabela rates tem cotações repetidas (%v); execute POST /admin/dedup", err)
+	_, err = p.db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS rates_pair_timestamp_idx ON rates (pair, timestamp)")
+	return err
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func partitionName(month time.Time) string {
+	return fmt.Sprintf("rates_y%04dm%02d", month.Year(), int(month.Month()))
+}
+
+// maintainPartitions cria as partições do mês atual e dos próximos ahead meses e remove as
+// que terminam antes do corte da retenção
+func (p *postgresRateRepository) maintainPartitions(ctx context.Context) error {
+	now := time.Now().UTC()
+	for i := 0; i <= p.ahead; i++ {
+		month := monthStart(now).AddDate(0, i, 0)
+		ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF rates FOR VALUES FROM (%d) TO (%d)",
+			partitionName(month), month.Unix(), month.AddDate(0, 1, 0).Unix())
+		if _, err := p.db.ExecContext(ctx, ddl); err != nil {
+			return fmt.Errorf("erro ao criar a partição %s: %w", partitionName(month), err)
+		}
+	}
+
+	if p.retention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-p.retention)
+
+	rows, err := p.db.QueryContext(ctx, `SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class parent ON parent.oid = i.inhparent
+		WHERE parent.relname = 'rates'`)
+	if err != nil {
+		return err
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		m := partitionNamePattern.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		month, err := time.Parse("2006-01", m[1]+"-"+m[2])
+		if err != nil {
+			continue
+		}
+		// Só descarta o mês inteiro depois que a última cotação dele expira
+		if !month.AddDate(0, 1, 0).After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range expired {
+		var count int64
+		if err := p.db.QueryRowContext(ctx, "SELECT count(*) FROM "+name).Scan(&count); err != nil {
+			return fmt.Errorf("erro ao contar as cotações da partição %s: %w", name, err)
+		}
+		if _, err := p.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+name); err != nil {
+			return fmt.Errorf("erro ao remover a partição %s: %w", name, err)
+		}
+		log.Printf("Retenção: partição %s removida", name)
+		recordAudit(ctx, newAuditEntry(ctx, auditPrune, "", count, "partition="+name))
+	}
+	return nil
+}
+
+// startMaintenance repete a manutenção das partições uma vez por dia
+func (p *postgresRateRepository) startMaintenance(ctx context.Context) {
+	runPeriodically(ctx, 24*time.Hour, func(ctx context.Context) {
+		if err := p.maintainPartitions(ctx); err != nil {
+			log.Printf("Erro na manutenção das partições: %v", err)
+		}
+	})
+}
+
+func (p *postgresRateRepository) Save(ctx context.Context, row *USDToBRLRateDB) error {
+	err := p.db.QueryRowContext(ctx,
+		`INSERT INTO rates (uid, code, pair, bid, ask, timestamp, request_id, created_by, source, provider)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT DO NOTHING RETURNING id, create_date`,
+		row.UID, row.Code, row.Pair, row.Bid, row.Ask, row.Timestamp, row.RequestID, row.CreatedBy, row.Source, row.Provider,
+	).Scan(&row.ID, &row.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errDuplicateRate
+	}
+	return err
+}
+
+// Deduplicate remove as cotações repetidas, mantendo a de menor id, e cria o índice único
+func (p *postgresRateRepository) Deduplicate(ctx context.Context) (DedupResult, error) {
+	var (
+		result   DedupResult
+		from, to int64
+	)
+	err := p.db.QueryRowContext(ctx, `WITH removed AS (
+			DELETE FROM rates a USING rates b
+			WHERE a.pair = b.pair AND a.timestamp = b.timestamp AND a.id > b.id
+			RETURNING a.timestamp
+		)
+		SELECT count(*), COALESCE(min(timestamp), 0), COALESCE(max(timestamp), 0) FROM removed`,
+	).Scan(&result.Removed, &from, &to)
+	if err != nil {
+		return result, err
+	}
+	if result.Removed > 0 {
+		result.From, result.To = time.Unix(from, 0).UTC(), time.Unix(to, 0).UTC()
+	}
+	return result, p.ensureUniqueIndex(ctx)
+}
+
+func (p *postgresRateRepository) Latest(ctx context.Context, limit int) ([]USDToBRLRateDB, error) {
+	return p.query(ctx, `SELECT `+postgresRateColumns+`
+		FROM rates ORDER BY timestamp DESC, id DESC LIMIT $1`, limit)
+}
+
+func (p *postgresRateRepository) Range(ctx context.Context, pair string, from, to time.Time, limit int) ([]USDToBRLRateDB, error) {
+	return p.query(ctx, `SELECT `+postgresRateColumns+`
+		FROM rates WHERE pair = $1 AND timestamp >= $2 AND timestamp < $3
+		ORDER BY timestamp, id LIMIT $4`, pair, from.Unix(), to.Unix(), limit)
+}
+
+func (p *postgresRateRepository) List(ctx context.Context, q listQuery) ([]USDToBRLRateDB, int64, error) {
+	where, args := q.rateConditions()
+	// O Postgres numera os argumentos ($1, $2...)
+	for i := 1; strings.Contains(where, "?"); i++ {
+		where = strings.Replace(where, "?", "$"+strconv.Itoa(i), 1)
+	}
+
+	var total int64
+	if err := p.db.QueryRowContext(ctx, "SELECT count(*) FROM rates WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := p.query(ctx, fmt.Sprintf(`SELECT %s
+		FROM rates WHERE %s ORDER BY %s %s, id %[4]s LIMIT %d OFFSET %d`,
+		postgresRateColumns, where, q.Sort, q.direction(), q.PerPage, q.offset()), args...)
+	return rows, total, err
+}
+
+func (p *postgresRateRepository) query(ctx context.Context, query string, args ...any) ([]USDToBRLRateDB, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []USDToBRLRateDB
+	for rows.Next() {
+		var row USDToBRLRateDB
+		if err := rows.Scan(&row.ID, &row.UID, &row.Code, &row.Pair, &row.Bid, &row.Ask, &row.Timestamp, &row.RequestID,
+			&row.CreatedBy, &row.Source, &row.Provider, &row.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (p *postgresRateRepository) Close() error {
+	return p.db.Close()
+}
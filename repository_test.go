@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// repositoryBackends são os backends testados com o mesmo contrato de RateRepository; o SQLite
+// roda com cada driver compilado no binário, para garantir que se comportam igual
+var repositoryBackends = []struct {
+	name string
+	open func(t *testing.T) RateRepository
+}{
+	{"sqlite-cgo", sqliteBackend(sqliteDriverCGO)},
+	{"sqlite-purego", sqliteBackend(sqliteDriverPureGo)},
+	{"memory", func(t *testing.T) RateRepository { return newMemoryRateRepository(100) }},
+}
+
+func sqliteBackend(driver string) func(t *testing.T) RateRepository {
+	return func(t *testing.T) RateRepository { return &gormRateRepository{db: newTestDBWith(t, driver)} }
+}
+
+func testRate(pair string, timestamp int64, bid string) USDToBRLRateDB {
+	value := decimal.RequireFromString(bid)
+	return USDToBRLRateDB{UID: newID(), Code: pair[:3], Pair: pair, Bid: value, Ask: value, Timestamp: timestamp}
+}
+
+// seedRates grava as cotações, falhando o teste em qualquer erro
+func seedRates(t *testing.T, repo RateRepository, rows ...USDToBRLRateDB) {
+	t.Helper()
+	for i := range rows {
+		if err := repo.Save(context.Background(), &rows[i]); err != nil {
+			t.Fatalf("Save(%s, %d): %v", rows[i].Pair, rows[i].Timestamp, err)
+		}
+	}
+}
+
+func TestRateRepositorySave(t *testing.T) {
+	for _, backend := range repositoryBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			repo := backend.open(t)
+			ctx := context.Background()
+
+			first := testRate("USD-BRL", 1000, "5.1")
+			if err := repo.Save(ctx, &first); err != nil {
+				t.Fatal(err)
+			}
+			if first.ID == 0 {
+				t.Error("ID não preenchido na gravação")
+			}
+
+			tests := []struct {
+				name    string
+				row     USDToBRLRateDB
+				wantErr error
+			}{
+				{"mesmo par e timestamp", testRate("USD-BRL", 1000, "5.2"), errDuplicateRate},
+				{"outro timestamp", testRate("USD-BRL", 1001, "5.2"), nil},
+				{"outro par no mesmo timestamp", testRate("EUR-BRL", 1000, "6.1"), nil},
+			}
+			for _, tt := range tests {
+				if err := repo.Save(ctx, &tt.row); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s: erro = %v, esperado %v", tt.name, err, tt.wantErr)
+				}
+			}
+
+			rows, err := repo.Latest(ctx, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 3 {
+				t.Fatalf("cotações gravadas = %d, esperado 3", len(rows))
+			}
+			if rows[0].Timestamp != 1001 {
+				t.Errorf("mais recente = %d, esperado 1001", rows[0].Timestamp)
+			}
+		})
+	}
+}
+
+func TestRateRepositoryRange(t *testing.T) {
+	for _, backend := range repositoryBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			repo := backend.open(t)
+			seedRates(t, repo,
+				testRate("USD-BRL", 300, "5.3"),
+				testRate("USD-BRL", 100, "5.1"),
+				testRate("EUR-BRL", 150, "6.0"),
+				testRate("USD-BRL", 200, "5.2"),
+				testRate("USD-BRL", 400, "5.4"),
+			)
+
+			tests := []struct {
+				name     string
+				pair     string
+				from, to int64
+				limit    int
+				want     []int64
+			}{
+				{"período inteiro em ordem cronológica", "USD-BRL", 0, 1000, 10, []int64{100, 200, 300, 400}},
+				{"fim exclusivo", "USD-BRL", 100, 300, 10, []int64{100, 200}},
+				{"limite", "USD-BRL", 0, 1000, 2, []int64{100, 200}},
+				{"outro par", "EUR-BRL", 0, 1000, 10, []int64{150}},
+				{"sem cotações", "USD-BRL", 500, 1000, 10, nil},
+			}
+			for _, tt := range tests {
+				rows, err := repo.Range(context.Background(), tt.pair, time.Unix(tt.from, 0), time.Unix(tt.to, 0), tt.limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := rateTimestamps(rows); !slices.Equal(got, tt.want) {
+					t.Errorf("%s: timestamps = %v, esperado %v", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateRepositoryList(t *testing.T) {
+	minBid := decimal.RequireFromString("5.2")
+
+	for _, backend := range repositoryBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			repo := backend.open(t)
+			seedRates(t, repo,
+				testRate("USD-BRL", 100, "5.3"),
+				testRate("USD-BRL", 200, "5.1"),
+				testRate("USD-BRL", 300, "5.4"),
+				testRate("USD-BRL", 400, "5.2"),
+				testRate("EUR-BRL", 250, "6.0"),
+			)
+
+			tests := []struct {
+				name      string
+				q         listQuery
+				want      []int64
+				wantTotal int64
+			}{
+				{"todas por timestamp", listQuery{Page: 1, PerPage: 10, Sort: "timestamp"}, []int64{100, 200, 250, 300, 400}, 5},
+				{"par em ordem decrescente", listQuery{Page: 1, PerPage: 10, Sort: "timestamp", Desc: true, Pair: "USD-BRL"}, []int64{400, 300, 200, 100}, 4},
+				{"segunda página", listQuery{Page: 2, PerPage: 2, Sort: "timestamp", Pair: "USD-BRL"}, []int64{300, 400}, 4},
+				{"por bid", listQuery{Page: 1, PerPage: 10, Sort: "bid", Pair: "USD-BRL"}, []int64{200, 400, 100, 300}, 4},
+				{"bid mínimo", listQuery{Page: 1, PerPage: 10, Sort: "timestamp", Pair: "USD-BRL", MinValue: &minBid}, []int64{100, 300, 400}, 3},
+				{"período", listQuery{Page: 1, PerPage: 10, Sort: "timestamp", Since: time.Unix(200, 0), Until: time.Unix(300, 0)}, []int64{200, 250}, 2},
+				{"página além do fim", listQuery{Page: 5, PerPage: 10, Sort: "timestamp"}, nil, 5},
+			}
+			for _, tt := range tests {
+				rows, total, err := repo.List(context.Background(), tt.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := rateTimestamps(rows); !slices.Equal(got, tt.want) || total != tt.wantTotal {
+					t.Errorf("%s: timestamps = %v (total %d), esperado %v (total %d)", tt.name, got, total, tt.want, tt.wantTotal)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryRateRepositoryEviction(t *testing.T) {
+	repo := newMemoryRateRepository(3)
+	seedRates(t, repo,
+		testRate("USD-BRL", 100, "5.1"),
+		testRate("USD-BRL", 200, "5.2"),
+		testRate("USD-BRL", 300, "5.3"),
+		testRate("USD-BRL", 400, "5.4"),
+	)
+
+	rows, err := repo.Latest(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rateTimestamps(rows); !slices.Equal(got, []int64{400, 300, 200}) {
+		t.Errorf("timestamps = %v, esperado a mais antiga descartada", got)
+	}
+
+	// A cotação descartada deixa de contar como repetida
+	evicted := testRate("USD-BRL", 100, "5.1")
+	if err := repo.Save(context.Background(), &evicted); err != nil {
+		t.Errorf("regravar a cotação descartada: %v", err)
+	}
+}
+
+func rateTimestamps(rows []USDToBRLRateDB) []int64 {
+	var timestamps []int64
+	for _, row := range rows {
+		timestamps = append(timestamps, row.Timestamp)
+	}
+	return timestamps
+}
+
+// Os dois drivers SQLite gravam e leem as mesmas cotações (decimais, horários, remoção lógica)
+// e chegam ao mesmo schema pelas migrações
+func TestSQLiteDriverParity(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	drivers := []string{sqliteDriverCGO, sqliteDriverPureGo}
+	stored := make(map[string]string)
+	schemas := make(map[string]dbSchema)
+	for _, driver := range drivers {
+		conn := newTestDBWith(t, driver)
+		repo := &gormRateRepository{db: conn}
+
+		rows := []USDToBRLRateDB{
+			testRate("USD-BRL", 100, "5.1234"),
+			testRate("USD-BRL", 200, "0.0001"),
+			testRate("EUR-BRL", 150, "999999.9999"),
+			testRate("USD-BRL", 300, "5.3"),
+		}
+		for i := range rows {
+			rows[i].UID, rows[i].CreatedAt, rows[i].Source = fmt.Sprintf("uid-%d", i), createdAt, sourceRequest
+		}
+		seedRates(t, repo, rows...)
+		if err := conn.Where("timestamp = ?", 300).Delete(&USDToBRLRateDB{}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := repo.Range(context.Background(), "USD-BRL", time.Unix(0, 0), time.Unix(1000, 0), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored[driver] = string(data)
+
+		sqlDB, err := conn.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schemas[driver], err = readSchema(context.Background(), sqlDB); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stored[sqliteDriverCGO] != stored[sqliteDriverPureGo] {
+		t.Errorf("cotações divergem:\ncgo:    %s\npurego: %s", stored[sqliteDriverCGO], stored[sqliteDriverPureGo])
+	}
+	if diffs := diffSchemas(schemas[sqliteDriverCGO], schemas[sqliteDriverPureGo]); len(diffs) > 0 {
+		t.Errorf("schemas divergem: %v", diffs)
+	}
+}
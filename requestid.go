@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+const requestIDKey contextKey = "requestID"
+
+// RequestIDMiddleware reaproveita o X-Request-ID recebido ou gera um novo UUID,
+// propagando-o pelo contexto e pelo cabeçalho da resposta
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// logf registra a mensagem prefixada com o request ID do contexto, quando houver
+func logf(ctx context.Context, format string, v ...any) {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		log.Printf("[%s] %s", requestID, fmt.Sprintf(format, v...))
+		return
+	}
+	log.Printf(format, v...)
+}
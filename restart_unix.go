@@ -0,0 +1,164 @@
+//go:build !windows
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Reinício sem interrupção: ao receber SIGUSR2 o servidor inicia uma nova instância do
+// binário (possivelmente atualizado, com a configuração relida do ambiente) e lhe entrega o
+// socket em escuta. Quando a nova instância avisa que está pronta, a antiga para de aceitar
+// conexões e conclui as requisições em andamento; nenhuma conexão é recusada no processo.
+//
+// A nova instância herda o socket no descritor COTACAO_LISTEN_FD e avisa que está pronta
+// escrevendo em COTACAO_READY_FD. Backends que travam o armazenamento (badger) não permitem
+// as duas instâncias ao mesmo tempo; nesse caso a nova termina e a antiga segue atendendo.
+const (
+	envListenFD = "COTACAO_LISTEN_FD"
+	envReadyFD  = "COTACAO_READY_FD"
+)
+
+// Prazo para a nova instância ficar pronta antes de a troca ser abandonada
+const restartReadyTimeout = 30 * time.Second
+
+// Depois de parar de aceitar conexões, espera as recém-aceitas enviarem a requisição antes do
+// encerramento, que descartaria as que ainda não foram lidas
+const handoverGrace = time.Second
+
+// listen usa o socket herdado do processo anterior, se houver, ou abre um novo
+func listen(addr string) (net.Listener, error) {
+	v := os.Getenv(envListenFD)
+	if v == "" {
+		return net.Listen("tcp", addr)
+	}
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s inválido: %q", envListenFD, v)
+	}
+	file := os.NewFile(uintptr(fd), "listener")
+	defer file.Close()
+	ln, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao usar o socket herdado: %w", err)
+	}
+	log.Printf("Socket herdado do processo anterior (%s)", ln.Addr())
+	return ln, nil
+}
+
+// notifyReady avisa o processo anterior, se houver, de que esta instância já atende
+func notifyReady() {
+	v := os.Getenv(envReadyFD)
+	if v == "" {
+		return
+	}
+	os.Unsetenv(envListenFD)
+	os.Unsetenv(envReadyFD)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("%s inválido: %q", envReadyFD, v)
+		return
+	}
+	file := os.NewFile(uintptr(fd), "ready")
+	defer file.Close()
+	if _, err := file.Write([]byte{1}); err != nil {
+		log.Printf("Erro ao avisar o processo anterior: %v", err)
+	}
+}
+
+// watchRestart atende SIGUSR2 até ctx terminar; o canal retornado é fechado quando uma nova
+// instância assume o socket e esta deve encerrar
+func watchRestart(ctx context.Context, ln net.Listener) <-chan struct{} {
+	handedOver := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+			}
+
+			log.Println("SIGUSR2 recebido: iniciando nova instância do servidor")
+			if err := startSuccessor(ln); err != nil {
+				log.Printf("Reinício abandonado, esta instância segue atendendo: %v", err)
+				continue
+			}
+			log.Println("Nova instância pronta; concluindo as requisições em andamento")
+			ln.Close()
+			time.Sleep(handoverGrace)
+			close(handedOver)
+			return
+		}
+	}()
+	return handedOver
+}
+
+// startSuccessor executa o binário atual com o socket e o pipe de prontidão nos descritores
+// 3 e 4 e aguarda o aviso de que está pronto. O socket é passado com syscall.ForkExec porque
+// os/exec colocaria o descritor, compartilhado com esta instância, em modo bloqueante
+func startSuccessor(ln net.Listener) error {
+	tcp, ok := ln.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("socket %T não pode ser transferido", ln)
+	}
+	raw, err := tcp.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		readyWriter.Close()
+		return err
+	}
+
+	var pid int
+	ctrlErr := raw.Control(func(fd uintptr) {
+		pid, err = syscall.ForkExec(executable, os.Args, &syscall.ProcAttr{
+			Env:   append(os.Environ(), envListenFD+"=3", envReadyFD+"=4"),
+			Files: []uintptr{os.Stdin.Fd(), os.Stdout.Fd(), os.Stderr.Fd(), fd, readyWriter.Fd()},
+		})
+	})
+	// Com a cópia do processo filho fechada, a leitura termina se ele encerrar antes do aviso
+	readyWriter.Close()
+	if err = cmp.Or(ctrlErr, err); err != nil {
+		return err
+	}
+	successor, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	go successor.Wait()
+
+	ready.SetReadDeadline(time.Now().Add(restartReadyTimeout))
+	if _, err := ready.Read(make([]byte, 1)); err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			successor.Signal(syscall.SIGTERM)
+			return fmt.Errorf("nova instância (pid %d) não ficou pronta em %s", pid, restartReadyTimeout)
+		}
+		return fmt.Errorf("nova instância (pid %d) terminou antes de ficar pronta", pid)
+	}
+	log.Printf("Socket transferido para a nova instância (pid %d)", pid)
+	return nil
+}
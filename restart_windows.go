@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"net"
+)
+
+// No Windows não há herança de descritores nem SIGUSR2; o reinício interrompe o serviço
+
+func listen(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
+
+func notifyReady() {}
+
+func watchRestart(context.Context, net.Listener) <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PruneResult struct {
+	Cutoff            time.Time `json:"cutoff"`
+	AggregatedPeriods int64     `json:"aggregated_periods"`
+	PrunedRows        int64     `json:"pruned_rows"`
+}
+
+// pruneRates consolida nos agregados e remove as cotações mais antigas que retention;
+// o corte é truncado para o início do dia (UTC) para que cada dia seja consolidado por inteiro.
+// A remoção é física, inclusive das cotações já removidas pela deduplicação, para que a retenção
+// limite de fato o armazenamento, e fica no log de auditoria quando remove alguma cotação ou é
+// pedida por um usuário
+func pruneRates(ctx context.Context, retention time.Duration) (PruneResult, error) {
+	result := PruneResult{Cutoff: time.Now().UTC().Add(-retention).Truncate(24 * time.Hour)}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		aggregated, err := rollup(tx, time.Unix(0, 0), result.Cutoff, dailyRollup, hourlyRollup)
+		if err != nil {
+			return err
+		}
+		result.AggregatedPeriods = aggregated
+
+		pruned := tx.Unscoped().Where("timestamp < ?", result.Cutoff.Unix()).Delete(&USDToBRLRateDB{})
+		if pruned.Error != nil {
+			return pruned.Error
+		}
+		result.PrunedRows = pruned.RowsAffected
+
+		if _, manual := userIDFromContext(ctx); result.PrunedRows == 0 && !manual {
+			return nil
+		}
+		audit := newAuditEntry(ctx, auditPrune, "", result.PrunedRows,
+			fmt.Sprintf("cutoff=%s aggregated_periods=%d", result.Cutoff.Format(time.RFC3339), result.AggregatedPeriods))
+		return tx.Create(&audit).Error
+	})
+	if err == nil {
+		historyResults.invalidateRange(time.Unix(0, 0), result.Cutoff)
+	}
+	return result, err
+}
+
+// startRetention agenda a execução periódica da política de retenção
+func startRetention(ctx context.Context, interval, retention time.Duration) {
+	if interval <= 0 || retention <= 0 {
+		return
+	}
+
+	log.Printf("Retenção iniciada: dados brutos mantidos por %s, verificação a cada %s", retention, interval)
+	runPeriodically(ctx, interval, func(ctx context.Context) {
+		result, err := pruneRates(ctx, retention)
+		if err != nil {
+			log.Printf("Erro ao aplicar retenção: %v", err)
+			return
+		}
+		if result.PrunedRows > 0 {
+			log.Printf("Retenção: %d cotações anteriores a %s consolidadas em %d períodos", result.PrunedRows, result.Cutoff.Format(time.DateOnly), result.AggregatedPeriods)
+		}
+	})
+}
+
+// PruneHandler aplica a retenção sob demanda (DELETE), aceitando ?days= para sobrescrever o padrão
+func PruneHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	retention := cfg.RetentionRaw
+	if v := r.URL.Query().Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 {
+			writeError(w, http.StatusBadRequest, "days deve ser um inteiro positivo")
+			return
+		}
+		retention = time.Duration(days) * 24 * time.Hour
+	}
+	if retention <= 0 {
+		writeError(w, http.StatusBadRequest, "retenção desabilitada; informe ?days=")
+		return
+	}
+
+	result, err := pruneRates(r.Context(), retention)
+	if err != nil {
+		logf(r.Context(), "Erro ao aplicar retenção: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	logf(r.Context(), "Retenção manual: %d cotações removidas", result.PrunedRows)
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// maxPollJitter limita POLL_JITTER, para que um intervalo nunca caia abaixo da metade
+const maxPollJitter = 50
+
+// scheduler busca periodicamente a cotação dos pares habilitados, cada um no seu intervalo
+// (o do cadastro do par ou POLL_INTERVAL). Cada agendamento recebe uma variação aleatória
+// de até POLL_JITTER%, para que pares com o mesmo intervalo não consultem o provedor no
+// mesmo instante. A lista é relida a cada ciclo, refletindo alterações feitas pela API de
+// pares. Com as filas de gravação acumuladas o agendador desacelera ou pausa até que elas
+// sejam drenadas. Com várias réplicas e o Redis, cada consulta é reservada por uma delas
+// (pollLock), e as demais recebem a cotação pelo cache compartilhado
+type scheduler struct {
+	interval time.Duration
+	jitter   int
+	tick     time.Duration
+
+	mu       sync.Mutex // protege o estado abaixo, lido por /admin/stats
+	running  bool
+	term     int                  // execuções iniciadas, uma por liderança
+	next     map[string]time.Time // próxima consulta de cada par
+	pressure int
+	skipped  int
+	lastPoll time.Time
+	polls    int64
+	failures int64
+	claimed  int64 // consultas feitas por outra réplica
+}
+
+// pollLock reserva as consultas entre as réplicas; nil sem Redis, quando cada réplica
+// consulta por conta própria
+var pollLock interface {
+	acquire(ctx context.Context, pair string, lease time.Duration) (bool, error)
+}
+
+// pollScheduler é o agendador em execução, nil enquanto não iniciado
+var pollScheduler *scheduler
+
+// SchedulerStatus resume o estado do agendador para /admin/stats
+type SchedulerStatus struct {
+	Running   bool                 `json:"running"`
+	Interval  Duration             `json:"interval"`
+	Jitter    int                  `json:"jitter_percent"`
+	Pressure  string               `json:"pressure"`
+	LastPoll  *time.Time           `json:"last_poll"`
+	Polls     int64                `json:"polls"` // consultas desde a inicialização
+	Failures  int64                `json:"failures"`
+	Elsewhere int64                `json:"polled_elsewhere"` // consultas reservadas por outra réplica
+	NextPolls map[string]time.Time `json:"next_polls"`
+}
+
+// backgroundJobs acompanha as goroutines periódicas, aguardadas no encerramento
+var backgroundJobs sync.WaitGroup
+
+func newScheduler(interval time.Duration, jitter int) *scheduler {
+	tick := time.Second
+	if interval > 0 && interval < tick {
+		tick = interval
+	}
+	return &scheduler{interval: interval, jitter: jitter, tick: tick, next: make(map[string]time.Time)}
+}
+
+// start consulta os pares até o cancelamento de ctx. Pode ser chamado de novo a cada
+// liderança: as consultas voltam a ser espalhadas e a execução anterior, já cancelada, não
+// altera o estado da nova ao terminar
+func (s *scheduler) start(ctx context.Context) {
+	s.mu.Lock()
+	s.term++
+	term := s.term
+	s.running = true
+	s.next = make(map[string]time.Time)
+	s.mu.Unlock()
+	context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.term == term {
+			s.running = false
+		}
+	})
+
+	log.Printf("Agendador iniciado com intervalo padrão de %s e jitter de %d%%", s.interval, s.jitter)
+	runPeriodically(ctx, s.tick, s.poll)
+}
+
+// intervalOf devolve o intervalo do par; zero indica que o par não é consultado
+func (s *scheduler) intervalOf(row PairDB) time.Duration {
+	if row.PollInterval > 0 {
+		return time.Duration(row.PollInterval)
+	}
+	return s.interval
+}
+
+// delay aplica o jitter ao intervalo, sorteando um valor em interval ± jitter%
+func (s *scheduler) delay(interval time.Duration) time.Duration {
+	spread := interval * time.Duration(s.jitter) / 100
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + rand.N(2*spread)
+}
+
+// due devolve os pares cuja consulta venceu. Um par visto pela primeira vez é agendado em
+// um instante aleatório dentro do seu intervalo, espalhando as consultas da inicialização
+func (s *scheduler) due(now time.Time) []PairDB {
+	var due []PairDB
+	seen := make(map[string]bool)
+	for _, row := range pairs.list(true) {
+		interval := s.intervalOf(row)
+		// as séries derivadas são recalculadas a cada cotação dos pares que usam
+		if _, derived := derivedPairs[row.Symbol]; derived || interval <= 0 {
+			continue
+		}
+		seen[row.Symbol] = true
+
+		next, scheduled := s.next[row.Symbol]
+		if !scheduled {
+			s.next[row.Symbol] = now.Add(rand.N(interval) + s.tick)
+			continue
+		}
+		if !now.Before(next) {
+			due = append(due, row)
+		}
+	}
+	// pares removidos ou desabilitados voltam a ser espalhados se reaparecerem
+	for symbol := range s.next {
+		if !seen[symbol] {
+			delete(s.next, symbol)
+		}
+	}
+	return due
+}
+
+func (s *scheduler) poll(ctx context.Context) {
+	now := time.Now()
+	s.mu.Lock()
+	due := s.due(now)
+	// o ciclo pulado pela contrapressão também é reagendado, como se tivesse sido executado
+	for _, row := range due {
+		s.next[row.Symbol] = now.Add(s.delay(s.intervalOf(row)))
+	}
+	s.mu.Unlock()
+	if len(due) == 0 || !s.shouldPoll(ctx) {
+		return
+	}
+
+	ctx = withRateSource(ctx, sourceScheduler)
+	var polls, failures, claimed int64
+	for _, row := range due {
+		if !s.claim(ctx, row) {
+			claimed++
+			continue
+		}
+		polls++
+		if _, err := fetchAndPersist(ctx, row.Symbol); err != nil {
+			failures++
+			log.Printf("Agendador: erro ao obter cotação de %s: %v", row.Symbol, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.lastPoll = now
+	s.polls += polls
+	s.failures += failures
+	s.claimed += claimed
+	s.mu.Unlock()
+}
+
+// claim reserva a consulta do par entre as réplicas. A reserva dura o menor intervalo que o
+// jitter pode sortear, para vencer antes da próxima consulta de qualquer réplica. Sem resposta
+// do Redis a consulta é feita assim mesmo: melhor repetida que perdida
+func (s *scheduler) claim(ctx context.Context, row PairDB) bool {
+	if pollLock == nil {
+		return true
+	}
+	interval := s.intervalOf(row)
+	lease := max(interval-interval*time.Duration(s.jitter)/100, time.Second)
+
+	lockCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	claimed, err := pollLock.acquire(lockCtx, row.Symbol, lease)
+	reportRedis("lock", err)
+	return claimed || err != nil
+}
+
+func (s *scheduler) status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := SchedulerStatus{
+		Running:   s.running,
+		Interval:  Duration(s.interval),
+		Jitter:    s.jitter,
+		Pressure:  pressureNames[s.pressure],
+		Polls:     s.polls,
+		Failures:  s.failures,
+		Elsewhere: s.claimed,
+		NextPolls: make(map[string]time.Time, len(s.next)),
+	}
+	if !s.lastPoll.IsZero() {
+		lastPoll := s.lastPoll.UTC()
+		status.LastPoll = &lastPoll
+	}
+	for symbol, next := range s.next {
+		status.NextPolls[symbol] = next.UTC()
+	}
+	return status
+}
+
+// shouldPoll aplica a contrapressão, avisando os administradores a cada mudança de estado
+func (s *scheduler) shouldPoll(ctx context.Context) bool {
+	level, reason := backpressure(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if level != s.pressure {
+		message := fmt.Sprintf("Agendador passou de %s para %s", pressureNames[s.pressure], pressureNames[level])
+		if level != pressureNormal {
+			message += ": " + reason
+		}
+		notifyAdmins("backpressure", message)
+		s.pressure, s.skipped = level, 0
+		schedulerPressure.Set(float64(level))
+	}
+
+	switch level {
+	case pressurePaused:
+		return false
+	case pressureSlow:
+		s.skipped++
+		if s.skipped < slowCycles {
+			return false
+		}
+		s.skipped = 0
+	}
+	return true
+}
+
+// runPeriodically executa fn a cada intervalo até o cancelamento do contexto,
+// registrando a goroutine em backgroundJobs para permitir aguardar seu término
+func runPeriodically(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
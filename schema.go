@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+	"github.com/shopspring/decimal"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaResponses associa cada rota ao tipo da resposta de sucesso; o schema é gerado a
+// partir dos próprios tipos, para não divergir do que os handlers escrevem
+var schemaResponses = []struct {
+	route string
+	value any
+}{
+	{"GET /cotacao", ExchangeRate{}},
+	{"GET /cotacao/compare", CompareResponse{}},
+	{"GET /cotacao/interna", InternalRateResponse{}},
+	{"GET /cotacao/ptax", PTAXRateDB{}},
+	{"GET /converter", ConversionResponse{}},
+	{"GET /historico", []USDToBRLRateDB{}},
+	{"GET /historico?from=&to=", HistoryResponse{}},
+	{"POST /historico/compartilhar", ShareLinkResponse{}},
+	{"GET /compartilhado/historico", HistoryResponse{}},
+	{"GET /pairs", []PairDB{}},
+	{"GET /pairs/{symbol}", PairDB{}},
+	{"POST /pairs", PairDB{}},
+	{"GET /trades", TradeJournal{}},
+	{"POST /trades", TradeDB{}},
+	{"POST /auth/login", TokenResponse{}},
+}
+
+// schemaResources são os documentos de /schema/{resource}. Os de entrada (input) descrevem
+// os corpos aceitos pelos POST e são usados para validá-los antes da decodificação; neles
+// só os campos de required são obrigatórios, já que os handlers completam os demais
+var schemaResources = map[string]struct {
+	value       any
+	input       bool
+	required    []string
+	description string
+}{
+	"quote":   {value: ExchangeRate{}, description: "Resposta de GET /cotacao, indexada pelo par sem hífen."},
+	"history": {value: HistoryResponse{}, description: "Resposta de GET /historico com from/to."},
+	"stats":   {value: HistoryAnalytics{}, description: "Estatísticas da série de um par, como na consulta analytics do GraphQL."},
+	"error":   {value: ErrorResponse{}, description: "Corpo das respostas de erro de todas as rotas."},
+	"alert": {value: AlertRequest{}, input: true, required: []string{"condition", "threshold"},
+		description: "Corpo de POST e PUT /alerts, incluindo o canal de webhook."},
+	"trade": {value: TradeRequest{}, input: true, required: []string{"side", "amount"},
+		description: "Corpo de POST /trades."},
+	"pair": {value: PairRequest{}, input: true, required: []string{"symbol"},
+		description: "Corpo de POST /pairs."},
+}
+
+var (
+	decimalType  = reflect.TypeOf(decimal.Decimal{})
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(Duration(0))
+)
+
+// jsonSchemaBuilder converte tipos Go em JSON Schema seguindo as regras do encoding/json:
+// tags json, omitempty (campo opcional) e campos embutidos. Structs nomeados viram
+// definições em $defs, referenciadas por $ref. Com input, o schema descreve o que a API
+// aceita: decimais também como string e nenhum campo obrigatório
+type jsonSchemaBuilder struct {
+	defs  map[string]any
+	input bool
+}
+
+func (b *jsonSchemaBuilder) schemaOf(t reflect.Type) map[string]any {
+	switch t {
+	case decimalType:
+		if b.input {
+			return map[string]any{"type": []string{"number", "string"}, "pattern": `^-?[0-9]+(\.[0-9]+)?$`}
+		}
+		return map[string]any{"type": "number"}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|^0$`}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schemaOf(t.Elem())
+		if typ, ok := schema["type"].(string); ok && b.input {
+			schema["type"] = []string{typ, "null"}
+		}
+		return schema
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return b.named(t, func() map[string]any {
+			return map[string]any{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+		})
+	case reflect.Struct:
+		return b.named(t, func() map[string]any { return b.structSchema(t) })
+	default:
+		return map[string]any{}
+	}
+}
+
+// named registra os tipos nomeados em $defs; o sufixo DB dos modelos do banco é omitido
+func (b *jsonSchemaBuilder) named(t reflect.Type, build func() map[string]any) map[string]any {
+	if t.Name() == "" {
+		return build()
+	}
+	name := strings.TrimSuffix(t.Name(), "DB")
+	ref := map[string]any{"$ref": "#/$defs/" + name}
+	if _, ok := b.defs[name]; !ok {
+		b.defs[name] = map[string]any{} // reservado antes de descer, para tipos recursivos
+		b.defs[name] = build()
+	}
+	return ref
+}
+
+func (b *jsonSchemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	b.addFields(t, properties, &required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func (b *jsonSchemaBuilder) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaOf(field.Type)
+		if !b.input && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// refine ajusta as definições que a reflexão não consegue descrever sozinha
+func (b *jsonSchemaBuilder) refine() {
+	// ExchangeRate é indexado pelo par sem hífen ({"USDBRL": {...}}); com X-Debug-Timing
+	// traz também o detalhamento do tempo em timing
+	if rates, ok := b.defs["ExchangeRate"].(map[string]any); ok {
+		rates["propertyNames"] = map[string]any{"pattern": "^([A-Z0-9_.]{3,24}|" + domain.TimingKey + ")$"}
+		rates["properties"] = map[string]any{domain.TimingKey: b.schemaOf(reflect.TypeOf(domain.Timing{}))}
+	}
+	// os campos de REDACT_FIELDS faltam nas respostas a chamadas anônimas
+	if quote, ok := b.defs["Quote"].(map[string]any); ok {
+		quote["required"] = slices.DeleteFunc(quote["required"].([]string), func(name string) bool {
+			return redactedFields[name]
+		})
+	}
+}
+
+// apiSchema monta o documento de /schema uma única vez
+var apiSchema = sync.OnceValue(func() []byte {
+	b := &jsonSchemaBuilder{defs: map[string]any{}}
+	responses := map[string]any{}
+	for _, r := range schemaResponses {
+		responses[r.route] = b.schemaOf(reflect.TypeOf(r.value))
+	}
+	b.refine()
+
+	resources := make([]string, 0, len(schemaResources))
+	for name := range schemaResources {
+		resources = append(resources, "/schema/"+name)
+	}
+	slices.Sort(resources)
+
+	doc := map[string]any{
+		"$schema":        jsonSchemaDialect,
+		"$id":            "/schema",
+		"title":          "API de cotações",
+		"description":    "Formatos das respostas da API. x-responses associa cada rota ao schema da resposta de sucesso; as falhas seguem x-error. Os documentos de cada recurso, inclusive dos corpos aceitos, ficam em x-resources.",
+		"x-wire-version": domain.WireVersion,
+		"x-responses":    responses,
+		"x-error":        b.schemaOf(reflect.TypeOf(ErrorResponse{})),
+		"x-resources":    resources,
+		"$defs":          b.defs,
+	}
+	data, _ := json.MarshalIndent(doc, "", "  ")
+	return data
+})
+
+// resourceSchema é um documento de /schema/{resource}, com suas próprias $defs
+type resourceSchema struct {
+	root map[string]any
+	defs map[string]any
+	data []byte
+}
+
+var resourceSchemas = sync.OnceValue(func() map[string]resourceSchema {
+	docs := make(map[string]resourceSchema, len(schemaResources))
+	for name, res := range schemaResources {
+		b := &jsonSchemaBuilder{defs: map[string]any{}, input: res.input}
+		root := b.schemaOf(reflect.TypeOf(res.value))
+		b.refine()
+		// o tipo raiz é o próprio documento, não uma referência a $defs
+		if ref, ok := root["$ref"].(string); ok {
+			def := strings.TrimPrefix(ref, "#/$defs/")
+			root = b.defs[def].(map[string]any)
+			delete(b.defs, def)
+		}
+		if res.input {
+			root["required"] = res.required
+		}
+
+		doc := maps.Clone(root)
+		doc["$schema"] = jsonSchemaDialect
+		doc["$id"] = "/schema/" + name
+		doc["title"] = name
+		doc["description"] = res.description
+		doc["x-wire-version"] = domain.WireVersion
+		if len(b.defs) > 0 {
+			doc["$defs"] = b.defs
+		}
+		data, _ := json.MarshalIndent(doc, "", "  ")
+		docs[name] = resourceSchema{root: root, defs: b.defs, data: data}
+	}
+	return docs
+})
+
+// SchemaHandler publica o JSON Schema dos formatos da API, para que integradores gerem
+// validadores e clientes tipados em outras linguagens: /schema reúne as respostas e
+// /schema/{resource} traz o documento de um recurso
+func SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := apiSchema()
+	if name, ok := strings.CutPrefix(r.URL.Path, "/schema/"); ok {
+		doc, found := resourceSchemas()[name]
+		if !found {
+			writeError(w, http.StatusNotFound, "schema não encontrado")
+			return
+		}
+		data = doc.data
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set(domain.WireVersionHeader, strconv.Itoa(domain.WireVersion))
+	w.Write(data)
+}
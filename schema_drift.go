@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Modos de SCHEMA_DRIFT
+const (
+	driftFail = "fail"
+	driftWarn = "warn"
+	driftOff  = "off"
+)
+
+// Tabelas mantidas pelo SQLite e pelo golang-migrate, fora do schema das migrações
+var driftIgnoredTables = []string{"schema_migrations", "sqlite_sequence"}
+
+type columnSchema struct {
+	Type    string
+	NotNull bool
+	Default sql.NullString
+	PK      int
+}
+
+func (c columnSchema) String() string {
+	s := c.Type
+	if c.NotNull {
+		s += " NOT NULL"
+	}
+	if c.Default.Valid {
+		s += " DEFAULT " + c.Default.String
+	}
+	if c.PK > 0 {
+		s += " PRIMARY KEY"
+	}
+	return s
+}
+
+type tableSchema struct {
+	columns map[string]columnSchema
+	// índices pelo nome, descritos como "UNIQUE (a, b)"
+	indexes map[string]string
+}
+
+// dbSchema descreve tabelas, índices, views e triggers de um banco SQLite
+type dbSchema struct {
+	tables map[string]tableSchema
+	// views e triggers pelo "tipo nome", com o SQL normalizado
+	objects map[string]string
+}
+
+// checkSchemaDrift compara o schema do banco com o obtido aplicando as migrações embutidas
+// em um banco vazio. Conforme SCHEMA_DRIFT, diferenças impedem a inicialização (fail),
+// apenas geram avisos (warn) ou não são verificadas (off)
+func checkSchemaDrift(ctx context.Context) error {
+	switch cfg.SchemaDrift {
+	case driftOff:
+		return nil
+	case driftFail, driftWarn:
+	default:
+		return fmt.Errorf("SCHEMA_DRIFT deve ser %s, %s ou %s", driftFail, driftWarn, driftOff)
+	}
+
+	live, err := db.DB()
+	if err != nil {
+		return err
+	}
+	actual, err := readSchema(ctx, live)
+	if err != nil {
+		return fmt.Errorf("erro ao ler o schema do banco: %w", err)
+	}
+	expected, err := expectedSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao montar o schema esperado: %w", err)
+	}
+
+	diffs := diffSchemas(expected, actual)
+	if len(diffs) == 0 {
+		return nil
+	}
+	for _, d := range diffs {
+		log.Printf("Divergência no schema: %s", d)
+	}
+	if cfg.SchemaDrift == driftWarn {
+		log.Printf("AVISO: o schema diverge das migrações em %d ponto(s); SCHEMA_DRIFT=warn, seguindo mesmo assim", len(diffs))
+		return nil
+	}
+	return fmt.Errorf("o schema diverge das migrações em %d ponto(s); corrija o banco ou use SCHEMA_DRIFT=warn", len(diffs))
+}
+
+// expectedSchema aplica as migrações embutidas em um banco em memória descartável
+func expectedSchema(ctx context.Context) (dbSchema, error) {
+	_, driver, err := sqliteDriver()
+	if err != nil {
+		return dbSchema{}, err
+	}
+	ref, err := sql.Open(driver.sqlName, ":memory:")
+	if err != nil {
+		return dbSchema{}, err
+	}
+	defer ref.Close()
+	// Cada conexão a ":memory:" abre um banco diferente
+	ref.SetMaxOpenConns(1)
+
+	m, err := newMigratorFor(ref)
+	if err != nil {
+		return dbSchema{}, err
+	}
+	if err := runMigrations(m); err != nil {
+		return dbSchema{}, err
+	}
+	return readSchema(ctx, ref)
+}
+
+func readSchema(ctx context.Context, sqlDB *sql.DB) (dbSchema, error) {
+	schema := dbSchema{tables: make(map[string]tableSchema), objects: make(map[string]string)}
+
+	rows, err := sqlDB.QueryContext(ctx, "SELECT type, name, COALESCE(sql, '') FROM sqlite_master WHERE type IN ('table', 'view', 'trigger')")
+	if err != nil {
+		return schema, err
+	}
+	var tables []string
+	for rows.Next() {
+		var kind, name, ddl string
+		if err := rows.Scan(&kind, &name, &ddl); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		if kind == "table" {
+			if !slices.Contains(driftIgnoredTables, name) && !strings.HasPrefix(name, "sqlite_") {
+				tables = append(tables, name)
+			}
+			continue
+		}
+		schema.objects[kind+" "+name] = strings.Join(strings.Fields(ddl), " ")
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return schema, err
+	}
+
+	for _, table := range tables {
+		t, err := readTable(ctx, sqlDB, table)
+		if err != nil {
+			return schema, fmt.Errorf("tabela %s: %w", table, err)
+		}
+		schema.tables[table] = t
+	}
+	return schema, nil
+}
+
+func readTable(ctx context.Context, sqlDB *sql.DB, table string) (tableSchema, error) {
+	t := tableSchema{columns: make(map[string]columnSchema), indexes: make(map[string]string)}
+
+	rows, err := sqlDB.QueryContext(ctx, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return t, err
+	}
+	for rows.Next() {
+		var name string
+		var c columnSchema
+		if err := rows.Scan(&name, &c.Type, &c.NotNull, &c.Default, &c.PK); err != nil {
+			rows.Close()
+			return t, err
+		}
+		c.Type = strings.ToUpper(c.Type)
+		t.columns[name] = c
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return t, err
+	}
+
+	rows, err = sqlDB.QueryContext(ctx, `SELECT name, "unique" FROM pragma_index_list(?)`, table)
+	if err != nil {
+		return t, err
+	}
+	type index struct {
+		name   string
+		unique bool
+	}
+	var indexes []index
+	for rows.Next() {
+		var idx index
+		if err := rows.Scan(&idx.name, &idx.unique); err != nil {
+			rows.Close()
+			return t, err
+		}
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return t, err
+	}
+
+	for _, idx := range indexes {
+		var columns []string
+		rows, err := sqlDB.QueryContext(ctx, "SELECT COALESCE(name, '<expr>') FROM pragma_index_info(?) ORDER BY seqno", idx.name)
+		if err != nil {
+			return t, err
+		}
+		for rows.Next() {
+			var column string
+			if err := rows.Scan(&column); err != nil {
+				rows.Close()
+				return t, err
+			}
+			columns = append(columns, column)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return t, err
+		}
+
+		desc := "(" + strings.Join(columns, ", ") + ")"
+		if idx.unique {
+			desc = "UNIQUE " + desc
+		}
+		t.indexes[idx.name] = desc
+	}
+	return t, nil
+}
+
+// diffSchemas lista as diferenças do schema real em relação ao esperado, em ordem estável
+func diffSchemas(expected, actual dbSchema) []string {
+	var diffs []string
+
+	for _, name := range slices.Sorted(maps.Keys(expected.tables)) {
+		want := expected.tables[name]
+		got, ok := actual.tables[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("tabela %s ausente", name))
+			continue
+		}
+		for _, col := range slices.Sorted(maps.Keys(want.columns)) {
+			gotCol, ok := got.columns[col]
+			switch {
+			case !ok:
+				diffs = append(diffs, fmt.Sprintf("coluna %s.%s ausente (esperada %s)", name, col, want.columns[col]))
+			case gotCol != want.columns[col]:
+				diffs = append(diffs, fmt.Sprintf("coluna %s.%s: esperada %s, encontrada %s", name, col, want.columns[col], gotCol))
+			}
+		}
+		for _, col := range slices.Sorted(maps.Keys(got.columns)) {
+			if _, ok := want.columns[col]; !ok {
+				diffs = append(diffs, fmt.Sprintf("coluna %s.%s inesperada (%s)", name, col, got.columns[col]))
+			}
+		}
+		for _, idx := range slices.Sorted(maps.Keys(want.indexes)) {
+			gotIdx, ok := got.indexes[idx]
+			switch {
+			case !ok:
+				diffs = append(diffs, fmt.Sprintf("índice %s em %s ausente (esperado %s)", idx, name, want.indexes[idx]))
+			case gotIdx != want.indexes[idx]:
+				diffs = append(diffs, fmt.Sprintf("índice %s em %s: esperado %s, encontrado %s", idx, name, want.indexes[idx], gotIdx))
+			}
+		}
+		for _, idx := range slices.Sorted(maps.Keys(got.indexes)) {
+			if _, ok := want.indexes[idx]; !ok {
+				diffs = append(diffs, fmt.Sprintf("índice %s em %s inesperado %s", idx, name, got.indexes[idx]))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(actual.tables)) {
+		if _, ok := expected.tables[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("tabela %s inesperada", name))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(expected.objects)) {
+		got, ok := actual.objects[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s ausente", name))
+		case got != expected.objects[name]:
+			diffs = append(diffs, fmt.Sprintf("%s com definição diferente: %s", name, got))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(actual.objects)) {
+		if _, ok := expected.objects[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s inesperado", name))
+		}
+	}
+	return diffs
+}
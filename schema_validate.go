@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schemaPatterns guarda os padrões já compilados dos schemas
+var schemaPatterns sync.Map
+
+func schemaPattern(pattern string) *regexp.Regexp {
+	if re, ok := schemaPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	schemaPatterns.Store(pattern, re)
+	return re
+}
+
+// validateAgainst confere o valor (decodificado com UseNumber) com o schema, cobrindo as
+// palavras-chave geradas por jsonSchemaBuilder. O erro indica o caminho do campo inválido
+func validateAgainst(schema, defs map[string]any, v any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		return validateAgainst(def, defs, v, path)
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !slices.Contains(types, jsonTypeOf(v, types)) {
+		return fmt.Errorf("%s: esperado %s", path, strings.Join(types, " ou "))
+	}
+
+	switch v := v.(type) {
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok && !schemaPattern(pattern).MatchString(v) {
+			return fmt.Errorf("%s: valor %q fora do formato esperado", path, v)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return fmt.Errorf("%s: data inválida, use RFC 3339", path)
+			}
+		}
+	case json.Number:
+		if minimum, ok := schema["minimum"].(int); ok {
+			if n, err := v.Float64(); err == nil && n < float64(minimum) {
+				return fmt.Errorf("%s: deve ser no mínimo %d", path, minimum)
+			}
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range v {
+			if err := validateAgainst(items, defs, item, fmt.Sprintf("%s%d", childPath(path), i)); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s%s: campo obrigatório", childPath(path), name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		names, _ := schema["propertyNames"].(map[string]any)
+		for name, value := range v {
+			if pattern, ok := names["pattern"].(string); ok && !schemaPattern(pattern).MatchString(name) {
+				return fmt.Errorf("%s%s: nome de campo inválido", childPath(path), name)
+			}
+			prop, known := properties[name].(map[string]any)
+			if !known {
+				switch extra := schema["additionalProperties"].(type) {
+				case bool:
+					if !extra {
+						return fmt.Errorf("%s%s: campo desconhecido", childPath(path), name)
+					}
+					continue
+				case map[string]any:
+					prop = extra
+				default:
+					continue
+				}
+			}
+			if err := validateAgainst(prop, defs, value, childPath(path)+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func childPath(path string) string {
+	if path == "/" {
+		return path
+	}
+	return path + "/"
+}
+
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+// jsonTypeOf devolve o tipo JSON do valor; números inteiros valem como integer apenas quando
+// o schema pede integer
+func jsonTypeOf(v any, accepted []string) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil && slices.Contains(accepted, "integer") {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// decodeValidated lê o corpo, valida-o contra o schema de /schema/{resource} e só então o
+// decodifica em v, respondendo 400 com o campo inválido em caso de erro
+func decodeValidated(w http.ResponseWriter, r *http.Request, resource string, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return false
+	}
+	if err := validateBody(resource, body); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido: "+err.Error())
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return false
+	}
+	return true
+}
+
+func validateBody(resource string, body []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return errors.New("JSON inválido")
+	}
+	if dec.More() {
+		return errors.New("JSON inválido: conteúdo após o objeto")
+	}
+
+	schema := resourceSchemas()[resource]
+	return validateAgainst(schema.root, schema.defs, doc, "")
+}
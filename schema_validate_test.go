@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestValidateBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		body     string
+		wantErr  string
+	}{
+		{"par completo", "pair", `{"symbol":"EUR-BRL","provider":"frankfurter","poll_interval":"30s","enabled":true}`, ""},
+		{"par só com símbolo", "pair", `{"symbol":"EUR-BRL"}`, ""},
+		{"campos opcionais nulos", "pair", `{"symbol":"EUR-BRL","provider":null,"enabled":null}`, ""},
+		{"símbolo ausente", "pair", `{"enabled":true}`, "/symbol: campo obrigatório"},
+		{"campo desconhecido", "pair", `{"symbol":"EUR-BRL","interval":"30s"}`, "/interval: campo desconhecido"},
+		{"tipo errado", "pair", `{"symbol":"EUR-BRL","enabled":"sim"}`, "/enabled: esperado boolean ou null"},
+		{"duração inválida", "pair", `{"symbol":"EUR-BRL","poll_interval":"30 segundos"}`, `/poll_interval: valor "30 segundos" fora do formato esperado`},
+		{"corpo não é objeto", "pair", `["EUR-BRL"]`, "/: esperado object"},
+		{"JSON inválido", "pair", `{"symbol":`, "JSON inválido"},
+		{"conteúdo após o objeto", "pair", `{"symbol":"EUR-BRL"} {}`, "JSON inválido: conteúdo após o objeto"},
+		{"decimal como número", "trade", `{"side":"buy","amount":100.5}`, ""},
+		{"decimal como texto", "trade", `{"side":"buy","amount":"100.50"}`, ""},
+		{"decimal em texto inválido", "trade", `{"side":"buy","amount":"cem"}`, `/amount: valor "cem" fora do formato esperado`},
+		{"operação sem valor", "trade", `{"side":"buy"}`, "/amount: campo obrigatório"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBody(tt.resource, []byte(tt.body))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("erro inesperado: %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("erro = %v, esperado %q", err, tt.wantErr)
+			}
+		})
+	}
+}
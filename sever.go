@@ -3,65 +3,107 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	"gorm.io/driver/sqlite"
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-type USDToBRLRate struct {
-	USDBRL struct {
-		Code       string `json:"code"`
-		Codein     string `json:"codein"`
-		Name       string `json:"name"`
-		High       string `json:"high"`
-		Low        string `json:"low"`
-		VarBid     string `json:"varBid"`
-		PctChange  string `json:"pctChange"`
-		Bid        string `json:"bid"`
-		Ask        string `json:"ask"`
-		Timestamp  string `json:"timestamp"`
-		CreateDate string `json:"create_date"`
-	} `json:"USDBRL"`
-}
+// Quote e ExchangeRate são o formato de fio compartilhado com o client (pkg/domain)
+type (
+	Quote        = domain.Quote
+	ExchangeRate = domain.ExchangeRate
+)
 
 type USDToBRLRateDB struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	Code      string    `gorm:"type:varchar(10);not null"`
-	Bid       float64   `gorm:"type:decimal(10,4);not null"`
-	Ask       float64   `gorm:"type:decimal(10,4);not null"`
-	Timestamp int64     `gorm:"not null"`                    // Unix timestamp
-	CreatedAt time.Time `gorm:"column:create_date;not null"` // Mapeia para o campo "create_date" no banco
+	ID        uint            `gorm:"primaryKey;autoIncrement" json:"-"`       // chave local, fora da API
+	UID       string          `gorm:"type:varchar(36);uniqueIndex" json:"uid"` // ID global (ULID ou UUIDv7)
+	Code      string          `gorm:"type:varchar(10);not null" json:"code"`
+	Pair      string          `gorm:"type:varchar(21);not null;default:USD-BRL;index" json:"pair"`
+	Bid       decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"bid"`
+	Ask       decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"ask"`
+	Timestamp int64           `gorm:"not null" json:"timestamp"` // Unix timestamp
+	RequestID string          `gorm:"type:varchar(128);index" json:"request_id,omitempty"`
+	CreatedBy string          `gorm:"type:varchar(100);not null;default:''" json:"created_by,omitempty"` // user:{id}, anonymous ou system
+	Source    string          `gorm:"type:varchar(20);not null;default:''" json:"source,omitempty"`      // request, scheduler, backfill...
+	Provider  string          `gorm:"type:varchar(20);not null;default:''" json:"provider,omitempty"`
+	CreatedAt time.Time       `gorm:"column:create_date;not null" json:"create_date"` // Mapeia para o campo "create_date" no banco, gravado em UTC
+	// Cotações removidas pela deduplicação ficam no banco marcadas com deleted_at, fora do
+	// índice único parcial de (pair, timestamp); a retenção as apaga de vez. O índice é
+	// criado pela migração 000022, já que as tags do gorm não declaram índices parciais
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Time      time.Time      `gorm:"-" json:"time"` // Timestamp em RFC3339, preenchido nas respostas
 }
 
+const defaultPair = "USD-BRL"
+
+// Prazo máximo para a gravação da cotação no banco
+const persistTimeout = 10 * time.Millisecond
+
+var fetchGroup singleflight.Group
+
 var db *gorm.DB
-var errorDB error
+var cfg *config.Config
 
 func main() {
+	cfg = config.Load()
 
-	db, errorDB = gorm.Open(sqlite.Open("./data/exchange.db"), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-
-	if errorDB != nil {
-		log.Fatal("failed to connect database: ", errorDB)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		var err error
+		if db, err = openDatabase(); err != nil {
+			log.Fatal("failed to connect database: ", err)
+		}
+		if memoryOnly {
+			log.Fatal("cannot migrate: ", cfg.DBPath, " is read-only")
+		}
+		migrateCommand(os.Args[2:])
+		return
 	}
 
-	// Migrate the schema
-	if err := db.AutoMigrate(&USDToBRLRateDB{}); err != nil {
-		log.Fatal("failed to migrate schema: ", err)
+	report := &shutdownReport{}
+	var srv *httpServer
+	app := newApp(report, &srv)
+	if err := app.Start(context.Background()); err != nil {
+		log.Fatal("failed to start: ", err)
 	}
 
-	log.Println("Database connected and schema migrated successfully.")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	notifyReady()
+	select {
+	case <-ctx.Done():
+	case <-watchRestart(ctx, srv.ln):
+		// A nova instância já atende; encerra os jobs desta e conclui as requisições
+		stop()
+	}
 
-	http.HandleFunc("/cotacao", GetExchangeRateHandler)
-	log.Println("Servidor iniciado na porta 8080...")
-	http.ListenAndServe(":8080", nil)
+	log.Println("Encerrando servidor...")
+	report.begin()
+	// Sem prazo: cada etapa limita a própria espera, e um prazo vencido faria o fx pular
+	// as etapas seguintes, como a gravação do buffer em lote
+	if err := app.Stop(context.Background()); err != nil {
+		report.addError("stop", err)
+	}
+	report.log()
 }
 
 func GetExchangeRateHandler(w http.ResponseWriter, r *http.Request) {
@@ -70,50 +112,151 @@ func GetExchangeRateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rate, err := GetExchangeRate()
-	if err != nil {
-		log.Printf("Erro ao obter taxa de câmbio: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	r, timing := withTiming(r)
+	loc, ok := requestLocation(w, r)
+	if !ok {
+		return
+	}
+	pair, quote, ok := quoteForRequest(w, r)
+	if !ok {
 		return
 	}
 
-	// Criar contexto com timeout de 10ms para a "persistência"
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Millisecond)
-	defer cancel()
+	localized := withQuotedAt(localizeQuote(*quote, requestLanguage(w, r)), loc)
+	body := map[string]any{domain.PairKey(pair): publicQuote(r, localized)}
+	if timing != nil {
+		body[domain.TimingKey] = timing.report()
+	}
+	setQuoteCacheHeaders(w, pair, quote)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(domain.WireVersionHeader, strconv.Itoa(domain.WireVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
 
-	select {
-	case <-time.After(5 * time.Millisecond):
-		err := SaveExchangeRate(rate)
+// quoteForRequest obtém a cotação do par informado em ?pair= (padrão USD-BRL), já
+// respondendo com o erro adequado quando não for possível
+func quoteForRequest(w http.ResponseWriter, r *http.Request) (string, *Quote, bool) {
+	pair := strings.ToUpper(r.URL.Query().Get("pair"))
+	if pair == "" {
+		pair = defaultPair
+	}
+	quote, ok := quoteForPair(w, r, pair)
+	return pair, quote, ok
+}
+
+// quoteForPair obtém a cotação de um par habilitado, do cache conforme QUOTE_CACHE_TTL ou do
+// provedor, respondendo o erro adequado em caso de falha
+func quoteForPair(w http.ResponseWriter, r *http.Request, pair string) (*Quote, bool) {
+	if !pairs.isEnabled(pair) {
+		writeError(w, http.StatusNotFound, "par não habilitado")
+		return nil, false
+	}
+
+	quote, err := cachedOrFresh(r.Context(), pair)
+	if errors.Is(err, errQuotaExhausted) {
+		writeError(w, http.StatusServiceUnavailable, "cota diária do provedor esgotada e sem cotação em cache")
+		return nil, false
+	}
+	if err != nil {
+		logf(r.Context(), "Erro ao obter taxa de câmbio: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro ao obter taxa de câmbio")
+		return nil, false
+	}
+
+	return quote, true
+}
+
+// fetchAndPersist busca e grava a cotação do par; requisições concorrentes para o mesmo
+// par compartilham uma única chamada ao upstream e uma única gravação no banco
+func fetchAndPersist(ctx context.Context, pair string) (*Quote, error) {
+	quote, err := fetchFresh(ctx, pair)
+
+	// Com a cota diária esgotada e sem outro provedor disponível, apenas o cache é utilizado
+	if errors.Is(err, errQuotaExhausted) {
+		if entry, ok := cache.get(pair); ok {
+			logf(ctx, "Cota do provedor esgotada, servindo cotação de %s do cache", pair)
+			timingFromContext(ctx).record(0, 0, timingCacheHit)
+			return entry.quote, nil
+		}
+	}
+	return quote, err
+}
+
+// fetchFresh busca e grava a cotação do par no upstream, sem recorrer ao cache
+func fetchFresh(ctx context.Context, pair string) (*Quote, error) {
+	// Desacoplado do cancelamento de quem chegou primeiro, pois o resultado é compartilhado
+	sharedCtx := context.WithoutCancel(ctx)
+
+	v, err, shared := fetchGroup.Do(pair, func() (any, error) {
+		start := time.Now()
+		quote, err := fetchQuote(sharedCtx, pair)
 		if err != nil {
-			log.Println("Dados gravados com sucesso no banco (simulação).")
+			return nil, err
 		}
-	case <-ctx.Done():
-		log.Println("Timeout: operação de gravação no banco excedeu 10ms.")
+		fetched := time.Now()
+
+		persist(sharedCtx, pair, quote)
+		cache.set(pair, quote)
+		return &fetchResult{quote: quote, upstream: fetched.Sub(start), db: time.Since(fetched)}, nil
+	})
+	if shared {
+		logf(ctx, "Cotação de %s compartilhada com requisições concorrentes", pair)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(rate)
+	res := v.(*fetchResult)
+	origin := timingCacheMiss
+	if shared {
+		origin = timingCacheShared
+	}
+	timingFromContext(ctx).record(res.upstream, res.db, origin)
+	return res.quote, nil
+}
+
+// fetchResult é o resultado compartilhado por fetchGroup, com o tempo gasto no provedor e
+// na gravação
+type fetchResult struct {
+	quote    *Quote
+	upstream time.Duration
+	db       time.Duration
 }
 
-func GetExchangeRate() (*USDToBRLRate, error) {
-	// Timeout de 200ms para a requisição HTTP
-	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+// Prazo de cada chamada ao AwesomeAPI
+const awesomeAPITimeout = 200 * time.Millisecond
+
+func GetExchangeRate(ctx context.Context, pair string) (_ *Quote, err error) {
+	ctx, span := tracer.Start(ctx, "GetExchangeRate", trace.WithAttributes(
+		requestIDAttr(ctx),
+		attribute.String("rate.pair", pair),
+	))
+	defer func() { endSpan(span, err) }()
+
+	// Timeout de 200ms para a requisição HTTP, encurtado quando resta menos de UPSTREAM_BUDGET
+	ctx, cancel := context.WithTimeout(ctx, awesomeAPITimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://economia.awesomeapi.com.br/last/USD-BRL", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.AwesomeAPIBaseURL+"/last/"+url.PathEscape(pair), nil)
 	if err != nil {
 		return nil, err
 	}
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		req.Header.Set(requestIDHeader, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	resp, err := http.DefaultClient.Do(req)
+	quota.increment()
+	resp, err := upstreamClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 	if resp.StatusCode != http.StatusOK {
-		return nil, err
+		return nil, fmt.Errorf("upstream respondeu com status %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -121,45 +264,132 @@ func GetExchangeRate() (*USDToBRLRate, error) {
 		return nil, err
 	}
 
-	var rate USDToBRLRate
-	err = json.Unmarshal(body, &rate)
-	if err != nil {
-		return nil, err
+	return parseAwesomePayload(body, pair)
+}
+
+// persist grava a cotação respeitando o prazo de 10ms, distinguindo timeout de erro do banco;
+// falhas na gravação não impedem a resposta ao cliente. Cotações com valores inválidos vão
+// para a quarentena em vez da série
+func persist(ctx context.Context, pair string, quote *Quote) {
+	if kind, reason := inspectQuote(quote); kind != "" {
+		quarantine(ctx, pair, quote, kind, reason)
+		return
+	}
+
+	// Sem persistência não há outbox; os alertas, as assinaturas, as séries derivadas e o
+	// barramento recebem a cotação diretamente
+	if memoryOnly {
+		dbWrites.WithLabelValues("disabled").Inc()
+		alertsEngine.observe(pair, quote)
+		liveQuotes.publish(pair, quote)
+		evaluateDerived(ctx, pair, quote)
+		if event, err := newRateEvent(pair, quote); err == nil {
+			event.CreatedAt = time.Now()
+			messageBus.enqueue(event)
+		}
+		return
 	}
 
-	return &rate, nil
+	ctx, cancel := context.WithTimeout(ctx, persistTimeout)
+	defer cancel()
+
+	err := SaveExchangeRate(ctx, pair, quote)
+	switch {
+	case err == nil && batcher != nil:
+		dbWrites.WithLabelValues("queued").Inc()
+	case err == nil:
+		dbWrites.WithLabelValues("ok").Inc()
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		dbWrites.WithLabelValues("timeout").Inc()
+		logf(ctx, "Timeout: operação de gravação no banco excedeu %s.", persistTimeout)
+		journalRate(ctx, pair, quote, context.DeadlineExceeded)
+	default:
+		dbWrites.WithLabelValues("error").Inc()
+		logf(ctx, "Erro ao gravar cotação no banco: %v", err)
+		journalRate(ctx, pair, quote, err)
+	}
 }
 
 // Função para persistir os dados no banco de dados
-func SaveExchangeRate(rate *USDToBRLRate) error {
-	rateDB := USDToBRLRateDB{
-		Code:      rate.USDBRL.Code,
-		Bid:       parseFloat(rate.USDBRL.Bid),
-		Ask:       parseFloat(rate.USDBRL.Ask),
-		Timestamp: parseTimestamp(rate.USDBRL.Timestamp),
-	}
+func SaveExchangeRate(ctx context.Context, pair string, quote *Quote) (err error) {
+	ctx, span := tracer.Start(ctx, "SaveExchangeRate", trace.WithAttributes(
+		requestIDAttr(ctx),
+		attribute.String("db.system", "sqlite"),
+		attribute.String("rate.pair", pair),
+	))
+	defer func() { endSpan(span, err) }()
 
-	if err := db.Create(&rateDB).Error; err != nil {
+	rateDB := newRateRow(ctx, pair, quote)
+	event, err := newRateEvent(pair, quote)
+	if err != nil {
 		return err
 	}
 
+	// No modo em lote a gravação é feita de forma assíncrona pelo batcher; depois do
+	// encerramento do buffer a cotação segue pela gravação direta
+	if batcher != nil {
+		if err := batcher.enqueue(ctx, batchRow{rate: rateDB, event: event}); !errors.Is(err, errBatcherClosed) {
+			return err
+		}
+	}
+
+	// O evento só é gravado com a cotação confirmada, para que os consumidores da outbox
+	// nunca recebam uma cotação que não foi persistida
+	if repo, ok := rateRepo.(outboxRepository); ok {
+		err = repo.SaveWithEvent(ctx, &rateDB, &event)
+	} else {
+		err = saveWithIntent(ctx, &rateDB, &event)
+	}
+	// A mesma cotação já gravada não gera novo evento, para não repetir alertas
+	if errors.Is(err, errDuplicateRate) {
+		duplicateRates.Inc()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	historyResults.invalidate(pair, rateDB.Timestamp)
+	outbox.notify()
 	return nil
 }
 
-func parseTimestamp(s string) int64 {
-	i, err := strconv.ParseInt(s, 10, 64)
+func newRateRow(ctx context.Context, pair string, quote *Quote) USDToBRLRateDB {
+	return USDToBRLRateDB{
+		UID:       newID(),
+		Code:      quote.Code,
+		Pair:      pair,
+		Bid:       parseDecimal(quote.Bid).Round(moneyScale),
+		Ask:       parseDecimal(quote.Ask).Round(moneyScale),
+		Timestamp: quoteTimestamp(quote),
+		RequestID: requestIDFromContext(ctx),
+		CreatedBy: auditActor(ctx),
+		Source:    rateSourceFromContext(ctx),
+		Provider:  quote.Provider,
+	}
+}
+
+func quoteTimestamp(quote *Quote) int64 {
+	i, err := quote.Unix()
 	if err != nil {
-		log.Printf("Erro ao converter '%s' para int64: %v", s, err)
+		log.Printf("Erro ao converter '%s' para int64: %v", quote.Timestamp, err)
 		return 0
 	}
 	return i
 }
 
-func parseFloat(s string) float64 {
-	f, err := strconv.ParseFloat(s, 64)
+// Casas decimais das colunas de valores monetários (decimal(10,4))
+const moneyScale = 4
+
+func init() {
+	// Valores decimais continuam sendo números no JSON, como eram com float64
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
+func parseDecimal(s string) decimal.Decimal {
+	d, err := decimal.NewFromString(s)
 	if err != nil {
-		log.Printf("Erro ao converter '%s' para float64: %v", s, err)
-		return 0
+		log.Printf("Erro ao converter '%s' para decimal: %v", s, err)
+		return decimal.Zero
 	}
-	return f
+	return d
 }
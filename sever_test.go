@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestGetExchangeRateHandler(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		path     string
+		upstream http.HandlerFunc
+		setup    func(t *testing.T, conn *gorm.DB)
+
+		wantStatus      int
+		wantBid         string
+		wantError       string
+		wantRows        int64
+		wantQuarantined int64
+		wantWrites      map[string]float64 // incremento esperado em db_writes_total
+	}{
+		{
+			name:       "sucesso grava a cotação",
+			path:       "/cotacao",
+			upstream:   lastHandler("USD-BRL", testQuote("5.8050", now)),
+			wantStatus: http.StatusOK,
+			wantBid:    "5.8050",
+			wantRows:   1,
+			wantWrites: map[string]float64{"ok": 1},
+		},
+		{
+			name: "par informado em pair",
+			path: "/cotacao?pair=eur-brl",
+			upstream: lastHandler("EUR-BRL", Quote{
+				Code: "EUR", Codein: "BRL", Bid: "6.1", Ask: "6.2", Timestamp: "1700000000",
+			}),
+			setup: func(t *testing.T, conn *gorm.DB) {
+				if _, err := pairs.save(PairDB{Symbol: "EUR-BRL", Enabled: true}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBid:    "6.1",
+			wantRows:   1,
+		},
+		{
+			name:       "par não habilitado",
+			path:       "/cotacao?pair=JPY-BRL",
+			upstream:   lastHandler("JPY-BRL", testQuote("0.04", now)),
+			wantStatus: http.StatusNotFound,
+			wantError:  "par não habilitado",
+		},
+		{
+			name: "timeout do upstream",
+			path: "/cotacao",
+			upstream: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "erro ao obter taxa de câmbio",
+		},
+		{
+			name: "upstream com erro",
+			path: "/cotacao",
+			upstream: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "erro ao obter taxa de câmbio",
+		},
+		{
+			name: "par ausente na resposta do upstream",
+			path: "/cotacao",
+			upstream: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{}`))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "erro ao obter taxa de câmbio",
+		},
+		{
+			name:       "timeout do banco não impede a resposta",
+			path:       "/cotacao",
+			upstream:   lastHandler("USD-BRL", testQuote("5.8050", now)),
+			setup:      slowInserts,
+			wantStatus: http.StatusOK,
+			wantBid:    "5.8050",
+			wantWrites: map[string]float64{"timeout": 1, "ok": 0},
+		},
+		{
+			name:            "cotação inválida vai para a quarentena",
+			path:            "/cotacao",
+			upstream:        lastHandler("USD-BRL", testQuote("abc", now)),
+			wantStatus:      http.StatusInternalServerError,
+			wantError:       "erro ao obter taxa de câmbio",
+			wantQuarantined: 1,
+			wantWrites:      map[string]float64{"ok": 0},
+		},
+		{
+			name: "cota esgotada serve o cache",
+			path: "/cotacao",
+			upstream: func(w http.ResponseWriter, r *http.Request) {
+				t.Error("upstream consultado com a cota esgotada")
+			},
+			setup: func(t *testing.T, conn *gorm.DB) {
+				quote := testQuote("5.7", now)
+				cache.set("USD-BRL", &quote)
+				exhaustQuota(t)
+			},
+			wantStatus: http.StatusOK,
+			wantBid:    "5.7",
+		},
+		{
+			name: "cota esgotada sem cache",
+			path: "/cotacao",
+			upstream: func(w http.ResponseWriter, r *http.Request) {
+				t.Error("upstream consultado com a cota esgotada")
+			},
+			setup:      func(t *testing.T, conn *gorm.DB) { exhaustQuota(t) },
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  "cota diária do provedor esgotada e sem cotação em cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := newTestDB(t)
+			newTestUpstream(t, tt.upstream)
+			if tt.setup != nil {
+				tt.setup(t, conn)
+			}
+			writes := map[string]float64{}
+			for result := range tt.wantWrites {
+				writes[result] = testutil.ToFloat64(dbWrites.WithLabelValues(result))
+			}
+
+			rec := httptest.NewRecorder()
+			GetExchangeRateHandler(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, esperado %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantError != "" {
+				var body ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				if body.Error != tt.wantError {
+					t.Errorf("erro = %q, esperado %q", body.Error, tt.wantError)
+				}
+			}
+			if tt.wantBid != "" {
+				var body ExchangeRate
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				pair := strings.ToUpper(httptest.NewRequest(http.MethodGet, tt.path, nil).URL.Query().Get("pair"))
+				if pair == "" {
+					pair = defaultPair
+				}
+				quote, ok := body.Lookup(pair)
+				if !ok || quote.Bid != tt.wantBid {
+					t.Errorf("cotação = %+v, esperado bid %s", body, tt.wantBid)
+				}
+			}
+
+			var rows, quarantined int64
+			conn.Model(&USDToBRLRateDB{}).Count(&rows)
+			conn.Model(&QuarantinedRateDB{}).Count(&quarantined)
+			if rows != tt.wantRows {
+				t.Errorf("cotações gravadas = %d, esperado %d", rows, tt.wantRows)
+			}
+			if quarantined != tt.wantQuarantined {
+				t.Errorf("cotações em quarentena = %d, esperado %d", quarantined, tt.wantQuarantined)
+			}
+			for result, want := range tt.wantWrites {
+				if got := testutil.ToFloat64(dbWrites.WithLabelValues(result)) - writes[result]; got != want {
+					t.Errorf("db_writes_total{result=%q} aumentou %v, esperado %v", result, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetExchangeRateHandlerCacheHeaders(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wantCache string
+	}{
+		{"sem cache", 0, "no-cache"},
+		{"com cache", time.Minute, "max-age=60, stale-while-revalidate=30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			calls := 0
+			upstream := lastHandler("USD-BRL", testQuote("5.8", now))
+			newTestUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				upstream(w, r)
+			})
+			withConfig(t, func(c *config.Config) {
+				c.QuoteCacheTTL = tt.ttl
+				c.QuoteStaleWhileRevalidate = 30 * time.Second
+			})
+
+			for range 2 {
+				rec := httptest.NewRecorder()
+				GetExchangeRateHandler(rec, httptest.NewRequest(http.MethodGet, "/cotacao", nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d (%s)", rec.Code, rec.Body)
+				}
+				if got := rec.Header().Get("Cache-Control"); got != tt.wantCache {
+					t.Errorf("Cache-Control = %q, esperado %q", got, tt.wantCache)
+				}
+			}
+
+			// Com cache, a segunda requisição não chega ao provedor
+			wantCalls := 2
+			if tt.ttl > 0 {
+				wantCalls = 1
+			}
+			if calls != wantCalls {
+				t.Errorf("chamadas ao upstream = %d, esperado %d", calls, wantCalls)
+			}
+		})
+	}
+}
+
+// slowInserts atrasa as gravações além de persistTimeout
+func slowInserts(t *testing.T, conn *gorm.DB) {
+	t.Helper()
+	err := conn.Callback().Create().Before("gorm:create").Register("test:slow", func(*gorm.DB) {
+		time.Sleep(3 * persistTimeout)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exhaustQuota(t *testing.T) {
+	t.Helper()
+	if err := quota.load(1); err != nil {
+		t.Fatal(err)
+	}
+	quota.increment()
+}
+
+// Os valores monetários passam do provedor ao banco e do banco ao JSON sem perder precisão,
+// arredondados só na escala da coluna (decimal(10,4))
+func TestDecimalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		bid      string
+		wantJSON string
+	}{
+		{name: "quatro casas", bid: "5.1234", wantJSON: "5.1234"},
+		{name: "menor valor da escala", bid: "0.0001", wantJSON: "0.0001"},
+		{name: "sem perda em float64", bid: "0.3", wantJSON: "0.3"},
+		{name: "maior valor da coluna", bid: "999999.9999", wantJSON: "999999.9999"},
+		{name: "zeros à direita", bid: "5.1000", wantJSON: "5.1"},
+		{name: "arredondado na escala", bid: "5.12345", wantJSON: "5.1235"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := newTestDB(t)
+			quote := testQuote(tt.bid, time.Now())
+			if err := SaveExchangeRate(context.Background(), "USD-BRL", &quote); err != nil {
+				t.Fatal(err)
+			}
+
+			var row USDToBRLRateDB
+			if err := conn.First(&row).Error; err != nil {
+				t.Fatal(err)
+			}
+			if want := decimal.RequireFromString(tt.wantJSON); !row.Bid.Equal(want) || !row.Ask.Equal(want) {
+				t.Errorf("bid/ask lidos do banco = %s/%s, esperado %s", row.Bid, row.Ask, want)
+			}
+
+			data, err := json.Marshal(row)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(fields["bid"]); got != tt.wantJSON {
+				t.Errorf("bid no JSON = %s, esperado %s", got, tt.wantJSON)
+			}
+			if got := string(fields["ask"]); got != tt.wantJSON {
+				t.Errorf("ask no JSON = %s, esperado %s", got, tt.wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultShareLinkTTL = 24 * time.Hour
+
+// ShareLinkRequest descreve o trecho do histórico compartilhado; from, to e resolution
+// seguem /historico e expires_in limita a validade do link
+type ShareLinkRequest struct {
+	Pair       string   `json:"pair"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Resolution string   `json:"resolution"`
+	ExpiresIn  Duration `json:"expires_in"`
+}
+
+// ShareLinkResponse traz os links assinados para o mesmo trecho: o painel, a série em JSON e
+// o CSV de /historico/export
+type ShareLinkResponse struct {
+	Pair         string    `json:"pair"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	DashboardURL string    `json:"dashboard_url"`
+	HistoryURL   string    `json:"history_url"`
+	ExportURL    string    `json:"export_url"`
+}
+
+// ShareLinkHandler gera links assinados e com prazo que dão acesso de leitura a um par e
+// intervalo do histórico, para compartilhar um gráfico sem criar uma conta ou chave de API.
+// O intervalo é fixado na geração: sem to, vale até o momento da geração
+func ShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+
+	scope, msg := parseHistoryRange(url.Values{"pair": {req.Pair}, "from": {req.From}, "to": {req.To}, "resolution": {req.Resolution}})
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if req.Resolution == "" {
+		scope.Resolution = ""
+	}
+
+	ttl := time.Duration(req.ExpiresIn)
+	if ttl == 0 {
+		ttl = defaultShareLinkTTL
+	}
+	if ttl < 0 || ttl > cfg.ShareLinkMaxTTL {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("expires_in deve estar entre 0 e %s", cfg.ShareLinkMaxTTL))
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	params := signShareLink(scope, expiresAt)
+	userID, _ := userIDFromContext(r.Context())
+	logf(r.Context(), "Usuário %d compartilhou %s de %s a %s até %s", userID, scope.Pair,
+		scope.From.Format(time.RFC3339), scope.To.Format(time.RFC3339), expiresAt.Format(time.RFC3339))
+
+	writeJSON(w, http.StatusCreated, ShareLinkResponse{
+		Pair:         scope.Pair,
+		From:         scope.From,
+		To:           scope.To,
+		ExpiresAt:    expiresAt.UTC(),
+		DashboardURL: cfg.PublicBaseURL + "/?" + params,
+		HistoryURL:   cfg.PublicBaseURL + "/compartilhado/historico?" + params,
+		ExportURL:    cfg.PublicBaseURL + "/compartilhado/export?" + params,
+	})
+}
+
+// signShareLink monta a query do link: o trecho, o prazo e a assinatura HMAC de todos eles
+func signShareLink(scope HistoryResponse, expiresAt time.Time) string {
+	params := url.Values{}
+	params.Set("pair", scope.Pair)
+	params.Set("from", strconv.FormatInt(scope.From.Unix(), 10))
+	params.Set("to", strconv.FormatInt(scope.To.Unix(), 10))
+	if scope.Resolution != "" {
+		params.Set("resolution", scope.Resolution)
+	}
+	params.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	params.Set("sig", shareLinkSignature(params))
+	return params.Encode()
+}
+
+// shareLinkSignature assina os parâmetros do link, exceto sig, na ordem de url.Values.Encode
+func shareLinkSignature(params url.Values) string {
+	signed := url.Values{}
+	for _, name := range []string{"pair", "from", "to", "resolution", "expires"} {
+		if v := params.Get(name); v != "" {
+			signed.Set(name, v)
+		}
+	}
+
+	secret := cfg.ShareLinkSecret
+	if secret == "" {
+		secret = "share-link:" + cfg.JWTSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyShareLink confere a assinatura e o prazo do link, respondendo 403 ou 410 quando
+// inválido; devolve só os parâmetros do trecho compartilhado
+func verifyShareLink(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	q := r.URL.Query()
+	expected := shareLinkSignature(q)
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(expected)) {
+		writeError(w, http.StatusForbidden, "link de compartilhamento inválido")
+		return nil, false
+	}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		writeError(w, http.StatusGone, "link de compartilhamento expirado")
+		return nil, false
+	}
+
+	scope := url.Values{}
+	for _, name := range []string{"pair", "from", "to", "resolution"} {
+		if v := q.Get(name); v != "" {
+			scope.Set(name, v)
+		}
+	}
+	w.Header().Set("X-Share-Expires", time.Unix(expires, 0).UTC().Format(time.RFC3339))
+	return scope, true
+}
+
+// SharedHistoryHandler responde como /historico com from/to para o trecho do link assinado
+func SharedHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	scope, ok := verifyShareLink(w, r)
+	if !ok {
+		return
+	}
+	// O fuso só muda a apresentação e pode ser escolhido por quem abre o link
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		scope.Set("tz", tz)
+	}
+	getHistoryRange(w, withQuery(r, scope))
+}
+
+// SharedExportHandler responde como /historico/export para o trecho do link assinado,
+// aceitando ?format=
+func SharedExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	scope, ok := verifyShareLink(w, r)
+	if !ok {
+		return
+	}
+	export := url.Values{"pair": {scope.Get("pair")}, "since": {scope.Get("from")}, "until": {scope.Get("to")}}
+	// Como o fuso no histórico, o formato do arquivo pode ser escolhido por quem abre o link
+	if format := r.URL.Query().Get("format"); format != "" {
+		export.Set("format", format)
+	}
+	ExportHandler(w, withQuery(r, export))
+}
+
+// withQuery devolve uma cópia da requisição com a query substituída
+func withQuery(r *http.Request, q url.Values) *http.Request {
+	r2 := r.Clone(r.Context())
+	r2.URL.RawQuery = q.Encode()
+	return r2
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+func TestShareLinkHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantTTL    time.Duration
+	}{
+		{name: "validade padrão", body: `{"pair":"usd-brl","from":"2024-01-01","to":"2024-01-02"}`, wantStatus: http.StatusCreated, wantTTL: defaultShareLinkTTL},
+		{name: "validade informada", body: `{"from":"2024-01-01","to":"2024-01-02","expires_in":"1h"}`, wantStatus: http.StatusCreated, wantTTL: time.Hour},
+		{name: "validade acima do máximo", body: `{"from":"2024-01-01","expires_in":"720h"}`, wantStatus: http.StatusBadRequest},
+		{name: "validade negativa", body: `{"from":"2024-01-01","expires_in":"-1h"}`, wantStatus: http.StatusBadRequest},
+		{name: "intervalo inválido", body: `{"from":"ontem"}`, wantStatus: http.StatusBadRequest},
+		{name: "corpo inválido", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+
+			rec, ok := serve(t, ShareLinkHandler, http.MethodPost, "/historico/compartilhar", tt.body, tt.wantStatus)
+			if !ok {
+				return
+			}
+
+			resp := decodeJSON[ShareLinkResponse](t, rec)
+			if resp.Pair != "USD-BRL" {
+				t.Errorf("par = %s, esperado USD-BRL", resp.Pair)
+			}
+			if ttl := time.Until(resp.ExpiresAt); ttl > tt.wantTTL || ttl < tt.wantTTL-time.Minute {
+				t.Errorf("validade = %s, esperado %s", ttl, tt.wantTTL)
+			}
+			for _, link := range []string{resp.DashboardURL, resp.HistoryURL, resp.ExportURL} {
+				if !strings.HasPrefix(link, cfg.PublicBaseURL+"/") || !strings.Contains(link, "sig=") {
+					t.Errorf("link %s sem a URL pública ou a assinatura", link)
+				}
+			}
+		})
+	}
+}
+
+func TestSharedLinks(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	scope := HistoryResponse{Pair: "USD-BRL", From: base, To: base.Add(time.Hour)}
+	valid := signShareLink(scope, time.Now().Add(time.Hour))
+	tamper := func(name, value string) string {
+		q, _ := url.ParseQuery(valid)
+		q.Set(name, value)
+		return q.Encode()
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantPoints int
+	}{
+		{name: "histórico", path: "/compartilhado/historico?" + valid, wantStatus: http.StatusOK, wantPoints: 2},
+		{name: "exportação", path: "/compartilhado/export?" + valid, wantStatus: http.StatusOK},
+		{name: "par alterado", path: "/compartilhado/historico?" + tamper("pair", "EUR-BRL"), wantStatus: http.StatusForbidden},
+		{name: "intervalo ampliado", path: "/compartilhado/historico?" + tamper("to", "2024-12-31"), wantStatus: http.StatusForbidden},
+		{name: "prazo estendido", path: "/compartilhado/export?" + tamper("expires", "4102444800"), wantStatus: http.StatusForbidden},
+		{name: "sem assinatura", path: "/compartilhado/historico?pair=USD-BRL&from=2024-01-01", wantStatus: http.StatusForbidden},
+		{name: "expirado", path: "/compartilhado/historico?" + signShareLink(scope, time.Now().Add(-time.Second)), wantStatus: http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			withConfig(t, func(c *config.Config) { c.ExportDir = t.TempDir() })
+			seedRates(t, rateRepo,
+				testRate("USD-BRL", base.Unix(), "5.00"),
+				testRate("USD-BRL", base.Add(30*time.Minute).Unix(), "5.10"),
+				testRate("USD-BRL", base.Add(2*time.Hour).Unix(), "5.20"),
+				testRate("EUR-BRL", base.Unix(), "6.00"),
+			)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/compartilhado/historico", SharedHistoryHandler)
+			mux.HandleFunc("/compartilhado/export", SharedExportHandler)
+			rec, ok := serve(t, mux.ServeHTTP, http.MethodGet, tt.path, "", tt.wantStatus)
+			if !ok {
+				return
+			}
+
+			if strings.HasPrefix(tt.path, "/compartilhado/export") {
+				// Cabeçalho e as duas cotações do trecho
+				if lines := strings.Count(rec.Body.String(), "\n"); lines != 3 {
+					t.Errorf("linhas do CSV = %d, esperado 3:\n%s", lines, rec.Body)
+				}
+				return
+			}
+			resp := decodeJSON[HistoryResponse](t, rec)
+			if len(resp.Points) != tt.wantPoints {
+				t.Errorf("pontos = %d, esperado %d", len(resp.Points), tt.wantPoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// shutdownReport resume o encerramento gracioso, para que os operadores confirmem após um
+// deploy que nada se perdeu. Clean é falso quando alguma requisição, cotação ou entrega de
+// alerta foi interrompida
+type shutdownReport struct {
+	Duration string `json:"duration"`
+	Clean    bool   `json:"clean"`
+
+	RequestsInFlight  int64 `json:"requests_in_flight"` // em andamento ao receber o sinal
+	RequestsDrained   int64 `json:"requests_drained"`   // concluídas durante o encerramento
+	RequestsAbandoned int64 `json:"requests_abandoned"` // ainda em andamento após o prazo
+
+	BatchRowsFlushed   int64 `json:"batch_rows_flushed"`   // cotações do buffer em lote gravadas
+	BatchRowsDropped   int64 `json:"batch_rows_dropped"`   // cotações do buffer em lote perdidas
+	AlertQuotesDropped int   `json:"alert_quotes_dropped"` // cotações não avaliadas pelos alertas
+	OutboxPending      int64 `json:"outbox_pending"`       // eventos mantidos no banco para a próxima execução
+	BusMessagesDropped int64 `json:"bus_messages_dropped"` // cotações da fila do barramento não publicadas
+	JournalPending     int64 `json:"journal_pending"`      // cotações no journal local, regravadas na próxima execução
+
+	// entregas de alertas (webhooks e demais canais) ainda pendentes ao encerrar; são
+	// interrompidas sem confirmação de entrega
+	AlertDeliveriesPending int64 `json:"alert_deliveries_pending"`
+
+	DBConnectionsClosed int `json:"db_connections_closed"`
+
+	Errors []string `json:"errors,omitempty"`
+
+	started time.Time
+}
+
+// begin marca o início do encerramento; o relatório é preenchido pelos hooks de parada
+// dos componentes (ver newApp)
+func (s *shutdownReport) begin() {
+	s.started = time.Now()
+	s.RequestsInFlight = activeRequests.Load()
+}
+
+func (s *shutdownReport) addError(step string, err error) {
+	if err != nil {
+		s.Errors = append(s.Errors, step+": "+err.Error())
+	}
+}
+
+// serverStopped registra as requisições que o encerramento do servidor HTTP não esperou
+func (s *shutdownReport) serverStopped() {
+	s.RequestsAbandoned = activeRequests.Load()
+	s.RequestsDrained = max(s.RequestsInFlight-s.RequestsAbandoned, 0)
+}
+
+// jobsStopped registra o que os jobs em segundo plano deixaram para trás
+func (s *shutdownReport) jobsStopped() {
+	s.AlertDeliveriesPending = alertsEngine.abortedDeliveries.Load()
+	s.AlertQuotesDropped = len(alertsEngine.quotes)
+	if memoryOnly {
+		return
+	}
+	err := db.Model(&OutboxEventDB{}).Where("dispatched_at IS NULL AND attempts < ?", outboxMaxAttempts).Count(&s.OutboxPending).Error
+	s.addError("outbox", err)
+}
+
+// closeDatabase fecha as conexões com o banco, que deixa de ser usado depois dos jobs e do
+// buffer em lote
+func (s *shutdownReport) closeDatabase() {
+	sqlDB, err := db.DB()
+	if err != nil {
+		s.addError("database", err)
+		return
+	}
+	s.DBConnectionsClosed = sqlDB.Stats().OpenConnections
+	s.addError("database", sqlDB.Close())
+}
+
+func (s *shutdownReport) log() {
+	s.Duration = time.Since(s.started).Round(time.Millisecond).String()
+	s.Clean = s.RequestsAbandoned == 0 && s.BatchRowsDropped == 0 && s.AlertQuotesDropped == 0 &&
+		s.BusMessagesDropped == 0 && s.AlertDeliveriesPending == 0 && len(s.Errors) == 0
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		log.Printf("Erro ao gerar o relatório de encerramento: %v", err)
+		return
+	}
+	log.Printf("Relatório de encerramento: %s", data)
+}
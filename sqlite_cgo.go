@@ -0,0 +1,16 @@
+//go:build cgo && !purego
+
+package main
+
+import (
+	puregosqlite "github.com/glebarez/sqlite"
+	"gorm.io/driver/sqlite"
+)
+
+// Com CGO o driver padrão é o mattn/go-sqlite3; o Go puro continua disponível por SQLITE_DRIVER
+const defaultSQLiteDriver = sqliteDriverCGO
+
+var sqliteDrivers = map[string]sqliteDriverInfo{
+	sqliteDriverCGO:    {sqlName: "sqlite3", open: sqlite.Open},
+	sqliteDriverPureGo: {sqlName: "sqlite", open: puregosqlite.Open},
+}
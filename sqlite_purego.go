@@ -0,0 +1,13 @@
+//go:build !cgo || purego
+
+package main
+
+import "github.com/glebarez/sqlite"
+
+// Sem CGO, ou com a tag purego, só o driver em Go puro (glebarez/sqlite, sobre o
+// modernc.org/sqlite) entra no binário, sem o mattn/go-sqlite3
+const defaultSQLiteDriver = sqliteDriverPureGo
+
+var sqliteDrivers = map[string]sqliteDriverInfo{
+	sqliteDriverPureGo: {sqlName: "sqlite", open: sqlite.Open},
+}
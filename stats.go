@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// PairStorageStats resume as cotações gravadas de um par
+type PairStorageStats struct {
+	Pair   string     `json:"pair"`
+	Rows   int64      `json:"rows"`
+	Oldest *time.Time `json:"oldest"` // null sem cotações gravadas
+	Newest *time.Time `json:"newest"`
+}
+
+// DatabaseStats descreve o armazenamento; o tamanho é o do arquivo SQLite com o WAL, que
+// guarda os demais dados mesmo com as cotações em outro backend
+type DatabaseStats struct {
+	Backend    string `json:"backend"`
+	Path       string `json:"path,omitempty"` // vazio no modo somente memória
+	SizeBytes  int64  `json:"size_bytes"`
+	Rows       int64  `json:"rows"`
+	MemoryOnly bool   `json:"memory_only"`
+
+	// cotações que o banco recusou, no journal local até serem regravadas
+	JournalPending int64 `json:"journal_pending"`
+}
+
+// CacheStats conta as consultas atendidas pelo cache desde a inicialização
+type CacheStats struct {
+	Entries  int     `json:"entries"`
+	Hits     int64   `json:"hits"`
+	Stale    int64   `json:"stale,omitempty"` // só no cache de cotações
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"` // hits (e stale) sobre o total de consultas
+}
+
+// ProviderStats conta as tentativas e falhas de cada provedor da cadeia desde a inicialização
+type ProviderStats struct {
+	Name      string  `json:"name"`
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+type StatsResponse struct {
+	GeneratedAt  time.Time          `json:"generated_at"`
+	Pairs        []PairStorageStats `json:"pairs"`
+	Database     DatabaseStats      `json:"database"`
+	QuoteCache   CacheStats         `json:"quote_cache"`
+	HistoryCache CacheStats         `json:"history_cache"`
+	Providers    []ProviderStats    `json:"providers"`
+	Quota        ProviderStatus     `json:"quota"`
+	Scheduler    SchedulerStatus    `json:"scheduler"`
+}
+
+// StatsHandler reúne em um único JSON o estado operacional do serviço, para painéis: as
+// cotações gravadas por par, o tamanho do banco, o uso dos caches, a taxa de erros dos
+// provedores e o agendador. Os contadores são os mesmos de /metrics
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := StatsResponse{
+		GeneratedAt: time.Now().UTC(),
+		Pairs:       []PairStorageStats{},
+		Database:    DatabaseStats{Backend: cfg.StorageBackend, MemoryOnly: memoryOnly},
+		Quota:       quota.status(),
+	}
+
+	for _, row := range pairs.list(false) {
+		stats, err := pairStorageStats(r.Context(), row.Symbol)
+		if err != nil {
+			logf(r.Context(), "Erro ao consultar as cotações de %s: %v", row.Symbol, err)
+			writeError(w, http.StatusInternalServerError, "erro interno")
+			return
+		}
+		resp.Pairs = append(resp.Pairs, stats)
+		resp.Database.Rows += stats.Rows
+	}
+
+	if !memoryOnly {
+		resp.Database.Path = cfg.DBPath
+		size, err := sqliteFileSize(cfg.DBPath)
+		if err != nil {
+			logf(r.Context(), "Erro ao obter o tamanho de %s: %v", cfg.DBPath, err)
+		}
+		resp.Database.SizeBytes = size
+	}
+	if journal != nil {
+		resp.Database.JournalPending = journal.pending.Load()
+	}
+
+	hits, stale := counterValue(quoteCacheRequests, "hit"), counterValue(quoteCacheRequests, "stale")
+	misses := counterValue(quoteCacheRequests, "miss")
+	resp.QuoteCache = CacheStats{
+		Entries: cache.len(), Hits: hits, Stale: stale, Misses: misses,
+		HitRatio: ratio(hits+stale, hits+stale+misses),
+	}
+	hits, misses = counterValue(historyCacheRequests, "hit"), counterValue(historyCacheRequests, "miss")
+	resp.HistoryCache = CacheStats{Entries: historyResults.len(), Hits: hits, Misses: misses, HitRatio: ratio(hits, hits+misses)}
+
+	for _, provider := range providerChain {
+		requests := counterValue(providerRequests, provider.Name())
+		errs := counterValue(providerErrors, provider.Name())
+		resp.Providers = append(resp.Providers, ProviderStats{
+			Name: provider.Name(), Requests: requests, Errors: errs, ErrorRate: ratio(errs, requests),
+		})
+	}
+
+	// Fora da instância líder o agendador fica parado, com running false
+	if pollScheduler != nil {
+		resp.Scheduler = pollScheduler.status()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// pairStorageStats conta as cotações do par e obtém a mais antiga e a mais recente pela
+// listagem do repositório, disponível em todos os backends
+func pairStorageStats(ctx context.Context, pair string) (PairStorageStats, error) {
+	stats := PairStorageStats{Pair: pair}
+	q := listQuery{Page: 1, PerPage: 1, Sort: "timestamp", Pair: pair}
+
+	oldest, total, err := rateRepo.List(ctx, q)
+	if err != nil || total == 0 {
+		return stats, err
+	}
+	q.Desc = true
+	newest, _, err := rateRepo.List(ctx, q)
+	if err != nil || len(oldest) == 0 || len(newest) == 0 {
+		return stats, err
+	}
+
+	from, to := time.Unix(oldest[0].Timestamp, 0).UTC(), time.Unix(newest[0].Timestamp, 0).UTC()
+	stats.Rows, stats.Oldest, stats.Newest = total, &from, &to
+	return stats, nil
+}
+
+// sqliteFileSize soma o arquivo do banco e o WAL, que pode não existir
+func sqliteFileSize(path string) (int64, error) {
+	var size int64
+	for _, name := range []string{path, path + "-wal"} {
+		info, err := os.Stat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return size, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+// counterValue lê o valor atual do contador com o rótulo informado
+func counterValue(vec *prometheus.CounterVec, label string) int64 {
+	var m dto.Metric
+	if err := vec.WithLabelValues(label).Write(&m); err != nil {
+		return 0
+	}
+	return int64(m.GetCounter().GetValue())
+}
+
+func ratio(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
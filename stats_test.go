@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+type failingProvider struct{ name string }
+
+func (p failingProvider) Name() string { return p.name }
+
+func (p failingProvider) Fetch(context.Context, string) (*Quote, error) {
+	return nil, errors.New("indisponível")
+}
+
+func TestStatsHandler(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		seed      []USDToBRLRateDB
+		scheduler bool
+		wantRows  map[string]int64
+		wantRange map[string][2]time.Time
+	}{
+		{name: "sem cotações", wantRows: map[string]int64{"USD-BRL": 0, "EUR-BRL": 0}},
+		{
+			name: "cotações de dois pares",
+			seed: []USDToBRLRateDB{
+				testRate("USD-BRL", base.Unix(), "5.0"),
+				testRate("USD-BRL", base.Add(time.Hour).Unix(), "5.1"),
+				testRate("USD-BRL", base.Add(2*time.Hour).Unix(), "5.2"),
+				testRate("EUR-BRL", base.Add(time.Minute).Unix(), "6.0"),
+			},
+			scheduler: true,
+			wantRows:  map[string]int64{"USD-BRL": 3, "EUR-BRL": 1},
+			wantRange: map[string][2]time.Time{
+				"USD-BRL": {base, base.Add(2 * time.Hour)},
+				"EUR-BRL": {base.Add(time.Minute), base.Add(time.Minute)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			if _, err := pairs.save(PairDB{Symbol: "EUR-BRL", Enabled: true}); err != nil {
+				t.Fatal(err)
+			}
+			seedRates(t, rateRepo, tt.seed...)
+			withConfig(t, func(c *config.Config) { c.DBPath = filepath.Join(t.TempDir(), "vazio.db") })
+
+			savedChain, savedScheduler := providerChain, pollScheduler
+			t.Cleanup(func() { providerChain, pollScheduler = savedChain, savedScheduler })
+			provider := failingProvider{name: "stats-" + t.Name()}
+			providerChain = []RateProvider{provider}
+			pollScheduler = nil
+			if tt.scheduler {
+				ctx, cancel := context.WithCancel(context.Background())
+				t.Cleanup(cancel)
+				pollScheduler = newScheduler(time.Minute, 10)
+				pollScheduler.start(ctx)
+			}
+			fetchQuote(context.Background(), "USD-BRL")
+
+			rec, _ := serve(t, StatsHandler, http.MethodGet, "/admin/stats", "", http.StatusOK)
+			resp := decodeJSON[StatsResponse](t, rec)
+
+			var total int64
+			for _, p := range resp.Pairs {
+				want, ok := tt.wantRows[p.Pair]
+				if !ok {
+					continue
+				}
+				total += p.Rows
+				if p.Rows != want {
+					t.Errorf("%s: rows = %d, esperado %d", p.Pair, p.Rows, want)
+				}
+				bounds, ok := tt.wantRange[p.Pair]
+				if !ok {
+					if p.Oldest != nil || p.Newest != nil {
+						t.Errorf("%s: oldest/newest = %v/%v, esperado null", p.Pair, p.Oldest, p.Newest)
+					}
+					continue
+				}
+				if p.Oldest == nil || p.Newest == nil || !p.Oldest.Equal(bounds[0]) || !p.Newest.Equal(bounds[1]) {
+					t.Errorf("%s: oldest/newest = %v/%v, esperado %v", p.Pair, p.Oldest, p.Newest, bounds)
+				}
+			}
+			if resp.Database.Rows != total || resp.Database.SizeBytes != 0 {
+				t.Errorf("database = %+v, esperado %d cotações e arquivo inexistente", resp.Database, total)
+			}
+
+			if len(resp.Providers) != 1 || resp.Providers[0].Requests != 1 || resp.Providers[0].ErrorRate != 1 {
+				t.Errorf("provedores = %+v, esperado 1 tentativa com falha", resp.Providers)
+			}
+			if resp.Scheduler.Running != tt.scheduler {
+				t.Errorf("scheduler.running = %v, esperado %v", resp.Scheduler.Running, tt.scheduler)
+			}
+			if tt.scheduler && (resp.Scheduler.Pressure != "normal" || time.Duration(resp.Scheduler.Interval) != time.Minute) {
+				t.Errorf("scheduler = %+v", resp.Scheduler)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Drivers SQLite, pelo nome usado em SQLITE_DRIVER
+const (
+	sqliteDriverCGO    = "cgo"
+	sqliteDriverPureGo = "purego"
+)
+
+// sqliteDriverInfo é um driver SQLite compilado no binário (ver sqlite_cgo.go e
+// sqlite_purego.go): o nome registrado em database/sql e o dialeto do GORM
+type sqliteDriverInfo struct {
+	sqlName string
+	open    func(dsn string) gorm.Dialector
+}
+
+// DSN do banco em memória usado quando o diretório de dados é somente leitura; o cache
+// compartilhado mantém o mesmo banco entre as conexões do pool
+const memoryDSN = "file:cotacoes?mode=memory&cache=shared"
+
+// memoryOnly indica que o servidor roda sem persistência de cotações: o banco em memória
+// mantém usuários, pares e demais tabelas apenas durante a vida do processo
+var memoryOnly bool
+
+// openDatabase abre o SQLite em cfg.DBPath ou, se o diretório de dados for somente leitura
+// e DB_MEMORY_FALLBACK estiver habilitado, um banco em memória
+func openDatabase() (*gorm.DB, error) {
+	// CreatedAt e demais horários preenchidos pelo GORM são gravados em UTC
+	gormConfig := &gorm.Config{
+		Logger:  logger.Default.LogMode(logger.Info),
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	}
+
+	driver, info, err := sqliteDriver()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Driver SQLite: %s", driver)
+	open := func(dsn string) (*gorm.DB, error) {
+		return gorm.Open(info.open(dsn), gormConfig)
+	}
+
+	err = checkWritable(cfg.DBPath)
+	if err == nil {
+		return open(cfg.DBPath)
+	}
+	if !isReadOnly(err) || !cfg.DBMemoryFallback {
+		return nil, fmt.Errorf("diretório de dados sem permissão de escrita: %w", err)
+	}
+
+	memoryOnly = true
+	log.Printf("AVISO: %s não permite escrita (%v)", cfg.DBPath, err)
+	log.Printf("AVISO: modo somente memória ativo; cotações não serão gravadas e usuários, pares e demais dados serão perdidos ao reiniciar")
+	return open(memoryDSN)
+}
+
+// sqliteDriver retorna o driver escolhido em SQLITE_DRIVER (ou o padrão do build)
+func sqliteDriver() (string, sqliteDriverInfo, error) {
+	driver := cfg.SQLiteDriver
+	if driver == "" {
+		driver = defaultSQLiteDriver
+	}
+	info, ok := sqliteDrivers[driver]
+	if !ok {
+		if driver == sqliteDriverCGO || driver == sqliteDriverPureGo {
+			return "", info, fmt.Errorf("driver SQLite %q indisponível neste build (compilado sem CGO ou com a tag purego)", driver)
+		}
+		return "", info, fmt.Errorf("driver SQLite desconhecido %q: use %s ou %s", driver, sqliteDriverCGO, sqliteDriverPureGo)
+	}
+	return driver, info, nil
+}
+
+// checkWritable verifica se é possível criar arquivos no diretório do banco e abrir o
+// arquivo existente para escrita
+func checkWritable(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS) || errors.Is(err, os.ErrPermission)
+}
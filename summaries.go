@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Resumo das cotações de um par em um período de 1m, 5m ou 1h, atualizado a cada inserção,
+// para que estatísticas e painéis sobre intervalos longos não varram os dados brutos
+type RateSummaryDB struct {
+	Pair       string          `gorm:"primaryKey;type:varchar(21)" json:"pair"`
+	Resolution string          `gorm:"primaryKey;type:varchar(3)" json:"resolution"`
+	Bucket     int64           `gorm:"primaryKey" json:"bucket"` // início do período, Unix timestamp
+	Count      int64           `gorm:"not null" json:"count"`
+	MinBid     decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"min_bid"`
+	MaxBid     decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"max_bid"`
+	SumBid     decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"sum_bid"`
+}
+
+type summaryResolution struct {
+	name   string
+	period time.Duration
+}
+
+var summaryResolutions = []summaryResolution{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// Soma o resumo das novas cotações ao período já gravado
+const summaryUpsertSQL = `
+INSERT INTO rate_summary_dbs (pair, resolution, bucket, count, min_bid, max_bid, sum_bid)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (pair, resolution, bucket) DO UPDATE SET
+	count = rate_summary_dbs.count + excluded.count,
+	min_bid = MIN(rate_summary_dbs.min_bid, excluded.min_bid),
+	max_bid = MAX(rate_summary_dbs.max_bid, excluded.max_bid),
+	sum_bid = rate_summary_dbs.sum_bid + excluded.sum_bid`
+
+type summaryKey struct {
+	pair       string
+	resolution string
+	bucket     int64
+}
+
+// addToSummaries atualiza os resumos com as cotações recém-gravadas, na mesma transação da
+// inserção; as cotações do lote são agrupadas antes para gravar cada período uma vez só
+func addToSummaries(tx *gorm.DB, rows []USDToBRLRateDB) error {
+	var keys []summaryKey
+	summaries := make(map[summaryKey]*RateSummaryDB)
+	for _, row := range rows {
+		for _, res := range summaryResolutions {
+			key := summaryKey{row.Pair, res.name, summaryBucket(row.Timestamp, res.period)}
+			s, ok := summaries[key]
+			if !ok {
+				s = &RateSummaryDB{MinBid: row.Bid, MaxBid: row.Bid}
+				summaries[key] = s
+				keys = append(keys, key)
+			}
+			s.add(row.Bid)
+		}
+	}
+
+	for _, key := range keys {
+		s := summaries[key]
+		err := tx.Exec(summaryUpsertSQL, key.pair, key.resolution, key.bucket, s.Count, s.MinBid, s.MaxBid, s.SumBid).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func summaryBucket(timestamp int64, period time.Duration) int64 {
+	size := int64(period / time.Second)
+	return timestamp - timestamp%size
+}
+
+func (s *RateSummaryDB) add(bid decimal.Decimal) {
+	s.Count++
+	s.MinBid = decimal.Min(s.MinBid, bid)
+	s.MaxBid = decimal.Max(s.MaxBid, bid)
+	s.SumBid = s.SumBid.Add(bid)
+}
+
+type SummaryPoint struct {
+	Time  time.Time       `json:"time"`
+	Count int64           `json:"count"`
+	Min   decimal.Decimal `json:"min"`
+	Max   decimal.Decimal `json:"max"`
+	Sum   decimal.Decimal `json:"sum"`
+	Avg   decimal.Decimal `json:"avg"`
+}
+
+func newSummaryPoint(s RateSummaryDB) SummaryPoint {
+	p := SummaryPoint{Time: time.Unix(s.Bucket, 0).UTC(), Count: s.Count, Min: s.MinBid, Max: s.MaxBid, Sum: s.SumBid}
+	if s.Count > 0 {
+		p.Avg = s.SumBid.Div(decimal.NewFromInt(s.Count)).Round(moneyScale)
+	}
+	return p
+}
+
+type SummaryResponse struct {
+	Pair       string    `json:"pair"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Resolution string    `json:"resolution"`
+	// Totais do intervalo, somados a partir dos períodos
+	Total  SummaryPoint   `json:"total"`
+	Points []SummaryPoint `json:"points"`
+}
+
+// SummariesHandler retorna os resumos (count, min, max, sum e média do bid) de ?pair= em
+// [from, to) na resolução 1m, 5m ou 1h; sem ?resolution=, usa a menor que caiba no limite
+// de pontos, com os horários no fuso de ?tz=. Serve estatísticas e painéis do Grafana sobre
+// intervalos longos
+func SummariesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Reaproveita a validação de pair/from/to do histórico
+	q := r.URL.Query()
+	resolution := q.Get("resolution")
+	q.Del("resolution")
+	scope, msg := parseHistoryRange(q)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	loc, ok := requestLocation(w, r)
+	if !ok {
+		return
+	}
+
+	res, ok := chooseSummaryResolution(resolution, scope.To.Sub(scope.From))
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("resolution deve ser 1m, 5m ou 1h, com no máximo %d pontos no intervalo", maxHistoryPoints))
+		return
+	}
+
+	resp := SummaryResponse{Pair: scope.Pair, From: scope.From, To: scope.To, Resolution: res.name}
+	rows, err := loadSummaries(r.Context(), resp.Pair, res, resp.From, resp.To)
+	if err != nil {
+		logf(r.Context(), "Erro ao consultar resumos: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	total := RateSummaryDB{Bucket: resp.From.Unix()}
+	resp.Points = make([]SummaryPoint, 0, len(rows))
+	for _, row := range rows {
+		resp.Points = append(resp.Points, newSummaryPoint(row))
+		if total.Count == 0 {
+			total.MinBid, total.MaxBid = row.MinBid, row.MaxBid
+		}
+		total.Count += row.Count
+		total.MinBid = decimal.Min(total.MinBid, row.MinBid)
+		total.MaxBid = decimal.Max(total.MaxBid, row.MaxBid)
+		total.SumBid = total.SumBid.Add(row.SumBid)
+	}
+	resp.Total = newSummaryPoint(total)
+
+	resp.in(loc)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// chooseSummaryResolution valida a resolução pedida ou escolhe a menor que caiba no limite
+func chooseSummaryResolution(name string, span time.Duration) (summaryResolution, bool) {
+	for _, res := range summaryResolutions {
+		fits := span <= res.period*maxHistoryPoints
+		if strings.EqualFold(name, res.name) {
+			return res, fits
+		}
+		if name == "" && fits {
+			return res, true
+		}
+	}
+	return summaryResolution{}, false
+}
+
+// loadSummaries lê os resumos gravados no SQLite; nos demais backends, que não mantêm a
+// tabela, agrega as cotações brutas em memória
+func loadSummaries(ctx context.Context, pair string, res summaryResolution, from, to time.Time) ([]RateSummaryDB, error) {
+	start := from.UTC().Truncate(res.period)
+	if cfg.StorageBackend == storageSQLite {
+		var rows []RateSummaryDB
+		err := db.WithContext(ctx).
+			Where("pair = ? AND resolution = ? AND bucket >= ? AND bucket < ?", pair, res.name, start.Unix(), to.Unix()).
+			Order("bucket").Limit(maxHistoryPoints).Find(&rows).Error
+		return rows, err
+	}
+
+	raw, err := rateRepo.Range(ctx, pair, start, to, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	var rows []RateSummaryDB
+	for _, r := range raw {
+		bucket := summaryBucket(r.Timestamp, res.period)
+		if n := len(rows); n == 0 || rows[n-1].Bucket != bucket {
+			rows = append(rows, RateSummaryDB{Pair: pair, Resolution: res.name, Bucket: bucket, MinBid: r.Bid, MaxBid: r.Bid})
+		}
+		rows[len(rows)-1].add(r.Bid)
+	}
+	return rows, nil
+}
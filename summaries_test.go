@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+func TestSummariesHandler(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC).Unix()
+	seed := func(t *testing.T) {
+		t.Helper()
+		rows := []USDToBRLRateDB{
+			testRate("USD-BRL", base, "5.00"),
+			testRate("USD-BRL", base+30, "5.20"),
+			testRate("USD-BRL", base+90, "5.10"),
+			testRate("USD-BRL", base+400, "4.90"),
+			testRate("USD-BRL", base+3700, "5.30"),
+			testRate("EUR-BRL", base+10, "6.00"),
+		}
+		seedRates(t, rateRepo, rows...)
+		// Cotação repetida não entra nos resumos
+		dup := testRate("USD-BRL", base+30, "9.99")
+		if err := rateRepo.Save(context.Background(), &dup); !errors.Is(err, errDuplicateRate) {
+			t.Fatalf("Save repetido = %v, esperado errDuplicateRate", err)
+		}
+	}
+
+	type point struct {
+		count    int64
+		min, max string
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantRes    string
+		wantTotal  point
+		wantPoints []point
+	}{
+		{
+			name: "1m", query: "from=2024-01-01T10:00:00Z&to=2024-01-01T12:00:00Z&resolution=1m",
+			wantStatus: http.StatusOK, wantRes: "1m", wantTotal: point{5, "4.9", "5.3"},
+			wantPoints: []point{{2, "5", "5.2"}, {1, "5.1", "5.1"}, {1, "4.9", "4.9"}, {1, "5.3", "5.3"}},
+		},
+		{
+			name: "5m", query: "from=2024-01-01T10:00:00Z&to=2024-01-01T12:00:00Z&resolution=5m",
+			wantStatus: http.StatusOK, wantRes: "5m", wantTotal: point{5, "4.9", "5.3"},
+			wantPoints: []point{{3, "5", "5.2"}, {1, "4.9", "4.9"}, {1, "5.3", "5.3"}},
+		},
+		{
+			name: "1h", query: "from=2024-01-01T10:00:00Z&to=2024-01-01T12:00:00Z&resolution=1h",
+			wantStatus: http.StatusOK, wantRes: "1h", wantTotal: point{5, "4.9", "5.3"},
+			wantPoints: []point{{4, "4.9", "5.2"}, {1, "5.3", "5.3"}},
+		},
+		{
+			name: "início no meio do período", query: "from=2024-01-01T10:30:00Z&to=2024-01-01T11:00:00Z&resolution=1h",
+			wantStatus: http.StatusOK, wantRes: "1h", wantTotal: point{4, "4.9", "5.2"},
+			wantPoints: []point{{4, "4.9", "5.2"}},
+		},
+		{
+			name: "resolução automática", query: "pair=eur-brl&from=2024-01-01&to=2024-01-02",
+			wantStatus: http.StatusOK, wantRes: "1m", wantTotal: point{1, "6", "6"},
+			wantPoints: []point{{1, "6", "6"}},
+		},
+		{
+			name: "automática em intervalo longo", query: "from=2024-01-01&to=2024-03-01",
+			wantStatus: http.StatusOK, wantRes: "1h", wantTotal: point{5, "4.9", "5.3"},
+			wantPoints: []point{{4, "4.9", "5.2"}, {1, "5.3", "5.3"}},
+		},
+		{name: "pontos demais", query: "from=2024-01-01&to=2024-02-01&resolution=1m", wantStatus: http.StatusBadRequest},
+		{name: "resolução desconhecida", query: "from=2024-01-01&to=2024-01-02&resolution=1d", wantStatus: http.StatusBadRequest},
+		{name: "intervalo invertido", query: "from=2024-01-02&to=2024-01-01", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, backend := range []string{storageSQLite, storageMemory} {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				newTestDB(t)
+				withConfig(t, func(c *config.Config) { c.StorageBackend = backend })
+				if backend == storageMemory {
+					rateRepo = newMemoryRateRepository(100)
+				}
+				seed(t)
+
+				rec, ok := serve(t, SummariesHandler, http.MethodGet, "/historico/resumos?"+tt.query, "", tt.wantStatus)
+				if !ok {
+					return
+				}
+
+				resp := decodeJSON[SummaryResponse](t, rec)
+				if resp.Resolution != tt.wantRes {
+					t.Errorf("resolution = %s, esperado %s", resp.Resolution, tt.wantRes)
+				}
+				check := func(label string, got SummaryPoint, want point) {
+					if got.Count != want.count || got.Min.String() != want.min || got.Max.String() != want.max {
+						t.Errorf("%s = (%d, %s, %s), esperado (%d, %s, %s)", label, got.Count, got.Min, got.Max, want.count, want.min, want.max)
+					}
+				}
+				check("total", resp.Total, tt.wantTotal)
+				if len(resp.Points) != len(tt.wantPoints) {
+					t.Fatalf("pontos = %+v, esperado %d", resp.Points, len(tt.wantPoints))
+				}
+				for i, want := range tt.wantPoints {
+					check(resp.Points[i].Time.Format(time.TimeOnly), resp.Points[i], want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	// Base de fusos embutida, para ?tz= funcionar em imagens sem /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+// Os horários são mantidos em UTC internamente: timestamps Unix no banco e time.Time em UTC
+// nas respostas, serializados em RFC3339. ?tz= apenas muda o fuso da saída
+
+// requestLocation lê ?tz= (nome IANA, ex.: America/Sao_Paulo; padrão UTC), respondendo 400
+// quando o fuso é desconhecido
+func requestLocation(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	name := strings.TrimSpace(r.URL.Query().Get("tz"))
+	if name == "" {
+		return time.UTC, true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "tz inválido: use um fuso IANA, ex.: America/Sao_Paulo")
+		return nil, false
+	}
+	return loc, true
+}
+
+// withQuotedAt preenche o horário da cotação em RFC3339 no fuso pedido
+func withQuotedAt(quote Quote, loc *time.Location) Quote {
+	if t, err := quote.Time(); err == nil {
+		quote.QuotedAt = t.In(loc).Format(time.RFC3339)
+	}
+	return quote
+}
+
+// in converte os horários da série para loc; os pontos são copiados porque podem vir do
+// cache de consultas, compartilhado entre requisições
+func (resp *HistoryResponse) in(loc *time.Location) {
+	resp.From, resp.To = resp.From.In(loc), resp.To.In(loc)
+	resp.Points = slices.Clone(resp.Points)
+	for i := range resp.Points {
+		resp.Points[i].Time = resp.Points[i].Time.In(loc)
+	}
+}
+
+func (resp *SummaryResponse) in(loc *time.Location) {
+	resp.From, resp.To = resp.From.In(loc), resp.To.In(loc)
+	resp.Total.Time = resp.Total.Time.In(loc)
+	for i := range resp.Points {
+		resp.Points[i].Time = resp.Points[i].Time.In(loc)
+	}
+}
+
+// withTime preenche Time a partir do timestamp Unix e converte os horários para loc
+func (r *USDToBRLRateDB) withTime(loc *time.Location) {
+	r.Time = time.Unix(r.Timestamp, 0).In(loc)
+	r.CreatedAt = r.CreatedAt.In(loc)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryTimezone(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFrom   string
+		wantTime   string
+	}{
+		{name: "UTC por padrão", query: "from=2024-01-01T09:00:00Z&to=2024-01-01T11:00:00Z", wantStatus: http.StatusOK,
+			wantFrom: "2024-01-01T09:00:00Z", wantTime: "2024-01-01T10:00:00Z"},
+		{name: "fuso informado", query: "from=2024-01-01T09:00:00Z&to=2024-01-01T11:00:00Z&tz=America/Sao_Paulo", wantStatus: http.StatusOK,
+			wantFrom: "2024-01-01T06:00:00-03:00", wantTime: "2024-01-01T07:00:00-03:00"},
+		{name: "lista com fuso", query: "tz=Asia/Tokyo", wantStatus: http.StatusOK, wantTime: "2024-01-01T19:00:00+09:00"},
+		{name: "fuso desconhecido", query: "from=2024-01-01&tz=Marte/Base", wantStatus: http.StatusBadRequest},
+	}
+
+	newTestDB(t)
+	seedRates(t, rateRepo, testRate("USD-BRL", base.Unix(), "5.00"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, ok := serve(t, GetHistoryHandler, http.MethodGet, "/historico?"+tt.query, "", tt.wantStatus)
+			if !ok {
+				return
+			}
+
+			if !strings.Contains(tt.query, "from=") {
+				var rows []struct {
+					Time string `json:"time"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != 1 || rows[0].Time != tt.wantTime {
+					t.Errorf("cotações = %+v, esperado time %s", rows, tt.wantTime)
+				}
+				return
+			}
+
+			var resp struct {
+				From   string `json:"from"`
+				Points []struct {
+					Time string `json:"time"`
+				} `json:"points"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.From != tt.wantFrom {
+				t.Errorf("from = %s, esperado %s", resp.From, tt.wantFrom)
+			}
+			if len(resp.Points) != 1 || resp.Points[0].Time != tt.wantTime {
+				t.Errorf("pontos = %+v, esperado time %s", resp.Points, tt.wantTime)
+			}
+		})
+	}
+
+	// A mesma consulta em outro fuso vem do cache sem alterar os pontos guardados
+	key, _ := parseHistoryRange(httptest.NewRequest(http.MethodGet, "/historico?from=2024-01-01T09:00:00Z&to=2024-01-01T11:00:00Z", nil).URL.Query())
+	if points, ok := historyResults.get(key); ok && points[0].Time.Location() != time.UTC {
+		t.Errorf("ponto em cache convertido para %s", points[0].Time.Location())
+	}
+}
+
+func TestWithQuotedAt(t *testing.T) {
+	quote := testQuote("5.1", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := withQuotedAt(quote, saoPaulo).QuotedAt; got != "2024-01-01T07:00:00-03:00" {
+		t.Errorf("quoted_at = %s, esperado 2024-01-01T07:00:00-03:00", got)
+	}
+	quote.Timestamp = "ontem"
+	if got := withQuotedAt(quote, time.UTC).QuotedAt; got != "" {
+		t.Errorf("quoted_at de timestamp inválido = %q, esperado vazio", got)
+	}
+}
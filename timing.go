@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/pkg/domain"
+)
+
+// Origens da cotação informadas em timing.cache
+const (
+	timingCacheMiss   = "miss"
+	timingCacheShared = "shared"
+	timingCacheHit    = "hit"
+	timingCacheStale  = "stale"
+)
+
+const timingKey contextKey = "timing"
+
+// requestTiming acumula o tempo gasto no provedor e no banco durante uma requisição que
+// enviou o cabeçalho X-Debug-Timing. Os métodos aceitam receptor nil, o caso comum
+type requestTiming struct {
+	mu       sync.Mutex
+	start    time.Time
+	upstream time.Duration
+	db       time.Duration
+	cache    string
+}
+
+// withTiming passa a medir a requisição quando o cliente pediu o detalhamento
+func withTiming(r *http.Request) (*http.Request, *requestTiming) {
+	if r.Header.Get(domain.TimingHeader) == "" {
+		return r, nil
+	}
+	t := &requestTiming{start: time.Now()}
+	return r.WithContext(context.WithValue(r.Context(), timingKey, t)), t
+}
+
+func timingFromContext(ctx context.Context) *requestTiming {
+	t, _ := ctx.Value(timingKey).(*requestTiming)
+	return t
+}
+
+func (t *requestTiming) record(upstream, db time.Duration, cache string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.upstream += upstream
+	t.db += db
+	t.cache = cache
+}
+
+func (t *requestTiming) report() domain.Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return domain.Timing{
+		UpstreamMS: milliseconds(t.upstream),
+		Cache:      t.cache,
+		DBMS:       milliseconds(t.db),
+		TotalMS:    milliseconds(time.Since(t.start)),
+	}
+}
+
+// milliseconds arredonda a duração para centésimos de milissegundo
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
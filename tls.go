@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// configureTLS prepara o servidor para HTTPS com o certificado de TLS_CERT_FILE/TLS_KEY_FILE
+// ou com certificados do Let's Encrypt para TLS_AUTOCERT_DOMAINS. Retorna o handler do
+// listener HTTP de redirecionamento (que no autocert também responde aos desafios ACME) ou
+// nil quando o TLS não está configurado
+func configureTLS(server *http.Server) (http.Handler, error) {
+	manual := cfg.TLSCertFile != "" || cfg.TLSKeyFile != ""
+	auto := len(cfg.TLSAutocertDomains) > 0
+	switch {
+	case manual && auto:
+		return nil, errors.New("use TLS_CERT_FILE/TLS_KEY_FILE ou TLS_AUTOCERT_DOMAINS, não ambos")
+	case manual && (cfg.TLSCertFile == "" || cfg.TLSKeyFile == ""):
+		return nil, errors.New("TLS_CERT_FILE e TLS_KEY_FILE devem ser informados juntos")
+	case manual:
+		certs := &certReloader{certFile: cfg.TLSCertFile, keyFile: cfg.TLSKeyFile}
+		if _, err := certs.GetCertificate(nil); err != nil {
+			return nil, err
+		}
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.GetCertificate}
+		return http.HandlerFunc(redirectToHTTPS), nil
+	case auto:
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		if cfg.TLSAutocertDirectory != "" {
+			m.Client = &acme.Client{DirectoryURL: cfg.TLSAutocertDirectory}
+		}
+		server.TLSConfig = m.TLSConfig()
+		server.TLSConfig.MinVersion = tls.VersionTLS12
+		return m.HTTPHandler(http.HandlerFunc(redirectToHTTPS)), nil
+	}
+	return nil, nil
+}
+
+// configureHTTP2 ajusta o HTTP/2 negociado via TLS e, com HTTP_H2C e sem TLS, aceita HTTP/2
+// em texto claro (h2c), usado por clientes gRPC-gateway atrás de proxies que terminam o TLS
+func configureHTTP2(server *http.Server) error {
+	h2 := &http2.Server{
+		MaxConcurrentStreams: uint32(cfg.HTTP2MaxConcurrentStreams),
+		IdleTimeout:          cfg.HTTPIdleTimeout,
+	}
+	if server.TLSConfig != nil {
+		if cfg.HTTPH2C {
+			log.Printf("HTTP_H2C ignorado: com TLS o HTTP/2 é negociado via ALPN")
+		}
+		return http2.ConfigureServer(server, h2)
+	}
+	if cfg.HTTPH2C {
+		server.Handler = h2c.NewHandler(server.Handler, h2)
+	}
+	return nil
+}
+
+// redirectToHTTPS redireciona para o mesmo caminho em HTTPS, na porta do servidor principal;
+// 308 preserva o método e o corpo das requisições que não são GET
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if cfg.Port != "443" {
+		host = net.JoinHostPort(host, cfg.Port)
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+}
+
+// serveRedirect atende o listener de redirecionamento até ctx terminar. Durante um reinício
+// sem interrupção o endereço ainda pertence à instância anterior, então a abertura é repetida
+// até ela encerrar
+func serveRedirect(ctx context.Context, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr: addr, Handler: handler,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+	}
+
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		var ln net.Listener
+		for {
+			var err error
+			if ln, err = net.Listen("tcp", addr); err == nil {
+				break
+			}
+			log.Printf("Erro ao abrir o listener de redirecionamento em %s, tentando novamente: %v", addr, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
+		log.Printf("Redirecionamento HTTP→HTTPS em %s", addr)
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Erro no listener de redirecionamento: %v", err)
+		}
+	}()
+}
+
+// certReloader relê o certificado quando o arquivo muda, para que renovações feitas por
+// ferramentas externas (certbot) valham sem reiniciar o servidor
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// verifica o arquivo no máximo uma vez por minuto
+	if c.cert != nil && time.Since(c.checked) < time.Minute {
+		return c.cert, nil
+	}
+	c.checked = time.Now()
+
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	if c.cert != nil && info.ModTime().Equal(c.modTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			log.Printf("Erro ao recarregar o certificado TLS, mantendo o anterior: %v", err)
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("erro ao carregar o certificado TLS: %w", err)
+	}
+	if c.cert != nil {
+		log.Printf("Certificado TLS recarregado de %s", c.certFile)
+	}
+	c.cert, c.modTime = &cert, info.ModTime()
+	return c.cert, nil
+}
@@ -0,0 +1,6 @@
+//go:build tools
+
+// Mantém no go.mod as ferramentas usadas pelo go generate
+package main
+
+import _ "github.com/99designs/gqlgen"
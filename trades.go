@@ -0,0 +1,176 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	tradeBuy  = "buy"
+	tradeSell = "sell"
+)
+
+// Operação hipotética de câmbio registrada por um usuário: a compra da moeda base é feita
+// pelo preço de venda (ask) e a venda pelo preço de compra (bid) do momento
+type TradeDB struct {
+	ID        uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint            `gorm:"index;not null" json:"-"`
+	Pair      string          `gorm:"type:varchar(21);not null" json:"pair"`
+	Side      string          `gorm:"type:varchar(4);not null" json:"side"`
+	Amount    decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"amount"`
+	Rate      decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"rate"`
+	CreatedAt time.Time       `gorm:"not null" json:"created_at"`
+}
+
+type TradeRequest struct {
+	Pair   string          `json:"pair"`
+	Side   string          `json:"side"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// Position consolida as operações de um par: Invested é o total pago nas compras, Proceeds o
+// total recebido nas vendas e PnL o resultado caso o saldo fosse vendido agora
+type Position struct {
+	Pair        string          `json:"pair"`
+	Balance     decimal.Decimal `json:"balance"`
+	Invested    decimal.Decimal `json:"invested"`
+	Proceeds    decimal.Decimal `json:"proceeds"`
+	MarketRate  decimal.Decimal `json:"market_rate"`
+	MarketValue decimal.Decimal `json:"market_value"`
+	PnL         decimal.Decimal `json:"pnl"`
+}
+
+type TradeJournal struct {
+	Trades    []TradeDB  `json:"trades"`
+	Positions []Position `json:"positions"`
+}
+
+// TradesHandler mantém o diário de operações simuladas do usuário autenticado:
+// GET lista as operações e o resultado por par, POST registra uma operação na cotação atual
+// e DELETE ?id= remove uma operação
+func TradesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		listTrades(w, r, userID)
+	case http.MethodPost:
+		createTrade(w, r, userID)
+	case http.MethodDelete:
+		deleteTrade(w, r, userID)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func createTrade(w http.ResponseWriter, r *http.Request, userID uint) {
+	var req TradeRequest
+	if !decodeValidated(w, r, "trade", &req) {
+		return
+	}
+	req.Pair = strings.ToUpper(strings.TrimSpace(req.Pair))
+	if req.Pair == "" {
+		req.Pair = defaultPair
+	}
+	if req.Side != tradeBuy && req.Side != tradeSell {
+		writeError(w, http.StatusBadRequest, "side deve ser buy ou sell")
+		return
+	}
+	if !req.Amount.IsPositive() {
+		writeError(w, http.StatusBadRequest, "amount deve ser positivo")
+		return
+	}
+
+	quote, ok := quoteForPair(w, r, req.Pair)
+	if !ok {
+		return
+	}
+
+	rate := parseDecimal(quote.Ask)
+	if req.Side == tradeSell {
+		rate = parseDecimal(quote.Bid)
+	}
+
+	trade := TradeDB{UserID: userID, Pair: req.Pair, Side: req.Side, Amount: req.Amount.Round(moneyScale), Rate: rate.Round(moneyScale)}
+	if err := db.WithContext(r.Context()).Create(&trade).Error; err != nil {
+		logf(r.Context(), "Erro ao registrar operação: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	writeJSON(w, http.StatusCreated, trade)
+}
+
+func listTrades(w http.ResponseWriter, r *http.Request, userID uint) {
+	var trades []TradeDB
+	if err := db.WithContext(r.Context()).Where("user_id = ?", userID).Order("created_at, id").Find(&trades).Error; err != nil {
+		logf(r.Context(), "Erro ao consultar operações: %v", err)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+
+	var order []string
+	positions := make(map[string]*Position)
+	for _, t := range trades {
+		p, ok := positions[t.Pair]
+		if !ok {
+			p = &Position{Pair: t.Pair}
+			positions[t.Pair] = p
+			order = append(order, t.Pair)
+		}
+		if t.Side == tradeBuy {
+			p.Balance = p.Balance.Add(t.Amount)
+			p.Invested = p.Invested.Add(t.Amount.Mul(t.Rate))
+		} else {
+			p.Balance = p.Balance.Sub(t.Amount)
+			p.Proceeds = p.Proceeds.Add(t.Amount.Mul(t.Rate))
+		}
+	}
+
+	journal := TradeJournal{Trades: trades, Positions: make([]Position, 0, len(order))}
+	for _, pair := range order {
+		p := positions[pair]
+		// Posições zeradas não dependem da cotação atual
+		if !p.Balance.IsZero() {
+			quote, err := fetchAndPersist(r.Context(), pair)
+			if err != nil {
+				logf(r.Context(), "Erro ao obter cotação de %s para o diário: %v", pair, err)
+				writeError(w, http.StatusServiceUnavailable, "cotação atual indisponível para "+pair)
+				return
+			}
+			p.MarketRate = parseDecimal(quote.Bid)
+		}
+		p.MarketValue = p.Balance.Mul(p.MarketRate).Round(moneyScale)
+		p.PnL = p.Proceeds.Add(p.MarketValue).Sub(p.Invested).Round(moneyScale)
+		p.Invested = p.Invested.Round(moneyScale)
+		p.Proceeds = p.Proceeds.Round(moneyScale)
+		journal.Positions = append(journal.Positions, *p)
+	}
+	if journal.Trades == nil {
+		journal.Trades = []TradeDB{}
+	}
+	writeJSON(w, http.StatusOK, journal)
+}
+
+func deleteTrade(w http.ResponseWriter, r *http.Request, userID uint) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	result := db.WithContext(r.Context()).Where("id = ? AND user_id = ?", id, userID).Delete(&TradeDB{})
+	if result.Error != nil {
+		logf(r.Context(), "Erro ao remover operação: %v", result.Error)
+		writeError(w, http.StatusInternalServerError, "erro interno")
+		return
+	}
+	if result.RowsAffected == 0 {
+		writeError(w, http.StatusNotFound, "operação não encontrada")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/guilhermeayusso/goexpert/desafio/1/config"
+)
+
+// upstreamClient é compartilhado entre as requisições para reaproveitar conexões e sessões TLS
+var upstreamClient *http.Client
+
+func newUpstreamClient(c *config.Config) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   c.UpstreamDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        c.UpstreamMaxIdleConns,
+		MaxIdleConnsPerHost: c.UpstreamMaxIdleConnsPerHost,
+		IdleConnTimeout:     c.UpstreamIdleConnTimeout,
+		TLSHandshakeTimeout: c.UpstreamTLSHandshakeTimeout,
+		DisableCompression:  c.UpstreamDisableCompression,
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(c.UpstreamTLSSessionCacheSize),
+		},
+	}
+
+	return &http.Client{Transport: transport}
+}
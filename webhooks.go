@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Tentativas de entrega de um alerta e espera inicial entre elas (dobrada a cada falha)
+const (
+	alertAttempts = 4
+	alertBackoff  = time.Second
+)
+
+// Alerta cadastrado por um usuário, entregue pelo canal escolhido: webhook (assinado com o
+// segredo), email ou Telegram (Target é o endereço ou o chat_id). Alertas desabilitados
+// continuam cadastrados, mas não são avaliados
+type AlertDB struct {
+	ID          uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint `gorm:"index;not null" json:"-"`
+	AlertRule   `gorm:"embedded"`
+	Channel     string     `gorm:"type:varchar(20);not null;default:webhook" json:"channel"`
+	Target      string     `gorm:"type:varchar(255);not null" json:"target,omitempty"`
+	WebhookURL  string     `gorm:"type:varchar(2048);not null" json:"webhook_url,omitempty"`
+	Secret      string     `gorm:"type:varchar(128);not null" json:"secret,omitempty"`
+	Enabled     bool       `gorm:"not null" json:"enabled"`
+	LastFiredAt *time.Time `json:"last_fired_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Name string `gorm:"-" json:"name,omitempty"` // só nas regras de ALERT_RULES_FILE, que não têm ID
+}
+
+// label identifica o alerta nos logs e em X-Alert-ID: o ID ou, nas regras do arquivo, "file:nome"
+func (a AlertDB) label() string {
+	if a.ID == 0 && a.Name != "" {
+		return "file:" + a.Name
+	}
+	return strconv.FormatUint(uint64(a.ID), 10)
+}
+
+// AlertRequest é o corpo de POST e PUT; Enabled ausente equivale a habilitado na criação e
+// mantém o valor atual na atualização
+type AlertRequest struct {
+	AlertRule
+	Channel    string `json:"channel"`
+	Target     string `json:"target"`
+	WebhookURL string `json:"webhook_url"`
+	Secret     string `json:"secret"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+// AlertEvent é o corpo enviado ao webhook quando o alerta dispara, e a base das mensagens
+// dos demais canais
+type AlertEvent struct {
+	AlertID    uint        `json:"alert_id,omitempty"`
+	AlertName  string      `json:"alert_name,omitempty"` // regras de ALERT_RULES_FILE
+	Rule       AlertRule   `json:"rule"`
+	Firing     AlertFiring `json:"firing"`
+	Bid        string      `json:"bid"`
+	Ask        string      `json:"ask"`
+	PctChange  string      `json:"pct_change,omitempty"`
+	Provider   string      `json:"provider,omitempty"`
+	Timestamp  int64       `json:"timestamp"`
+	HistoryURL string      `json:"history_url"`
+	Test       bool        `json:"test,omitempty"`
+}
+
+var webhookClient = newWebhookClient()
+
+// newWebhookClient chama os webhooks sem proxy e recusando, no momento da conexão, os endereços
+// internos: a URL é conferida no cadastro, mas o DNS pode passar a apontar para outro destino
+// depois disso, e os redirecionamentos também passam por aqui
+func newWebhookClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addr.Addr()) {
+				return fmt.Errorf("endereço %s não permitido para webhooks", addr.Addr())
+			}
+			return nil
+		},
+	}).DialContext
+	return &http.Client{Timeout: 5 * time.Second, Transport: transport}
+}
+
+// publicAddr informa se o webhook pode chamar o endereço: loopback, redes privadas, link-local
+// (como o 169.254.169.254 dos metadados de nuvem), multicast e o endereço não especificado
+// ficam de fora
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// alertEngine avalia os alertas cadastrados a cada nova cotação; as cotações chegam por
+// um canal para não atrasar quem as obteve. Só avalia na instância líder: nas demais as
+// cotações são ignoradas
+type alertEngine struct {
+	quotes  chan alertQuote
+	running atomic.Bool
+	stopped chan struct{} // fechado quando a avaliação da liderança anterior termina
+
+	mu         sync.Mutex
+	alerts     map[uint]AlertDB
+	evaluators map[uint]*alertEvaluator
+	fileRules  map[string]fileAlert
+
+	abortedDeliveries atomic.Int64 // entregas interrompidas pelo encerramento
+}
+
+// fileAlert é uma regra de ALERT_RULES_FILE; não é gravada no banco
+type fileAlert struct {
+	alert     AlertDB
+	evaluator *alertEvaluator
+}
+
+type alertQuote struct {
+	pair  string
+	quote *Quote
+}
+
+var alertsEngine = &alertEngine{
+	quotes:     make(chan alertQuote, 256),
+	alerts:     make(map[uint]AlertDB),
+	evaluators: make(map[uint]*alertEvaluator),
+	fileRules:  make(map[string]fileAlert),
+}
+
+func (e *alertEngine) load() error {
+	var alerts []AlertDB
+	if err := db.Find(&alerts).Error; err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, alert := range alerts {
+		e.addLocked(alert)
+	}
+	return nil
+}
+
+func (e *alertEngine) add(alert AlertDB) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.addLocked(alert)
+}
+
+func (e *alertEngine) addLocked(alert AlertDB) {
+	if !alert.Enabled {
+		delete(e.alerts, alert.ID)
+		delete(e.evaluators, alert.ID)
+		return
+	}
+	evaluator := newAlertEvaluator(alert.AlertRule)
+	if alert.LastFiredAt != nil {
+		evaluator.lastFired = *alert.LastFiredAt
+	}
+	e.alerts[alert.ID] = alert
+	e.evaluators[alert.ID] = evaluator
+}
+
+func (e *alertEngine) remove(id uint) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.alerts, id)
+	delete(e.evaluators, id)
+}
+
+// observe entrega a cotação ao avaliador sem bloquear; com o canal cheio a cotação é
+// descartada, pois a próxima a substitui
+func (e *alertEngine) observe(pair string, quote *Quote) {
+	if !e.running.Load() {
+		return
+	}
+	select {
+	case e.quotes <- alertQuote{pair: pair, quote: quote}:
+	default:
+		log.Printf("Fila de avaliação de alertas cheia, cotação de %s descartada", pair)
+	}
+}
+
+// start avalia as cotações até o cancelamento de ctx, quando a instância deixa de liderar ou
+// o servidor é encerrado. Numa nova liderança aguarda a avaliação anterior terminar, para que
+// duas goroutines não disputem a fila nem a anterior marque a nova como parada
+func (e *alertEngine) start(ctx context.Context) {
+	if e.stopped != nil {
+		<-e.stopped
+	}
+	stopped := make(chan struct{})
+	e.stopped = stopped
+	e.running.Store(true)
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		defer close(stopped)
+		defer e.running.Store(false)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case q := <-e.quotes:
+				e.evaluate(ctx, q)
+			}
+		}
+	}()
+}
+
+func (e *alertEngine) evaluate(ctx context.Context, q alertQuote) {
+	bid := parseDecimal(q.quote.Bid)
+	point := HistoryPoint{
+		Time: time.Unix(quoteTimestamp(q.quote), 0).UTC(),
+		Open: bid, High: bid, Low: bid, Close: bid,
+		AvgBid: bid, AvgAsk: parseDecimal(q.quote.Ask), Samples: 1,
+	}
+
+	type firedAlert struct {
+		alert AlertDB
+		event AlertEvent
+	}
+	var fired []firedAlert
+	check := func(alert AlertDB, evaluator *alertEvaluator) {
+		if alert.Pair != q.pair {
+			return
+		}
+		firing, ok := evaluator.observe(point)
+		if !ok {
+			return
+		}
+		fired = append(fired, firedAlert{alert, AlertEvent{
+			AlertID: alert.ID, AlertName: alert.Name, Rule: alert.AlertRule, Firing: firing,
+			Bid: q.quote.Bid, Ask: q.quote.Ask, PctChange: q.quote.PctChange, Provider: q.quote.Provider,
+			Timestamp: point.Time.Unix(), HistoryURL: historyURL(alert.Pair, point.Time),
+		}})
+	}
+
+	e.mu.Lock()
+	for id, alert := range e.alerts {
+		check(alert, e.evaluators[id])
+	}
+	for _, rule := range e.fileRules {
+		check(rule.alert, rule.evaluator)
+	}
+	e.mu.Unlock()
+
+	for _, f := range fired {
+		e.fire(ctx, f.alert, f.event)
+	}
+}
+
+func (e *alertEngine) fire(ctx context.Context, alert AlertDB, event AlertEvent) {
+	if alert.ID != 0 {
+		if err := db.Model(&AlertDB{ID: alert.ID}).Update("last_fired_at", time.Now()).Error; err != nil {
+			log.Printf("Erro ao registrar disparo do alerta %d: %v", alert.ID, err)
+		}
+	}
+
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		if err := deliverAlert(ctx, alert, event); err != nil {
+			if ctx.Err() != nil {
+				e.abortedDeliveries.Add(1)
+			}
+			log.Printf("Alerta %s não entregue via %s: %v", alert.label(), alert.Channel, err)
+		}
+	}()
+}
+
+// signPayload assina "timestamp.corpo" com HMAC-SHA256; incluir o timestamp permite ao
+// receptor rejeitar reenvios antigos
+func signPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverAlert envia o evento pelo canal do alerta, repetindo com espera exponencial em caso
+// de falha
+func deliverAlert(ctx context.Context, alert AlertDB, event AlertEvent) error {
+	send, ok := alertSenders[alert.Channel]
+	if !ok {
+		return fmt.Errorf("canal desconhecido %q", alert.Channel)
+	}
+
+	backoff := alertBackoff
+	for attempt := 1; ; attempt++ {
+		err := send(ctx, alert, event)
+		if err == nil || attempt == alertAttempts {
+			return err
+		}
+
+		log.Printf("Entrega do alerta %s via %s falhou (tentativa %d/%d): %v", alert.label(), alert.Channel, attempt, alertAttempts, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// sendWebhook publica o evento no webhook; respostas diferentes de 2xx contam como falha
+func sendWebhook(ctx context.Context, alert AlertDB, event AlertEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alert.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Alert-ID", alert.label())
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", signPayload(alert.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string // vazio: aceita
+	}{
+		{name: "endereço público", url: "https://93.184.216.34/hook"},
+		{name: "esquema inválido", url: "ftp://93.184.216.34/hook", wantErr: "http ou https"},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", wantErr: "endereço público"},
+		{name: "localhost", url: "http://localhost/hook", wantErr: "endereço público"},
+		{name: "loopback IPv6", url: "http://[::1]/hook", wantErr: "endereço público"},
+		{name: "rede privada", url: "http://10.0.0.5/hook", wantErr: "endereço público"},
+		{name: "metadados de nuvem", url: "http://169.254.169.254/latest/meta-data", wantErr: "endereço público"},
+		{name: "IPv4 mapeado em IPv6", url: "http://[::ffff:192.168.0.1]/hook", wantErr: "endereço público"},
+		{name: "multicast", url: "http://224.0.0.1/hook", wantErr: "endereço público"},
+		{name: "não especificado", url: "http://0.0.0.0/hook", wantErr: "endereço público"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateChannel(&AlertRequest{Channel: channelWebhook, WebhookURL: tt.url})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("erro inesperado: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A URL pode passar na validação e depois resolver para um endereço interno; a conexão é
+// recusada mesmo assim
+func TestWebhookRefusesInternalAddress(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	t.Cleanup(srv.Close)
+
+	err := sendWebhook(context.Background(), AlertDB{WebhookURL: srv.URL, Secret: "s"}, AlertEvent{})
+	if err == nil || !strings.Contains(err.Error(), "não permitido") {
+		t.Fatalf("erro = %v, esperado endereço não permitido", err)
+	}
+	if called {
+		t.Error("o webhook interno foi chamado")
+	}
+}